@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// expandAliases rewrites args when the command position holds a user-defined
+// alias from settings. Built-in commands can't be shadowed and expansion is
+// single-level, so an alias can't recurse into itself.
+func expandAliases(app *cli.App, args []string, aliases map[string]string) ([]string, error) {
+	if len(aliases) == 0 || len(args) < 2 {
+		return args, nil
+	}
+
+	// Global flags that consume the following argument as their value
+	valueFlags := make(map[string]bool)
+	for _, flag := range app.Flags {
+		if _, isBool := flag.(*cli.BoolFlag); isBool {
+			continue
+		}
+		for _, name := range flag.Names() {
+			valueFlags[name] = true
+		}
+	}
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return args, nil
+		}
+		if strings.HasPrefix(arg, "-") {
+			name := strings.TrimLeft(arg, "-")
+			if !strings.Contains(name, "=") && valueFlags[name] {
+				i++ // Skip the flag's value
+			}
+			continue
+		}
+
+		expansion, ok := aliases[arg]
+		if !ok || app.Command(arg) != nil {
+			return args, nil
+		}
+
+		words, err := splitCommandLine(expansion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alias %q: %w", arg, err)
+		}
+
+		expanded := make([]string, 0, len(args)+len(words))
+		expanded = append(expanded, args[:i]...)
+		expanded = append(expanded, words...)
+		expanded = append(expanded, args[i+1:]...)
+		return expanded, nil
+	}
+
+	return args, nil
+}
+
+// splitCommandLine splits an alias definition into words, honoring single
+// and double quotes
+func splitCommandLine(s string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	var quote rune
+	inWord := false
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty alias")
+	}
+
+	return words, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// createBackup runs `cflip backup`
+func createBackup(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if c.Bool("list") {
+		return listBackups(svc)
+	}
+
+	keep := c.Int("keep")
+	if keep < 0 {
+		return fmt.Errorf("--keep must be at least 1")
+	}
+
+	var passphrase string
+	if c.Bool("encrypt") {
+		if passphrase, err = transferPassphrase("backup --encrypt", true); err != nil {
+			return err
+		}
+	}
+
+	logger.Progress("Backing up cflip and Claude Code state...")
+	backup, err := svc.CreateBackup(passphrase, keep)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	logger.NewDefault().BackupCreated(backup.Path, backup.Encrypted)
+
+	if jsonOutput {
+		return printJSON(backup)
+	}
+	logger.Success("Backup written to %s (%s)", backup.Path, formatSize(backup.Size))
+	if backup.Manifest != nil {
+		logger.Plain("   Accounts: %d", len(backup.Manifest.Accounts))
+	}
+	if !backup.Encrypted {
+		logger.InfoMsg("💡 The backup only opens on this machine; use --encrypt for a copy that can be restored elsewhere")
+	}
+	return nil
+}
+
+// restoreBackup runs `cflip restore`
+func restoreBackup(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if c.Bool("list") {
+		return listBackups(svc)
+	}
+
+	path, err := svc.FindBackup(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	var passphrase string
+	encrypted, err := svc.IsEncryptedBackup(path)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		if passphrase, err = transferPassphrase("restore", false); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := svc.InspectBackup(path, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	logger.Progress("Restoring backup from %s (%d account(s))", manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"), len(manifest.Accounts))
+
+	if !c.Bool("force") {
+		if nonInteractive {
+			return errNeedsInteraction("restore", "pass --force to restore without asking")
+		}
+		if !confirmPrompt("Replace all cflip accounts and the live Claude Code login with this backup? [y/N]: ") {
+			logger.ErrorMsg("Restore cancelled")
+			return nil
+		}
+	}
+
+	manifest, previous, err := svc.RestoreBackup(path, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	logger.NewDefault().BackupRestored(path, previous.Path)
+
+	if jsonOutput {
+		return printJSON(struct {
+			Restored string                  `json:"restored"`
+			Manifest *service.BackupManifest `json:"manifest"`
+			Previous *service.Backup         `json:"previous"`
+		}{path, manifest, previous})
+	}
+	logger.Success("Restored %d account(s) from %s", len(manifest.Accounts), filepath.Base(path))
+	logger.InfoMsg("💡 The replaced state was backed up to %s; restore it to undo, and restart Claude Code", filepath.Base(previous.Path))
+	return nil
+}
+
+// listBackups prints the stored backups, newest first
+func listBackups(svc *service.Service) error {
+	backups, err := svc.ListBackups()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if backups == nil {
+			backups = []*service.Backup{}
+		}
+		return printJSON(backups)
+	}
+
+	if len(backups) == 0 {
+		logger.InfoMsg("No backups yet; create one with `cflip backup`")
+		return nil
+	}
+	logger.InfoMsg("📋 Backups (%d):", len(backups))
+	logger.Plain("")
+	for i, backup := range backups {
+		var details []string
+		if backup.Manifest != nil {
+			details = append(details, fmt.Sprintf("%d account(s)", len(backup.Manifest.Accounts)))
+		}
+		details = append(details, formatSize(backup.Size))
+		if backup.Encrypted {
+			details = append(details, "encrypted")
+		}
+		logger.Plain("  %d. %s  %s", i+1, backup.CreatedAt.Local().Format("2006-01-02 15:04:05"), strings.Join(details, ", "))
+	}
+	return nil
+}
+
+// formatSize renders a byte count compactly, e.g. "12.3 KB"
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
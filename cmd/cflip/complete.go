@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// bashCompletion is the bash script printed by `cflip completion bash`
+const bashCompletion = `_cflip_complete() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" "$cur" --generate-bash-completion 2>/dev/null)
+  else
+    opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null)
+  fi
+  COMPREPLY=($(compgen -W "$opts" -- "$cur"))
+  return 0
+}
+complete -o bashdefault -o default -F _cflip_complete cflip
+`
+
+// zshCompletion is the zsh script printed by `cflip completion zsh`
+const zshCompletion = `#compdef cflip
+_cflip() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} "$cur" --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+compdef _cflip cflip
+`
+
+// completeCommand prints account identifiers matching a prefix, one per
+// line. Its output is a stable interface for scripts (fzf, Raycast, Alfred).
+func completeCommand(c *cli.Context) error {
+	accounts, err := service.CompleteAccounts(c.Args().First())
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		fmt.Println(account)
+	}
+	return nil
+}
+
+// completeAccountArgs offers account identifiers for the first n
+// arguments, or for every argument when n is negative
+func completeAccountArgs(n int) cli.BashCompleteFunc {
+	return func(c *cli.Context) {
+		if n >= 0 && c.NArg() >= n {
+			return
+		}
+		accounts, err := service.CompleteAccounts("")
+		if err != nil {
+			return
+		}
+		for _, account := range accounts {
+			fmt.Println(account)
+		}
+	}
+}
+
+// printCompletion prints the shell completion script for bash or zsh
+func printCompletion(c *cli.Context) error {
+	switch shell := c.Args().First(); shell {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	default:
+		return fmt.Errorf("unsupported shell %q (use bash or zsh)", shell)
+	}
+	return nil
+}
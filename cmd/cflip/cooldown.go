@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// setCooldown runs `cflip cooldown set`
+func setCooldown(c *cli.Context) error {
+	resetsAt, err := parseResetTime(c.String("until"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	account, cooldown, err := svc.SetCooldown(c.Args().First(), resetsAt)
+	if err != nil {
+		return fmt.Errorf("failed to record the usage limit: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(struct {
+			Account  *service.ProfileInfo `json:"account"`
+			Cooldown *service.Cooldown    `json:"cooldown"`
+		}{account, cooldown})
+	}
+	logger.Success("%s is cooling down until %s", account.DisplayName(),
+		cooldown.ResetsAt.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+// clearCooldown runs `cflip cooldown clear`
+func clearCooldown(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	account, cleared, err := svc.ClearCooldown(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("failed to clear the usage limit: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(struct {
+			Account *service.ProfileInfo `json:"account"`
+			Cleared bool                 `json:"cleared"`
+		}{account, cleared})
+	}
+	if !cleared {
+		logger.InfoMsg("%s has no usage limit recorded", account.DisplayName())
+		return nil
+	}
+	logger.Success("Cleared the usage limit on %s", account.DisplayName())
+	return nil
+}
+
+// parseResetTime reads --until: an RFC 3339 time, a local HH:MM (tomorrow
+// when already past), or a duration from now. Empty means the default
+// cooldown, returned as the zero time.
+func parseResetTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if clock, err := time.ParseInLocation("15:04", value, time.Local); err == nil {
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --until %q: use an RFC 3339 time, HH:MM or a duration like 90m", value)
+}
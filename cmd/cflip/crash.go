@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// reportCrash turns a panic into a redacted crash report instead of a raw
+// Go panic dump, which may include sensitive paths. Deferred first in main.
+func reportCrash() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	path, err := service.WriteCrashReport(recovered, stack, os.Args)
+	if err != nil {
+		// Fall back to the raw panic so the failure is never silent
+		fmt.Fprintf(os.Stderr, "panic: %v\n\n%s\nfailed to save crash report: %v\n", recovered, stack, err)
+		os.Exit(2)
+	}
+
+	fmt.Fprintln(os.Stderr, "💥 cflip crashed unexpectedly. This is a bug.")
+	fmt.Fprintf(os.Stderr, "   A crash report with secrets removed was saved to %s\n", path)
+	fmt.Fprintf(os.Stderr, "   Please attach it to an issue: %s\n", service.IssuesURL)
+	os.Exit(2)
+}
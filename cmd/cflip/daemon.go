@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/daemon"
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// runDaemon runs `cflip daemon` in the foreground until interrupted
+func runDaemon(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	path, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+	logger.InfoMsg("👂 Serving accounts on %s (Ctrl+C to stop)...", path)
+
+	err = daemon.NewServer(svc, version).Run(ctx, c.Duration("interval"), func(event daemon.Event) {
+		switch event.Kind {
+		case "loaded":
+			logger.InfoMsg("🔄 Loaded %d account(s)", event.Accounts)
+		case "switched":
+			logger.Success("Switched to %s", event.Email)
+		case "rotated":
+			logger.Success("Rotated to %s (%s)", event.Email, event.Rule)
+			log := logger.NewDefault()
+			log.AccountSwitched(event.From, event.Email, event.Profile)
+		case "archived":
+			logger.InfoMsg("📦 Archived %s (%s)", event.Email, event.Reason)
+		case "refreshed":
+			logger.Success("Refreshed %s", event.Email)
+			log := logger.NewDefault()
+			log.TokenRefreshed(event.Email)
+		case "error":
+			logger.ErrorMsg("Daemon: %v", event.Err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	logger.InfoMsg("Daemon stopped")
+	return nil
+}
+
+// daemonStatus runs `cflip daemon status`
+func daemonStatus(c *cli.Context) error {
+	info, err := daemon.Ping()
+	if errors.Is(err, daemon.ErrNotRunning) {
+		if jsonOutput {
+			if err := printJSON(struct {
+				Running bool `json:"running"`
+			}{}); err != nil {
+				return err
+			}
+		} else {
+			logger.InfoMsg("The cflip daemon is not running; start it with `cflip daemon`")
+		}
+		return cli.Exit("", 1)
+	}
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(struct {
+			Running bool `json:"running"`
+			*daemon.Info
+		}{true, info})
+	}
+	logger.Success("The cflip daemon is running (pid %d, version %s)", info.PID, info.Version)
+	logger.Plain("   Socket: %s", info.Socket)
+	logger.Plain("   Up since: %s", info.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	logger.Plain("   Accounts: %d, loaded %s", info.Accounts, info.LoadedAt.Local().Format(time.TimeOnly))
+	return nil
+}
+
+// stopDaemon runs `cflip daemon stop`
+func stopDaemon(c *cli.Context) error {
+	err := daemon.Stop()
+	if errors.Is(err, daemon.ErrNotRunning) {
+		logger.InfoMsg("The cflip daemon is not running")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stop the daemon: %w", err)
+	}
+	logger.Success("Stopped the cflip daemon")
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// windowBarWidth is the number of cells in the usage window bar
+const windowBarWidth = 10
+
+// showDashboard runs `cflip dashboard`: a view of every account that
+// redraws each second and reloads state every --interval. Without a
+// terminal it prints a single snapshot.
+func showDashboard(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	snapshot, err := svc.Dashboard()
+	if err != nil {
+		return err
+	}
+
+	interval := c.Duration("interval")
+	if c.Bool("once") || nonInteractive || !logger.StdoutIsTerminal() {
+		fmt.Print(renderDashboard(snapshot, time.Now(), ""))
+		return nil
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Print("\033[?25l\033[2J") // Hide the cursor and clear the screen
+	defer fmt.Print("\033[?25h")
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	status := fmt.Sprintf("Refreshing every %s · Ctrl+C to quit", interval)
+	for {
+		// Redraw in place; \033[K and \033[J clear what the last frame left
+		frame := renderDashboard(snapshot, time.Now(), status)
+		fmt.Print("\033[H" + strings.ReplaceAll(frame, "\n", "\033[K\n") + "\033[J")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if now.Sub(snapshot.Taken) < interval {
+				continue
+			}
+			if fresh, err := svc.Dashboard(); err == nil {
+				snapshot = fresh
+				status = fmt.Sprintf("Refreshing every %s · Ctrl+C to quit", interval)
+			} else {
+				snapshot.Taken = now // Retry after another interval
+				status = fmt.Sprintf("Refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// renderDashboard draws one frame of the dashboard as of now
+func renderDashboard(snapshot *service.DashboardSnapshot, now time.Time, status string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cflip dashboard · %s\n", now.Format("2006-01-02 15:04:05"))
+	if status != "" {
+		fmt.Fprintf(&b, "%s\n", status)
+	}
+	b.WriteString("\n")
+
+	if len(snapshot.Accounts) == 0 {
+		b.WriteString("No accounts managed yet. Run `cflip add` to add the current account.\n")
+		return b.String()
+	}
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tACCOUNT\tORG\tTIER\tTOKEN\t5H WINDOW\tLAST USED")
+	for _, account := range snapshot.Accounts {
+		marker := "○"
+		if account.IsActive {
+			marker = "●"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s %s\t%s\n",
+			marker,
+			account.Label(),
+			orDash(account.Organization),
+			orDash(account.Tier),
+			account.TokenStatus(now),
+			windowBar(account.WindowFraction()),
+			account.WindowLabel(),
+			account.LastUsed())
+	}
+	tw.Flush()
+
+	b.WriteString("\nRecent switches\n")
+	if len(snapshot.Switches) == 0 {
+		b.WriteString("  none recorded\n")
+	}
+	for _, sw := range snapshot.Switches {
+		from := sw.From
+		if from == "" {
+			from = "(none)"
+		}
+		fmt.Fprintf(&b, "  %s  %s → %s\n", sw.Time.Local().Format("01-02 15:04"), from, sw.To)
+	}
+
+	return b.String()
+}
+
+// windowBar draws how much of the usage window is used
+func windowBar(fraction float64) string {
+	filled := int(fraction*windowBarWidth + 0.5)
+	if filled > windowBarWidth {
+		filled = windowBarWidth
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", windowBarWidth-filled)
+}
+
+// orDash shows "-" for empty table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// addToGroup runs `cflip group add <group> <account>...`
+func addToGroup(c *cli.Context) error {
+	return changeGroup(c, "add")
+}
+
+// removeFromGroup runs `cflip group remove <group> <account>...`
+func removeFromGroup(c *cli.Context) error {
+	return changeGroup(c, "remove")
+}
+
+// changeGroup adds accounts to or removes them from a group
+func changeGroup(c *cli.Context, action string) error {
+	if c.NArg() < 2 {
+		return fmt.Errorf("usage: cflip group %s <group> <account>...", action)
+	}
+	group := c.Args().First()
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	var accounts []*service.ProfileInfo
+	for _, target := range c.Args().Tail() {
+		var account *service.ProfileInfo
+		var changed bool
+		if action == "add" {
+			account, changed, err = svc.AddToGroup(target, group)
+		} else {
+			account, changed, err = svc.RemoveFromGroup(target, group)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s %s: %w", action, target, err)
+		}
+		accounts = append(accounts, account)
+
+		switch {
+		case jsonOutput:
+		case !changed && action == "add":
+			logger.InfoMsg("%s is already in %s", account.DisplayName(), group)
+		case !changed:
+			logger.InfoMsg("%s is not in %s", account.DisplayName(), group)
+		case action == "add":
+			logger.Success("Added %s to %s", account.DisplayName(), group)
+		default:
+			logger.Success("Removed %s from %s", account.DisplayName(), group)
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(accounts)
+	}
+	return nil
+}
+
+// listGroups runs `cflip group list`
+func listGroups(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	groups, err := svc.Groups()
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if groups == nil {
+			groups = []service.Group{}
+		}
+		return printJSON(groups)
+	}
+
+	if len(groups) == 0 {
+		logger.InfoMsg("No groups yet. Use 'cflip group add <group> <account>' to create one.")
+		return nil
+	}
+
+	accounts, err := svc.ListProfiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+	displayNames := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		if account.Source == "" {
+			displayNames[account.Name] = account.DisplayName()
+		}
+	}
+
+	logger.InfoMsg("🗂️  Groups (%d):", len(groups))
+	logger.Plain("")
+	for _, group := range groups {
+		members := make([]string, len(group.Profiles))
+		for i, name := range group.Profiles {
+			members[i] = displayNames[name]
+			if members[i] == "" {
+				members[i] = name
+			}
+		}
+		logger.Plain("  %s (%d): %s", group.Name, len(members), strings.Join(members, ", "))
+	}
+	return nil
+}
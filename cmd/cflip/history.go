@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// undoSwitch runs `cflip undo`
+func undoSwitch(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	result, err := svc.Undo(c.Bool("force"))
+	if err != nil {
+		return err
+	}
+	logger.Success("Switched back to: %s", result.To.DisplayName())
+	printSwitchNotes(result)
+	logger.InfoMsg("💡 Please restart Claude Code to use the new account")
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	return nil
+}
+
+// showHistory runs `cflip history`
+func showHistory(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	entries, err := svc.History(c.Int("limit"))
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		logger.InfoMsg("No switches recorded yet")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tFROM\tTO\tBY")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04"),
+			orDash(historyAccount(entry.From, entry.FromEmail)), historyAccount(entry.To, entry.ToEmail), entry.Initiator)
+	}
+	return tw.Flush()
+}
+
+// historyAccount shows a profile name, with the email when it differs
+func historyAccount(name, email string) string {
+	if name == "" || name == email || email == "" {
+		if name == "" {
+			return email
+		}
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, email)
+}
@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/phathdt/claude-flip/internal/daemon"
 	"github.com/phathdt/claude-flip/internal/logger"
 	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/phathdt/claude-flip/internal/transfer"
+	"github.com/phathdt/claude-flip/pkg/cflip"
 
 	"github.com/urfave/cli/v2"
 )
 
-const version = "0.1.0"
+// version is overridden at build time with -ldflags "-X main.version=..."
+var version = "0.1.0"
 
 // setupLogging configures the logger based on CLI flags
 func setupLogging(c *cli.Context) error {
@@ -48,14 +59,40 @@ func setupLogging(c *cli.Context) error {
 	}
 
 	logger.SetDefault(log)
+
+	if auditPath, err := service.AuditLogPath(); err == nil {
+		logger.SetAuditLogPath(auditPath)
+	}
+
+	if settings, err := service.LoadSettings(); err == nil {
+		logger.SetTheme(settings.Theme)
+		if err := service.ApplySettings(settings); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// newClient creates the client the account commands (list, switch, add,
+// remove, validate) run on: the same one Go programs embed, printing to
+// the terminal
+func newClient() (*cflip.Client, error) {
+	client, err := cflip.New(&cflip.Options{Terminal: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize service: %w", err)
+	}
+	return client, nil
+}
+
 func main() {
+	defer reportCrash()
+
 	app := &cli.App{
-		Name:    "cflip",
-		Usage:   "A fast CLI tool to manage and switch between multiple Claude Code accounts",
-		Version: version,
+		Name:                 "cflip",
+		Usage:                "A fast CLI tool to manage and switch between multiple Claude Code accounts",
+		Version:              version,
+		EnableBashCompletion: true,
 		Authors: []*cli.Author{
 			{
 				Name:  "phathdt",
@@ -76,10 +113,58 @@ func main() {
 				Value:   "text",
 				EnvVars: []string{"CFLIP_LOG_FORMAT"},
 			},
+			&cli.StringFlag{
+				Name:  "account",
+				Usage: "Target a stored account for read-only commands (current, get, validate) without switching",
+			},
+			&cli.BoolFlag{
+				Name:    "no-expiry-warning",
+				Usage:   "Suppress the warning about the active token expiring soon",
+				EnvVars: []string{"CFLIP_NO_EXPIRY_WARNING"},
+			},
+			&cli.StringFlag{
+				Name:    "remote",
+				Usage:   "Operate on Claude Code on a remote host over SSH (user@host)",
+				EnvVars: []string{"CFLIP_REMOTE"},
+			},
+			&cli.BoolFlag{
+				Name:    "non-interactive",
+				Usage:   "Never prompt; fail with an explanation instead (for CI and automation)",
+				EnvVars: []string{"CFLIP_NON_INTERACTIVE"},
+			},
+			&cli.DurationFlag{
+				Name:    "prompt-timeout",
+				Usage:   "Take the default answer (usually cancel) when a prompt gets no answer in time, e.g. 30s; 0 waits forever",
+				EnvVars: []string{"CFLIP_PROMPT_TIMEOUT"},
+			},
+			&cli.StringFlag{
+				Name:    "keychain",
+				Usage:   "Use a dedicated macOS keychain file (unlocked with CFLIP_KEYCHAIN_PASSWORD)",
+				EnvVars: []string{"CFLIP_KEYCHAIN"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "Output format of list, current, validate, switch and add (text, json)",
+				Value:   outputText,
+				EnvVars: []string{"CFLIP_OUTPUT"},
+			},
 		},
 		Before: func(c *cli.Context) error {
-			return setupLogging(c)
+			service.SetVersion(version)
+			if err := setupLogging(c); err != nil {
+				return err
+			}
+			configureAutomation(c)
+			if err := configureOutput(c); err != nil {
+				return err
+			}
+			if err := forwardRemoteCommand(c); err != nil {
+				return err
+			}
+			autoAdopt(c)
+			return nil
 		},
+		After: warnIfExpiring,
 		Commands: []*cli.Command{
 			{
 				Name:    "add",
@@ -91,6 +176,26 @@ func main() {
 						Aliases: []string{"n"},
 						Usage:   "Custom alias for the account",
 					},
+					&cli.StringFlag{
+						Name:  "from-file",
+						Usage: "Register an account from an exported .credentials.json instead of the logged-in one",
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Exported Claude Code config (~/.claude.json) to pair with --from-file",
+					},
+					&cli.StringFlag{
+						Name:  "email",
+						Usage: "Account email for --from-file when no --config is given",
+					},
+					&cli.StringFlag{
+						Name:  "scan",
+						Usage: "Find saved credential/config backups in a directory and offer to import each account",
+					},
+					&cli.BoolFlag{
+						Name:  "manual",
+						Usage: "Enter tokens by hand; they are verified with the Anthropic API before saving",
+					},
 				},
 				Action: addAccount,
 			},
@@ -104,15 +209,56 @@ func main() {
 						Aliases: []string{"v"},
 						Usage:   "Show detailed account information",
 					},
+					&cli.StringFlag{
+						Name:  "group-by",
+						Usage: "Group accounts under headers (supported: org, group)",
+					},
+					&cli.StringFlag{
+						Name:  "group",
+						Usage: "Only list the accounts in this group",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "Only list the accounts with this tag (repeat to require several)",
+					},
+					&cli.BoolFlag{
+						Name:  "shared",
+						Usage: "Also fetch and list the accounts of shared team sources",
+					},
 				},
 				Action: listAccounts,
 			},
 			{
-				Name:      "switch",
-				Aliases:   []string{"sw", "s"},
-				Usage:     "Switch to account (next in sequence if no argument provided)",
-				ArgsUsage: "[account_number|email]",
+				Name:         "switch",
+				Aliases:      []string{"sw", "s"},
+				Usage:        "Switch to account (shows a menu on a terminal, or the next in sequence, if no argument is provided)",
+				ArgsUsage:    "[account_number|email]",
+				BashComplete: completeAccountArgs(1),
 				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "next",
+						Aliases: []string{"n"},
+						Usage:   "Switch to the next account in sequence instead of showing a menu",
+					},
+					&cli.BoolFlag{
+						Name:    "pick",
+						Aliases: []string{"p"},
+						Usage:   "Choose the account with a type-to-filter picker over alias, email and organization",
+					},
+					&cli.BoolFlag{
+						Name:    "lru",
+						Aliases: []string{"least-recently-used"},
+						Usage:   "Switch to the account that has been idle the longest",
+					},
+					&cli.BoolFlag{
+						Name:  "available",
+						Usage: "Switch to the next account whose usage window has reset, skipping those cooling down after a usage limit",
+					},
+					&cli.StringFlag{
+						Name:    "group",
+						Aliases: []string{"g"},
+						Usage:   "Switch to the next account in sequence within this group",
+					},
 					&cli.BoolFlag{
 						Name:    "confirm",
 						Aliases: []string{"c"},
@@ -122,343 +268,3551 @@ func main() {
 						Name:  "force",
 						Usage: "Force switch (skip safety checks)",
 					},
+					&cli.BoolFlag{
+						Name:  "no-desktop",
+						Usage: "Do not switch Claude Desktop, even when the desktop target is enabled",
+					},
+					&cli.StringFlag{
+						Name:  "org",
+						Usage: "Organization (name or UUID) to use within the target account",
+					},
+					&cli.BoolFlag{
+						Name:  "verify",
+						Usage: "Check the new access token against the API after switching",
+					},
+					&cli.BoolFlag{
+						Name:  "auto",
+						Usage: "Switch to the account bound to this directory by a .cflip file; does nothing without one",
+					},
 				},
 				Action: switchAccount,
 			},
 			{
-				Name:      "remove",
-				Aliases:   []string{"rm", "r"},
-				Usage:     "Remove an account from management",
-				ArgsUsage: "<account_number|email>",
-				Action:    removeAccount,
+				Name:         "use",
+				Usage:        "Switch to the account a project's .cflip file names, or bind the project to an account",
+				ArgsUsage:    "--project [account]",
+				BashComplete: completeAccountArgs(1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "project",
+						Usage: "Find the .cflip or .cflip.json file in this directory or its parents; with an account, write one here",
+					},
+					&cli.StringFlag{
+						Name:  "org",
+						Usage: "With an account, also bind the organization (name or UUID) to use",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Force switch (skip safety checks)",
+					},
+				},
+				Action: useProject,
+			},
+			{
+				Name:  "status",
+				Usage: "Print the active account on one line for shell prompts, without touching the keychain",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: service.DefaultStatusFormat,
+						Usage: "Go template over Name, Profile, Email, Alias, Expiry, ExpiresAt, Expired and Mark",
+					},
+				},
+				Action: promptStatus,
+			},
+			{
+				Name:   "sync",
+				Usage:  "Save tokens Claude Code refreshed and other live changes into the active account's profile",
+				Action: syncActive,
+				Subcommands: []*cli.Command{
+					{
+						Name:  "push",
+						Usage: "Encrypt profiles changed here into the git sync repository and push it",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Overwrite the repository's copy of profiles that changed on both sides",
+							},
+						},
+						Action: gitSyncPush,
+					},
+					{
+						Name:  "pull",
+						Usage: "Apply profiles changed on other devices from the git sync repository",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Overwrite this device's copy of profiles that changed on both sides",
+							},
+						},
+						Action: gitSyncPull,
+					},
+					{
+						Name:   "status",
+						Usage:  "Compare the profiles here with the git sync repository",
+						Action: gitSyncStatus,
+					},
+				},
+			},
+			{
+				Name:  "undo",
+				Usage: "Switch back to the account that was active before the current one",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Force switch (skip safety checks)",
+					},
+				},
+				Action: undoSwitch,
+			},
+			{
+				Name:  "history",
+				Usage: "Show recent account switches, newest first",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "limit",
+						Aliases: []string{"n"},
+						Value:   20,
+						Usage:   "Show at most this many switches (0 shows all)",
+					},
+				},
+				Action: showHistory,
+			},
+			{
+				Name:         "remove",
+				Aliases:      []string{"rm", "r"},
+				Usage:        "Remove an account from management",
+				ArgsUsage:    "<account_number|email>",
+				BashComplete: completeAccountArgs(1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Remove without asking for confirmation",
+					},
+					&cli.BoolFlag{
+						Name:  "purge",
+						Usage: "Delete permanently instead of moving to the trash",
+					},
+				},
+				Action: removeAccount,
+			},
+			{
+				Name:      "undelete",
+				Usage:     "Restore a removed account from the trash (lists the trash without an argument)",
+				ArgsUsage: "[email|alias]",
+				Action:    undeleteAccount,
+			},
+			{
+				Name:      "unarchive",
+				Usage:     "Restore an account archived by `cflip enforce` (lists the archive without an argument)",
+				ArgsUsage: "[email|alias]",
+				Action:    unarchiveAccount,
 			},
 			{
 				Name:    "current",
 				Aliases: []string{"cur"},
 				Usage:   "Show current active account",
-				Action:  currentAccount,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "Print nothing and exit 0 only if the active account is managed, logged in and not expired",
+					},
+					&cli.BoolFlag{
+						Name:    "quiet",
+						Aliases: []string{"q"},
+						Usage:   "With --check, do not explain failures",
+					},
+					&cli.BoolFlag{
+						Name:  "reconcile",
+						Usage: "Without asking, mark the account Claude Code is logged in with as active",
+					},
+				},
+				Action: currentAccount,
+			},
+			{
+				Name:         "show",
+				Usage:        "Print everything stored for an account, with tokens masked",
+				ArgsUsage:    "<account_number|email>",
+				BashComplete: completeAccountArgs(1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "reveal",
+						Usage: "Print the access and refresh tokens in full, after confirming",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "With --reveal, do not ask for confirmation",
+					},
+				},
+				Action: showAccount,
+			},
+			{
+				Name:         "rename",
+				Usage:        "Rename account alias",
+				ArgsUsage:    "<account_number|email> <new_alias>",
+				BashComplete: completeAccountArgs(1),
+				Action:       renameAccount,
+			},
+			{
+				Name:            "tag",
+				Usage:           "Add (+tag or tag) and remove (-tag) free-form tags on an account, or print its tags",
+				ArgsUsage:       "<account_number|email> [+tag|-tag]...",
+				BashComplete:    completeAccountArgs(1),
+				SkipFlagParsing: true,
+				Action:          tagAccount,
+			},
+			{
+				Name:         "note",
+				Usage:        "Set the notes on an account, or print them",
+				ArgsUsage:    "<account_number|email> [text]",
+				BashComplete: completeAccountArgs(1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "clear",
+						Usage: "Remove the notes",
+					},
+				},
+				Action: noteAccount,
 			},
 			{
-				Name:      "rename",
-				Usage:     "Rename account alias",
-				ArgsUsage: "<account_number|email> <new_alias>",
-				Action:    renameAccount,
+				Name:         "move",
+				Usage:        "Give an account a new number in the list; the accounts in between shift by one",
+				ArgsUsage:    "<account_number|email> <position>",
+				BashComplete: completeAccountArgs(1),
+				Action:       moveAccount,
 			},
 			{
-				Name:   "validate",
-				Usage:  "Validate all stored accounts",
+				Name:  "validate",
+				Usage: "Validate all stored accounts (or only --account)",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "schema",
+						Usage: "Check config.json and profile files against the built-in JSON Schemas",
+					},
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "Repair what can be fixed: refresh expired tokens, re-capture account info, rebuild config.json",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print results as JSON (account, email, status, error, expires_at)",
+					},
+					&cli.BoolFlag{
+						Name:  "online",
+						Usage: "Also confirm each token with the Anthropic API, telling expired, revoked and unreachable apart",
+					},
+					&cli.IntFlag{
+						Name:    "jobs",
+						Aliases: []string{"j"},
+						Usage:   "How many accounts to check at once",
+						Value:   service.DefaultValidateWorkers,
+					},
+					&cli.BoolFlag{
+						Name:  "accept-modified",
+						Usage: "Trust profiles that were modified outside cflip and record their current contents",
+					},
+				},
 				Action: validateAccounts,
 			},
-		},
+			{
+				Name:   "reconcile",
+				Usage:  "Adopt, refresh and re-point accounts changed outside cflip (e.g. by logging in through Claude Code)",
+				Action: reconcileAccounts,
+			},
+			{
+				Name:   "rewrite",
+				Usage:  "Rewrite every stored profile with the current sops setting (encrypt or decrypt)",
+				Action: rewriteProfiles,
+			},
+			{
+				Name:      "restore-config",
+				Usage:     "Restore ~/.claude.json and credentials from the snapshot taken before a switch",
+				ArgsUsage: "[restore_point_number]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "List restore points instead of restoring",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Restore without confirmation",
+					},
+				},
+				Action: restoreConfig,
+			},
+			{
+				Name:  "backup",
+				Usage: "Back up cflip's state, ~/.claude.json and the live credentials to a timestamped archive",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "List backups instead of creating one",
+					},
+					&cli.BoolFlag{
+						Name:  "encrypt",
+						Usage: "Encrypt the backup with a passphrase (or $" + transfer.PassphraseEnv + ")",
+					},
+					&cli.IntFlag{
+						Name:  "keep",
+						Usage: "Number of backups to keep, deleting the oldest (default: settings.backup.keep, or 10)",
+					},
+				},
+				Action: createBackup,
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore cflip's state, ~/.claude.json and the live credentials from a backup",
+				ArgsUsage: "[backup_number|file]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "List backups instead of restoring",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Restore without confirmation",
+					},
+				},
+				Action: restoreBackup,
+			},
+			{
+				Name:  "doctor",
+				Usage: "Check credentials access, files, tokens and the platform, printing pass/warn/fail per check",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "fix-perms",
+						Usage: "Tighten loose permissions (0700 directories, 0600 files)",
+					},
+					&cli.BoolFlag{
+						Name:    "verbose",
+						Aliases: []string{"v"},
+						Usage:   "Show the details of passing checks too",
+					},
+				},
+				Action: runDoctor,
+			},
+			{
+				Name:  "self-update",
+				Usage: "Replace cflip with the latest release after verifying its signature",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "Only report whether a newer release exists",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Reinstall even when already up to date",
+					},
+				},
+				Action: selfUpdate,
+			},
+			{
+				Name:  "upgrade",
+				Usage: "Upgrade cflip the way it was installed (Homebrew, apt, go install, or self-update)",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the detected install method and upgrade command without running it",
+					},
+				},
+				Action: upgradeCflip,
+			},
+			{
+				Name:         "export",
+				Usage:        "Export accounts to a passphrase-encrypted archive, or a sanitized snapshot for bug reports with --redact",
+				ArgsUsage:    "[account_number|email...]",
+				BashComplete: completeAccountArgs(-1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "redact",
+						Usage: "Export a snapshot of cflip's state with every token, UUID and email hashed",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-emails",
+						Usage: "Leave email addresses readable in the redacted export",
+					},
+					&cli.IntFlag{
+						Name:  "lines",
+						Usage: "Number of recent audit log lines to include",
+						Value: service.DefaultExportLogLines,
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Write the export to a file instead of stdout",
+					},
+					&cli.BoolFlag{
+						Name:  "qr",
+						Usage: "Show the archive as QR codes to scan on a machine without a network connection",
+					},
+				},
+				Action: exportState,
+			},
+			{
+				Name:      "import",
+				Usage:     "Import accounts from an archive made with `cflip export`",
+				ArgsUsage: "<archive>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "qr",
+						Usage: "Read the archive from the codes of `cflip export --qr`, scanned to stdin",
+					},
+					&cli.StringFlag{
+						Name:  "on-conflict",
+						Usage: "What to do with accounts already stored: skip, overwrite or newer",
+						Value: string(transfer.KeepExisting),
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show what would be imported without changing anything",
+					},
+				},
+				Action: importAccounts,
+			},
+			{
+				Name:  "debug-bundle",
+				Usage: "Collect a redacted zip of diagnostics to attach to GitHub issues",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Zip file to write (default: cflip-debug-<timestamp>.zip)",
+					},
+					&cli.BoolFlag{
+						Name:  "keep-emails",
+						Usage: "Leave email addresses readable in the bundle",
+					},
+					&cli.IntFlag{
+						Name:  "lines",
+						Usage: "Number of recent audit log lines to include",
+						Value: service.DefaultExportLogLines,
+					},
+				},
+				Action: debugBundle,
+			},
+			{
+				Name:         "refresh",
+				Usage:        "Refresh OAuth tokens of stored accounts without switching",
+				ArgsUsage:    "[account_number|email]",
+				BashComplete: completeAccountArgs(1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Refresh every account whose token is nearing expiry",
+					},
+					&cli.DurationFlag{
+						Name:  "within",
+						Usage: "With --all, refresh tokens expiring within this window",
+						Value: service.DefaultRefreshWindow,
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "With --all, refresh every account regardless of expiry",
+					},
+				},
+				Action: refreshTokens,
+			},
+			{
+				Name:         "copy-settings",
+				Usage:        "Copy non-credential Claude Code settings from one account to another",
+				ArgsUsage:    "<from> <to>",
+				BashComplete: completeAccountArgs(-1),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "keys",
+						Usage: "Comma-separated sections to copy: settings, mcp, projects, claude_md",
+						Value: strings.Join(service.DefaultCopySections, ","),
+					},
+				},
+				Action: copySettings,
+			},
+			{
+				Name:  "overlay",
+				Usage: "Manage per-account overlays merged into ~/.claude/settings.json on switch",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "show",
+						Usage:        "Print an account's settings overlay",
+						ArgsUsage:    "<account_number|email>",
+						BashComplete: completeAccountArgs(1),
+						Action:       showOverlay,
+					},
+					{
+						Name:         "set",
+						Usage:        "Set an account's overlay from a JSON file ('-' reads stdin)",
+						ArgsUsage:    "<account_number|email> <file.json|->",
+						BashComplete: completeAccountArgs(1),
+						Action:       setOverlay,
+					},
+					{
+						Name:         "clear",
+						Usage:        "Remove an account's settings overlay",
+						ArgsUsage:    "<account_number|email>",
+						BashComplete: completeAccountArgs(1),
+						Action:       clearOverlay,
+					},
+				},
+			},
+			{
+				Name:  "group",
+				Usage: "Organize accounts into named groups (work, personal, client-x) to list and rotate through",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "add",
+						Usage:        "Add accounts to a group, creating it",
+						ArgsUsage:    "<group> <account_number|email>...",
+						BashComplete: completeAccountArgs(-1),
+						Action:       addToGroup,
+					},
+					{
+						Name:         "remove",
+						Aliases:      []string{"rm"},
+						Usage:        "Remove accounts from a group",
+						ArgsUsage:    "<group> <account_number|email>...",
+						BashComplete: completeAccountArgs(-1),
+						Action:       removeFromGroup,
+					},
+					{
+						Name:   "list",
+						Usage:  "List the groups and their accounts",
+						Action: listGroups,
+					},
+				},
+			},
+			{
+				Name:  "org",
+				Usage: "Manage the organizations/workspaces an account belongs to",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "list",
+						Usage:        "List an account's known organizations",
+						ArgsUsage:    "<account_number|email>",
+						BashComplete: completeAccountArgs(1),
+						Action:       listOrganizations,
+					},
+					{
+						Name:         "add",
+						Usage:        "Record an organization membership for an account",
+						ArgsUsage:    "<account_number|email>",
+						BashComplete: completeAccountArgs(1),
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "uuid", Usage: "Organization UUID", Required: true},
+							&cli.StringFlag{Name: "name", Usage: "Organization name"},
+							&cli.StringFlag{Name: "role", Usage: "Organization role (e.g. admin, user)"},
+							&cli.StringFlag{Name: "workspace-role", Usage: "Workspace role"},
+						},
+						Action: addOrganization,
+					},
+				},
+			},
+			{
+				Name:  "proxy",
+				Usage: "Manage per-account proxy settings applied on switch",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "show",
+						Usage:        "Print an account's proxy settings",
+						ArgsUsage:    "<account_number|email>",
+						BashComplete: completeAccountArgs(1),
+						Action:       showProxy,
+					},
+					{
+						Name:         "set",
+						Usage:        "Route an account through a proxy, or 'direct' to force no proxy",
+						ArgsUsage:    "<account_number|email> <proxy_url|direct>",
+						BashComplete: completeAccountArgs(1),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "no-proxy",
+								Usage: "Comma-separated hosts that bypass the proxy",
+							},
+						},
+						Action: setProxy,
+					},
+					{
+						Name:         "clear",
+						Usage:        "Remove an account's proxy settings",
+						ArgsUsage:    "<account_number|email>",
+						BashComplete: completeAccountArgs(1),
+						Action:       clearProxy,
+					},
+				},
+			},
+			{
+				Name:  "storage",
+				Usage: "Manage the backend stored accounts keep their credentials in",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "List the storage backends and which one is in use",
+						Action: listStorageBackends,
+					},
+					{
+						Name:      "migrate",
+						Usage:     "Move all stored credentials to another backend and switch to it",
+						ArgsUsage: "<from> <to>",
+						Action:    migrateStorage,
+					},
+				},
+			},
+			{
+				Name:  "unlock",
+				Usage: "Unlock stored credentials protected by the vault passphrase",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Value: 15 * time.Minute,
+						Usage: "Lock again after this long (0 keeps them unlocked until `cflip lock` or a reboot)",
+					},
+					&cli.BoolFlag{
+						Name:  "status",
+						Usage: "Only show whether stored credentials are locked",
+					},
+				},
+				Action: unlockVault,
+			},
+			{
+				Name:   "lock",
+				Usage:  "Lock stored credentials until `cflip unlock`",
+				Action: lockVault,
+			},
+			{
+				Name:  "passwd",
+				Usage: "Set or change the vault passphrase protecting stored credentials, re-encrypting them",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "remove",
+						Usage: "Remove the passphrase and go back to the machine-derived key",
+					},
+					&cli.StringFlag{
+						Name:  "hardware-key",
+						Usage: "Also require this age recipient of a hardware key (age-plugin-yubikey, age-plugin-fido2-hmac) to unlock",
+					},
+					&cli.StringFlag{
+						Name:  "hardware-identity",
+						Usage: "The age identity file of --hardware-key",
+					},
+					&cli.BoolFlag{
+						Name:  "remove-hardware-key",
+						Usage: "Stop requiring the hardware key to unlock",
+					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Value: 15 * time.Minute,
+						Usage: "Keep stored credentials unlocked this long afterwards",
+					},
+				},
+				Action: changeVaultPassphrase,
+			},
+			{
+				Name:         "which",
+				Usage:        "Show how an account identifier resolves",
+				ArgsUsage:    "<account_number|email|alias>",
+				BashComplete: completeAccountArgs(1),
+				Action:       whichAccount,
+			},
+			{
+				Name:      "get",
+				Usage:     "Print a single field of the active (or --account) profile",
+				ArgsUsage: "<field>",
+				Action:    getField,
+			},
+			{
+				Name:  "enforce",
+				Usage: "Apply retention policies (archive inactive profiles, flag expired tokens)",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show what would be done without changing anything",
+					},
+				},
+				Action: enforcePolicies,
+			},
+			{
+				Name:  "stats",
+				Usage: "Show account usage statistics from the audit log",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "days",
+						Usage: "Only include activity from the last N days (0 for all time)",
+						Value: 30,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Output the report as JSON",
+					},
+				},
+				Action: showStats,
+			},
+			{
+				Name:  "rotate",
+				Usage: "Apply scheduled rotation rules (run from cron or the daemon)",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show which account the schedule wants without switching",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Force switch (skip safety checks)",
+					},
+				},
+				Action: rotateAccount,
+			},
+			{
+				Name:  "recommend",
+				Usage: "Rank accounts by estimated remaining capacity",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "switch",
+						Usage: "Switch to the top recommendation",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Force switch (skip safety checks)",
+					},
+				},
+				Action: recommendAccount,
+			},
+			{
+				Name:  "cooldown",
+				Usage: "Record or clear an account's usage limit so `switch --available` skips it until the window resets",
+				Subcommands: []*cli.Command{
+					{
+						Name:         "set",
+						Usage:        "Record that an account (the active one by default) hit its usage limit",
+						ArgsUsage:    "[account]",
+						BashComplete: completeAccountArgs(1),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "until",
+								Usage: "When the limit resets, as RFC 3339 (2026-01-02T15:04:05Z), a local HH:MM, or a duration from now (90m); defaults to 5h",
+							},
+						},
+						Action: setCooldown,
+					},
+					{
+						Name:         "clear",
+						Usage:        "Forget an account's usage limit (the active one by default)",
+						ArgsUsage:    "[account]",
+						BashComplete: completeAccountArgs(1),
+						Action:       clearCooldown,
+					},
+				},
+			},
+			{
+				Name:  "dashboard",
+				Usage: "Live view of every account: token expiry countdowns, usage windows and recent switches",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "How often to reload account state",
+						Value: 5 * time.Second,
+					},
+					&cli.BoolFlag{
+						Name:  "once",
+						Usage: "Print a single snapshot and exit",
+					},
+				},
+				Action: showDashboard,
+			},
+			{
+				Name:  "ui",
+				Usage: "Browse accounts full-screen: type to filter, switch, rename and remove",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Switch even while Claude Code is running",
+					},
+				},
+				Action: runUI,
+			},
+			{
+				Name:  "monitor",
+				Usage: "Watch Claude Code logs for usage-limit errors and auto-switch accounts",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "How often to poll the session logs",
+						Value: 5 * time.Second,
+					},
+					&cli.BoolFlag{
+						Name:  "refresh",
+						Usage: "Also refresh stored tokens nearing expiry in the background (or set token_refresh.enabled)",
+					},
+				},
+				Action: monitorAccounts,
+			},
+			{
+				Name:  "daemon",
+				Usage: "Keep accounts in memory and serve list, current, status and switch over a unix socket",
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "How often to check ~/.claude.json and the stored profiles for changes",
+						Value: 2 * time.Second,
+					},
+				},
+				Action: runDaemon,
+				Subcommands: []*cli.Command{
+					{
+						Name:   "status",
+						Usage:  "Report whether the daemon is running (exit 1 when it is not)",
+						Action: daemonStatus,
+					},
+					{
+						Name:   "stop",
+						Usage:  "Stop the running daemon",
+						Action: stopDaemon,
+					},
+				},
+			},
+			{
+				Name:      "completion",
+				Usage:     "Print the shell completion script (add `source <(cflip completion bash)` to your shell rc)",
+				ArgsUsage: "<bash|zsh>",
+				Action:    printCompletion,
+			},
+			{
+				Name:   "_complete",
+				Usage:  "Machine-readable completion candidates for scripts",
+				Hidden: true,
+				Subcommands: []*cli.Command{
+					{
+						Name:      "accounts",
+						Usage:     "Print aliases and emails starting with prefix, one per line",
+						ArgsUsage: "[prefix]",
+						Action:    completeCommand,
+					},
+				},
+			},
+			{
+				Name:         "exec",
+				Usage:        "Run a command under an account, then switch back to the previous one",
+				ArgsUsage:    "<account_number|email> [-- command [args...]]",
+				BashComplete: completeAccountArgs(1),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Force switch (skip safety checks)",
+					},
+				},
+				Action: execAccount,
+			},
+			{
+				Name:            "claude",
+				Usage:           "Run the claude CLI under the active, --account, --project or --available account and save refreshed tokens afterwards",
+				ArgsUsage:       "[--account <account_number|email> | --project | --available] [--] [claude args...]",
+				SkipFlagParsing: true,
+				Action:          runClaude,
+			},
+		},
+	}
+
+	args := os.Args
+	if settings, err := service.LoadSettings(); err == nil {
+		expanded, err := expandAliases(app, args, settings.Aliases)
+		if err != nil {
+			log.Fatal(err)
+		}
+		args = expanded
+	}
+
+	if err := app.Run(args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func addAccount(c *cli.Context) error {
+	alias := c.String("alias")
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if c.Bool("manual") {
+		return addManualAccount(svc, alias)
+	}
+
+	if dir := c.String("scan"); dir != "" {
+		return addAccountsFromScan(svc, dir)
+	}
+
+	if credentialsPath := c.String("from-file"); credentialsPath != "" {
+		return addAccountFromFiles(svc, credentialsPath, c.String("config"), c.String("email"), alias)
+	}
+	if c.String("config") != "" || c.String("email") != "" {
+		return fmt.Errorf("--config and --email require --from-file")
+	}
+
+	if alias != "" {
+		logger.Progress("Adding current account with alias: %s", alias)
+	} else {
+		logger.Progress("Adding current Claude Code account...")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	profile, err := client.Add(c.Context, alias)
+	if service.IsCredentialsMissing(err) {
+		recovered, recoverErr := recoverMissingCredentials(svc)
+		if recoverErr != nil {
+			return recoverErr
+		}
+		if !recovered {
+			return nil
+		}
+		profile, err = client.Add(c.Context, alias)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add account: %w", err)
+	}
+
+	displayName := profile.Alias
+	if displayName == "" {
+		displayName = profile.Email
+	}
+
+	logger.Success("Account added successfully: %s", displayName)
+	if profile.Email != displayName {
+		logger.Plain("   Email: %s", profile.Email)
+	}
+
+	if jsonOutput {
+		return printJSON(profile)
+	}
+	return nil
+}
+
+// addManualAccount prompts for tokens and account details, hiding secrets
+func addManualAccount(svc *service.Service, alias string) error {
+	if nonInteractive {
+		return errNeedsInteraction("add --manual", "use --from-file with an exported credentials file")
+	}
+
+	var entry service.ManualAccount
+	var err error
+	if entry.AccessToken, err = promptSecret("Access token: "); err != nil {
+		return err
+	}
+	if entry.RefreshToken, err = promptSecret("Refresh token: "); err != nil {
+		return err
+	}
+
+	if expiry := promptLine("Expires (RFC 3339 time or duration like 8h, blank if unknown): "); expiry != "" {
+		if d, parseErr := time.ParseDuration(expiry); parseErr == nil {
+			entry.ExpiresAt = time.Now().Add(d)
+		} else if t, parseErr := time.Parse(time.RFC3339, expiry); parseErr == nil {
+			entry.ExpiresAt = t
+		} else {
+			return fmt.Errorf("invalid expiry %q: use an RFC 3339 time or a duration", expiry)
+		}
+	}
+
+	entry.Email = promptLine("Email (blank to take it from the API): ")
+	entry.Organization = promptLine("Organization (optional): ")
+
+	logger.Progress("Verifying token with the Anthropic API...")
+	profile, err := svc.AddManualAccount(entry, alias)
+	if err != nil {
+		return fmt.Errorf("failed to add account: %w", err)
+	}
+
+	logger.Success("Account added successfully: %s", profile.Email)
+	if profile.Organization != "" {
+		logger.Plain("   Organization: %s", profile.Organization)
+	}
+	logger.InfoMsg("Switch to it with: cflip switch %s", profile.Email)
+
+	log := logger.NewDefault()
+	log.AccountAdded(profile.Email, profile.Alias)
+
+	if jsonOutput {
+		return printJSON(profile)
+	}
+	return nil
+}
+
+// addAccountsFromScan offers to import every distinct account found in a
+// directory of backups
+func addAccountsFromScan(svc *service.Service, dir string) error {
+	logger.Progress("Scanning %s for Claude Code backups...", dir)
+
+	result, err := svc.ScanBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range result.Unmatched {
+		logger.Warning("Skipping %s: no valid .claude.json next to it identifies the account", path)
+	}
+	if len(result.Candidates) == 0 {
+		logger.InfoMsg("No importable accounts found in %s", dir)
+		if jsonOutput {
+			return printJSON([]*service.ProfileInfo{})
+		}
+		return nil
+	}
+
+	imported := []*service.ProfileInfo{}
+	for _, candidate := range result.Candidates {
+		label := candidate.Email
+		if candidate.Organization != "" {
+			label = fmt.Sprintf("%s (%s)", candidate.Email, candidate.Organization)
+		}
+
+		if candidate.Managed {
+			logger.Plain("   %s - already managed, skipping", label)
+			continue
+		}
+
+		logger.Plain("")
+		logger.Plain("   %s", label)
+		logger.Plain("   credentials: %s", candidate.CredentialsPath)
+		if candidate.ExpiresAt.UnixMilli() != 0 {
+			logger.Plain("   token expires: %s", candidate.ExpiresAt.Format("2006-01-02 15:04"))
+		}
+
+		if nonInteractive {
+			return errNeedsInteraction("add --scan", "import accounts one at a time with --from-file")
+		}
+		if !confirmPrompt("Import this account? [y/N]: ") {
+			continue
+		}
+
+		profile, err := svc.AddAccountFromFiles(candidate.CredentialsPath, candidate.ConfigPath, "", "")
+		if err != nil {
+			logger.ErrorMsg("Failed to import %s: %v", candidate.Email, err)
+			continue
+		}
+
+		log := logger.NewDefault()
+		log.AccountAdded(profile.Email, profile.Alias)
+		imported = append(imported, profile)
+	}
+
+	logger.Success("Imported %d account(s) from %s", len(imported), dir)
+	if jsonOutput {
+		return printJSON(imported)
+	}
+	return nil
+}
+
+// addAccountFromFiles registers an account from exported files without
+// touching the account Claude Code is logged in with
+func addAccountFromFiles(svc *service.Service, credentialsPath, configPath, email, alias string) error {
+	logger.Progress("Importing account from %s...", credentialsPath)
+
+	profile, err := svc.AddAccountFromFiles(credentialsPath, configPath, email, alias)
+	if err != nil {
+		return fmt.Errorf("failed to add account: %w", err)
+	}
+
+	displayName := profile.Alias
+	if displayName == "" {
+		displayName = profile.Email
+	}
+
+	logger.Success("Account added successfully: %s", displayName)
+	if profile.Email != displayName {
+		logger.Plain("   Email: %s", profile.Email)
+	}
+	logger.InfoMsg("Switch to it with: cflip switch %s", profile.Email)
+
+	log := logger.NewDefault()
+	log.AccountAdded(profile.Email, profile.Alias)
+
+	if jsonOutput {
+		return printJSON(profile)
+	}
+	return nil
+}
+
+func listAccounts(c *cli.Context) error {
+	verbose := c.Bool("verbose")
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	// A running daemon already holds the accounts in memory
+	profiles, err := daemon.List()
+	if err != nil {
+		if profiles, err = client.List(c.Context); err != nil {
+			return fmt.Errorf("failed to list profiles: %w", err)
+		}
+	}
+
+	// Account numbers stay the ones `switch` accepts, even when filtered or
+	// grouped; shared accounts are switched to by name and have none
+	numbers := make(map[*service.ProfileInfo]int, len(profiles))
+	for i, profile := range profiles {
+		numbers[profile] = i + 1
+	}
+
+	if c.Bool("shared") {
+		shared, failures, err := client.ListShared(c.Context)
+		if err != nil {
+			return fmt.Errorf("failed to list shared profiles: %w", err)
+		}
+		names := make([]string, 0, len(failures))
+		for name := range failures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			logger.Warning("Could not fetch shared source %q: %v", name, failures[name])
+		}
+		profiles = append(profiles, shared...)
+	}
+
+	group, tags := c.String("group"), c.StringSlice("tag")
+	if group != "" || len(tags) > 0 {
+		var members []*service.ProfileInfo
+		for _, profile := range profiles {
+			if (group == "" || profile.InGroup(group)) && profile.HasTags(tags...) {
+				members = append(members, profile)
+			}
+		}
+		profiles = members
+	}
+
+	if jsonOutput {
+		if profiles == nil {
+			profiles = []*service.ProfileInfo{}
+		}
+		return printJSON(profiles)
+	}
+
+	if len(profiles) == 0 {
+		if len(tags) > 0 {
+			logger.InfoMsg("No accounts match %s", formatTags(tags))
+			return nil
+		}
+		if group != "" {
+			logger.InfoMsg("No accounts in group %q. Use 'cflip group add %s <account>' to add one.", group, group)
+			return nil
+		}
+		logger.InfoMsg("No accounts found. Use 'cflip add' to add your first account.")
+		return nil
+	}
+
+	groupBy := c.String("group-by")
+	if groupBy != "" && groupBy != "org" && groupBy != "group" {
+		return fmt.Errorf("unsupported --group-by %q (supported: org, group)", groupBy)
+	}
+
+	if group != "" {
+		logger.InfoMsg("📋 Managed accounts in %s (%d):", group, len(profiles))
+	} else {
+		logger.InfoMsg("📋 Managed accounts (%d):", len(profiles))
+	}
+	if groupBy == "" {
+		logger.Plain("")
+	}
+
+	if groupBy == "group" {
+		groups := make(map[string][]*service.ProfileInfo)
+		var names []string
+		for _, profile := range profiles {
+			memberOf := profile.Groups
+			if len(memberOf) == 0 {
+				memberOf = []string{""}
+			}
+			for _, name := range memberOf {
+				key := strings.ToLower(name)
+				if _, seen := groups[key]; !seen {
+					names = append(names, name)
+				}
+				groups[key] = append(groups[key], profile)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			// Accounts without a group go last
+			if (names[i] == "") != (names[j] == "") {
+				return names[j] == ""
+			}
+			return strings.ToLower(names[i]) < strings.ToLower(names[j])
+		})
+
+		for _, name := range names {
+			header := name
+			if header == "" {
+				header = "No group"
+			}
+			members := groups[strings.ToLower(name)]
+			logger.Header("🗂️  %s (%d)", header, len(members))
+			printAccountRows(members, numbers, true, verbose)
+		}
+		return nil
+	}
+
+	if groupBy == "org" {
+		groups := make(map[string][]*service.ProfileInfo)
+		var orgs []string
+		for _, profile := range profiles {
+			org := profile.Organization
+			if _, seen := groups[org]; !seen {
+				orgs = append(orgs, org)
+			}
+			groups[org] = append(groups[org], profile)
+		}
+		sort.Slice(orgs, func(i, j int) bool {
+			// Accounts without an organization go last
+			if (orgs[i] == "") != (orgs[j] == "") {
+				return orgs[j] == ""
+			}
+			return strings.ToLower(orgs[i]) < strings.ToLower(orgs[j])
+		})
+
+		for _, org := range orgs {
+			header := org
+			if header == "" {
+				header = "No organization"
+			}
+			logger.Header("🏢 %s (%d)", header, len(groups[org]))
+			printAccountRows(groups[org], numbers, false, verbose)
+		}
+		return nil
+	}
+
+	printAccountRows(profiles, numbers, true, verbose)
+	return nil
+}
+
+// printAccountRows prints list entries, optionally with an aligned Org column
+func printAccountRows(profiles []*service.ProfileInfo, numbers map[*service.ProfileInfo]int, orgColumn, verbose bool) {
+	names := make([]string, len(profiles))
+	width := 0
+	for i, profile := range profiles {
+		statusIcon := "○"
+		if profile.IsActive {
+			statusIcon = "●"
+		}
+
+		displayName := profile.Alias
+		if displayName == "" {
+			displayName = profile.Email
+		}
+
+		number := "-"
+		if n, ok := numbers[profile]; ok {
+			number = fmt.Sprintf("%d.", n)
+		}
+		names[i] = fmt.Sprintf("%s %s %s", statusIcon, number, displayName)
+		if profile.Email != displayName {
+			names[i] += fmt.Sprintf(" (%s)", profile.Email)
+		}
+		if n := len([]rune(names[i])); n > width {
+			width = n
+		}
+	}
+
+	for i, profile := range profiles {
+		accountInfo := names[i]
+		if orgColumn && profile.Organization != "" {
+			accountInfo += strings.Repeat(" ", width-len([]rune(names[i]))) + "  " + profile.Organization
+		}
+
+		if profile.Source != "" {
+			accountInfo += fmt.Sprintf(" [SHARED: %s]", profile.Source)
+		}
+
+		if profile.Modified {
+			accountInfo += " [MODIFIED OUTSIDE CFLIP]"
+		}
+
+		if profile.Locked {
+			accountInfo += " [LOCKED]"
+		}
+
+		if badge := profile.ExpiryBadge(); badge != "" {
+			accountInfo += fmt.Sprintf(" [%s]", badge)
+		}
+
+		if badge := profile.CooldownBadge(); badge != "" {
+			accountInfo += fmt.Sprintf(" [%s]", badge)
+		}
+
+		if profile.IsActive {
+			accountInfo += " [ACTIVE]"
+		} else if profile.LastUsed != "" {
+			accountInfo += fmt.Sprintf(" - last used %s", profile.LastUsed)
+		}
+
+		logger.Plain("%s", accountInfo)
+
+		if verbose {
+			logger.Plain("   Created: %s", profile.CreatedAt)
+			logger.Plain("   Updated: %s", profile.UpdatedAt)
+			if profile.LastActiveAt != "" {
+				logger.Plain("   Last Active: %s (%s)", profile.LastActiveAt, profile.LastUsed)
+			}
+			logger.Plain("   Switches: %d", profile.SwitchCount)
+			if len(profile.Tags) > 0 {
+				logger.Plain("   Tags: %s", formatTags(profile.Tags))
+			}
+			if profile.Notes != "" {
+				logger.Plain("   Notes: %s", profile.Notes)
+			}
+			logger.Plain("")
+		}
+	}
+}
+
+// warnIfExpiring prints a one-line stderr warning after any command when the
+// active account's token is about to expire
+func warnIfExpiring(c *cli.Context) error {
+	if c.Bool("no-expiry-warning") || c.String("remote") != "" || c.Args().Len() == 0 || c.Args().First() == "status" {
+		return nil
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return nil
+	}
+
+	if warning, err := svc.ExpiryWarning(); err == nil && warning != "" {
+		logger.Notice("%s", warning)
+	}
+
+	return nil
+}
+
+// remoteNativeCommands run locally and reach the remote host with raw SSH
+// file operations; every other command is forwarded to a remote cflip
+var remoteNativeCommands = map[string]bool{
+	"switch": true, "sw": true, "s": true,
+	"current": true, "cur": true,
+}
+
+// forwardRemoteCommand hands the command to cflip on the --remote host when
+// it cannot be served with local profiles and raw SSH file operations
+func forwardRemoteCommand(c *cli.Context) error {
+	host := c.String("remote")
+	if host == "" || c.Args().Len() == 0 || remoteNativeCommands[c.Args().First()] {
+		return nil
+	}
+
+	code, err := service.ForwardRemote(host, c.Args().Slice())
+	if err != nil {
+		return err
+	}
+	return cli.Exit("", code)
+}
+
+// autoAdoptSkips are commands that manage the live account themselves
+var autoAdoptSkips = map[string]bool{
+	"add": true, "a": true,
+	"reconcile":      true,
+	"restore-config": true,
+	"restore":        true,
+	"help":           true, "h": true,
+	// status runs in every shell prompt, so it must stay fast
+	"status": true,
+}
+
+// autoAdopt silently stores the live account before the command runs when
+// settings.auto_adopt is on. Failures never block the command.
+func autoAdopt(c *cli.Context) {
+	if c.String("remote") != "" || c.Args().Len() == 0 || autoAdoptSkips[c.Args().First()] {
+		return
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return
+	}
+	log := logger.NewDefault()
+	adopted, err := svc.AutoAdopt()
+	if err != nil {
+		log.DebugContext(c.Context, "auto-adopt failed", "error", err)
+		return
+	}
+	if adopted != nil {
+		log.AccountAdded(adopted.Email, "")
+	}
+}
+
+// switchRemoteAccount pushes a local profile to the --remote host
+func switchRemoteAccount(svc *service.Service, host, target string) error {
+	if target == "" {
+		return fmt.Errorf("an account identifier is required when switching a remote host")
+	}
+
+	fromEmail, _ := svc.RemoteCurrentEmail(host)
+
+	logger.Progress("Switching %s to account: %s", host, target)
+	profile, err := svc.SwitchRemote(host, target)
+	if err != nil {
+		return fmt.Errorf("failed to switch remote account: %w", err)
+	}
+
+	logger.Success("Successfully switched %s to: %s", host, profile.Email)
+	logger.InfoMsg("💡 Please restart Claude Code on %s to use the new account", host)
+
+	// Log audit event
+	log := logger.NewDefault()
+	log.RemoteAccountSwitched(host, fromEmail, profile.Email)
+
+	return nil
+}
+
+func switchAccount(c *cli.Context) error {
+	target := c.Args().First()
+	confirm := c.Bool("confirm")
+	force := c.Bool("force")
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if c.Bool("auto") {
+		if target != "" || c.Bool("next") || c.Bool("pick") || c.Bool("lru") || c.Bool("available") || c.String("group") != "" || c.String("org") != "" {
+			return fmt.Errorf("--auto cannot be combined with an account argument, --next, --pick, --lru, --available, --group or --org")
+		}
+		if c.Bool("no-desktop") {
+			svc.SkipDesktop()
+		}
+		return switchToProject(svc, force, false)
+	}
+
+	group := c.String("group")
+	if group != "" && (target != "" || c.Bool("pick") || c.Bool("lru") || c.Bool("available")) {
+		return fmt.Errorf("--group cannot be combined with an account argument, --pick, --lru or --available")
+	}
+
+	if c.Bool("available") {
+		if target != "" || c.Bool("next") || c.Bool("pick") || c.Bool("lru") {
+			return fmt.Errorf("--available cannot be combined with an account argument, --next, --pick or --lru")
+		}
+		if target, err = svc.NextAvailableAccount(); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("lru") {
+		if target != "" {
+			return fmt.Errorf("--lru cannot be combined with an account argument")
+		}
+		lru, err := svc.LeastRecentlyUsedAccount()
+		if err != nil {
+			return fmt.Errorf("failed to find the least recently used account: %w", err)
+		}
+		target = lru.Name
+	}
+
+	if c.Bool("pick") {
+		if target != "" {
+			return fmt.Errorf("--pick cannot be combined with an account argument")
+		}
+		if target, err = pickAccount(svc); err != nil {
+			return err
+		}
+		if target == "" {
+			logger.ErrorMsg("Switch cancelled")
+			return nil
+		}
+	}
+
+	// Rotating silently surprises people at a terminal, so ask instead
+	if target == "" && !c.Bool("next") && group == "" && c.String("remote") == "" && !nonInteractive && stdinIsTerminal() {
+		if target, err = chooseFromMenu(svc); err != nil {
+			return err
+		}
+		if target == "" {
+			logger.ErrorMsg("Switch cancelled")
+			return nil
+		}
+	}
+
+	if c.String("org") != "" && target == "" {
+		return fmt.Errorf("--org requires an account to switch to")
+	}
+
+	// If target is numeric, convert to account by index; shared accounts
+	// are switched to by name
+	if target != "" {
+		if index, err := strconv.Atoi(target); err == nil && index > 0 {
+			accounts, err := svc.ListProfiles()
+			if err != nil {
+				return err
+			}
+			if index <= len(accounts) {
+				target = accounts[index-1].Name
+			} else {
+				return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+			}
+		}
+	}
+
+	if host := c.String("remote"); host != "" {
+		return switchRemoteAccount(svc, host, target)
+	}
+
+	switch {
+	case target != "":
+		logger.Progress("Switching to account: %s", target)
+	case group != "":
+		logger.Progress("Switching to next account in %s...", group)
+	default:
+		logger.Progress("Switching to next account in sequence...")
+	}
+
+	if confirm && !force {
+		if nonInteractive {
+			return errNeedsInteraction("switch --confirm", "pass --force to switch without asking")
+		}
+		if !confirmPrompt("Are you sure you want to switch accounts? [y/N]: ") {
+			logger.ErrorMsg("Switch cancelled")
+			return nil
+		}
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	result, err := client.Switch(c.Context, target, &cflip.SwitchOptions{
+		Force:        force,
+		Organization: c.String("org"),
+		SkipDesktop:  c.Bool("no-desktop"),
+		Group:        group,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Success("Successfully switched to: %s", result.To.DisplayName())
+	printSwitchNotes(result)
+
+	// The switch already happened, so a failed check only warns
+	check, err := client.VerifySwitch(c.Context, c.Bool("verify"))
+	switch {
+	case err != nil:
+		logger.Warning("Could not verify the new credentials: %v", err)
+	case check.Refreshed:
+		logger.InfoMsg("🔄 Access token was rejected; refreshed it")
+	case check.Pinged:
+		logger.Success("Access token accepted")
+	}
+	if err == nil && check.Captured {
+		logger.InfoMsg("🔄 Captured tokens Claude Code refreshed into the profile")
+	}
+
+	logger.InfoMsg("💡 Please restart Claude Code to use the new account")
+
+	if jsonOutput {
+		output := struct {
+			*service.SwitchResult
+			Verify      *service.SwitchCheck `json:"verify,omitempty"`
+			VerifyError string               `json:"verify_error,omitempty"`
+		}{SwitchResult: result, Verify: check}
+		if err != nil {
+			output.VerifyError = err.Error()
+		}
+		return printJSON(output)
+	}
+	return nil
+}
+
+// printSwitchNotes reports what was synced from Claude Code into the
+// outgoing account and the token refresh done before a switch, and warns
+// when the account switched to is left with an expired token
+func printSwitchNotes(result *service.SwitchResult) {
+	if result.Sync != nil && result.Sync.Changed() {
+		logger.InfoMsg("🔄 Saved Claude Code's changes to %s: %s", result.Sync.Email, strings.Join(result.Sync.Fields(), ", "))
+	}
+
+	refresh := result.Refresh
+	switch {
+	case refresh == nil:
+	case refresh.Error != "":
+		logger.Warning("Token was due for a refresh that failed: %s", refresh.Error)
+	default:
+		logger.InfoMsg("🔄 Refreshed the expiring token (valid until %s)", refresh.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	if result.To.Expired {
+		logger.Warning("The token for %s expired at %s; run `cflip refresh` or log in again",
+			result.To.DisplayName(), result.To.ExpiresAt)
+	}
+}
+
+func removeAccount(c *cli.Context) error {
+	target := c.Args().First()
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	// If target is numeric, convert to account by index
+	if index, err := strconv.Atoi(target); err == nil && index > 0 {
+		accounts, _ := client.List(c.Context)
+		if index <= len(accounts) {
+			target = accounts[index-1].Name
+		} else {
+			return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+		}
+	}
+
+	logger.Warning("🗑️  Removing account: %s", target)
+
+	// Confirmation prompt
+	if !c.Bool("force") {
+		if nonInteractive {
+			return errNeedsInteraction("remove", "pass --force to remove without asking")
+		}
+		if !confirmPrompt("Are you sure you want to remove this account? [y/N]: ") {
+			logger.ErrorMsg("Removal cancelled")
+			return nil
+		}
+	}
+
+	if err := client.Remove(c.Context, target, &cflip.RemoveOptions{Purge: c.Bool("purge")}); err != nil {
+		return fmt.Errorf("failed to remove account: %w", err)
+	}
+
+	logger.Success("Account removed successfully: %s", target)
+	if !c.Bool("purge") {
+		logger.InfoMsg("💡 Changed your mind? Run `cflip undelete %s`", target)
+	}
+	return nil
+}
+
+func undeleteAccount(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target := c.Args().First()
+	if target == "" {
+		removed, err := svc.ListRemovedAccounts()
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			logger.InfoMsg("The trash is empty")
+			return nil
+		}
+
+		logger.InfoMsg("🗑️  Removed accounts (%d):", len(removed))
+		logger.Plain("")
+		for _, account := range removed {
+			name := account.Email
+			if account.Alias != "" {
+				name = fmt.Sprintf("%s (%s)", account.Alias, account.Email)
+			}
+			logger.Plain("  %s - removed %s, kept until %s", name,
+				account.DeletedAt.Format("2006-01-02 15:04"), account.PurgeAt.Format("2006-01-02"))
+		}
+		return nil
+	}
+
+	restored, err := svc.UndeleteAccount(target)
+	if err != nil {
+		return fmt.Errorf("failed to undelete account: %w", err)
+	}
+
+	logger.Success("Account restored: %s", restored.Email)
+	return nil
+}
+
+func unarchiveAccount(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target := c.Args().First()
+	if target == "" {
+		archived, err := svc.ListArchivedAccounts()
+		if err != nil {
+			return err
+		}
+		if len(archived) == 0 {
+			logger.InfoMsg("The archive is empty")
+			return nil
+		}
+
+		logger.InfoMsg("📦 Archived accounts (%d):", len(archived))
+		logger.Plain("")
+		for _, account := range archived {
+			name := account.Email
+			if account.Alias != "" {
+				name = fmt.Sprintf("%s (%s)", account.Alias, account.Email)
+			}
+			logger.Plain("  %s - archived %s", name, account.ArchivedAt.Format("2006-01-02 15:04"))
+		}
+		return nil
+	}
+
+	restored, err := svc.UnarchiveAccount(target)
+	if err != nil {
+		return fmt.Errorf("failed to unarchive account: %w", err)
+	}
+
+	logger.Success("Account restored: %s", restored.Email)
+	return nil
+}
+
+func currentAccount(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if c.Bool("check") {
+		if err := svc.CheckCurrent(); err != nil {
+			if c.Bool("quiet") {
+				return cli.Exit("", 1)
+			}
+			return cli.Exit("cflip: "+err.Error(), 1)
+		}
+		return nil
+	}
+
+	if host := c.String("remote"); host != "" {
+		email, err := svc.RemoteCurrentEmail(host)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			remote := struct {
+				Host  string `json:"host"`
+				Email string `json:"email"`
+				Alias string `json:"alias,omitempty"`
+			}{Host: host, Email: email}
+			if profile, err := svc.GetAccountByIdentifier(email); err == nil {
+				remote.Alias = profile.Alias
+			}
+			return printJSON(remote)
+		}
+
+		logger.InfoMsg("📍 Current account on %s:", host)
+		logger.Plain("   Email: %s", email)
+		if profile, err := svc.GetAccountByIdentifier(email); err == nil && profile.Alias != "" {
+			logger.Plain("   Name: %s", profile.Alias)
+		}
+		return nil
+	}
+
+	if c.String("account") == "" {
+		if err := checkLiveAccount(c, svc); err != nil {
+			return err
+		}
+	}
+
+	profile, err := resolveTargetAccount(c, svc)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(profile)
+	}
+
+	displayName := profile.Alias
+	if displayName == "" {
+		displayName = profile.Email
+	}
+
+	if !profile.IsActive {
+		logger.InfoMsg("📍 Account:")
+	} else {
+		logger.InfoMsg("📍 Current active account:")
+	}
+	logger.Plain("   Name: %s", displayName)
+	logger.Plain("   Email: %s", profile.Email)
+	if profile.AccountUuid != "" {
+		logger.Plain("   User ID: %s", profile.AccountUuid)
+	}
+	if profile.SubscriptionType != "" {
+		logger.Plain("   Subscription: %s", profile.SubscriptionType)
+	}
+	if badge := profile.ExpiryBadge(); badge != "" {
+		logger.Plain("   Token: %s (%s)", badge, profile.ExpiresAt)
+	}
+	if len(profile.Tags) > 0 {
+		logger.Plain("   Tags: %s", formatTags(profile.Tags))
+	}
+	if profile.Notes != "" {
+		logger.Plain("   Notes: %s", profile.Notes)
+	}
+	logger.Plain("   Last Updated: %s", profile.UpdatedAt)
+
+	if profile.IsActive {
+		logger.Success("   Status: ACTIVE")
+	} else {
+		logger.Plain("   Status: inactive")
+	}
+
+	return nil
+}
+
+// checkLiveAccount warns when Claude Code is logged in to a different account
+// than cflip's active pointer says, and offers to fix the pointer
+func checkLiveAccount(c *cli.Context, svc *service.Service) error {
+	status, err := svc.DetectActive()
+	if err != nil || status.InSync {
+		return nil
+	}
+
+	pointer := "no account"
+	if status.Pointer != nil {
+		pointer = status.Pointer.Email
+	}
+
+	if status.LiveEmail == "" && status.LiveUuid == "" {
+		logger.Warning("Claude Code is not logged in, but cflip's active account is %s", pointer)
+		return nil
+	}
+
+	logger.Warning("Claude Code is logged in as %s, but cflip's active account is %s", status.LiveEmail, pointer)
+	if status.Managed == nil {
+		logger.InfoMsg("💡 Run `cflip add` to manage %s", status.LiveEmail)
+		return nil
+	}
+
+	reconcile := c.Bool("reconcile")
+	if !reconcile && !nonInteractive {
+		reconcile = confirmPrompt("Mark %s as the active account? [y/N]: ", status.Managed.Email)
+	}
+	if !reconcile {
+		logger.InfoMsg("💡 Run `cflip current --reconcile` to fix the active account")
+		return nil
+	}
+
+	if _, err := svc.ReconcileActive(); err != nil {
+		return fmt.Errorf("failed to reconcile active account: %w", err)
+	}
+	logger.Success("Active account updated to %s", status.Managed.Email)
+	return nil
+}
+
+// resolveTargetAccount returns the profile named by the global --account
+// flag, or the active profile when the flag is not set
+func resolveTargetAccount(c *cli.Context, svc *service.Service) (*service.ProfileInfo, error) {
+	target := c.String("account")
+	if target == "" {
+		profile, err := svc.GetCurrentAccount()
+		if err != nil {
+			return nil, fmt.Errorf("no active account found: %w", err)
+		}
+		return profile, nil
+	}
+
+	// If target is numeric, convert to account by index
+	if index, err := strconv.Atoi(target); err == nil && index > 0 {
+		accounts, _ := svc.ListProfiles()
+		if index > len(accounts) {
+			return nil, fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+		}
+		return accounts[index-1], nil
+	}
+
+	profile, err := svc.GetAccountByIdentifier(target)
+	if err != nil {
+		return nil, fmt.Errorf("account not found: %w", err)
+	}
+	return profile, nil
+}
+
+func getField(c *cli.Context) error {
+	field := c.Args().First()
+	if field == "" {
+		return fmt.Errorf("field name required (e.g. email, alias, expires_at)")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	profile, err := resolveTargetAccount(c, svc)
+	if err != nil {
+		return err
+	}
+
+	// Fields are addressed by their JSON names so scripts see a stable schema
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to unmarshal profile: %w", err)
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown or empty field %q (available: %s)", field, strings.Join(names, ", "))
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+func renameAccount(c *cli.Context) error {
+	if c.Args().Len() < 2 {
+		return fmt.Errorf("both account identifier and new alias required")
+	}
+	target := c.Args().Get(0)
+	newAlias := c.Args().Get(1)
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	// Get old alias for audit logging
+	var oldAlias string
+	if acc, err := svc.GetAccountByIdentifier(target); err == nil {
+		oldAlias = acc.Alias
+	}
+
+	// If target is numeric, convert to account by index
+	if index, err := strconv.Atoi(target); err == nil && index > 0 {
+		accounts, _ := svc.ListProfiles()
+		if index <= len(accounts) {
+			target = accounts[index-1].Name
+		} else {
+			return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+		}
+	}
+
+	logger.Progress("🏷️  Renaming account %s to alias: %s", target, newAlias)
+
+	err = svc.RenameAccount(target, newAlias)
+	if err != nil {
+		return fmt.Errorf("failed to rename account: %w", err)
+	}
+
+	logger.Success("Account renamed successfully: %s", newAlias)
+
+	// Log audit event
+	log := logger.NewDefault()
+	log.AccountRenamed(target, oldAlias, newAlias)
+
+	return nil
+}
+
+func moveAccount(c *cli.Context) error {
+	if c.Args().Len() < 2 {
+		return fmt.Errorf("both account identifier and position required")
+	}
+	target := c.Args().Get(0)
+	position, err := strconv.Atoi(c.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("invalid position: %s", c.Args().Get(1))
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	account, err := svc.MoveAccount(target, position)
+	if err != nil {
+		return fmt.Errorf("failed to move account: %w", err)
+	}
+
+	if jsonOutput {
+		accounts, err := svc.ListProfiles()
+		if err != nil {
+			return err
+		}
+		return printJSON(accounts)
+	}
+	logger.Success("%s is now account %d", account.DisplayName(), position)
+	return nil
+}
+
+func validateAccounts(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if c.Bool("online") && (c.Bool("schema") || c.Bool("fix") || c.Bool("accept-modified")) {
+		return fmt.Errorf("--online cannot be combined with --schema, --fix or --accept-modified")
+	}
+
+	if c.String("account") != "" {
+		profile, err := resolveTargetAccount(c, svc)
+		if err != nil {
+			return err
+		}
+		if c.Bool("online") {
+			return validateAccountOnline(svc, profile)
+		}
+
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		err = client.Validate(c.Context, profile.Name)
+		if jsonOutput {
+			result := service.ValidationResult{Account: profile.Alias, Email: profile.Email, Status: service.ValidationValid}
+			if result.Account == "" {
+				result.Account = profile.Name
+			}
+			if err != nil {
+				result.Status, result.Error = service.ValidationInvalid, err.Error()
+			}
+			if printErr := printJSON(result); printErr != nil {
+				return printErr
+			}
+			if err != nil {
+				return cli.Exit("", 1)
+			}
+			return nil
+		}
+		if err != nil {
+			logger.ErrorMsg("%s: %s", profile.Email, err.Error())
+			return fmt.Errorf("account failed validation")
+		}
+
+		logger.Success("Account is valid: %s", profile.Email)
+		return nil
+	}
+
+	if jsonOutput && (c.Bool("schema") || c.Bool("fix") || c.Bool("accept-modified")) {
+		return fmt.Errorf("--output json is not supported with --schema, --fix or --accept-modified")
+	}
+
+	if c.Bool("schema") {
+		return validateSchemas(svc)
+	}
+
+	if c.Bool("fix") {
+		return repairAccounts(svc)
+	}
+
+	if c.Bool("accept-modified") {
+		return acceptModified(svc)
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	if c.Bool("json") || jsonOutput {
+		return validateAccountsJSON(c, client)
+	}
+
+	if c.Bool("online") {
+		logger.Progress("🔍 Validating all stored accounts against the Anthropic API...")
+	} else {
+		logger.Progress("🔍 Validating all stored accounts...")
+	}
+
+	results, err := client.ValidateAll(c.Context, &cflip.ValidateOptions{
+		Jobs:   c.Int("jobs"),
+		Online: c.Bool("online"),
+		Progress: func(done, total int, result service.ValidationResult) {
+			if result.Err != nil {
+				logger.Plain("  [%d/%d] ❌ %s: %s", done, total, result.Account, validationFailure(result))
+			} else {
+				logger.Plain("  [%d/%d] ✅ %s: %s", done, total, result.Account, validationSummary(result))
+			}
+			if result.Refresh != nil && result.Refresh.Error != "" {
+				logger.Plain("         token refresh failed: %s", result.Refresh.Error)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to validate accounts: %w", err)
+	}
+
+	var failed, unchecked int
+	for _, result := range results {
+		if result.Status == service.ValidationNetworkError {
+			unchecked++
+		} else if result.Err != nil {
+			failed++
+		}
+	}
+
+	logger.Plain("")
+	if failed == 0 && unchecked == 0 {
+		logger.Success("All accounts are valid")
+		return nil
+	}
+
+	if failed > 0 {
+		logger.ErrorMsg("Found %d invalid accounts", failed)
+		logger.InfoMsg("Run `cflip validate --fix` to attempt automatic repairs")
+	}
+	if unchecked > 0 {
+		logger.Warning("Could not reach the Anthropic API to check %d account(s); try again later", unchecked)
+	}
+
+	return fmt.Errorf("%d accounts failed validation", failed+unchecked)
+}
+
+// validateAccountsJSON runs validate --json: one result object per account
+// on stdout, exiting 1 when any account is invalid
+func validateAccountsJSON(c *cli.Context, client *cflip.Client) error {
+	results, err := client.ValidateAll(c.Context, &cflip.ValidateOptions{Jobs: c.Int("jobs"), Online: c.Bool("online")})
+	if err != nil {
+		return fmt.Errorf("failed to validate accounts: %w", err)
+	}
+	if results == nil {
+		results = []service.ValidationResult{}
+	}
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal validation results: %w", err)
+	}
+	fmt.Println(string(data))
+
+	for _, result := range results {
+		if result.Err != nil {
+			return cli.Exit("", 1)
+		}
+	}
+	return nil
+}
+
+// validateAccountOnline runs validate --online --account
+func validateAccountOnline(svc *service.Service, profile *service.ProfileInfo) error {
+	result, err := svc.ValidateAccountOnline(profile.Name)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+		if result.Err != nil {
+			return cli.Exit("", 1)
+		}
+		return nil
+	}
+	if result.Err != nil {
+		logger.ErrorMsg("%s: %s", profile.Email, validationFailure(result))
+		return fmt.Errorf("account failed validation")
+	}
+
+	logger.Success("Account is valid: %s (%s)", profile.Email, validationSummary(result))
+	return nil
+}
+
+// validationSummary describes a passing result, e.g. "valid (Acme, max)"
+func validationSummary(result service.ValidationResult) string {
+	var details []string
+	if result.Organization != "" {
+		details = append(details, result.Organization)
+	}
+	if result.Subscription != "" {
+		details = append(details, result.Subscription)
+	}
+	if result.Refresh != nil && result.Refresh.Refreshed {
+		details = append(details, "token refreshed")
+	}
+	if len(details) == 0 {
+		return "valid"
+	}
+	return "valid (" + strings.Join(details, ", ") + ")"
+}
+
+// validationFailure describes a failing result, naming online failures
+func validationFailure(result service.ValidationResult) string {
+	switch result.Status {
+	case service.ValidationExpired, service.ValidationRevoked:
+		return result.Status + ": " + result.Error
+	case service.ValidationNetworkError:
+		return "could not check: " + result.Error
+	}
+	return result.Error
+}
+
+// acceptModified runs validate --accept-modified
+func acceptModified(svc *service.Service) error {
+	accepted, err := svc.AcceptModified()
+	for _, email := range accepted {
+		logger.Success("Accepted current contents of %s", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(accepted) == 0 {
+		logger.InfoMsg("No profiles were modified outside cflip")
+	}
+	return nil
+}
+
+// validateSchemas runs validate --schema and reports problems by file and path
+func validateSchemas(svc *service.Service) error {
+	logger.Progress("🔍 Checking stored files against the JSON Schemas...")
+
+	problems, err := svc.ValidateSchemas()
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		logger.Success("All files match the schema")
+		return nil
+	}
+
+	lastFile := ""
+	for _, problem := range problems {
+		if problem.File != lastFile {
+			logger.Plain("")
+			logger.ErrorMsg("%s", problem.File)
+			lastFile = problem.File
+		}
+		logger.Plain("  • %s: %s", problem.Path, problem.Message)
+	}
+
+	return fmt.Errorf("%d schema problem(s) found", len(problems))
+}
+
+// repairAccounts runs validate --fix and reports fixed versus manual items
+func repairAccounts(svc *service.Service) error {
+	logger.Progress("🔧 Checking stored accounts and repairing what can be fixed...")
+
+	results, err := svc.RepairAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to repair accounts: %w", err)
+	}
+
+	if len(results) == 0 {
+		logger.Success("No problems found")
+		return nil
+	}
+
+	var manual int
+	for _, result := range results {
+		if result.Fixed {
+			logger.Success("%s: %s - %s", result.Account, result.Problem, result.Action)
+		} else {
+			manual++
+			logger.Warning("%s: %s - %s", result.Account, result.Problem, result.Action)
+		}
+	}
+
+	logger.Plain("")
+	logger.InfoMsg("Fixed %d problem(s), %d need manual action", len(results)-manual, manual)
+	if manual > 0 {
+		return fmt.Errorf("%d problem(s) need manual action", manual)
+	}
+
+	return nil
+}
+
+func reconcileAccounts(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("🔄 Comparing the live Claude Code state with stored accounts...")
+
+	actions, err := svc.Reconcile()
+	for _, action := range actions {
+		logger.Success("%s: %s", action.Account, action.Action)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reconcile: %w", err)
+	}
+
+	if len(actions) == 0 {
+		logger.Success("Everything is in sync")
+	}
+	return nil
+}
+
+func rewriteProfiles(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	rewritten, err := svc.RewriteProfiles()
+	for _, name := range rewritten {
+		logger.Success("%s: rewritten", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if svc.SopsEnabled() {
+		logger.InfoMsg("%d profile(s) are now encrypted with sops", len(rewritten))
+	} else {
+		logger.InfoMsg("%d profile(s) are now stored as plain JSON", len(rewritten))
+	}
+	return nil
+}
+
+func restoreConfig(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	points, err := svc.ListRestorePoints()
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("list") {
+		if len(points) == 0 {
+			logger.InfoMsg("No restore points yet; one is created before every switch")
+			return nil
+		}
+		logger.InfoMsg("📋 Restore points (%d):", len(points))
+		logger.Plain("")
+		for i, point := range points {
+			email := point.Email
+			if email == "" {
+				email = "unknown account"
+			}
+			logger.Plain("  %d. %s  %s (before %s)", i+1, point.CreatedAt.Local().Format("2006-01-02 15:04:05"), email, point.Reason)
+		}
+		return nil
+	}
+
+	number := 1
+	if arg := c.Args().First(); arg != "" {
+		if number, err = strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("invalid restore point number: %s", arg)
+		}
+	}
+	if len(points) > 0 && number >= 1 && number <= len(points) {
+		point := points[number-1]
+		logger.Progress("Restoring snapshot of %s from %s", point.Email, point.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if !c.Bool("force") {
+		if nonInteractive {
+			return errNeedsInteraction("restore-config", "pass --force to restore without asking")
+		}
+		if !confirmPrompt("Overwrite the live Claude Code config and credentials? [y/N]: ") {
+			logger.ErrorMsg("Restore cancelled")
+			return nil
+		}
+	}
+
+	point, err := svc.RestoreConfig(number)
+	if err != nil {
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+
+	logger.Success("Restored Claude Code config and credentials for %s", point.Email)
+	logger.InfoMsg("💡 Run `cflip restore-config` again to undo, and restart Claude Code")
+	return nil
+}
+
+func selfUpdate(c *cli.Context) error {
+	logger.Progress("Checking for a newer release...")
+	result, err := service.SelfUpdate(c.Bool("check"), c.Bool("force"))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.Updated:
+		logger.Success("Updated cflip %s → %s (%s)", result.Current, result.Latest, result.Path)
+		logger.Plain("   Signature and checksum verified")
+	case result.UpdateAvailable():
+		logger.InfoMsg("cflip %s is available (you have %s); run `cflip self-update` to install it", result.Latest, result.Current)
+	default:
+		logger.Success("cflip %s is up to date", result.Current)
+	}
+	return nil
+}
+
+func upgradeCflip(c *cli.Context) error {
+	plan, err := service.PlanUpgrade()
+	if err != nil {
+		return err
+	}
+
+	logger.InfoMsg("Installed via %s: %s", plan.Method, plan.Path)
+	if len(plan.Command) == 0 {
+		if c.Bool("dry-run") {
+			logger.Plain("   Would run: cflip self-update")
+			return nil
+		}
+		return selfUpdate(c)
+	}
+
+	command := strings.Join(plan.Command, " ")
+	if c.Bool("dry-run") {
+		logger.Plain("   Would run: %s", command)
+		return nil
+	}
+
+	logger.Progress("Running %s", command)
+	if err := plan.RunUpgrade(); err != nil {
+		return err
+	}
+	logger.Success("cflip upgraded")
+	return nil
+}
+
+func exportState(c *cli.Context) error {
+	if !c.Bool("redact") {
+		return exportAccounts(c)
+	}
+	if c.Args().Present() {
+		return fmt.Errorf("--redact exports cflip's whole state; do not name accounts")
+	}
+	if c.Bool("qr") {
+		return fmt.Errorf("--qr shows an account archive; it cannot be combined with --redact")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	export := svc.ExportRedacted(service.ExportOptions{
+		KeepEmails: c.Bool("keep-emails"),
+		LogLines:   c.Int("lines"),
+	})
+	data, err := export.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal export: %w", err)
+	}
+
+	output := c.String("output")
+	if output == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+	if err := os.WriteFile(output, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	logger.Success("Redacted export written to %s", output)
+	if c.Bool("keep-emails") {
+		logger.Warning("Email addresses were left readable; review the file before sharing it")
+	}
+	return nil
+}
+
+// exportAccounts seals accounts, credentials included, into an encrypted
+// archive for `cflip import` on another machine
+func exportAccounts(c *cli.Context) error {
+	if c.Bool("qr") && c.String("output") != "" {
+		return fmt.Errorf("--qr and --output cannot be used together")
+	}
+	passphrase, err := transferPassphrase("export", true)
+	if err != nil {
+		return err
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("Encrypting accounts...")
+	data, entries, err := svc.ExportAccounts(c.Args().Slice(), passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to export accounts: %w", err)
+	}
+
+	output := c.String("output")
+	if c.Bool("qr") {
+		if err := printQRCodes(data); err != nil {
+			return fmt.Errorf("failed to show QR codes: %w", err)
+		}
+	} else if output == "" {
+		fmt.Println(string(data))
+	} else if err := os.WriteFile(output, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	emails := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		emails = append(emails, entry.Email)
+	}
+	logger.NewDefault().AccountsExported(emails)
+
+	if output != "" {
+		logger.Success("Exported %d account(s) to %s", len(entries), output)
+		for _, entry := range entries {
+			logger.Plain("   %s", entry.Name)
+		}
+		logger.Warning("The archive holds live tokens; keep it and its passphrase private")
+	}
+	return nil
+}
+
+func importAccounts(c *cli.Context) error {
+	if c.NArg() != 1 && !(c.Bool("qr") && c.NArg() == 0) {
+		return fmt.Errorf("usage: cflip import <archive> or cflip import --qr")
+	}
+	policy, err := transfer.ParseCollisionPolicy(c.String("on-conflict"))
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if c.Bool("qr") {
+		data, err = readQRArchive()
+	} else {
+		data, err = os.ReadFile(c.Args().First())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	passphrase, err := transferPassphrase("import", false)
+	if err != nil {
+		return err
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	dryRun := c.Bool("dry-run")
+	plan, err := svc.ImportAccounts(data, passphrase, policy, dryRun)
+	log := logger.NewDefault()
+	var added, replaced int
+	for _, item := range plan {
+		switch item.Action {
+		case transfer.ActionAdd:
+			added++
+			logger.Plain("   %s: add", item.Entry.Name)
+		case transfer.ActionReplace:
+			replaced++
+			logger.Plain("   %s: replace (%s)", item.Entry.Name, item.Reason)
+		default:
+			logger.Plain("   %s: skip (%s)", item.Entry.Name, item.Reason)
+			continue
+		}
+		if !dryRun {
+			log.AccountImported(item.Entry.Email, item.Action == transfer.ActionReplace)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to import accounts: %w", err)
+	}
+
+	if dryRun {
+		logger.InfoMsg("Dry run: %d to add, %d to replace, %d to skip", added, replaced, len(plan)-added-replaced)
+		return nil
+	}
+	logger.Success("Imported %d account(s), replaced %d, skipped %d", added, replaced, len(plan)-added-replaced)
+	return nil
+}
+
+// transferPassphrase reads the archive passphrase from $CFLIP_TRANSFER_PASSPHRASE
+// or prompts for it; a new passphrase is asked for twice
+func transferPassphrase(what string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(transfer.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if nonInteractive {
+		return "", fmt.Errorf("%s needs a passphrase but cflip is running non-interactively; set %s", what, transfer.PassphraseEnv)
+	}
+
+	passphrase, err := promptSecret("Archive passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		if len(passphrase) < transfer.MinPassphraseLength {
+			return "", fmt.Errorf("passphrase must be at least %d characters", transfer.MinPassphraseLength)
+		}
+		again, err := promptSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+func debugBundle(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	output := c.String("output")
+	if output == "" {
+		output = fmt.Sprintf("cflip-debug-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	logger.Progress("Collecting diagnostics...")
+	if err := svc.WriteDebugBundle(output, service.DebugBundleOptions{
+		KeepEmails: c.Bool("keep-emails"),
+		LogLines:   c.Int("lines"),
+	}); err != nil {
+		return err
+	}
+
+	logger.Success("Debug bundle written to %s", output)
+	logger.InfoMsg("Tokens and UUIDs are hashed; review the files before attaching them to an issue")
+	if c.Bool("keep-emails") {
+		logger.Warning("Email addresses were left readable")
+	}
+	return nil
+}
+
+func runDoctor(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("🩺 Checking cflip and Claude Code...")
+	report := svc.Doctor(c.Bool("fix-perms"))
+
+	if jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		for _, check := range report.Checks {
+			switch check.Status {
+			case service.DoctorPass:
+				logger.Success("%s: %s", check.Name, check.Message)
+			case service.DoctorWarn:
+				logger.Warning("%s: %s", check.Name, check.Message)
+			default:
+				logger.ErrorMsg("%s: %s", check.Name, check.Message)
+			}
+			if check.Status != service.DoctorPass || c.Bool("verbose") {
+				for _, detail := range check.Details {
+					logger.Plain("   %s", detail)
+				}
+			}
+		}
+		logger.Plain("")
+		logger.Plain("summary: pass=%d warn=%d fail=%d", report.Summary.Pass, report.Summary.Warn, report.Summary.Fail)
+	}
+
+	if !report.Healthy() {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func refreshTokens(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	log := logger.NewDefault()
+
+	if c.Bool("all") {
+		logger.Progress("Refreshing tokens expiring within %s...", c.Duration("within"))
+
+		results, err := svc.RefreshAccounts(c.Duration("within"), c.Bool("force"))
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, result := range results {
+			switch {
+			case result.Refreshed:
+				logger.Success("%s: refreshed, valid until %s", result.Email, result.ExpiresAt.Format("2006-01-02 15:04"))
+				log.TokenRefreshed(result.Email)
+			case result.Error != "":
+				failed++
+				logger.ErrorMsg("%s: %s", result.Email, result.Error)
+			default:
+				logger.Plain("   %s: skipped (%s)", result.Email, result.Skipped)
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d account(s) failed to refresh", failed)
+		}
+		return nil
+	}
+
+	target := c.Args().First()
+	if target == "" {
+		current, err := svc.GetCurrentAccount()
+		if err != nil {
+			return fmt.Errorf("account identifier or --all required: %w", err)
+		}
+		target = current.Name
+	} else if target, err = resolveAccountArg(svc, target); err != nil {
+		return err
+	}
+
+	logger.Progress("Refreshing tokens for %s...", target)
+	result, err := svc.RefreshAccount(target)
+	if err != nil {
+		return fmt.Errorf("failed to refresh %s: %w", target, err)
+	}
+
+	logger.Success("%s: refreshed, valid until %s", result.Email, result.ExpiresAt.Format("2006-01-02 15:04"))
+	log.TokenRefreshed(result.Email)
+
+	return nil
+}
+
+// resolveAccountArg turns an account number into its profile name; other
+// identifiers are returned unchanged
+func resolveAccountArg(svc *service.Service, target string) (string, error) {
+	index, err := strconv.Atoi(target)
+	if err != nil || index <= 0 {
+		return target, nil
+	}
+
+	accounts, _ := svc.ListProfiles()
+	if index > len(accounts) {
+		return "", fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+	}
+	return accounts[index-1].Name, nil
+}
+
+func copySettings(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: cflip copy-settings [--keys settings,mcp] <from> <to>")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	from, err := resolveAccountArg(svc, c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	to, err := resolveAccountArg(svc, c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	var sections []string
+	for _, key := range strings.Split(c.String("keys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			sections = append(sections, key)
+		}
+	}
+
+	copied, notes, err := svc.CopySettings(from, to, sections)
+	for _, note := range notes {
+		logger.InfoMsg("%s", note)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy settings: %w", err)
+	}
+	if len(copied) == 0 {
+		return nil
+	}
+
+	logger.Success("Copied %s from %s to %s", strings.Join(copied, ", "), from, to)
+	return nil
+}
+
+func showOverlay(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	overlay, err := svc.SettingsOverlay(target)
+	if err != nil {
+		return err
+	}
+	if overlay == nil {
+		logger.InfoMsg("%s has no settings overlay", target)
+		return nil
+	}
+
+	fmt.Println(string(overlay))
+	return nil
+}
+
+func setOverlay(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: cflip overlay set <account> <file.json|->")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if path := c.Args().Get(1); path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read overlay: %w", err)
+	}
+
+	if err := svc.SetSettingsOverlay(target, data); err != nil {
+		return fmt.Errorf("failed to set overlay: %w", err)
+	}
+
+	logger.Success("Settings overlay saved for %s (applied whenever it is active)", target)
+	return nil
+}
+
+func clearOverlay(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	if err := svc.SetSettingsOverlay(target, nil); err != nil {
+		return fmt.Errorf("failed to clear overlay: %w", err)
+	}
+
+	logger.Success("Settings overlay cleared for %s", target)
+	return nil
+}
+
+func listOrganizations(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	orgs, current, err := svc.Organizations(target)
+	if err != nil {
+		return err
+	}
+	if len(orgs) == 0 {
+		logger.InfoMsg("No organizations known for %s", target)
+		return nil
+	}
+
+	for _, org := range orgs {
+		marker := "○"
+		if org.UUID == current {
+			marker = "●"
+		}
+		name := org.Name
+		if name == "" {
+			name = org.UUID
+		}
+		line := fmt.Sprintf("%s %s", marker, name)
+		if org.OrganizationRole != "" {
+			line += fmt.Sprintf(" [%s]", org.OrganizationRole)
+		}
+		logger.Plain("%s", line)
+		logger.Plain("   %s", org.UUID)
+	}
+	return nil
+}
+
+func addOrganization(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	membership := service.OrgMembership{
+		UUID:             c.String("uuid"),
+		Name:             c.String("name"),
+		OrganizationRole: c.String("role"),
+		WorkspaceRole:    c.String("workspace-role"),
+	}
+	if err := svc.AddOrganization(target, membership); err != nil {
+		return fmt.Errorf("failed to add organization: %w", err)
+	}
+
+	logger.Success("Organization recorded for %s; switch with: cflip switch --org %q %s", target, membership.Name, target)
+	return nil
+}
+
+func showProxy(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	proxy, err := svc.Proxy(target)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case proxy == nil:
+		logger.InfoMsg("%s has no proxy settings (settings.json is left as is)", target)
+	case proxy.Direct:
+		logger.Plain("%s: direct (proxy variables removed)", target)
+	default:
+		logger.Plain("%s: %s", target, proxy.URL)
+		if proxy.NoProxy != "" {
+			logger.Plain("   no proxy: %s", proxy.NoProxy)
+		}
+	}
+	return nil
+}
+
+func setProxy(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: cflip proxy set [--no-proxy hosts] <account> <proxy_url|direct>")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	proxy := &service.ProxySettings{NoProxy: c.String("no-proxy")}
+	if value := c.Args().Get(1); value == "direct" {
+		proxy = &service.ProxySettings{Direct: true}
+	} else {
+		proxy.URL = value
+	}
+
+	if err := svc.SetProxy(target, proxy); err != nil {
+		return fmt.Errorf("failed to set proxy: %w", err)
+	}
+
+	logger.Success("Proxy saved for %s (applied whenever it is active)", target)
+	return nil
+}
+
+func clearProxy(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	target, err := resolveAccountArg(svc, c.Args().First())
+	if err != nil {
+		return err
+	}
+	if target == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
+	if err := svc.SetProxy(target, nil); err != nil {
+		return fmt.Errorf("failed to clear proxy: %w", err)
+	}
+
+	logger.Success("Proxy settings cleared for %s", target)
+	return nil
+}
+
+func enforcePolicies(c *cli.Context) error {
+	dryRun := c.Bool("dry-run")
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("Enforcing retention policies...")
+
+	actions, err := svc.EnforcePolicies(dryRun)
+	for _, action := range actions {
+		displayName := action.Alias
+		if displayName == "" {
+			displayName = action.Email
+		}
+
+		switch action.Action {
+		case "archive":
+			if dryRun {
+				logger.Plain("  • would archive %s (%s)", displayName, action.Reason)
+			} else {
+				logger.Plain("  • archived %s (%s)", displayName, action.Reason)
+			}
+		default:
+			logger.Warning("%s: %s", displayName, action.Reason)
+		}
+	}
+	if err != nil {
+		return err
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+	if len(actions) == 0 {
+		logger.Success("All profiles comply with retention policies")
+	}
+	for _, action := range actions {
+		if action.Action == "archive" && !dryRun {
+			logger.InfoMsg("💡 Bring an archived account back with `cflip unarchive <email>`")
+			break
+		}
 	}
+
+	return nil
 }
 
-func addAccount(c *cli.Context) error {
-	alias := c.String("alias")
+func showStats(c *cli.Context) error {
+	days := c.Int("days")
 
 	svc, err := service.NewService()
 	if err != nil {
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	if alias != "" {
-		logger.Progress("Adding current account with alias: %s", alias)
-	} else {
-		logger.Progress("Adding current Claude Code account...")
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
 	}
 
-	profile, err := svc.AddCurrentAccount(alias)
+	report, err := svc.Stats(since)
 	if err != nil {
-		return fmt.Errorf("failed to add account: %w", err)
+		return fmt.Errorf("failed to build stats: %w", err)
 	}
 
-	displayName := profile.Alias
-	if displayName == "" {
-		displayName = profile.Email
+	if c.Bool("json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
 	}
 
-	logger.Success("Account added successfully: %s", displayName)
-	if profile.Email != displayName {
-		logger.Plain("   Email: %s", profile.Email)
+	if len(report.Accounts) == 0 {
+		logger.InfoMsg("No usage recorded yet.")
+		return nil
 	}
 
-	// Log audit event
-	log := logger.NewDefault()
-	log.AccountAdded(profile.Email, profile.Alias)
+	if days > 0 {
+		logger.InfoMsg("📊 Usage over the last %d days:", days)
+	} else {
+		logger.InfoMsg("📊 Usage (all time):")
+	}
+	logger.Plain("")
+
+	for _, account := range report.Accounts {
+		displayName := account.Alias
+		if displayName == "" {
+			displayName = account.Email
+		}
+
+		logger.Plain("%s", displayName)
+		logger.Plain("   Switches: %d", account.Switches)
+		logger.Plain("   Time active: %s (avg session %s)", account.TotalActive, account.AverageSession)
+		if account.ClaudeSessions > 0 {
+			logger.Plain("   Claude sessions: %d", account.ClaudeSessions)
+		}
+		if account.Usage != nil {
+			printUsageTotals(account.Usage)
+		}
+
+		weeks := make([]string, 0, len(account.SwitchesPerWeek))
+		for week := range account.SwitchesPerWeek {
+			weeks = append(weeks, week)
+		}
+		sort.Strings(weeks)
+		for _, week := range weeks {
+			logger.Plain("     %s: %d", week, account.SwitchesPerWeek[week])
+		}
+		logger.Plain("")
+	}
+
+	logger.Plain("Most used:  %s", report.MostUsed)
+	logger.Plain("Least used: %s", report.LeastUsed)
+
+	if report.Unattributed != nil {
+		logger.Plain("")
+		logger.Plain("Unattributed (no switch history at the time):")
+		printUsageTotals(report.Unattributed)
+	}
 
 	return nil
 }
 
-func listAccounts(c *cli.Context) error {
-	verbose := c.Bool("verbose")
+// printUsageTotals prints token usage and cost lines for stats output
+func printUsageTotals(usage *service.UsageTotals) {
+	logger.Plain("   Messages: %d", usage.Messages)
+	logger.Plain("   Tokens: %d in / %d out / %d cache write / %d cache read",
+		usage.InputTokens, usage.OutputTokens, usage.CacheCreationTokens, usage.CacheReadTokens)
+	if usage.CostUSD > 0 {
+		logger.Plain("   Cost: $%.2f", usage.CostUSD)
+	}
+}
+
+func rotateAccount(c *cli.Context) error {
+	dryRun := c.Bool("dry-run")
 
 	svc, err := service.NewService()
 	if err != nil {
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	profiles, err := svc.ListProfiles()
+	result, err := svc.Rotate(dryRun, c.Bool("force"))
+	if result != nil && result.Applied {
+		// Log audit event even if a hook failed afterwards
+		log := logger.NewDefault()
+		log.AccountSwitched(result.FromEmail, result.ToEmail, result.ToProfile)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to list profiles: %w", err)
+		return fmt.Errorf("failed to rotate account: %w", err)
 	}
 
-	if len(profiles) == 0 {
-		logger.InfoMsg("No accounts found. Use 'cflip add' to add your first account.")
+	if result == nil {
+		logger.Success("Schedule satisfied, no rotation needed")
 		return nil
 	}
 
-	logger.InfoMsg("📋 Managed accounts (%d):", len(profiles))
-	logger.Plain("")
+	if dryRun {
+		logger.InfoMsg("Would switch to %s (%s)", result.ToEmail, result.Rule)
+		return nil
+	}
 
-	for i, profile := range profiles {
-		statusIcon := "○"
-		if profile.IsActive {
-			statusIcon = "●"
-		}
+	logger.Success("Rotated to %s (%s)", result.ToEmail, result.Rule)
+	return nil
+}
 
-		displayName := profile.Alias
+func recommendAccount(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	recommendations, err := svc.Recommend()
+	if err != nil {
+		return fmt.Errorf("failed to rank accounts: %w", err)
+	}
+
+	if len(recommendations) == 0 {
+		logger.InfoMsg("No accounts found. Use 'cflip add' to add your first account.")
+		return nil
+	}
+
+	logger.InfoMsg("🎯 Accounts ranked by estimated remaining capacity:")
+	logger.Plain("")
+	logger.Plain("   %-4s %-32s %-8s %-10s %-9s %s", "#", "ACCOUNT", "TIER", "WINDOW", "TOKEN", "SCORE")
+	for i, rec := range recommendations {
+		displayName := rec.Alias
 		if displayName == "" {
-			displayName = profile.Email
+			displayName = rec.Email
+		}
+		if rec.IsActive {
+			displayName += " *"
 		}
 
-		accountInfo := fmt.Sprintf("%s %d. %s", statusIcon, i+1, displayName)
-		if profile.Email != displayName {
-			accountInfo += fmt.Sprintf(" (%s)", profile.Email)
+		tier := rec.Tier
+		if tier == "" {
+			tier = "-"
 		}
 
-		if profile.IsActive {
-			accountInfo += " [ACTIVE]"
+		window := rec.WindowUsed
+		if rec.CooldownUntil != "" {
+			window = "limited"
 		}
 
-		// Note: We don't have expiration check in ProfileInfo, could add if needed
+		logger.Plain("   %-4d %-32s %-8s %-10s %-9s %.2f", i+1, displayName, tier, window, rec.TokenStatus, rec.Score)
+	}
+	logger.Plain("")
 
-		logger.Plain("%s", accountInfo)
+	top := recommendations[0]
+	if !c.Bool("switch") {
+		return nil
+	}
 
-		if verbose {
-			logger.Plain("   Created: %s", profile.CreatedAt)
-			logger.Plain("   Updated: %s", profile.UpdatedAt)
-			if profile.LastActiveAt != "" {
-				logger.Plain("   Last Active: %s", profile.LastActiveAt)
-			}
-			logger.Plain("")
-		}
+	if top.IsActive {
+		logger.Success("Already on the top recommendation: %s", top.Email)
+		return nil
 	}
 
+	logger.Progress("Switching to account: %s", top.Email)
+	svc.SetInitiator("recommend")
+	if _, err := svc.Switch(top.Name, c.Bool("force")); err != nil {
+		return err
+	}
+
+	logger.Success("Successfully switched to: %s", top.Email)
+	logger.InfoMsg("💡 Please restart Claude Code to use the new account")
 	return nil
 }
 
-func switchAccount(c *cli.Context) error {
-	target := c.Args().First()
-	confirm := c.Bool("confirm")
-	force := c.Bool("force")
-
+func monitorAccounts(c *cli.Context) error {
 	svc, err := service.NewService()
 	if err != nil {
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	// Get current account for audit logging
-	var fromEmail string
-	if currentAcc, err := svc.GetCurrentAccount(); err == nil {
-		fromEmail = currentAcc.Email
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// If target is numeric, convert to account by index
-	if target != "" {
-		if index, err := strconv.Atoi(target); err == nil && index > 0 {
-			accounts, _ := svc.ListProfiles()
-			if index <= len(accounts) {
-				target = accounts[index-1].Email
-			} else {
-				return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
-			}
-		}
-	}
+	logger.InfoMsg("👀 Monitoring Claude Code for usage limits (Ctrl+C to stop)...")
 
-	if target != "" {
-		logger.Progress("Switching to account: %s", target)
-	} else {
-		logger.Progress("Switching to next account in sequence...")
+	if err := startAutoRefresh(ctx, c, svc); err != nil {
+		return err
 	}
 
-	if confirm && !force {
-		logger.Question("Are you sure you want to switch accounts? [y/N]: ")
-		var response string
-		fmt.Scanln(&response)
-		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
-			logger.ErrorMsg("Switch cancelled")
-			return nil
+	return svc.Monitor(ctx, c.Duration("interval"), func(event service.MonitorEvent) {
+		switch event.Kind {
+		case "rate_limit":
+			logger.Warning("Usage limit detected on %s", event.FromEmail)
+		case "switched":
+			logger.Success("Switched to %s", event.ToEmail)
+
+			// Log audit event
+			log := logger.NewDefault()
+			log.AccountSwitched(event.FromEmail, event.ToEmail, event.ToProfile)
+		case "captured":
+			logger.InfoMsg("🔄 Stored tokens Claude Code refreshed for %s", event.ToEmail)
+		case "error":
+			logger.ErrorMsg("Monitor: %v", event.Err)
 		}
-	}
+	})
+}
 
-	err = svc.SwitchToAccount(target, force)
+// startAutoRefresh runs scheduled token refresh alongside the monitor when
+// enabled by --refresh or the token_refresh setting
+func startAutoRefresh(ctx context.Context, c *cli.Context, svc *service.Service) error {
+	settings, err := service.LoadSettings()
 	if err != nil {
-		return fmt.Errorf("failed to switch account: %w", err)
+		return err
+	}
+	if !c.Bool("refresh") && !settings.TokenRefresh.Enabled {
+		return nil
 	}
 
-	// Get the account we switched to
-	currentAccount, err := svc.GetCurrentAccount()
+	window, err := settings.TokenRefresh.WindowDuration()
 	if err != nil {
-		return fmt.Errorf("failed to get current account: %w", err)
+		return err
 	}
-
-	displayName := currentAccount.Alias
-	if displayName == "" {
-		displayName = currentAccount.Email
+	interval, err := settings.TokenRefresh.IntervalDuration()
+	if err != nil {
+		return err
 	}
-	logger.Success("Successfully switched to: %s", displayName)
-	logger.InfoMsg("💡 Please restart Claude Code to use the new account")
 
-	// Log audit event
-	log := logger.NewDefault()
-	log.AccountSwitched(fromEmail, currentAccount.Email)
+	logger.InfoMsg("🔄 Refreshing tokens expiring within %s, checking about every %s", window, interval)
+
+	go svc.AutoRefresh(ctx, service.RefreshSchedule{Window: window, Interval: interval}, func(result service.RefreshResult) {
+		switch {
+		case result.Refreshed:
+			logger.Success("Refreshed %s, valid until %s", result.Email, result.ExpiresAt.Format("2006-01-02 15:04"))
+			log := logger.NewDefault()
+			log.TokenRefreshed(result.Email)
+		case result.Email != "":
+			logger.ErrorMsg("Refresh %s: %s", result.Email, result.Error)
+		default:
+			logger.ErrorMsg("Refresh: %s", result.Error)
+		}
+	})
 
 	return nil
 }
 
-func removeAccount(c *cli.Context) error {
-	target := c.Args().First()
-	if target == "" {
+// claudeSelection is how `cflip claude` picks the account to run under; the
+// active account is used when nothing is set
+type claudeSelection struct {
+	account   string // --account: an explicit account
+	project   bool   // --project: the account a .cflip file binds
+	available bool   // --available: skip the active account when it is limited
+}
+
+// splitClaudeArgs extracts cflip's leading flags from the args passed
+// through to claude. An optional -- ends them.
+func splitClaudeArgs(args []string) (claudeSelection, []string, error) {
+	var selection claudeSelection
+	for len(args) > 0 {
+		switch {
+		case args[0] == "--account":
+			if len(args) < 2 {
+				return selection, nil, fmt.Errorf("--account requires a value")
+			}
+			selection.account = args[1]
+			args = args[2:]
+			continue
+		case strings.HasPrefix(args[0], "--account="):
+			selection.account = strings.TrimPrefix(args[0], "--account=")
+		case args[0] == "--project":
+			selection.project = true
+		case args[0] == "--available":
+			selection.available = true
+		case args[0] == "--":
+			return selection, args[1:], nil
+		default:
+			return selection, args, nil
+		}
+		args = args[1:]
+	}
+	return selection, args, nil
+}
+
+func execAccount(c *cli.Context) error {
+	args := c.Args().Slice()
+	if len(args) == 0 {
 		return fmt.Errorf("account identifier required")
 	}
+	target, command := args[0], args[1:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		command = []string{"claude"}
+	}
 
 	svc, err := service.NewService()
 	if err != nil {
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	// If target is numeric, convert to account by index
 	if index, err := strconv.Atoi(target); err == nil && index > 0 {
 		accounts, _ := svc.ListProfiles()
-		if index <= len(accounts) {
-			target = accounts[index-1].Email
-		} else {
+		if index > len(accounts) {
 			return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
 		}
+		target = accounts[index-1].Name
 	}
 
-	logger.Warning("🗑️  Removing account: %s", target)
-
-	// Confirmation prompt
-	logger.Question("Are you sure you want to remove this account? [y/N]: ")
-	var response string
-	fmt.Scanln(&response)
-	if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
-		logger.ErrorMsg("Removal cancelled")
-		return nil
+	result, err := svc.Exec(target, command, c.Bool("force"))
+	if result != nil && result.Recovered != "" {
+		logger.Warning("Switched back to %s, which an interrupted `cflip exec` left unrestored", result.Recovered)
 	}
-
-	err = svc.RemoveAccount(target)
 	if err != nil {
-		return fmt.Errorf("failed to remove account: %w", err)
+		return err
+	}
+	if result.Restored != "" {
+		logger.InfoMsg("🔄 Switched back to %s", result.Restored)
 	}
 
-	logger.Success("Account removed successfully: %s", target)
-
-	// Log audit event
-	log := logger.NewDefault()
-	log.AccountRemoved(target)
-
+	if result.ExitCode != 0 {
+		return cli.Exit("", result.ExitCode)
+	}
 	return nil
 }
 
-func currentAccount(c *cli.Context) error {
-	svc, err := service.NewService()
+func runClaude(c *cli.Context) error {
+	selection, args, err := splitClaudeArgs(c.Args().Slice())
 	if err != nil {
-		return fmt.Errorf("failed to initialize service: %w", err)
+		return err
+	}
+	if selection.account == "" {
+		selection.account = c.String("account")
+	}
+	set := 0
+	for _, on := range []bool{selection.account != "", selection.project, selection.available} {
+		if on {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("--account, --project and --available cannot be combined")
 	}
 
-	profile, err := svc.GetCurrentAccount()
+	svc, err := service.NewService()
 	if err != nil {
-		return fmt.Errorf("no active account found: %w", err)
+		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	displayName := profile.Alias
-	if displayName == "" {
-		displayName = profile.Email
+	target := selection.account
+	switch {
+	case selection.project:
+		if err := switchToProjectForClaude(svc); err != nil {
+			return err
+		}
+	case selection.available:
+		// The active account is kept unless it is cooling down
+		if current, err := svc.GetCurrentAccount(); err != nil || current.CooldownUntil != "" {
+			if target, err = svc.NextAvailableAccount(); err != nil {
+				return err
+			}
+		}
 	}
 
-	logger.InfoMsg("📍 Current active account:")
-	logger.Plain("   Name: %s", displayName)
-	logger.Plain("   Email: %s", profile.Email)
-	if profile.AccountUuid != "" {
-		logger.Plain("   User ID: %s", profile.AccountUuid)
+	if target != "" {
+		if index, err := strconv.Atoi(target); err == nil && index > 0 {
+			accounts, _ := svc.ListProfiles()
+			if index > len(accounts) {
+				return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+			}
+			target = accounts[index-1].Name
+		}
+
+		if err := switchForClaude(svc, target); err != nil {
+			return err
+		}
 	}
-	logger.Plain("   Last Updated: %s", profile.UpdatedAt)
 
-	// For now, we'll always show as ACTIVE since it's the current profile
-	logger.Success("   Status: ACTIVE")
+	for {
+		result, err := svc.RunClaude(args)
+		if err != nil {
+			return err
+		}
 
-	return nil
-}
+		// Record the session against the account for usage stats
+		log := logger.NewDefault()
+		log.ClaudeSession(result.Email, result.Duration, result.ExitCode, result.RateLimited)
 
-func renameAccount(c *cli.Context) error {
-	if c.Args().Len() < 2 {
-		return fmt.Errorf("both account identifier and new alias required")
-	}
-	target := c.Args().Get(0)
-	newAlias := c.Args().Get(1)
+		if result.Sync != nil {
+			logger.InfoMsg("🔄 Saved Claude Code's changes to %s: %s", result.Sync.Email, strings.Join(result.Sync.Fields(), ", "))
+		}
 
-	svc, err := service.NewService()
-	if err != nil {
-		return fmt.Errorf("failed to initialize service: %w", err)
-	}
+		if !result.RateLimited {
+			if result.ExitCode != 0 {
+				return cli.Exit("", result.ExitCode)
+			}
+			return nil
+		}
 
-	// Get old alias for audit logging
-	var oldAlias string
-	if acc, err := svc.GetAccountByIdentifier(target); err == nil {
-		oldAlias = acc.Alias
-	}
+		next, err := svc.NextHealthyAccount()
+		if err != nil {
+			logger.Warning("Usage limit hit on %s and no other healthy account is available", result.Email)
+			return cli.Exit("", result.ExitCode)
+		}
 
-	// If target is numeric, convert to account by index
-	if index, err := strconv.Atoi(target); err == nil && index > 0 {
-		accounts, _ := svc.ListProfiles()
-		if index <= len(accounts) {
-			target = accounts[index-1].Email
-		} else {
-			return fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(accounts))
+		logger.Warning("Usage limit hit on %s", result.Email)
+		if nonInteractive {
+			logger.InfoMsg("Not switching to %s in non-interactive mode; run `cflip switch %s` to continue", next.Email, next.Name)
+			return cli.Exit("", result.ExitCode)
+		}
+		if !confirmPrompt("Switch to %s and retry? [y/N]: ", next.Email) {
+			return cli.Exit("", result.ExitCode)
 		}
-	}
 
-	logger.Progress("🏷️  Renaming account %s to alias: %s", target, newAlias)
+		if err := switchForClaude(svc, next.Name); err != nil {
+			return err
+		}
+	}
+}
 
-	err = svc.RenameAccount(target, newAlias)
+// switchToProjectForClaude switches to the account the nearest .cflip file
+// binds before launching claude
+func switchToProjectForClaude(svc *service.Service) error {
+	dir, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to rename account: %w", err)
+		return fmt.Errorf("failed to get current directory: %w", err)
 	}
 
-	logger.Success("Account renamed successfully: %s", newAlias)
+	result, err := svc.SwitchToProject(dir, false)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("no .cflip file found in %s or its parents; run `cflip use --project <account>` to create one", dir)
+	}
+	if result.Switch != nil {
+		logger.Success("Switched to %s (from %s)", result.Switch.To.DisplayName(), result.File)
+	}
+	return nil
+}
 
-	// Log audit event
-	log := logger.NewDefault()
-	log.AccountRenamed(target, oldAlias, newAlias)
+// switchForClaude switches accounts before launching claude, logging the audit event
+func switchForClaude(svc *service.Service, target string) error {
+	// Resolve first: organizations of one login share an email
+	resolution, err := svc.ResolveIdentifier(target)
+	if err != nil {
+		return err
+	}
+	if resolution.Profile.IsActive {
+		return nil
+	}
 
-	return nil
+	logger.Progress("Switching to account: %s", resolution.Profile.DisplayName())
+	svc.SetInitiator("claude")
+	_, err = svc.Switch(resolution.Profile.Name, false)
+	return err
 }
 
-func validateAccounts(c *cli.Context) error {
+func whichAccount(c *cli.Context) error {
+	identifier := c.Args().First()
+	if identifier == "" {
+		return fmt.Errorf("account identifier required")
+	}
+
 	svc, err := service.NewService()
 	if err != nil {
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	logger.Progress("🔍 Validating all stored accounts...")
+	resolution, err := svc.ResolveIdentifier(identifier)
+	if err != nil {
+		return err
+	}
 
-	errors := svc.ValidateAccounts()
-	if len(errors) == 0 {
-		logger.Success("All accounts are valid")
-		return nil
+	displayName := resolution.Profile.Alias
+	if displayName == "" {
+		displayName = resolution.Profile.Email
 	}
 
-	logger.ErrorMsg("Found %d invalid accounts:", len(errors))
-	logger.Plain("")
-	for accountName, err := range errors {
-		logger.Plain("  • %s: %s", accountName, err.Error())
+	logger.InfoMsg("🔎 %q resolves to %s", identifier, displayName)
+	logger.Plain("   Matched by: %s", resolution.Rule)
+	logger.Plain("   Email: %s", resolution.Profile.Email)
+	logger.Plain("   Profile name: %s", resolution.Profile.Name)
+	if resolution.ProfilePath != "" {
+		logger.Plain("   Profile file: %s", resolution.ProfilePath)
 	}
+	logger.Plain("   Credentials: %s", resolution.Credentials)
 
-	return fmt.Errorf("%d accounts failed validation", len(errors))
+	return nil
 }
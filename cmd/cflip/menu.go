@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// chooseFromMenu prints a numbered menu of accounts and reads a selection.
+// It returns the chosen profile name, or "" when the user cancels.
+func chooseFromMenu(svc *service.Service) (string, error) {
+	profiles, err := svc.ListProfiles()
+	if err != nil {
+		return "", err
+	}
+	if len(profiles) == 0 {
+		return "", fmt.Errorf("no accounts found; run `cflip add` first")
+	}
+
+	logger.InfoMsg("📋 Switch to which account?")
+	logger.Plain("")
+	for i, profile := range profiles {
+		name := profile.Email
+		if profile.Alias != "" {
+			name = fmt.Sprintf("%s (%s)", profile.Alias, profile.Email)
+		}
+		if profile.Organization != "" {
+			name += " · " + profile.Organization
+		}
+		if profile.IsActive {
+			name += " [ACTIVE]"
+		}
+		logger.Plain("  %d) %s", i+1, name)
+	}
+	logger.Plain("")
+
+	answer := promptLine(fmt.Sprintf("Account [1-%d, Enter to cancel]: ", len(profiles)))
+	if answer == "" {
+		return "", nil
+	}
+
+	index, err := strconv.Atoi(answer)
+	if err != nil || index < 1 || index > len(profiles) {
+		return "", fmt.Errorf("invalid selection: %s", answer)
+	}
+	return profiles[index-1].Name, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/urfave/cli/v2"
+)
+
+// Values of the global --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// jsonOutput is set by --output json: list, current, validate, switch, add
+// and doctor print one JSON document on stdout and their messages on stderr
+var jsonOutput bool
+
+// configureOutput applies --output before any command runs
+func configureOutput(c *cli.Context) error {
+	switch format := c.String("output"); format {
+	case outputText, "":
+		jsonOutput = false
+	case outputJSON:
+		jsonOutput = true
+	default:
+		return fmt.Errorf("unsupported --output %q (use text or json)", format)
+	}
+	logger.SetStructuredOutput(jsonOutput)
+	return nil
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// pickerRows is how many matches the picker shows at once
+const pickerRows = 10
+
+// pickerItem is one account offered by the picker
+type pickerItem struct {
+	name  string
+	label string
+}
+
+// pickerMatch is a filtered item with its fuzzy score
+type pickerMatch struct {
+	item  pickerItem
+	score int
+}
+
+// pickAccount opens a type-to-filter picker over alias, email and
+// organization. It returns the chosen profile name, or "" when the user cancels.
+func pickAccount(svc *service.Service) (string, error) {
+	if !stdinIsTerminal() {
+		return "", fmt.Errorf("--pick needs a terminal")
+	}
+
+	profiles, err := svc.ListProfiles()
+	if err != nil {
+		return "", err
+	}
+	if len(profiles) == 0 {
+		return "", fmt.Errorf("no accounts found; run `cflip add` first")
+	}
+
+	items := make([]pickerItem, 0, len(profiles))
+	for _, profile := range profiles {
+		label := profile.Email
+		if profile.Alias != "" {
+			label = profile.Alias + "  " + profile.Email
+		}
+		if profile.Organization != "" {
+			label += "  " + profile.Organization
+		}
+		if profile.IsActive {
+			label += "  [ACTIVE]"
+		}
+		items = append(items, pickerItem{name: profile.Name, label: label})
+	}
+
+	restore, err := rawInput()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	return runPicker(items)
+}
+
+// rawInput puts the terminal into unbuffered, unechoed input and returns a
+// function restoring the previous settings
+func rawInput() (func(), error) {
+	saved, err := sttySettings()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read terminal settings: %w", err)
+	}
+	if err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, fmt.Errorf("cannot switch the terminal to raw input: %w", err)
+	}
+	stop := restoreTerminalOnSignal(func() { stty(saved) })
+	return func() {
+		stop()
+		stty(saved)
+	}, nil
+}
+
+// runPicker handles keystrokes until a selection is made or cancelled
+func runPicker(items []pickerItem) (string, error) {
+	var query []rune
+	selected, drawn := 0, 0
+	buf := make([]byte, 16)
+
+	for {
+		matches := filterItems(items, string(query))
+		if selected >= len(matches) {
+			selected = len(matches) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+		drawn = drawPicker(string(query), matches, selected, drawn)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		key := buf[:n]
+
+		switch {
+		case string(key) == "\x1b[A" || key[0] == 0x10: // Up, Ctrl-P
+			selected--
+		case string(key) == "\x1b[B" || key[0] == 0x0e: // Down, Ctrl-N
+			selected++
+		case key[0] == '\r' || key[0] == '\n':
+			clearPicker(drawn)
+			if len(matches) == 0 {
+				return "", nil
+			}
+			return matches[selected].item.name, nil
+		case key[0] == 0x1b || key[0] == 0x03 || key[0] == 0x04: // Esc, Ctrl-C, Ctrl-D
+			clearPicker(drawn)
+			return "", nil
+		case key[0] == 0x7f || key[0] == 0x08: // Backspace
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			selected = 0
+		case key[0] == 0x15: // Ctrl-U
+			query = query[:0]
+			selected = 0
+		default:
+			query = appendPrintable(query, key)
+			selected = 0
+		}
+	}
+}
+
+// drawPicker redraws the prompt and matches on stderr, replacing the
+// previous frame, and returns how many lines it drew
+func drawPicker(query string, matches []pickerMatch, selected, previous int) int {
+	var b strings.Builder
+	if previous > 0 {
+		fmt.Fprintf(&b, "\r\x1b[%dA", previous)
+	}
+	b.WriteString("\r\x1b[J")
+
+	lines := 0
+	start := 0
+	if selected >= pickerRows {
+		start = selected - pickerRows + 1
+	}
+	for i := start; i < len(matches) && i < start+pickerRows; i++ {
+		marker := "  "
+		if i == selected {
+			marker = "> "
+		}
+		b.WriteString(marker + matches[i].item.label + "\n")
+		lines++
+	}
+	if len(matches) == 0 {
+		b.WriteString("  (no matching accounts)\n")
+		lines++
+	}
+	fmt.Fprintf(&b, "Switch to (↑/↓, Enter, Esc): %s", query)
+
+	fmt.Fprint(os.Stderr, b.String())
+	return lines
+}
+
+// clearPicker erases the picker before returning to normal output
+func clearPicker(drawn int) {
+	if drawn > 0 {
+		fmt.Fprintf(os.Stderr, "\r\x1b[%dA", drawn)
+	}
+	fmt.Fprint(os.Stderr, "\r\x1b[J")
+}
+
+// filterItems keeps items matching the query, best matches first
+func filterItems(items []pickerItem, query string) []pickerMatch {
+	var matches []pickerMatch
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item.label); ok {
+			matches = append(matches, pickerMatch{item: item, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
+
+// fuzzyScore reports whether the query's characters appear in order in text,
+// ignoring case. Consecutive characters and matches at word starts score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, last := 0, 0, -1
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if last == ti-1 {
+			score += 2
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 3
+		}
+		last = ti
+		qi++
+	}
+	return score, qi == len(q)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// useProject runs `cflip use --project [account]`
+func useProject(c *cli.Context) error {
+	if !c.Bool("project") {
+		return fmt.Errorf("use needs --project; run `cflip switch <account>` to switch directly")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if account := c.Args().First(); account != "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		path, info, err := svc.BindProject(dir, account, c.String("org"))
+		if err != nil {
+			return fmt.Errorf("failed to bind project: %w", err)
+		}
+		logger.Success("Bound %s to %s", dir, info.DisplayName())
+		logger.InfoMsg("💡 Commit %s so everyone on the project uses the same account", path)
+	} else if c.String("org") != "" {
+		return fmt.Errorf("--org requires an account to bind")
+	}
+
+	return switchToProject(svc, c.Bool("force"), true)
+}
+
+// switchToProject switches to the account bound to the current directory.
+// Without a binding it fails when required, and otherwise does nothing so
+// `switch --auto` can run from shell hooks.
+func switchToProject(svc *service.Service, force, required bool) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	result, err := svc.SwitchToProject(dir, force)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		if required {
+			return fmt.Errorf("no .cflip file found in %s or its parents; run `cflip use --project <account>` to create one", dir)
+		}
+		logger.InfoMsg("No .cflip file in %s or its parents; nothing to switch", dir)
+		if jsonOutput {
+			return printJSON(nil)
+		}
+		return nil
+	}
+
+	if result.Switch == nil {
+		logger.InfoMsg("Already using %s (from %s)", result.Binding.Account, result.File)
+	} else {
+		logger.Success("Switched to %s (from %s)", result.Switch.To.DisplayName(), result.File)
+		printSwitchNotes(result.Switch)
+		logger.InfoMsg("💡 Please restart Claude Code to use the new account")
+	}
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/storage"
+	"github.com/urfave/cli/v2"
+)
+
+// nonInteractive is set by --non-interactive or detected in CI; prompts fail
+// instead of waiting for input that will never arrive
+var nonInteractive bool
+
+// promptTimeout is set by --prompt-timeout; unanswered prompts take their
+// default after it (zero waits forever)
+var promptTimeout time.Duration
+
+// stdinReader is shared so buffered input survives across prompts
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// errPromptTimeout is returned by readLine when no answer arrived in time
+var errPromptTimeout = errors.New("no answer before the prompt timed out")
+
+// lineResult is one line read from stdin
+type lineResult struct {
+	line string
+	err  error
+}
+
+// pendingLine holds a read still in flight after a prompt timed out, so the
+// next prompt receives that line instead of racing a second reader
+var pendingLine chan lineResult
+
+// prompt is a question answered on stdin. Answers may be typed or piped in;
+// an empty line, end of input, or the timeout all select the default.
+type prompt struct {
+	question      string
+	defaultAnswer string
+	timeout       time.Duration
+}
+
+// ask shows the question and returns the answer, or the default
+func (p prompt) ask() string {
+	logger.Question("%s", p.question)
+
+	answer, err := readLine(p.timeout)
+	switch {
+	case errors.Is(err, errPromptTimeout):
+		fmt.Println()
+		logger.Notice("No answer within %s; using the default (%s)", p.timeout, describeAnswer(p.defaultAnswer))
+		return p.defaultAnswer
+	case err != nil:
+		// End of input: nothing more will be piped in
+		fmt.Println()
+		return p.defaultAnswer
+	}
+
+	if !stdinIsTerminal() {
+		fmt.Println(answer) // Show piped answers, which the terminal did not echo
+	}
+	if answer == "" {
+		return p.defaultAnswer
+	}
+	return answer
+}
+
+// describeAnswer names a default answer for messages
+func describeAnswer(answer string) string {
+	switch strings.ToLower(answer) {
+	case "":
+		return "cancel"
+	case "n", "no":
+		return "no"
+	case "y", "yes":
+		return "yes"
+	default:
+		return answer
+	}
+}
+
+// readLine reads one trimmed line from stdin, giving up after timeout
+func readLine(timeout time.Duration) (string, error) {
+	if pendingLine == nil {
+		pendingLine = make(chan lineResult, 1)
+		go func(result chan<- lineResult) {
+			line, err := stdinReader.ReadString('\n')
+			result <- lineResult{line, err}
+		}(pendingLine)
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case result := <-pendingLine:
+		pendingLine = nil
+		if result.err != nil && result.line == "" {
+			return "", result.err
+		}
+		return strings.TrimSpace(result.line), nil
+	case <-expired:
+		return "", errPromptTimeout
+	}
+}
+
+// errNeedsInteraction explains why a prompt was refused in non-interactive mode
+func errNeedsInteraction(what, hint string) error {
+	return fmt.Errorf("%s requires confirmation but cflip is running non-interactively; %s", what, hint)
+}
+
+// confirmPrompt asks a yes/no question, defaulting to no
+func confirmPrompt(question string, args ...interface{}) bool {
+	response := prompt{
+		question:      fmt.Sprintf(question, args...),
+		defaultAnswer: "n",
+		timeout:       promptTimeout,
+	}.ask()
+	response = strings.ToLower(response)
+	return response == "y" || response == "yes"
+}
+
+// promptLine asks for a line of visible input; no answer returns ""
+func promptLine(question string) string {
+	return prompt{question: question, timeout: promptTimeout}.ask()
+}
+
+// promptSecret asks for input without echoing it, via stty so no terminal
+// library is needed. Piped input is read as-is.
+func promptSecret(question string) (string, error) {
+	logger.Question("%s", question)
+
+	if !stdinIsTerminal() {
+		line, err := readLine(promptTimeout)
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+		return line, nil
+	}
+
+	if err := stty("-echo"); err != nil {
+		return "", fmt.Errorf("cannot hide input on this terminal: %w", err)
+	}
+	stop := restoreTerminalOnSignal(func() { stty("echo") })
+	line, err := readLine(promptTimeout)
+	stop()
+	stty("echo")
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return line, nil
+}
+
+// runningInCI reports whether a CI system is running cflip, going by the
+// CI variable that GitHub Actions, GitLab, CircleCI and others set
+func runningInCI() bool {
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "0", "false", "no":
+		return false
+	default:
+		return true
+	}
+}
+
+// stdinIsTerminal reports whether stdin is a terminal rather than a pipe or file
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file that answers are
+// being fed from
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && (info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular())
+}
+
+// stty changes terminal modes on stdin
+func stty(modes ...string) error {
+	cmd := exec.Command("stty", modes...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// restoreTerminalOnSignal runs restore and exits if cflip is interrupted or
+// terminated before stop is called, so a signal during a prompt does not
+// leave the shell without echo
+func restoreTerminalOnSignal(restore func()) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		select {
+		case sig := <-signals:
+			restore()
+			fmt.Fprintln(os.Stderr)
+			code := 1
+			if s, ok := sig.(syscall.Signal); ok {
+				code = 128 + int(s)
+			}
+			os.Exit(code)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// sttySettings returns the current terminal modes in a form stty can restore
+func sttySettings() (string, error) {
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// configureAutomation applies --non-interactive and --keychain before any
+// command runs. Without an explicit --non-interactive, CI and runs with no
+// terminal at all are detected and treated as non-interactive. Piped stdin
+// still counts as interactive, since it carries the answers.
+func configureAutomation(c *cli.Context) {
+	if c.IsSet("non-interactive") {
+		nonInteractive = c.Bool("non-interactive")
+	} else {
+		nonInteractive = runningInCI() || (!stdinIsTerminal() && !stdinIsPiped() && !logger.StdoutIsTerminal())
+	}
+	promptTimeout = c.Duration("prompt-timeout")
+	logger.SetAutomation(nonInteractive)
+	storage.SetKeychainOptions(storage.KeychainOptions{
+		Path:           c.String("keychain"),
+		NonInteractive: nonInteractive,
+	})
+}
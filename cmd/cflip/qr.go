@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/qr"
+	"github.com/phathdt/claude-flip/internal/transfer"
+)
+
+// printQRCodes shows an export archive as a series of QR codes, one screen
+// at a time on a terminal
+func printQRCodes(data []byte) error {
+	parts, err := transfer.SplitQR(data)
+	if err != nil {
+		return err
+	}
+	codes := make([]*qr.Code, len(parts))
+	for i, part := range parts {
+		if codes[i], err = qr.Encode([]byte(part)); err != nil {
+			return err
+		}
+	}
+
+	paged := stdinIsTerminal() && logger.StdoutIsTerminal() && !nonInteractive
+	for i, code := range codes {
+		if paged {
+			fmt.Print("\033[H\033[2J")
+		}
+		fmt.Print(code)
+		logger.Plain("Code %d of %d", i+1, len(codes))
+		if paged && i < len(codes)-1 {
+			prompt{question: "Press Enter once it is scanned, for the next code"}.ask()
+		}
+	}
+	return nil
+}
+
+// readQRArchive reassembles an export archive from the scanned codes of
+// `cflip export --qr`, one per line on stdin as a scanner types them
+func readQRArchive() ([]byte, error) {
+	if nonInteractive && !stdinIsPiped() {
+		return nil, fmt.Errorf("import --qr reads the scanned codes from stdin, but cflip is running non-interactively")
+	}
+	if stdinIsTerminal() {
+		logger.InfoMsg("📷 Scan the codes shown by `cflip export --qr`, in any order; each scan must end with Enter")
+	}
+
+	var joiner transfer.QRJoiner
+	for !joiner.Done() {
+		line, err := readLine(0)
+		if err != nil {
+			break // end of input; Data reports what is missing
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, transfer.QRPrefix) {
+			logger.Warning("Ignoring a line that is not a cflip QR code")
+			continue
+		}
+		if err := joiner.Add(line); err != nil {
+			logger.Warning("%v", err)
+			continue
+		}
+		received, total := joiner.Progress()
+		logger.InfoMsg("Received %d of %d codes", received, total)
+	}
+	return joiner.Data()
+}
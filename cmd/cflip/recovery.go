@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// recoverMissingCredentials walks the user through restoring Claude Code's
+// credentials when its keychain item or credentials file is missing.
+// It returns true when credentials were restored and the command may retry.
+func recoverMissingCredentials(svc *service.Service) (bool, error) {
+	if nonInteractive {
+		return false, fmt.Errorf("stored Claude Code credentials were not found; log in with `claude` first (guided recovery needs an interactive terminal)")
+	}
+
+	logger.Warning("Claude Code's stored credentials were not found (keychain item or ~/.claude/.credentials.json).")
+	logger.Plain("   This usually means Claude Code was logged out or its keychain entry was deleted.")
+	logger.Plain("")
+	logger.Plain("   1) Log in again in Claude Code (run `claude` and use /login), then rerun this command")
+	logger.Plain("   2) Restore credentials from an account stored in cflip")
+	logger.Plain("   3) Import credentials from a saved .credentials.json file")
+	logger.Plain("")
+	choice := promptLine("Choose an option [1-3, anything else cancels]: ")
+
+	switch choice {
+	case "1":
+		logger.InfoMsg("Log in with Claude Code, then run the command again.")
+		return false, nil
+
+	case "2":
+		profiles, err := svc.ListProfiles()
+		if err != nil {
+			return false, fmt.Errorf("failed to list profiles: %w", err)
+		}
+		if len(profiles) == 0 {
+			return false, fmt.Errorf("no stored accounts to restore from; log in with Claude Code instead")
+		}
+
+		for i, p := range profiles {
+			logger.Plain("   %d. %s", i+1, p.Email)
+		}
+		selection := promptLine("Restore which account? [number]: ")
+
+		var index int
+		if _, err := fmt.Sscanf(selection, "%d", &index); err != nil || index < 1 || index > len(profiles) {
+			return false, fmt.Errorf("invalid selection: %s", selection)
+		}
+
+		svc.SetInitiator("recover")
+		if err := svc.SwitchToAccount(profiles[index-1].Name, false); err != nil {
+			return false, fmt.Errorf("failed to restore account: %w", err)
+		}
+		logger.Success("Restored credentials for %s", profiles[index-1].Email)
+		return true, nil
+
+	case "3":
+		path := promptLine("Path to credentials file: ")
+
+		if err := svc.ImportCredentialsFile(path); err != nil {
+			return false, err
+		}
+		logger.Success("Imported credentials from %s", path)
+		return true, nil
+	}
+
+	logger.ErrorMsg("Recovery cancelled")
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// showAccount runs `cflip show [--reveal] <account>`
+func showAccount(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("usage: cflip show [--reveal] <account_number|email>")
+	}
+	reveal := c.Bool("reveal")
+	if reveal && !c.Bool("force") {
+		if nonInteractive {
+			return errNeedsInteraction("show --reveal", "pass --force to reveal the tokens without asking")
+		}
+		if !confirmPrompt("Print this account's tokens in full? Anyone who sees them can use the account [y/N]: ") {
+			logger.ErrorMsg("Reveal cancelled")
+			return nil
+		}
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	account, err := svc.ShowAccount(c.Args().First(), reveal)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(account)
+	}
+
+	logger.InfoMsg("📍 %s", account.DisplayName())
+	logger.Plain("   Email: %s", account.Email)
+	logger.Plain("   Profile: %s", account.Name)
+	if account.AccountUuid != "" {
+		logger.Plain("   User ID: %s", account.AccountUuid)
+	}
+	if account.Organization != "" {
+		logger.Plain("   Organization: %s", account.Organization)
+	}
+	if account.OrganizationUuid != "" {
+		logger.Plain("   Organization ID: %s", account.OrganizationUuid)
+	}
+	if account.SubscriptionType != "" {
+		logger.Plain("   Subscription: %s", account.SubscriptionType)
+	}
+	if len(account.Scopes) > 0 {
+		logger.Plain("   Scopes: %s", strings.Join(account.Scopes, ", "))
+	}
+	logger.Plain("   Created: %s", account.CreatedAt)
+	logger.Plain("   Updated: %s", account.UpdatedAt)
+	if account.LastActiveAt != "" {
+		logger.Plain("   Last Active: %s (%d switches)", account.LastActiveAt, account.SwitchCount)
+	}
+	if badge := account.ExpiryBadge(); badge != "" {
+		logger.Plain("   Token: %s (%s)", badge, account.ExpiresAt)
+	}
+	if badge := account.CooldownBadge(); badge != "" {
+		logger.Plain("   Usage: %s", badge)
+	}
+
+	store := "profile file"
+	if account.CredentialStore != "" {
+		store = account.CredentialStore + " storage"
+	}
+	logger.Plain("   Credentials: %s", store)
+	if account.AccessToken != "" {
+		logger.Plain("   Access Token: %s", account.AccessToken)
+	}
+	if account.RefreshToken != "" {
+		logger.Plain("   Refresh Token: %s", account.RefreshToken)
+	}
+
+	if len(account.ConfigKeys) > 0 {
+		logger.Plain("   Config Keys: %s", strings.Join(account.ConfigKeys, ", "))
+	}
+	if len(account.OverlayKeys) > 0 {
+		logger.Plain("   Settings Overlay: %s", strings.Join(account.OverlayKeys, ", "))
+	}
+	if account.Proxy != nil {
+		proxy := account.Proxy.URL
+		if account.Proxy.Direct {
+			proxy = "direct"
+		}
+		logger.Plain("   Proxy: %s", proxy)
+	}
+	if account.HasDesktop {
+		logger.Plain("   Claude Desktop: captured")
+	}
+	if len(account.Groups) > 0 {
+		logger.Plain("   Groups: %s", strings.Join(account.Groups, ", "))
+	}
+	if len(account.Tags) > 0 {
+		logger.Plain("   Tags: %s", formatTags(account.Tags))
+	}
+	if account.Notes != "" {
+		logger.Plain("   Notes: %s", account.Notes)
+	}
+	if account.Modified {
+		logger.Warning("The profile was modified outside cflip")
+	}
+	if !account.Revealed && account.AccessToken != "" {
+		logger.InfoMsg("💡 Tokens are masked; pass --reveal to print them")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/daemon"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// promptStatus runs `cflip status`: one line for PS1 or starship, printing
+// nothing and exiting 1 when no account is active
+func promptStatus(c *cli.Context) error {
+	tmpl, err := template.New("status").Parse(c.String("format"))
+	if err != nil {
+		return fmt.Errorf("invalid --format: %w", err)
+	}
+
+	status, err := daemon.Status()
+	if err != nil {
+		if status, err = service.ReadStatus(time.Now()); err != nil {
+			return err
+		}
+	}
+	if jsonOutput {
+		if err := printJSON(status); err != nil {
+			return err
+		}
+	} else if status != nil {
+		if err := tmpl.Execute(os.Stdout, status); err != nil {
+			return fmt.Errorf("invalid --format: %w", err)
+		}
+		fmt.Println()
+	}
+
+	if status == nil {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/phathdt/claude-flip/internal/storage"
+	"github.com/urfave/cli/v2"
+)
+
+// listStorageBackends runs `cflip storage list`
+func listStorageBackends(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	backends := svc.StorageBackends()
+	if jsonOutput {
+		return printJSON(backends)
+	}
+
+	logger.InfoMsg("📋 Storage backends:")
+	logger.Plain("")
+	for _, backend := range backends {
+		marker := "  "
+		if backend.Active {
+			marker = "* "
+		}
+		line := fmt.Sprintf("%s%-15s %s", marker, backend.Name, backend.Description)
+		if !backend.Available {
+			line += fmt.Sprintf(" (unavailable: %s)", backend.Unavailable)
+		}
+		logger.Plain("  %s", line)
+	}
+	if os.Getenv(storage.StorageBackendEnv) != "" {
+		logger.Plain("")
+		logger.Notice("%s overrides settings.storage_backend", storage.StorageBackendEnv)
+	}
+	return nil
+}
+
+// migrateStorage runs `cflip storage migrate`
+func migrateStorage(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("usage: cflip storage migrate <from> <to>")
+	}
+	from, to := c.Args().Get(0), c.Args().Get(1)
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("Moving stored credentials from %s to %s...", from, to)
+	result, err := svc.MigrateStorage(from, to)
+	if err != nil {
+		return fmt.Errorf("failed to migrate credentials: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	logger.Success("Moved the credentials of %d profile(s) from %s to %s", len(result.Migrated), from, to)
+	for _, key := range result.Missing {
+		logger.Warning("%s had no credentials in %s; log in to it again and re-add it", key, from)
+	}
+	if env := os.Getenv(storage.StorageBackendEnv); env != "" && env != to {
+		logger.Warning("%s=%s still overrides the new setting; unset it to use %s", storage.StorageBackendEnv, env, to)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/gitsync"
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// syncActive runs `cflip sync`
+func syncActive(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	result, err := svc.Sync()
+	if err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+	if jsonOutput {
+		if result.Changes == nil {
+			result.Changes = []service.SyncChange{}
+		}
+		return printJSON(result)
+	}
+
+	if !result.Changed() {
+		logger.Success("%s is already up to date with Claude Code", result.Email)
+		return nil
+	}
+	logger.Success("Saved %d change(s) from Claude Code to %s:", len(result.Changes), result.Email)
+	for _, change := range result.Changes {
+		logger.Plain("   %s: %s", change.Field, change.Detail)
+	}
+	return nil
+}
+
+// gitSyncPush runs `cflip sync push`
+func gitSyncPush(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("Pushing profiles to the sync repository...")
+	result, err := svc.GitSyncPush(c.Bool("force"))
+	if err != nil {
+		return fmt.Errorf("failed to push: %w", err)
+	}
+	return printGitSync(result, gitsync.ActionPush, "Pushed", "Nothing to push; %s has every change made here")
+}
+
+// gitSyncPull runs `cflip sync pull`
+func gitSyncPull(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	logger.Progress("Pulling profiles from the sync repository...")
+	result, err := svc.GitSyncPull(c.Bool("force"))
+	if err != nil {
+		return fmt.Errorf("failed to pull: %w", err)
+	}
+	return printGitSync(result, gitsync.ActionPull, "Pulled", "Nothing to pull; this device has every change in %s")
+}
+
+// gitSyncStatus runs `cflip sync status`
+func gitSyncStatus(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	result, err := svc.GitSyncStatus()
+	if err != nil {
+		return fmt.Errorf("failed to read the sync repository: %w", err)
+	}
+	if jsonOutput {
+		return printJSON(result)
+	}
+
+	logger.InfoMsg("📋 Sync status of %s against %s:", result.Device, result.Repo)
+	logger.Plain("")
+	if len(result.Items) == 0 {
+		logger.Plain("   No profiles here or in the repository yet")
+		return nil
+	}
+	for _, item := range result.Items {
+		action := item.Action
+		switch {
+		case action == gitsync.ActionNone:
+			action = "-"
+		case action == gitsync.ActionDelete && item.Remote != nil && item.Remote.Deleted:
+			action = "pull delete"
+		case action == gitsync.ActionDelete:
+			action = "push delete"
+		}
+		logger.Plain("   %-30s %-12s %s", gitSyncLabel(item), action, item.Reason)
+	}
+	if conflicts := result.Conflicts(); len(conflicts) > 0 {
+		logger.Plain("")
+		logger.Warning("%d profile(s) changed both here and on another device", len(conflicts))
+		logger.Plain("   Keep this device's copy with `cflip sync push --force`, or the repository's with `cflip sync pull --force`")
+	}
+	return nil
+}
+
+// printGitSync reports a push or pull, failing when conflicts were left
+func printGitSync(result *service.GitSyncResult, action, verb, upToDate string) error {
+	conflicts := result.Conflicts()
+	if jsonOutput {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return cli.Exit("", 1)
+		}
+		return nil
+	}
+
+	applied, deleted := 0, 0
+	for _, item := range result.Items {
+		if item.Action == action || item.Action == gitsync.ActionDelete {
+			logger.Plain("   %s %s: %s", item.Action, gitSyncLabel(item), item.Reason)
+			applied++
+		}
+		if item.Action == gitsync.ActionDelete {
+			deleted++
+		}
+	}
+	if applied > 0 {
+		logger.Success("%s %d profile change(s) (%s)", verb, applied, result.Repo)
+	} else if len(conflicts) == 0 {
+		logger.Success(upToDate, result.Repo)
+	}
+	if action == gitsync.ActionPull && deleted > 0 {
+		logger.InfoMsg("💡 Removed accounts stay restorable with `cflip undelete`")
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+	for _, item := range conflicts {
+		logger.Warning("Conflict on %s: %s", gitSyncLabel(item), item.Reason)
+	}
+	logger.Plain("   Keep this device's copy with `cflip sync push --force`, or the repository's with `cflip sync pull --force`")
+	return cli.Exit("", 1)
+}
+
+// gitSyncLabel names a synced profile, by ID when this device lacks it
+func gitSyncLabel(item service.GitSyncItem) string {
+	if item.Name != "" {
+		return item.Name
+	}
+	return "profile " + item.ID
+}
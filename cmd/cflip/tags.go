@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// tagAccount runs `cflip tag <account> [+tag|-tag]...`; without changes it
+// prints the account's tags
+func tagAccount(c *cli.Context) error {
+	args := c.Args().Slice()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cflip tag <account> [+tag|-tag]...")
+	}
+
+	var add, remove []string
+	for _, arg := range args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			remove = append(remove, strings.TrimPrefix(arg, "-"))
+		default:
+			add = append(add, strings.TrimPrefix(arg, "+"))
+		}
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	var account *service.ProfileInfo
+	if len(add) == 0 && len(remove) == 0 {
+		var resolution *service.Resolution
+		if resolution, err = svc.ResolveIdentifier(args[0]); err == nil {
+			account = resolution.Profile
+		}
+	} else {
+		account, err = svc.TagAccount(args[0], add, remove)
+	}
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		tags := account.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		return printJSON(tags)
+	}
+	if len(account.Tags) == 0 {
+		logger.InfoMsg("%s has no tags", account.DisplayName())
+		return nil
+	}
+	logger.Plain("%s: %s", account.DisplayName(), formatTags(account.Tags))
+	return nil
+}
+
+// noteAccount runs `cflip note <account> [text]`; without text it prints
+// the account's notes
+func noteAccount(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("usage: cflip note [--clear] <account> [text]")
+	}
+	target := c.Args().First()
+	text := strings.Join(c.Args().Tail(), " ")
+	if c.Bool("clear") && text != "" {
+		return fmt.Errorf("--clear cannot be combined with text")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if text == "" && !c.Bool("clear") {
+		resolution, err := svc.ResolveIdentifier(target)
+		if err != nil {
+			return err
+		}
+		account := resolution.Profile
+		if jsonOutput {
+			return printJSON(account.Notes)
+		}
+		if account.Notes == "" {
+			logger.InfoMsg("%s has no notes", account.DisplayName())
+			return nil
+		}
+		logger.Plain("%s", account.Notes)
+		return nil
+	}
+
+	account, err := svc.SetNotes(target, text)
+	if err != nil {
+		return fmt.Errorf("failed to save notes: %w", err)
+	}
+	if jsonOutput {
+		return printJSON(account)
+	}
+	if text == "" {
+		logger.Success("Cleared the notes on %s", account.DisplayName())
+	} else {
+		logger.Success("Saved notes on %s", account.DisplayName())
+	}
+	return nil
+}
+
+// formatTags renders tags as "#billing #old"
+func formatTags(tags []string) string {
+	return "#" + strings.Join(tags, " #")
+}
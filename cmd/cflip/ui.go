@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/urfave/cli/v2"
+)
+
+// uiRows is how many accounts `cflip ui` shows at once
+const uiRows = 15
+
+// uiMode is what keystrokes currently edit in `cflip ui`
+type uiMode int
+
+const (
+	uiBrowse  uiMode = iota // filter and move through accounts
+	uiRename                // type a new alias for the selected account
+	uiConfirm               // answer whether to remove the selected account
+)
+
+// accountUI is the state of `cflip ui`
+type accountUI struct {
+	svc      *service.Service
+	accounts []service.DashboardAccount
+	matches  []service.DashboardAccount
+	query    []rune
+	input    []rune
+	selected int
+	mode     uiMode
+	force    bool
+	status   string
+}
+
+// runUI runs `cflip ui`: a full-screen account list with type-to-filter,
+// switching, renaming and removal
+func runUI(c *cli.Context) error {
+	if nonInteractive || !stdinIsTerminal() || !logger.StdoutIsTerminal() {
+		return fmt.Errorf("cflip ui needs a terminal; use `cflip list` and `cflip switch` instead")
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	svc.SetInitiator("ui")
+	ui := &accountUI{svc: svc, force: c.Bool("force")}
+	if err := ui.reload(); err != nil {
+		return err
+	}
+
+	restore, err := rawInput()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	fmt.Print("\033[?1049h\033[?25l") // Alternate screen, hidden cursor
+	defer fmt.Print("\033[?25h\033[?1049l")
+
+	buf := make([]byte, 16)
+	for {
+		ui.filter()
+		ui.draw()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		if !ui.handle(buf[:n]) {
+			return nil
+		}
+	}
+}
+
+// reload reads the accounts again after a change
+func (ui *accountUI) reload() error {
+	snapshot, err := ui.svc.Dashboard()
+	if err != nil {
+		return err
+	}
+	ui.accounts = snapshot.Accounts
+	return nil
+}
+
+// filter keeps the accounts matching the query, best matches first
+func (ui *accountUI) filter() {
+	items := make([]pickerItem, len(ui.accounts))
+	byName := make(map[string]service.DashboardAccount, len(ui.accounts))
+	for i, account := range ui.accounts {
+		items[i] = pickerItem{name: account.Name, label: account.Label() + " " + account.Organization + " " + account.Tier}
+		byName[account.Name] = account
+	}
+
+	ui.matches = ui.matches[:0]
+	for _, match := range filterItems(items, string(ui.query)) {
+		ui.matches = append(ui.matches, byName[match.item.name])
+	}
+	if ui.selected >= len(ui.matches) {
+		ui.selected = len(ui.matches) - 1
+	}
+	if ui.selected < 0 {
+		ui.selected = 0
+	}
+}
+
+// current is the selected account, or nil when nothing matches
+func (ui *accountUI) current() *service.DashboardAccount {
+	if len(ui.matches) == 0 {
+		return nil
+	}
+	return &ui.matches[ui.selected]
+}
+
+// handle applies one keystroke and reports whether the UI keeps running
+func (ui *accountUI) handle(key []byte) bool {
+	if key[0] == 0x03 || key[0] == 0x04 { // Ctrl-C, Ctrl-D
+		return false
+	}
+
+	switch ui.mode {
+	case uiRename:
+		ui.handleRename(key)
+		return true
+	case uiConfirm:
+		if key[0] == 'y' || key[0] == 'Y' {
+			ui.remove()
+		} else {
+			ui.status = "Removal cancelled"
+		}
+		ui.mode = uiBrowse
+		return true
+	}
+
+	switch {
+	case string(key) == "\x1b[A" || key[0] == 0x10: // Up, Ctrl-P
+		ui.selected--
+	case string(key) == "\x1b[B" || key[0] == 0x0e: // Down, Ctrl-N
+		ui.selected++
+	case key[0] == '\r' || key[0] == '\n':
+		ui.switchTo()
+	case key[0] == 0x12: // Ctrl-R
+		if account := ui.current(); account != nil {
+			ui.mode, ui.input = uiRename, []rune(account.Alias)
+		}
+	case string(key) == "\x1b[3~" || key[0] == 0x18: // Delete, Ctrl-X
+		if ui.current() != nil {
+			ui.mode = uiConfirm
+		}
+	case key[0] == 0x1b: // Esc clears the filter, then quits
+		if len(ui.query) == 0 {
+			return false
+		}
+		ui.query, ui.selected = ui.query[:0], 0
+	case key[0] == 0x7f || key[0] == 0x08: // Backspace
+		if len(ui.query) > 0 {
+			ui.query = ui.query[:len(ui.query)-1]
+		}
+		ui.selected = 0
+	case key[0] == 0x15: // Ctrl-U
+		ui.query, ui.selected = ui.query[:0], 0
+	default:
+		ui.query = appendPrintable(ui.query, key)
+		ui.selected = 0
+	}
+	return true
+}
+
+// handleRename edits the new alias
+func (ui *accountUI) handleRename(key []byte) {
+	switch {
+	case strings.ContainsAny(string(key), "\r\n"): // Enter, possibly after pasted text
+		ui.input = appendPrintable(ui.input, key)
+		ui.rename(strings.TrimSpace(string(ui.input)))
+		ui.mode = uiBrowse
+	case key[0] == 0x1b:
+		ui.mode, ui.status = uiBrowse, "Rename cancelled"
+	case key[0] == 0x7f || key[0] == 0x08:
+		if len(ui.input) > 0 {
+			ui.input = ui.input[:len(ui.input)-1]
+		}
+	case key[0] == 0x15:
+		ui.input = ui.input[:0]
+	default:
+		ui.input = appendPrintable(ui.input, key)
+	}
+}
+
+// switchTo switches to the selected account
+func (ui *accountUI) switchTo() {
+	account := ui.current()
+	if account == nil {
+		return
+	}
+	if account.IsActive {
+		ui.status = account.Label() + " is already active"
+		return
+	}
+
+	result, err := ui.svc.Switch(account.Name, ui.force)
+	if err != nil {
+		ui.status = "⚠️  " + err.Error()
+		return
+	}
+	ui.status = fmt.Sprintf("✅ Switched to %s; restart Claude Code to use it", result.To.DisplayName())
+	if result.Refresh != nil && result.Refresh.Error != "" {
+		ui.status += " (token refresh failed: " + result.Refresh.Error + ")"
+	}
+	ui.refresh()
+}
+
+// rename sets the selected account's alias
+func (ui *accountUI) rename(alias string) {
+	account := ui.current()
+	if account == nil {
+		return
+	}
+	if alias == "" || alias == account.Alias {
+		ui.status = "Alias unchanged"
+		return
+	}
+
+	if err := ui.svc.RenameAccount(account.Name, alias); err != nil {
+		ui.status = "⚠️  " + err.Error()
+		return
+	}
+	logger.NewDefault().AccountRenamed(account.Name, account.Alias, alias)
+	ui.status = fmt.Sprintf("✅ Renamed %s to %s", account.Email, alias)
+	ui.refresh()
+}
+
+// remove moves the selected account to the trash
+func (ui *accountUI) remove() {
+	account := ui.current()
+	if account == nil {
+		return
+	}
+
+	if err := ui.svc.RemoveAccount(account.Name); err != nil {
+		ui.status = "⚠️  " + err.Error()
+		return
+	}
+	logger.NewDefault().AccountRemoved(account.Name)
+	ui.status = fmt.Sprintf("🗑️  Removed %s; `cflip undelete %s` brings it back", account.Label(), account.Name)
+	ui.refresh()
+}
+
+// refresh reloads accounts after a change, keeping the status message
+func (ui *accountUI) refresh() {
+	if err := ui.reload(); err != nil {
+		ui.status = "⚠️  " + err.Error()
+	}
+}
+
+// draw renders the whole screen
+func (ui *accountUI) draw() {
+	var b strings.Builder
+	now := time.Now()
+	fmt.Fprintf(&b, "cflip · %d account(s)\n\n", len(ui.accounts))
+
+	start := 0
+	if ui.selected >= uiRows {
+		start = ui.selected - uiRows + 1
+	}
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tACCOUNT\tORG\tTIER\tTOKEN")
+	for i := start; i < len(ui.matches) && i < start+uiRows; i++ {
+		account := ui.matches[i]
+		cursor, marker := "  ", "○"
+		if i == ui.selected {
+			cursor = "> "
+		}
+		if account.IsActive {
+			marker = "●"
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\n", cursor, marker,
+			account.Label(), orDash(account.Organization), orDash(account.Tier), account.TokenStatus(now))
+	}
+	tw.Flush()
+	if len(ui.matches) == 0 {
+		b.WriteString("  (no matching accounts)\n")
+	}
+
+	b.WriteString("\n")
+	if ui.status != "" {
+		b.WriteString(ui.status + "\n")
+	}
+
+	account := ui.current()
+	switch {
+	case ui.mode == uiRename && account != nil:
+		fmt.Fprintf(&b, "New alias for %s (Enter, Esc): %s", account.Email, string(ui.input))
+	case ui.mode == uiConfirm && account != nil:
+		fmt.Fprintf(&b, "Remove %s? It goes to the trash [y/N]: ", account.Label())
+	default:
+		b.WriteString("↑/↓ move · Enter switch · Ctrl-R rename · Ctrl-X remove · Esc quit\n")
+		fmt.Fprintf(&b, "Filter: %s", string(ui.query))
+	}
+
+	// Redraw in place; \033[K and \033[J clear what the last frame left
+	fmt.Print("\033[H" + strings.ReplaceAll(b.String(), "\n", "\033[K\n") + "\033[J")
+}
+
+// appendPrintable appends the printable characters of a keystroke
+func appendPrintable(text []rune, key []byte) []rune {
+	for _, r := range string(key) {
+		if unicode.IsPrint(r) {
+			text = append(text, r)
+		}
+	}
+	return text
+}
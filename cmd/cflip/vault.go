@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/phathdt/claude-flip/internal/storage"
+	"github.com/urfave/cli/v2"
+)
+
+// vaultPassphraseEnv supplies the vault passphrase to `cflip unlock` for
+// non-interactive use
+const vaultPassphraseEnv = "CFLIP_VAULT_PASSPHRASE"
+
+// unlockVault runs `cflip unlock`
+func unlockVault(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	status := svc.VaultStatus()
+	if c.Bool("status") {
+		if jsonOutput {
+			return printJSON(status)
+		}
+		printVaultStatus(status)
+		return nil
+	}
+	if !status.Enabled {
+		return fmt.Errorf("no vault passphrase is set; set one with `cflip passwd`")
+	}
+
+	passphrase := os.Getenv(vaultPassphraseEnv)
+	if passphrase == "" {
+		if nonInteractive {
+			return fmt.Errorf("unlock needs the vault passphrase but cflip is running non-interactively; set %s", vaultPassphraseEnv)
+		}
+		if passphrase, err = promptSecret("Vault passphrase: "); err != nil {
+			return err
+		}
+	}
+
+	if status.HardwareKey != "" {
+		logger.InfoMsg("🔑 Touch your hardware key if it blinks")
+	}
+
+	timeout := c.Duration("timeout")
+	if err := svc.UnlockVault(passphrase, timeout); err != nil {
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+	if jsonOutput {
+		return printJSON(svc.VaultStatus())
+	}
+	if timeout > 0 {
+		logger.Success("Stored credentials are unlocked for %s", formatVaultTimeout(timeout))
+	} else {
+		logger.Success("Stored credentials are unlocked until `cflip lock` or a reboot")
+	}
+	return nil
+}
+
+// lockVault runs `cflip lock`
+func lockVault(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	if !svc.VaultStatus().Enabled {
+		return fmt.Errorf("no vault passphrase is set; set one with `cflip passwd`")
+	}
+	if err := svc.LockVault(); err != nil {
+		return fmt.Errorf("failed to lock: %w", err)
+	}
+	if jsonOutput {
+		return printJSON(svc.VaultStatus())
+	}
+	logger.Success("Stored credentials are locked")
+	return nil
+}
+
+// changeVaultPassphrase runs `cflip passwd`
+func changeVaultPassphrase(c *cli.Context) error {
+	svc, err := service.NewService()
+	if err != nil {
+		return fmt.Errorf("failed to initialize service: %w", err)
+	}
+	if nonInteractive {
+		return errNeedsInteraction("passwd", "run it in a terminal")
+	}
+
+	if c.IsSet("hardware-key") || c.Bool("remove-hardware-key") {
+		return changeVaultHardwareKey(c, svc)
+	}
+
+	enabled := svc.VaultStatus().Enabled
+	remove := c.Bool("remove")
+	if remove && !enabled {
+		return fmt.Errorf("no vault passphrase is set")
+	}
+
+	var current, next string
+	if enabled {
+		if current, err = promptSecret("Current vault passphrase: "); err != nil {
+			return err
+		}
+	}
+	if !remove {
+		if next, err = promptSecret("New vault passphrase: "); err != nil {
+			return err
+		}
+		if len(next) < storage.MinVaultPassphraseLength {
+			return fmt.Errorf("passphrase must be at least %d characters", storage.MinVaultPassphraseLength)
+		}
+		again, err := promptSecret("Repeat passphrase: ")
+		if err != nil {
+			return err
+		}
+		if again != next {
+			return fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	logger.Progress("Re-encrypting stored credentials...")
+	count, err := svc.ChangeVaultPassphrase(current, next, c.Duration("timeout"))
+	if err != nil {
+		return fmt.Errorf("failed to change the vault passphrase: %w", err)
+	}
+
+	switch {
+	case remove:
+		logger.Success("Removed the vault passphrase; %d credential file(s) use the machine-derived key again", count)
+	case enabled:
+		logger.Success("Changed the vault passphrase and re-encrypted %d credential file(s)", count)
+	default:
+		logger.Success("Set a vault passphrase and re-encrypted %d credential file(s)", count)
+		logger.InfoMsg("💡 Run `cflip lock` when you step away; `cflip unlock` opens the vault again")
+	}
+	if backend := storage.ProfileBackend(); !remove && backend != storage.BackendFile {
+		logger.Notice("Profiles keep their credentials in the %s backend, which the passphrase does not cover", backend)
+	}
+	return nil
+}
+
+// changeVaultHardwareKey runs `cflip passwd --hardware-key` and
+// `--remove-hardware-key`
+func changeVaultHardwareKey(c *cli.Context, svc *service.Service) error {
+	status := svc.VaultStatus()
+	if !status.Enabled {
+		return fmt.Errorf("no vault passphrase is set; set one with `cflip passwd` before adding a hardware key")
+	}
+
+	var key *service.HardwareKey
+	if c.IsSet("hardware-key") {
+		if c.Bool("remove-hardware-key") {
+			return fmt.Errorf("--hardware-key and --remove-hardware-key cannot be used together")
+		}
+		identity := c.String("hardware-identity")
+		if identity == "" {
+			return fmt.Errorf("--hardware-key needs --hardware-identity, the age identity file of the key")
+		}
+		if abs, err := filepath.Abs(identity); err == nil {
+			identity = abs
+		}
+		key = &service.HardwareKey{Recipient: c.String("hardware-key"), Identity: identity}
+	} else if status.HardwareKey == "" {
+		return fmt.Errorf("the vault is not wrapped by a hardware key")
+	}
+
+	passphrase, err := promptSecret("Vault passphrase: ")
+	if err != nil {
+		return err
+	}
+	if status.HardwareKey != "" {
+		logger.InfoMsg("🔑 Touch your hardware key if it blinks")
+	}
+
+	logger.Progress("Re-encrypting stored credentials...")
+	count, err := svc.SetVaultHardwareKey(passphrase, key, c.Duration("timeout"))
+	if err != nil {
+		return fmt.Errorf("failed to change the hardware key: %w", err)
+	}
+	if key == nil {
+		logger.Success("Unlocking no longer needs the hardware key; re-encrypted %d credential file(s)", count)
+	} else {
+		logger.Success("Unlocking now needs the passphrase and the hardware key; re-encrypted %d credential file(s)", count)
+	}
+	return nil
+}
+
+// printVaultStatus describes the vault
+func printVaultStatus(status service.VaultStatus) {
+	switch {
+	case !status.Enabled:
+		logger.InfoMsg("No vault passphrase is set; stored credentials use the machine-derived key")
+	case !status.Unlocked:
+		logger.InfoMsg("🔒 Stored credentials are locked")
+	case status.ExpiresAt.IsZero():
+		logger.InfoMsg("🔓 Stored credentials are unlocked until `cflip lock`")
+	default:
+		logger.InfoMsg("🔓 Stored credentials are unlocked for %s", formatVaultTimeout(time.Until(status.ExpiresAt)))
+	}
+	if status.HardwareKey != "" {
+		logger.Plain("   Unlocking also needs the hardware key %s", status.HardwareKey)
+	}
+}
+
+// formatVaultTimeout renders how long the vault stays unlocked
+func formatVaultTimeout(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	text := strings.TrimSuffix(d.String(), "0s")
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Anthropic OAuth endpoints used by Claude Code
+const (
+	ProfileURL   = "https://api.anthropic.com/api/oauth/profile"
+	OAuthBetaTag = "oauth-2025-04-20"
+)
+
+const requestTimeout = 15 * time.Second
+
+// ErrTokenRejected is returned when the API refuses an access token
+var ErrTokenRejected = errors.New("access token was rejected")
+
+// OAuthProfile is the account an access token belongs to
+type OAuthProfile struct {
+	Account struct {
+		UUID         string `json:"uuid"`
+		Email        string `json:"email"`
+		DisplayName  string `json:"display_name"`
+		HasClaudeMax bool   `json:"has_claude_max"`
+		HasClaudePro bool   `json:"has_claude_pro"`
+	} `json:"account"`
+	Organization struct {
+		UUID             string `json:"uuid"`
+		Name             string `json:"name"`
+		OrganizationType string `json:"organization_type"`
+	} `json:"organization"`
+}
+
+// Subscription names the account's Claude plan: "max" or "pro", else the
+// organization type (e.g. "claude_team")
+func (p *OAuthProfile) Subscription() string {
+	switch {
+	case p.Account.HasClaudeMax:
+		return "max"
+	case p.Account.HasClaudePro:
+		return "pro"
+	}
+	return p.Organization.OrganizationType
+}
+
+// FetchProfile verifies an access token against the live API and returns
+// the account it belongs to
+func FetchProfile(accessToken string) (*OAuthProfile, error) {
+	req, err := http.NewRequest(http.MethodGet, ProfileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build profile request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("anthropic-beta", OAuthBetaTag)
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Anthropic API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("%w (%s)", ErrTokenRejected, resp.Status)
+	default:
+		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+	}
+
+	var profile OAuthProfile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to decode profile response: %w", err)
+	}
+
+	return &profile, nil
+}
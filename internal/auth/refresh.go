@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClientID is the OAuth client used by Claude Code; refresh tokens are bound to it
+const ClientID = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+
+// TokenURL is the OAuth token endpoint, a variable so tests can point it
+// at a local server
+var TokenURL = "https://console.anthropic.com/v1/oauth/token"
+
+// TokenResponse is the result of exchanging a refresh token
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Scope        string `json:"scope"`
+}
+
+// ExpiresAt returns when the new access token expires, relative to issued
+func (t *TokenResponse) ExpiresAt(issued time.Time) time.Time {
+	return issued.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// Scopes splits the space-separated scope string
+func (t *TokenResponse) Scopes() []string {
+	return strings.Fields(t.Scope)
+}
+
+// Refresh exchanges a refresh token for a new access token. Anthropic rotates
+// refresh tokens, so the returned RefreshToken replaces the one passed in.
+func Refresh(refreshToken string) (*TokenResponse, error) {
+	body, err := json.Marshal(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+		"client_id":     ClientID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build refresh request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, TokenURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Anthropic OAuth endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("token refresh rejected (%s): %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var token TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode refresh response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("refresh response did not include an access token")
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return &token, nil
+}
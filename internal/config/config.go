@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/phathdt/claude-flip/internal/fsutil"
 	"github.com/phathdt/claude-flip/internal/storage"
 )
 
@@ -35,6 +37,19 @@ type Credentials struct {
 	} `json:"claudeAiOauth"`
 }
 
+// ExpiresAtTime returns the access token expiry (ExpiresAt is in milliseconds)
+func (c *Credentials) ExpiresAtTime() time.Time {
+	return time.UnixMilli(c.ClaudeAiOauth.ExpiresAt)
+}
+
+// IsExpired reports whether the access token has already expired
+func (c *Credentials) IsExpired() bool {
+	if c.ClaudeAiOauth.ExpiresAt == 0 {
+		return false
+	}
+	return time.Now().After(c.ExpiresAtTime())
+}
+
 // AuthConfig contains authentication information
 type AuthConfig struct {
 	AccessToken  string `json:"access_token,omitempty"`
@@ -94,6 +109,7 @@ func LoadClaudeConfig() (*ClaudeConfig, error) {
 			lastErr = fmt.Errorf("failed to parse config file %s: %w", configPath, err)
 			continue
 		}
+		NormalizeConfig(config)
 		break
 	}
 
@@ -102,7 +118,7 @@ func LoadClaudeConfig() (*ClaudeConfig, error) {
 	}
 
 	// Load credentials using platform-specific method
-	if credentials, err := loadCredentialsForConfig(); err == nil {
+	if credentials, err := CaptureCredentials(); err == nil {
 		// Store credentials in a special field for our use
 		config["_cflip_credentials"] = *credentials
 	}
@@ -110,6 +126,52 @@ func LoadClaudeConfig() (*ClaudeConfig, error) {
 	return &config, nil
 }
 
+// LoadClaudeConfigFile reads an exported Claude Code config (e.g. a copy of
+// ~/.claude.json) and checks it identifies an account
+func LoadClaudeConfigFile(path string) (*ClaudeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := make(ClaudeConfig)
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	NormalizeConfig(config)
+
+	if err := ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	// Never carry over credentials smuggled into an exported config
+	delete(config, "_cflip_credentials")
+
+	return &config, nil
+}
+
+// LoadCredentialsFile reads an exported .credentials.json and checks it
+// holds usable OAuth tokens
+func LoadCredentialsFile(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	credentials, _, err := ParseCredentials(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	if credentials.ClaudeAiOauth.AccessToken == "" {
+		return nil, fmt.Errorf("invalid credentials file %s: no claudeAiOauth.accessToken", path)
+	}
+	if credentials.ClaudeAiOauth.RefreshToken == "" {
+		return nil, fmt.Errorf("invalid credentials file %s: no claudeAiOauth.refreshToken", path)
+	}
+
+	return credentials, nil
+}
+
 // SaveClaudeConfig writes the configuration back to disk
 func SaveClaudeConfig(config *ClaudeConfig) error {
 	home, err := os.UserHomeDir()
@@ -141,15 +203,8 @@ func SaveClaudeConfig(config *ClaudeConfig) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Write atomically using temporary file
-	tempPath := configPath + ".tmp"
-	if err := os.WriteFile(tempPath, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write temporary config file: %w", err)
-	}
-
-	if err := os.Rename(tempPath, configPath); err != nil {
-		os.Remove(tempPath) // Clean up temp file
-		return fmt.Errorf("failed to replace config file: %w", err)
+	if err := fsutil.WriteFileAtomic(configPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
@@ -161,9 +216,9 @@ func ValidateConfig(config ClaudeConfig) error {
 		return fmt.Errorf("config is nil")
 	}
 
-	oauthAccount, ok := config["oauthAccount"].(map[string]interface{})
+	oauthAccount, ok := config.oauthAccount()
 	if !ok || oauthAccount == nil {
-		return fmt.Errorf("no OAuth account information found")
+		return fmt.Errorf("no OAuth account information found (config format %s)", config.ConfigFormat())
 	}
 
 	email, ok := oauthAccount["emailAddress"].(string)
@@ -181,7 +236,7 @@ func ValidateConfig(config ClaudeConfig) error {
 
 // GetUserEmail extracts the user email from config
 func (c ClaudeConfig) GetUserEmail() string {
-	if oauthAccount, ok := c["oauthAccount"].(map[string]interface{}); ok {
+	if oauthAccount, ok := c.oauthAccount(); ok {
 		if email, ok := oauthAccount["emailAddress"].(string); ok {
 			return email
 		}
@@ -191,7 +246,7 @@ func (c ClaudeConfig) GetUserEmail() string {
 
 // GetAccountUuid extracts the account UUID from config
 func (c ClaudeConfig) GetAccountUuid() string {
-	if oauthAccount, ok := c["oauthAccount"].(map[string]interface{}); ok {
+	if oauthAccount, ok := c.oauthAccount(); ok {
 		if uuid, ok := oauthAccount["accountUuid"].(string); ok {
 			return uuid
 		}
@@ -199,9 +254,19 @@ func (c ClaudeConfig) GetAccountUuid() string {
 	return ""
 }
 
+// GetOrganizationUuid extracts the organization UUID from config
+func (c ClaudeConfig) GetOrganizationUuid() string {
+	if oauthAccount, ok := c.oauthAccount(); ok {
+		if uuid, ok := oauthAccount["organizationUuid"].(string); ok {
+			return uuid
+		}
+	}
+	return ""
+}
+
 // GetOrganizationName extracts the organization name from config
 func (c ClaudeConfig) GetOrganizationName() string {
-	if oauthAccount, ok := c["oauthAccount"].(map[string]interface{}); ok {
+	if oauthAccount, ok := c.oauthAccount(); ok {
 		if name, ok := oauthAccount["organizationName"].(string); ok {
 			return name
 		}
@@ -237,8 +302,10 @@ func (c ClaudeConfig) SetOAuthAccount(oauthData map[string]interface{}) {
 	c["oauthAccount"] = oauthData
 }
 
-// loadCredentialsForConfig loads credentials using platform-specific method
-func loadCredentialsForConfig() (*Credentials, error) {
+// CaptureCredentials reads Claude Code's live credentials from its native
+// storage. A missing keychain item or credentials file yields an error
+// wrapping storage.ErrNotFound.
+func CaptureCredentials() (*Credentials, error) {
 	// Use the SecureStorage Capture method to read from Claude Code's native storage
 	storage := storage.NewSecureStorage()
 	credentialsJSON, err := storage.Capture()
@@ -246,12 +313,12 @@ func loadCredentialsForConfig() (*Credentials, error) {
 		return nil, fmt.Errorf("failed to capture credentials: %w", err)
 	}
 
-	var credentials Credentials
-	if err := json.Unmarshal([]byte(credentialsJSON), &credentials); err != nil {
+	credentials, err := ParseLiveCredentials([]byte(credentialsJSON))
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
 	}
 
-	return &credentials, nil
+	return credentials, nil
 }
 
 // copyFile creates a copy of a file
@@ -260,5 +327,5 @@ func copyFile(src, dst string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(dst, data, 0o600)
+	return fsutil.WriteFileAtomic(dst, data, 0o600)
 }
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// desktopSessionPaths are the Claude Desktop files (relative to its config
+// directory) that hold the signed-in account. Directories are captured whole.
+var desktopSessionPaths = []string{
+	"config.json",
+	"Cookies",
+	"Cookies-journal",
+	filepath.Join("Local Storage", "leveldb"),
+}
+
+// DesktopSnapshot holds Claude Desktop's account state for one profile
+type DesktopSnapshot struct {
+	// Files maps paths relative to the Desktop config dir to their contents
+	Files map[string][]byte `json:"files"`
+}
+
+// FindDesktopConfigDir locates Claude Desktop's application data directory
+func FindDesktopConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	var dir string
+	switch runtime.GOOS {
+	case "darwin":
+		dir = filepath.Join(home, "Library", "Application Support", "Claude")
+	case "linux":
+		dir = filepath.Join(home, ".config", "Claude")
+	default:
+		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("Claude Desktop data directory not found: %s", dir)
+	}
+
+	return dir, nil
+}
+
+// CaptureDesktop snapshots Claude Desktop's signed-in session
+func CaptureDesktop() (*DesktopSnapshot, error) {
+	dir, err := FindDesktopConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &DesktopSnapshot{Files: make(map[string][]byte)}
+	for _, rel := range desktopSessionPaths {
+		root := filepath.Join(dir, rel)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || d.Name() == "LOCK" {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			snapshot.Files[filepath.ToSlash(relPath)] = data
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture Claude Desktop %s: %w", rel, err)
+		}
+	}
+
+	if len(snapshot.Files) == 0 {
+		return nil, fmt.Errorf("no Claude Desktop session found in %s", dir)
+	}
+
+	return snapshot, nil
+}
+
+// ApplyDesktop restores a Claude Desktop session snapshot. Captured
+// directories are replaced wholesale so no files from the previous account
+// linger alongside the restored ones.
+func ApplyDesktop(snapshot *DesktopSnapshot) error {
+	if snapshot == nil || len(snapshot.Files) == 0 {
+		return fmt.Errorf("profile has no Claude Desktop snapshot")
+	}
+
+	dir, err := FindDesktopConfigDir()
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range desktopSessionPaths {
+		path := filepath.Join(dir, rel)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed to clear %s: %w", rel, err)
+			}
+		}
+	}
+
+	for rel, data := range snapshot.Files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+
+		if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FormatVersion identifies a layout Claude Code has used for its config or
+// credentials. Newer layouts have higher versions.
+type FormatVersion int
+
+const (
+	FormatUnknown FormatVersion = iota
+	// FormatLegacy uses snake_case keys (oauth_account, claude_ai_oauth),
+	// expiry in seconds and space-separated scopes
+	FormatLegacy
+	// FormatFlat keeps the OAuth token fields at the top level of the
+	// credentials, without the claudeAiOauth wrapper
+	FormatFlat
+	// FormatCurrent is the camelCase oauthAccount / claudeAiOauth layout
+	FormatCurrent
+)
+
+// String names the format for diagnostics
+func (v FormatVersion) String() string {
+	switch v {
+	case FormatLegacy:
+		return "legacy (v1)"
+	case FormatFlat:
+		return "flat (v2)"
+	case FormatCurrent:
+		return "current (v3)"
+	default:
+		return "unknown"
+	}
+}
+
+// accountAdapter describes where one config layout keeps the logged-in
+// account and what its fields are called
+type accountAdapter struct {
+	version FormatVersion
+	section string
+	fields  map[string][]string // canonical oauthAccount key -> keys used by this layout
+}
+
+// accountAdapters are tried newest first
+var accountAdapters = []accountAdapter{
+	{
+		version: FormatCurrent,
+		section: "oauthAccount",
+	},
+	{
+		version: FormatLegacy,
+		section: "oauth_account",
+		fields: map[string][]string{
+			"accountUuid":      {"account_uuid", "uuid"},
+			"emailAddress":     {"email_address", "email"},
+			"organizationUuid": {"organization_uuid", "org_uuid"},
+			"organizationName": {"organization_name", "org_name"},
+			"organizationRole": {"organization_role"},
+			"workspaceRole":    {"workspace_role"},
+		},
+	},
+}
+
+// credentialFields maps canonical claudeAiOauth keys to the names other
+// credential layouts have used for them
+var credentialFields = map[string][]string{
+	"accessToken":      {"access_token"},
+	"refreshToken":     {"refresh_token"},
+	"expiresAt":        {"expires_at"},
+	"scopes":           {"scope"},
+	"subscriptionType": {"subscription_type"},
+}
+
+// liveCredentialFormat is the layout the live credentials were last read
+// in, so they are written back the way the installed Claude Code expects
+var liveCredentialFormat atomic.Int32
+
+func init() {
+	liveCredentialFormat.Store(int32(FormatCurrent))
+}
+
+// ConfigFormat detects the layout of a Claude Code config
+func (c ClaudeConfig) ConfigFormat() FormatVersion {
+	for _, adapter := range accountAdapters {
+		if _, ok := c[adapter.section].(map[string]interface{}); ok {
+			return adapter.version
+		}
+	}
+	return FormatUnknown
+}
+
+// NormalizeConfig makes a config in an older layout readable through the
+// current oauthAccount block. The original section is kept so the installed
+// Claude Code still finds it once the config is written back.
+func NormalizeConfig(c ClaudeConfig) FormatVersion {
+	version := c.ConfigFormat()
+	if version != FormatCurrent {
+		if account, ok := c.oauthAccount(); ok {
+			c["oauthAccount"] = account
+		}
+	}
+	return version
+}
+
+// oauthAccount returns the logged-in account block in the current layout,
+// translating older layouts
+func (c ClaudeConfig) oauthAccount() (map[string]interface{}, bool) {
+	for _, adapter := range accountAdapters {
+		section, ok := c[adapter.section].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if adapter.fields == nil {
+			return section, true
+		}
+
+		account := make(map[string]interface{}, len(adapter.fields))
+		for canonical, aliases := range adapter.fields {
+			for _, alias := range aliases {
+				if value, ok := section[alias]; ok {
+					account[canonical] = value
+					break
+				}
+			}
+		}
+		return account, true
+	}
+	return nil, false
+}
+
+// ParseCredentials decodes credentials in any known layout into the
+// current one
+func ParseCredentials(data []byte) (*Credentials, FormatVersion, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, FormatUnknown, err
+	}
+
+	version := FormatUnknown
+	var fields map[string]json.RawMessage
+	switch {
+	case raw["claudeAiOauth"] != nil:
+		version = FormatCurrent
+		err := json.Unmarshal(raw["claudeAiOauth"], &fields)
+		if err != nil {
+			return nil, version, fmt.Errorf("invalid claudeAiOauth: %w", err)
+		}
+	case raw["claude_ai_oauth"] != nil:
+		version = FormatLegacy
+		err := json.Unmarshal(raw["claude_ai_oauth"], &fields)
+		if err != nil {
+			return nil, version, fmt.Errorf("invalid claude_ai_oauth: %w", err)
+		}
+	case raw["accessToken"] != nil || raw["access_token"] != nil:
+		version = FormatFlat
+		fields = raw
+	default:
+		// Nothing recognisable; decode as current so callers report the
+		// missing token the way they always have
+		var credentials Credentials
+		err := json.Unmarshal(data, &credentials)
+		return &credentials, version, err
+	}
+
+	var credentials Credentials
+	oauth := &credentials.ClaudeAiOauth
+	for canonical, aliases := range credentialFields {
+		value := lookupField(fields, canonical, aliases)
+		if value == nil {
+			continue
+		}
+		var err error
+		switch canonical {
+		case "accessToken":
+			err = json.Unmarshal(value, &oauth.AccessToken)
+		case "refreshToken":
+			err = json.Unmarshal(value, &oauth.RefreshToken)
+		case "subscriptionType":
+			err = json.Unmarshal(value, &oauth.SubscriptionType)
+		case "expiresAt":
+			oauth.ExpiresAt, err = parseExpiry(value)
+		case "scopes":
+			oauth.Scopes, err = parseScopes(value)
+		}
+		if err != nil {
+			return nil, version, fmt.Errorf("invalid %s: %w", canonical, err)
+		}
+	}
+	return &credentials, version, nil
+}
+
+// ParseLiveCredentials parses credentials read from Claude Code's own
+// storage and remembers their layout for writing them back
+func ParseLiveCredentials(data []byte) (*Credentials, error) {
+	credentials, version, err := ParseCredentials(data)
+	if err != nil {
+		return nil, err
+	}
+	if version != FormatUnknown {
+		liveCredentialFormat.Store(int32(version))
+	}
+	return credentials, nil
+}
+
+// LiveCredentialFormat is the layout the live credentials were last read in
+func LiveCredentialFormat() FormatVersion {
+	return FormatVersion(liveCredentialFormat.Load())
+}
+
+// EncodeLiveCredentials encodes credentials in the layout the live ones
+// were read in (the current layout when none were read)
+func EncodeLiveCredentials(credentials *Credentials, indent bool) ([]byte, error) {
+	oauth := credentials.ClaudeAiOauth
+	var v interface{} = credentials
+
+	switch LiveCredentialFormat() {
+	case FormatLegacy:
+		v = map[string]interface{}{
+			"claude_ai_oauth": map[string]interface{}{
+				"access_token":      oauth.AccessToken,
+				"refresh_token":     oauth.RefreshToken,
+				"expires_at":        oauth.ExpiresAt / 1000,
+				"scope":             strings.Join(oauth.Scopes, " "),
+				"subscription_type": oauth.SubscriptionType,
+			},
+		}
+	case FormatFlat:
+		v = oauth
+	}
+
+	if indent {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return json.Marshal(v)
+}
+
+// lookupField returns the first of canonical and its aliases present in fields
+func lookupField(fields map[string]json.RawMessage, canonical string, aliases []string) json.RawMessage {
+	if value, ok := fields[canonical]; ok {
+		return value
+	}
+	for _, alias := range aliases {
+		if value, ok := fields[alias]; ok {
+			return value
+		}
+	}
+	return nil
+}
+
+// parseExpiry reads an expiry in milliseconds, seconds or RFC 3339 and
+// returns it in milliseconds
+func parseExpiry(value json.RawMessage) (int64, error) {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		if text == "" {
+			return 0, nil
+		}
+		at, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return 0, err
+		}
+		return at.UnixMilli(), nil
+	}
+
+	var number float64
+	if err := json.Unmarshal(value, &number); err != nil {
+		return 0, err
+	}
+	// Anything below 1e12 is too early to be milliseconds (before 2001)
+	if number > 0 && number < 1e12 {
+		return int64(number) * 1000, nil
+	}
+	return int64(number), nil
+}
+
+// parseScopes reads scopes as a list or a space-separated string
+func parseScopes(value json.RawMessage) ([]string, error) {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		return strings.Fields(text), nil
+	}
+	var scopes []string
+	err := json.Unmarshal(value, &scopes)
+	return scopes, err
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// SettingsOverlay is a partial ~/.claude/settings.json merged in when a
+// profile is applied. Objects merge recursively; a null value removes the key.
+type SettingsOverlay map[string]interface{}
+
+// PreviousValue records a top-level settings.json key before an overlay changed it
+type PreviousValue struct {
+	Value   interface{} `json:"value,omitempty"`
+	Present bool        `json:"present"`
+}
+
+// OverlayUndo holds what is needed to revert an applied overlay
+type OverlayUndo map[string]PreviousValue
+
+// ClaudeSettingsPath returns the path of Claude Code's user settings file
+func ClaudeSettingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".claude", "settings.json"), nil
+}
+
+// LoadClaudeSettings reads ~/.claude/settings.json; a missing file is empty
+func LoadClaudeSettings() (map[string]interface{}, error) {
+	path, err := ClaudeSettingsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(map[string]interface{}), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Claude settings: %w", err)
+	}
+
+	settings := make(map[string]interface{})
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// SaveClaudeSettings atomically writes ~/.claude/settings.json
+func SaveClaudeSettings(settings map[string]interface{}) error {
+	path, err := ClaudeSettingsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create Claude settings directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Claude settings: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write Claude settings: %w", err)
+	}
+	return nil
+}
+
+// ApplySettingsOverlay merges an overlay into settings.json and returns the
+// undo record for every top-level key it touched
+func ApplySettingsOverlay(overlay SettingsOverlay) (OverlayUndo, error) {
+	settings, err := LoadClaudeSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	undo := make(OverlayUndo)
+	for key := range overlay {
+		previous, present := settings[key]
+		undo[key] = PreviousValue{Value: deepCopy(previous), Present: present}
+	}
+
+	mergeOverlay(settings, overlay)
+
+	if err := SaveClaudeSettings(settings); err != nil {
+		return nil, err
+	}
+	return undo, nil
+}
+
+// RevertSettingsOverlay restores the top-level keys recorded in undo
+func RevertSettingsOverlay(undo OverlayUndo) error {
+	if len(undo) == 0 {
+		return nil
+	}
+
+	settings, err := LoadClaudeSettings()
+	if err != nil {
+		return err
+	}
+
+	for key, previous := range undo {
+		if previous.Present {
+			settings[key] = previous.Value
+		} else {
+			delete(settings, key)
+		}
+	}
+
+	return SaveClaudeSettings(settings)
+}
+
+// mergeOverlay merges src into dst: objects recursively, null deletes
+func mergeOverlay(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if value == nil {
+			delete(dst, key)
+			continue
+		}
+
+		srcMap, srcIsMap := value.(map[string]interface{})
+		if srcIsMap {
+			dstMap, dstIsMap := dst[key].(map[string]interface{})
+			if !dstIsMap {
+				dstMap = make(map[string]interface{})
+			}
+			mergeOverlay(dstMap, srcMap)
+			if len(dstMap) > 0 {
+				dst[key] = dstMap
+			} else {
+				delete(dst, key)
+			}
+			continue
+		}
+
+		dst[key] = deepCopy(value)
+	}
+}
+
+// deepCopy clones a decoded JSON value so later merges cannot alias it
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// ProjectFileNames are the files binding a directory tree to an account,
+// checked in this order in each directory
+var ProjectFileNames = []string{".cflip", ".cflip.json"}
+
+// ProjectBinding is the account a project declares in its .cflip file.
+// A .cflip file holds either JSON or just the account on one line; lines
+// starting with # are comments.
+type ProjectBinding struct {
+	// Account is an account number, email, alias or profile name
+	Account string `json:"account"`
+	// Org optionally selects an organization within the account
+	Org string `json:"org,omitempty"`
+	// Path is the file the binding was read from
+	Path string `json:"-"`
+}
+
+// FindProjectBinding walks up from dir to the filesystem root and returns
+// the first binding found, or nil when no directory has one
+func FindProjectBinding(dir string) (*ProjectBinding, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		for _, name := range ProjectFileNames {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			return LoadProjectBinding(path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectBinding reads one .cflip or .cflip.json file
+func LoadProjectBinding(path string) (*ProjectBinding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	binding := &ProjectBinding{Path: path}
+	content := strings.TrimSpace(string(data))
+	if strings.HasPrefix(content, "{") || filepath.Ext(path) == ".json" {
+		if err := json.Unmarshal(data, binding); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else {
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				binding.Account = line
+				break
+			}
+		}
+	}
+
+	binding.Account = strings.TrimSpace(binding.Account)
+	if binding.Account == "" {
+		return nil, fmt.Errorf("%s does not name an account", path)
+	}
+	return binding, nil
+}
+
+// SaveProjectBinding writes a .cflip file binding dir to an account
+func SaveProjectBinding(dir string, binding *ProjectBinding) (string, error) {
+	data, err := json.MarshalIndent(binding, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal project binding: %w", err)
+	}
+
+	path := filepath.Join(dir, ProjectFileNames[0])
+	// Not a secret: the file is meant to be committed with the project
+	if err := fsutil.WriteFileAtomic(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
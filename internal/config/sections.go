@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Config sections that can be copied between profiles with CopySections
+const (
+	SectionSettings = "settings"
+	SectionMCP      = "mcp"
+	SectionProjects = "projects"
+	SectionClaudeMD = "claude_md"
+)
+
+// DefaultCopySections are copied when no sections are requested
+var DefaultCopySections = []string{SectionSettings, SectionMCP}
+
+// ErrSharedSection is returned for sections every account already shares
+var ErrSharedSection = errors.New("~/.claude/CLAUDE.md lives outside the per-account config and is already shared by every account")
+
+// accountKeys identify an account, hold secrets, or are per-install
+// bookkeeping; they are never copied as "settings"
+var accountKeys = map[string]bool{
+	"oauthAccount":             true,
+	"userID":                   true,
+	"primaryApiKey":            true,
+	"customApiKeyResponses":    true,
+	"numStartups":              true,
+	"firstStartTime":           true,
+	"tipsHistory":              true,
+	"subscriptionNoticeCount":  true,
+	"hasAvailableSubscription": true,
+	"claudeCodeFirstTokenDate": true,
+	"s1mAccessCache":           true,
+	"mcpServers":               true,
+	"projects":                 true,
+}
+
+// isSettingsKey reports whether a top-level key is a user preference
+func isSettingsKey(key string) bool {
+	if accountKeys[key] || strings.HasPrefix(key, "_cflip_") {
+		return false
+	}
+	return !strings.HasPrefix(key, "cached") && !strings.HasSuffix(key, "Cache")
+}
+
+// CopySections copies the named non-credential sections from one Claude
+// Code config into another, replacing what the destination had
+func CopySections(from, to ClaudeConfig, sections []string) error {
+	for _, section := range sections {
+		switch section {
+		case SectionSettings:
+			for key := range to {
+				if isSettingsKey(key) {
+					delete(to, key)
+				}
+			}
+			for key, value := range from {
+				if isSettingsKey(key) {
+					to[key] = value
+				}
+			}
+		case SectionMCP, SectionProjects:
+			key := "mcpServers"
+			if section == SectionProjects {
+				key = "projects"
+			}
+			if value, ok := from[key]; ok {
+				to[key] = value
+			} else {
+				delete(to, key)
+			}
+		case SectionClaudeMD:
+			return ErrSharedSection
+		default:
+			return fmt.Errorf("unknown section %q (use %s, %s, %s or %s)",
+				section, SectionSettings, SectionMCP, SectionProjects, SectionClaudeMD)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// UsageEntry is one assistant response recorded in Claude Code's session logs
+type UsageEntry struct {
+	Timestamp                time.Time
+	Model                    string
+	InputTokens              int64
+	OutputTokens             int64
+	CacheCreationInputTokens int64
+	CacheReadInputTokens     int64
+	CostUSD                  float64
+}
+
+// usageLine mirrors the subset of a session JSONL line cflip cares about
+type usageLine struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"requestId"`
+	CostUSD   float64   `json:"costUSD"`
+	Message   struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// UsageLogDirs returns the directories where Claude Code keeps per-project
+// session logs. Newer releases use ~/.config/claude, older ones ~/.claude.
+func UsageLogDirs() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []string{
+		filepath.Join(home, ".config", "claude", "projects"),
+		filepath.Join(home, ".claude", "projects"),
+	}
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		candidates = append([]string{filepath.Join(dir, "projects")}, candidates...)
+	}
+
+	var dirs []string
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// LoadUsageEntries parses Claude Code's session logs and returns every
+// assistant message with token usage recorded at or after since. Duplicate
+// entries (the same message logged by resumed sessions) are counted once.
+func LoadUsageEntries(since time.Time) ([]UsageEntry, error) {
+	dirs, err := UsageLogDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var entries []UsageEntry
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+				return nil
+			}
+
+			// Skip files untouched since the window started
+			if info, err := d.Info(); err == nil && info.ModTime().Before(since) {
+				return nil
+			}
+
+			return parseUsageFile(path, since, seen, &entries)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// parseUsageFile appends usage entries from a single session log
+func parseUsageFile(path string, since time.Time, seen map[string]bool, entries *[]UsageEntry) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil // Unreadable logs are skipped
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var line usageLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.Type != "assistant" || line.Message.Usage == nil || line.Timestamp.Before(since) {
+			continue
+		}
+
+		if line.Message.ID != "" && line.RequestID != "" {
+			key := line.Message.ID + ":" + line.RequestID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		usage := line.Message.Usage
+		*entries = append(*entries, UsageEntry{
+			Timestamp:                line.Timestamp,
+			Model:                    line.Message.Model,
+			InputTokens:              usage.InputTokens,
+			OutputTokens:             usage.OutputTokens,
+			CacheCreationInputTokens: usage.CacheCreationInputTokens,
+			CacheReadInputTokens:     usage.CacheReadInputTokens,
+			CostUSD:                  line.CostUSD,
+		})
+	}
+
+	return nil
+}
+
+// rateLimitMarkers are substrings Claude Code writes when a usage cap is hit
+var rateLimitMarkers = []string{
+	"usage limit reached",
+	"limit reached",
+	"rate_limit_error",
+	"rate limit",
+}
+
+// rateLimitLine mirrors the fields of a session log line that signal API errors
+type rateLimitLine struct {
+	Timestamp         time.Time `json:"timestamp"`
+	IsAPIErrorMessage bool      `json:"isApiErrorMessage"`
+	Message           struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+	Error json.RawMessage `json:"error"`
+}
+
+// DetectRateLimit reports whether a session log line records a rate-limit or
+// usage-cap error, returning the time it was logged.
+func DetectRateLimit(data []byte) (bool, time.Time) {
+	var line rateLimitLine
+	if err := json.Unmarshal(data, &line); err != nil {
+		return false, time.Time{}
+	}
+
+	if !line.IsAPIErrorMessage && len(line.Error) == 0 {
+		return false, time.Time{}
+	}
+
+	text := strings.ToLower(string(line.Message.Content) + string(line.Error))
+	for _, marker := range rateLimitMarkers {
+		if strings.Contains(text, marker) {
+			return true, line.Timestamp
+		}
+	}
+
+	return false, time.Time{}
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// ErrNotRunning is returned when no daemon answers on the socket
+var ErrNotRunning = errors.New("cflip daemon is not running")
+
+const (
+	// dialTimeout keeps commands fast when the socket is stale
+	dialTimeout = 200 * time.Millisecond
+	// callTimeout bounds a request; a switch may refresh tokens first
+	callTimeout = time.Minute
+)
+
+// Call sends one request to the running daemon and decodes its result into
+// result (ignored when nil). It returns ErrNotRunning when no daemon
+// answers, or when CFLIP_NO_DAEMON=1.
+func Call(req Request, result interface{}) error {
+	if os.Getenv(DisableEnv) == "1" {
+		return ErrNotRunning
+	}
+	path, err := SocketPath()
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(callTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("failed to send request to the daemon: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read the daemon's response: %w", err)
+	}
+	if !resp.OK {
+		return errors.New(resp.Error)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to parse the daemon's response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Ping describes the running daemon
+func Ping() (*Info, error) {
+	var info Info
+	if err := Call(Request{Method: MethodPing}, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// List returns the stored accounts the daemon holds
+func List() ([]*service.ProfileInfo, error) {
+	var profiles []*service.ProfileInfo
+	if err := Call(Request{Method: MethodList}, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// Current returns the active account
+func Current() (*service.ProfileInfo, error) {
+	var current service.ProfileInfo
+	if err := Call(Request{Method: MethodCurrent}, &current); err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// Status returns the active account for shell prompts, or nil when no
+// account is active
+func Status() (*service.PromptStatus, error) {
+	var status *service.PromptStatus
+	if err := Call(Request{Method: MethodStatus}, &status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Switch has the daemon switch accounts
+func Switch(identifier string, force bool) (*service.SwitchResult, error) {
+	var result service.SwitchResult
+	if err := Call(Request{Method: MethodSwitch, Identifier: identifier, Force: force}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Stop asks the running daemon to exit
+func Stop() error {
+	return Call(Request{Method: MethodStop}, nil)
+}
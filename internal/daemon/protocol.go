@@ -0,0 +1,73 @@
+// Package daemon runs cflip as a long-lived process that keeps the stored
+// accounts in memory and answers list, current, status and switch requests
+// over a unix socket. Commands run in shell prompts then skip loading every
+// profile (and its keychain item) on each call.
+package daemon
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// Methods the daemon answers
+const (
+	MethodPing    = "ping"
+	MethodList    = "list"
+	MethodCurrent = "current"
+	MethodStatus  = "status"
+	MethodSwitch  = "switch"
+	MethodStop    = "stop"
+)
+
+// SocketName is the daemon's socket in the data directory
+const SocketName = "daemon.sock"
+
+// SocketEnv overrides where the daemon listens
+const SocketEnv = "CFLIP_DAEMON_SOCKET"
+
+// DisableEnv makes commands ignore a running daemon when set to 1
+const DisableEnv = "CFLIP_NO_DAEMON"
+
+// Request is one call, sent as a line of JSON
+type Request struct {
+	Method string `json:"method"`
+	// Identifier is the account to switch to, resolved like on the command
+	// line; empty switches to the next account
+	Identifier string `json:"identifier,omitempty"`
+	Force      bool   `json:"force,omitempty"`
+}
+
+// Response answers a Request, also as a line of JSON
+type Response struct {
+	OK     bool            `json:"ok"`
+	Error  string          `json:"error,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// Info describes a running daemon
+type Info struct {
+	PID       int       `json:"pid"`
+	Version   string    `json:"version"`
+	Socket    string    `json:"socket"`
+	StartedAt time.Time `json:"started_at"`
+	// LoadedAt is when the account list was last read from disk
+	LoadedAt time.Time `json:"loaded_at"`
+	Accounts int       `json:"accounts"`
+}
+
+// SocketPath is where the daemon listens: $CFLIP_DAEMON_SOCKET, or
+// daemon.sock in the data directory
+func SocketPath() (string, error) {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path, nil
+	}
+	dir, err := profile.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SocketName), nil
+}
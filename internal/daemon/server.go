@@ -0,0 +1,309 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// maxCacheAge reloads the account list even without file changes, since
+// it carries relative times ("expires in", "last used") and macOS keychain
+// updates change no file
+const maxCacheAge = 30 * time.Second
+
+// Event reports something the daemon did
+type Event struct {
+	Time     time.Time
+	Kind     string // "loaded", "switched", "refreshed", "rotated", "archived", "error"
+	Accounts int    // loaded: accounts now held
+	Email    string // switched, rotated: the account switched to; refreshed, archived: the account
+	From     string // rotated: the account switched from
+	Profile  string // rotated: the profile switched to
+	Rule     string // rotated: the rotation rule that matched
+	Reason   string // archived: why the retention policy archived the account
+	Err      error
+}
+
+// Server holds the stored accounts in memory and answers requests for them
+type Server struct {
+	svc       *service.Service
+	version   string
+	onEvent   func(Event)
+	startedAt time.Time
+	stop      context.CancelFunc
+
+	mu       sync.Mutex
+	profiles []*service.ProfileInfo
+	loadedAt time.Time
+	// stamps are the modification times of the watched paths at the last load
+	stamps map[string]time.Time
+	// rotateErr is the last rotation failure reported, so a failure that
+	// repeats every check is reported once
+	rotateErr string
+}
+
+// NewServer creates a daemon serving svc's accounts
+func NewServer(svc *service.Service, version string) *Server {
+	return &Server{svc: svc, version: version}
+}
+
+// Run listens on the socket and serves requests until ctx is cancelled or
+// a stop request arrives. ~/.claude.json, config.json and the profile and
+// credential directories are polled every interval; the account list is
+// reloaded from disk when one of them changes. With token_refresh.enabled
+// tokens nearing expiry are refreshed in the background as well, with
+// rotation.daemon the rotation schedule is applied, and with
+// retention.daemon the retention policies are enforced. onEvent is never
+// called concurrently.
+func (s *Server) Run(ctx context.Context, interval time.Duration, onEvent func(Event)) error {
+	path, err := SocketPath()
+	if err != nil {
+		return err
+	}
+	if err := claimSocket(path); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	defer os.Remove(path)
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+
+	ctx, s.stop = context.WithCancel(ctx)
+	defer s.stop()
+	s.startedAt = time.Now()
+	var eventMu sync.Mutex
+	s.onEvent = func(event Event) {
+		eventMu.Lock()
+		defer eventMu.Unlock()
+		onEvent(event)
+	}
+
+	if err := s.startTasks(ctx); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.reloadIfChanged()
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				listener.Close()
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				s.reloadIfChanged()
+				s.mu.Unlock()
+			}
+		}
+	}()
+
+	// A switch in progress finishes before Run returns
+	defer s.mu.Unlock()
+	defer s.mu.Lock()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept a connection: %w", err)
+		}
+		go s.serve(conn)
+	}
+}
+
+// claimSocket removes a socket left behind by a daemon that died, and
+// fails when another daemon is still answering on it
+func claimSocket(path string) error {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return fmt.Errorf("a cflip daemon is already running on %s", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// serve answers the requests on one connection, one JSON line each
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("invalid request: %v", err)
+		} else if result, err := s.handle(req); err != nil {
+			resp.Error = err.Error()
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Error = fmt.Sprintf("failed to encode result: %v", err)
+		} else {
+			resp.OK = true
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers one request
+func (s *Server) handle(req Request) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Method {
+	case MethodPing:
+		path, _ := SocketPath()
+		return &Info{
+			PID:       os.Getpid(),
+			Version:   s.version,
+			Socket:    path,
+			StartedAt: s.startedAt,
+			LoadedAt:  s.loadedAt,
+			Accounts:  len(s.profiles),
+		}, nil
+
+	case MethodList:
+		if err := s.reloadIfChanged(); err != nil {
+			return nil, err
+		}
+		return s.profiles, nil
+
+	case MethodCurrent:
+		if err := s.reloadIfChanged(); err != nil {
+			return nil, err
+		}
+		for _, p := range s.profiles {
+			if p.IsActive {
+				return p, nil
+			}
+		}
+		return nil, fmt.Errorf("no active profile found")
+
+	case MethodStatus:
+		return service.ReadStatus(time.Now())
+
+	case MethodSwitch:
+		identifier := req.Identifier
+		if identifier != "" {
+			resolution, err := s.svc.ResolveIdentifier(identifier)
+			if err != nil {
+				return nil, err
+			}
+			identifier = resolution.Profile.Name
+		}
+		result, err := s.svc.Switch(identifier, req.Force)
+		if err != nil {
+			return nil, err
+		}
+		s.onEvent(Event{Time: time.Now(), Kind: "switched", Email: result.To.Email})
+		s.stamps = nil
+		return result, nil
+
+	case MethodStop:
+		s.stop()
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
+
+// reloadIfChanged reloads the account list when a watched path changed or
+// the list is older than maxCacheAge. The caller holds s.mu.
+func (s *Server) reloadIfChanged() error {
+	stamps, err := watchStamps()
+	if err != nil {
+		return err
+	}
+	if s.stamps != nil && sameStamps(s.stamps, stamps) && time.Since(s.loadedAt) < maxCacheAge {
+		return nil
+	}
+
+	profiles, err := s.svc.ListProfiles()
+	if err != nil {
+		s.onEvent(Event{Time: time.Now(), Kind: "error", Err: err})
+		return err
+	}
+	changed := s.stamps == nil || !sameStamps(s.stamps, stamps)
+	s.profiles, s.stamps, s.loadedAt = profiles, stamps, time.Now()
+	if changed {
+		s.onEvent(Event{Time: s.loadedAt, Kind: "loaded", Accounts: len(profiles)})
+	}
+	return nil
+}
+
+// watchStamps returns the modification times of the paths whose changes
+// invalidate the account list: Claude Code's config, cflip's config.json,
+// and the directories profiles and credential files are written into (an
+// atomic write replaces the file, which updates its directory)
+func watchStamps() (map[string]time.Time, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	dataDir, err := profile.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := profile.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	stamps := make(map[string]time.Time)
+	for _, path := range []string{
+		filepath.Join(home, ".claude.json"),
+		filepath.Join(home, ".claude"),
+		filepath.Join(configDir, "config.json"),
+		dataDir,
+	} {
+		info, err := os.Stat(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			stamps[path] = info.ModTime()
+		}
+	}
+	return stamps, nil
+}
+
+// sameStamps reports whether two sets of modification times match
+func sameStamps(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, t := range a {
+		if !b[path].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/auth"
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// setupDaemonHome points the home, data, config and runtime directories and
+// the socket at temporary ones
+func setupDaemonHome(t *testing.T) *profile.ProfileManager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	t.Setenv(SocketEnv, filepath.Join(t.TempDir(), SocketName))
+	if err := os.MkdirAll(filepath.Join(home, ".claude"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	pm, err := profile.NewProfileManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pm
+}
+
+// stubTokenEndpoint answers refresh requests with a new access token
+func stubTokenEndpoint(t *testing.T) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.TokenResponse{AccessToken: "new-token", RefreshToken: "new-refresh", ExpiresIn: 8 * 3600})
+	}))
+	t.Cleanup(server.Close)
+	previous := auth.TokenURL
+	auth.TokenURL = server.URL
+	t.Cleanup(func() { auth.TokenURL = previous })
+}
+
+// runServer runs a daemon until the test ends, sending its events to the
+// returned channel
+func runServer(t *testing.T) <-chan Event {
+	t.Helper()
+	svc, err := service.NewService()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan Event, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(svc, "test").Run(ctx, time.Hour, func(event Event) { events <- event })
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("daemon: %v", err)
+		}
+	})
+	return events
+}
+
+// saveTestProfile stores a profile whose token expires in expiresIn
+func saveTestProfile(t *testing.T, pm *profile.ProfileManager, email string, expiresIn time.Duration) {
+	t.Helper()
+	credentials := &config.Credentials{}
+	credentials.ClaudeAiOauth.AccessToken = "old-token"
+	credentials.ClaudeAiOauth.RefreshToken = "old-refresh"
+	credentials.ClaudeAiOauth.ExpiresAt = time.Now().Add(expiresIn).UnixMilli()
+	if err := pm.SaveProfile(&profile.Profile{
+		Name:         email,
+		Email:        email,
+		ClaudeConfig: &config.ClaudeConfig{},
+		Credentials:  credentials,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// updateSettings changes the settings in config.json
+func updateSettings(t *testing.T, pm *profile.ProfileManager, update func(*profile.Settings)) {
+	t.Helper()
+	cfg, err := pm.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	update(&cfg.Settings)
+	if err := pm.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForEvent returns the first event of kind, failing on an error event
+func waitForEvent(t *testing.T, events <-chan Event, kind string) Event {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Kind == kind {
+				return event
+			}
+			if event.Kind == "error" {
+				t.Fatalf("daemon error: %v", event.Err)
+			}
+		case <-timeout:
+			t.Fatalf("no %q event from the daemon", kind)
+		}
+	}
+}
+
+func TestDaemonRefreshesExpiringTokens(t *testing.T) {
+	pm := setupDaemonHome(t)
+	stubTokenEndpoint(t)
+
+	saveTestProfile(t, pm, "me@x.com", time.Hour)
+	updateSettings(t, pm, func(settings *profile.Settings) { settings.TokenRefresh.Enabled = true })
+
+	event := waitForEvent(t, runServer(t), "refreshed")
+	if event.Email != "me@x.com" {
+		t.Fatalf("refreshed %s, want me@x.com", event.Email)
+	}
+
+	stored, err := pm.LoadProfile("me@x.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored.Credentials.ClaudeAiOauth.AccessToken; got != "new-token" {
+		t.Errorf("stored access token = %q, want new-token", got)
+	}
+}
+
+func TestDaemonLeavesTokensAloneByDefault(t *testing.T) {
+	setupDaemonHome(t)
+	stubTokenEndpoint(t)
+
+	events := runServer(t)
+	select {
+	case event := <-events:
+		if event.Kind != "loaded" {
+			t.Fatalf("first event is %q, want loaded", event.Kind)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the daemon did not load the accounts")
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected %q event without token_refresh.enabled", event.Kind)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestDaemonAppliesRotationSchedule(t *testing.T) {
+	pm := setupDaemonHome(t)
+	saveTestProfile(t, pm, "a@x.com", 8*time.Hour)
+	saveTestProfile(t, pm, "b@x.com", 8*time.Hour)
+	home, _ := os.UserHomeDir()
+	marker := filepath.Join(home, "rotated")
+	updateSettings(t, pm, func(settings *profile.Settings) {
+		settings.Rotation.Daemon = true
+		settings.Rotation.Rules = []profile.RotationRule{{Name: "pinned", Account: "b@x.com"}}
+		settings.Rotation.Hooks = []string{`printf %s "$CFLIP_TO" > "` + marker + `"`}
+	})
+
+	event := waitForEvent(t, runServer(t), "rotated")
+	if event.Email != "b@x.com" || event.Profile != "b@x.com" || event.Rule != "pinned" {
+		t.Fatalf("rotated to %s (%s) by %q, want b@x.com by pinned", event.Email, event.Profile, event.Rule)
+	}
+	if hook, err := os.ReadFile(marker); err != nil || string(hook) != "b@x.com" {
+		t.Errorf("rotation hook wrote %q, %v", hook, err)
+	}
+	active, err := pm.GetActiveProfile()
+	if err != nil || active.Email != "b@x.com" {
+		t.Fatalf("active profile after rotation: %v, %v", active, err)
+	}
+}
+
+func TestDaemonEnforcesRetention(t *testing.T) {
+	pm := setupDaemonHome(t)
+	saveTestProfile(t, pm, "old@x.com", 8*time.Hour)
+	stale, err := pm.LoadProfile("old@x.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.LastActiveAt = time.Now().AddDate(0, 0, -100)
+	if err := pm.SaveProfile(stale); err != nil {
+		t.Fatal(err)
+	}
+	saveTestProfile(t, pm, "new@x.com", 8*time.Hour)
+	updateSettings(t, pm, func(settings *profile.Settings) {
+		settings.Retention.Daemon = true
+		settings.Retention.ArchiveInactiveDays = 90
+	})
+
+	event := waitForEvent(t, runServer(t), "archived")
+	if event.Email != "old@x.com" {
+		t.Fatalf("archived %s, want old@x.com", event.Email)
+	}
+	archived, err := pm.ListArchive()
+	if err != nil || len(archived) != 1 || archived[0].Profile.Email != "old@x.com" {
+		t.Fatalf("archive after enforcement: %v, %v", archived, err)
+	}
+	if _, err := pm.LoadProfile("new@x.com"); err != nil {
+		t.Errorf("the recently used profile was archived: %v", err)
+	}
+}
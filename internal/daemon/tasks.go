@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/service"
+)
+
+// How often the daemon applies the rotation schedule and the retention
+// policies
+const (
+	rotationInterval  = time.Minute
+	retentionInterval = time.Hour
+)
+
+// startTasks starts the background work enabled in settings: scheduled
+// token refresh (token_refresh.enabled), rotation (rotation.daemon) and
+// retention (retention.daemon). The tasks stop with ctx.
+func (s *Server) startTasks(ctx context.Context) error {
+	settings, err := service.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	if settings.TokenRefresh.Enabled {
+		window, err := settings.TokenRefresh.WindowDuration()
+		if err != nil {
+			return err
+		}
+		interval, err := settings.TokenRefresh.IntervalDuration()
+		if err != nil {
+			return err
+		}
+		go s.svc.AutoRefresh(ctx, service.RefreshSchedule{Window: window, Interval: interval}, s.refreshed)
+	}
+	if settings.Rotation.Daemon {
+		go every(ctx, rotationInterval, s.rotate)
+	}
+	if settings.Retention.Daemon {
+		go every(ctx, retentionInterval, s.enforce)
+	}
+	return nil
+}
+
+// every runs task right away and then every interval until ctx is cancelled
+func every(ctx context.Context, interval time.Duration, task func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		task()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rotate applies the rotation schedule like `cflip rotate`. It holds s.mu
+// so a rotation and a switch request do not overlap.
+func (s *Server) rotate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.svc.Rotate(false, false)
+	if result != nil && result.Applied {
+		s.stamps = nil
+		s.onEvent(Event{Time: time.Now(), Kind: "rotated", Email: result.ToEmail, From: result.FromEmail, Profile: result.ToProfile, Rule: result.Rule})
+	}
+	if err == nil {
+		s.rotateErr = ""
+		return
+	}
+	if err.Error() != s.rotateErr {
+		s.rotateErr = err.Error()
+		s.onEvent(Event{Time: time.Now(), Kind: "error", Err: fmt.Errorf("failed to rotate account: %w", err)})
+	}
+}
+
+// refreshed reports one result of the scheduled token refresh
+func (s *Server) refreshed(result service.RefreshResult) {
+	switch {
+	case result.Refreshed:
+		s.onEvent(Event{Time: time.Now(), Kind: "refreshed", Email: result.Email})
+	case result.Email != "":
+		s.onEvent(Event{Time: time.Now(), Kind: "error", Err: fmt.Errorf("refresh %s: %s", result.Email, result.Error)})
+	default:
+		s.onEvent(Event{Time: time.Now(), Kind: "error", Err: errors.New(result.Error)})
+	}
+}
+
+// enforce applies the retention policies like `cflip enforce`, holding s.mu
+// like rotate
+func (s *Server) enforce() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	actions, err := s.svc.EnforcePolicies(false)
+	for _, action := range actions {
+		if action.Action == "archive" {
+			s.stamps = nil
+			s.onEvent(Event{Time: time.Now(), Kind: "archived", Email: action.Email, Reason: action.Reason})
+		}
+	}
+	if err != nil {
+		s.onEvent(Event{Time: time.Now(), Kind: "error", Err: err})
+	}
+}
@@ -0,0 +1,81 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval is how often a waiting LockFile retries
+const lockPollInterval = 50 * time.Millisecond
+
+// ErrLockTimeout is returned when another process held a lock for longer
+// than the caller was willing to wait
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// FileLock is an exclusive advisory lock (flock) on a lock file. The kernel
+// drops it when the holder exits, so a crash never leaves a stale lock.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile takes an exclusive lock on path, creating it if needed, and
+// waits up to timeout for another holder to release it. The holder's PID is
+// written to the file so a waiting process can say who it waits for.
+func LockFile(path string, timeout time.Duration) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			holder := lockHolder(file)
+			file.Close()
+			if holder != 0 {
+				return nil, fmt.Errorf("%w %s held by process %d", ErrLockTimeout, path, holder)
+			}
+			return nil, fmt.Errorf("%w %s", ErrLockTimeout, path)
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	// Best effort: the PID only improves error messages
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &FileLock{file: file}, nil
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	defer l.file.Close()
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+	return nil
+}
+
+// lockHolder reads the PID the current holder wrote, or 0
+func lockHolder(file *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := file.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,127 @@
+package gitsync
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Encryption tools profiles can be synced with
+const (
+	ToolAge = "age"
+	ToolGPG = "gpg"
+)
+
+// Cipher encrypts profile blobs for a set of recipients with age or GPG.
+// The plaintext is passed on stdin so it never touches the disk.
+type Cipher struct {
+	// Tool is ToolAge (default) or ToolGPG
+	Tool string
+	// Recipients are age public keys or GPG key IDs; every device's key
+	// should be listed so each can decrypt what the others push
+	Recipients []string
+	// Identity is the age identity file to decrypt with (default
+	// ~/.config/age/keys.txt); GPG finds its secret keys itself
+	Identity string
+}
+
+// tool returns the configured tool, defaulting to age
+func (c Cipher) tool() string {
+	if c.Tool == "" {
+		return ToolAge
+	}
+	return c.Tool
+}
+
+// Ext is the file extension of blobs written by the cipher
+func (c Cipher) Ext() string {
+	if c.tool() == ToolGPG {
+		return ".gpg"
+	}
+	return ".age"
+}
+
+// Check reports why the cipher cannot be used, or nil when it can
+func (c Cipher) Check() error {
+	switch c.tool() {
+	case ToolAge, ToolGPG:
+	default:
+		return fmt.Errorf("unknown sync encryption %q (use age or gpg)", c.Tool)
+	}
+	if len(c.Recipients) == 0 {
+		return fmt.Errorf("no sync recipients configured; set settings.git_sync.recipients")
+	}
+	if _, err := exec.LookPath(c.tool()); err != nil {
+		return fmt.Errorf("%s not found in PATH", c.tool())
+	}
+	return nil
+}
+
+// Encrypt encrypts data for every recipient
+func (c Cipher) Encrypt(data []byte) ([]byte, error) {
+	args := []string{"--encrypt"}
+	if c.tool() == ToolGPG {
+		args = []string{"--batch", "--yes", "--quiet", "--trust-model", "always", "--encrypt"}
+	}
+	for _, recipient := range c.Recipients {
+		args = append(args, "--recipient", recipient)
+	}
+
+	encrypted, err := runTool(c.tool(), data, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with %s: %w", c.tool(), err)
+	}
+	return encrypted, nil
+}
+
+// Decrypt decrypts a blob written by Encrypt
+func (c Cipher) Decrypt(data []byte) ([]byte, error) {
+	args := []string{"--batch", "--quiet", "--decrypt"}
+	if c.tool() == ToolAge {
+		identity, err := c.identity()
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"--decrypt", "--identity", identity}
+	}
+
+	plain, err := runTool(c.tool(), data, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with %s: %w", c.tool(), err)
+	}
+	return plain, nil
+}
+
+// identity returns the age identity file, defaulting to age's usual one
+func (c Cipher) identity() (string, error) {
+	if c.Identity != "" {
+		return c.Identity, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "age", "keys.txt"), nil
+}
+
+// runTool runs an encryption tool with data on stdin
+func runTool(name string, data []byte, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in PATH: %w", name, err)
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
@@ -0,0 +1,179 @@
+package gitsync
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// Format identifies a cflip sync manifest
+const Format = "cflip-sync"
+
+// Version is the manifest version this build writes
+const Version = 1
+
+// ManifestName is the manifest's path in the repository
+const ManifestName = "manifest.json"
+
+// profilesDir holds the encrypted profile blobs in the repository
+const profilesDir = "profiles"
+
+// Manifest is the plaintext index of the repository. It names profiles by
+// ID only, so the repository does not reveal which accounts it holds.
+type Manifest struct {
+	Format   string           `json:"format"`
+	Version  int              `json:"version"`
+	Profiles map[string]Entry `json:"profiles"`
+	// Devices maps device IDs to the machines that pushed
+	Devices map[string]Device `json:"devices"`
+}
+
+// Entry is the synced state of one profile. Version counts the pushes
+// that changed it; a deleted profile stays as a tombstone so the deletion
+// reaches every device.
+type Entry struct {
+	Version   int       `json:"version"`
+	Device    string    `json:"device"`
+	UpdatedAt time.Time `json:"updated_at"`
+	File      string    `json:"file,omitempty"`
+	// Hash digests the plaintext, so a device holding identical content
+	// is recognized as in sync without decrypting anything
+	Hash    string `json:"hash,omitempty"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// Device is a machine that syncs
+type Device struct {
+	Name     string    `json:"name"`
+	LastPush time.Time `json:"last_push,omitempty"`
+}
+
+// DeviceName returns the name of the device with the given ID, or the ID
+// when the device is unknown
+func (m *Manifest) DeviceName(id string) string {
+	if device, ok := m.Devices[id]; ok && device.Name != "" {
+		return device.Name
+	}
+	return id
+}
+
+// ProfileID names a profile in the repository: a digest of its storage
+// key, stable across machines
+func ProfileID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// BlobName is the repository path of a profile's encrypted blob
+func BlobName(id, ext string) string {
+	return path.Join(profilesDir, id+ext)
+}
+
+// LoadManifest reads the manifest from the clone; a repository without
+// one yet yields an empty manifest
+func (r *Repo) LoadManifest() (*Manifest, error) {
+	manifest := &Manifest{Format: Format, Version: Version}
+	data, err := os.ReadFile(r.Path(ManifestName))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read the sync manifest: %w", err)
+	default:
+		if err := json.Unmarshal(data, manifest); err != nil || manifest.Format != Format {
+			return nil, fmt.Errorf("%s in %s is not a cflip sync manifest", ManifestName, r.URL)
+		}
+		if manifest.Version > Version {
+			return nil, fmt.Errorf("the sync repository was written by a newer cflip (manifest version %d); upgrade cflip", manifest.Version)
+		}
+	}
+	if manifest.Profiles == nil {
+		manifest.Profiles = make(map[string]Entry)
+	}
+	if manifest.Devices == nil {
+		manifest.Devices = make(map[string]Device)
+	}
+	return manifest, nil
+}
+
+// SaveManifest writes the manifest into the clone
+func (r *Repo) SaveManifest(manifest *Manifest) error {
+	manifest.Format, manifest.Version = Format, Version
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the sync manifest: %w", err)
+	}
+	return fsutil.WriteFileAtomic(r.Path(ManifestName), append(data, '\n'), 0o600)
+}
+
+// State is what this device last synced: its own identity and, per
+// profile ID, the version it last pushed or pulled and a hash of the
+// profile at that moment. A profile whose hash changed since has local
+// edits; one whose manifest version moved past the recorded one has
+// remote edits; both at once is a conflict.
+type State struct {
+	DeviceID   string            `json:"device_id"`
+	DeviceName string            `json:"device_name"`
+	Profiles   map[string]Synced `json:"profiles"`
+}
+
+// Synced is a profile's state at its last sync
+type Synced struct {
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+	// Name is the local profile name, to report a deletion by
+	Name string `json:"name,omitempty"`
+}
+
+// LoadState reads the device's sync state from path, creating a device
+// identity on first use
+func LoadState(path string) (*State, error) {
+	state := &State{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	default:
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("failed to parse sync state %s: %w", path, err)
+		}
+	}
+
+	if state.DeviceID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		state.DeviceID = hex.EncodeToString(id)
+	}
+	if state.DeviceName == "" {
+		state.DeviceName, _ = os.Hostname()
+	}
+	if state.Profiles == nil {
+		state.Profiles = make(map[string]Synced)
+	}
+	return state, nil
+}
+
+// Save writes the sync state to path
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+	return fsutil.WriteFileAtomic(path, append(data, '\n'), 0o600)
+}
+
+// Hash digests a profile's plaintext for change detection
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,174 @@
+package gitsync
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Sync actions
+const (
+	// ActionPush publishes a profile changed or added on this device
+	ActionPush = "push"
+	// ActionPull applies a profile changed or added on another device
+	ActionPull = "pull"
+	// ActionDelete publishes a deletion made on this device (push), or
+	// applies one made on another device (pull)
+	ActionDelete = "delete"
+	// ActionConflict marks a profile changed both here and elsewhere since
+	// the last sync
+	ActionConflict = "conflict"
+	// ActionNone marks a profile that is in sync
+	ActionNone = "none"
+)
+
+// Direction is which side of the sync a plan is for
+type Direction int
+
+// Directions of a plan. A Status plan reports what push and pull would
+// each do, and never resolves conflicts.
+const (
+	Push Direction = iota
+	Pull
+	Status
+)
+
+// Local is a stored profile as this device has it
+type Local struct {
+	ID   string
+	Name string
+	Hash string
+}
+
+// Item is what syncing one profile does
+type Item struct {
+	ID string `json:"id"`
+	// Name is the local profile name; empty for a profile this device
+	// does not have yet
+	Name   string `json:"name,omitempty"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+	// Remote is the profile's manifest entry, when there is one
+	Remote *Entry `json:"remote,omitempty"`
+}
+
+// Plan decides what syncing does to every profile known locally, to the
+// device's state or to the manifest. Items of the other direction are
+// reported as ActionNone with a reason. With force, conflicts resolve in
+// the plan's direction.
+func Plan(direction Direction, local []Local, state *State, manifest *Manifest, force bool) []Item {
+	locals := make(map[string]Local, len(local))
+	ids := make(map[string]bool)
+	for _, l := range local {
+		locals[l.ID] = l
+		ids[l.ID] = true
+	}
+	for id := range state.Profiles {
+		ids[id] = true
+	}
+	for id := range manifest.Profiles {
+		ids[id] = true
+	}
+
+	var items []Item
+	for id := range ids {
+		l, hasLocal := locals[id]
+		synced, wasSynced := state.Profiles[id]
+		item := Item{ID: id, Name: l.Name, Action: ActionNone}
+		if !hasLocal {
+			item.Name = synced.Name
+		}
+		var remote Entry
+		hasRemote := false
+		if entry, ok := manifest.Profiles[id]; ok {
+			remote, hasRemote = entry, true
+			item.Remote = &entry
+		}
+
+		localChanged := hasLocal && (!wasSynced || l.Hash != synced.Hash)
+		localDeleted := !hasLocal && wasSynced
+		remoteChanged := hasRemote && remote.Version > synced.Version
+		sameContent := hasLocal && hasRemote && !remote.Deleted && remote.Hash == l.Hash
+
+		switch {
+		case sameContent:
+			item.Reason = "in sync"
+		case !hasLocal && !wasSynced && remote.Deleted:
+			// A tombstone this device never had the profile for
+			continue
+		case !hasLocal && (!hasRemote || remote.Deleted):
+			item.Reason = "deleted everywhere"
+		case (localChanged || localDeleted) && remoteChanged:
+			item.Action, item.Reason = ActionConflict, conflictReason(localDeleted, remote, manifest.DeviceName(remote.Device))
+			if force && direction == Push {
+				item.Action = pushAction(localDeleted)
+				item.Reason += "; overwriting the repository"
+			} else if force && direction == Pull {
+				item.Action = pullAction(remote)
+				item.Reason += "; overwriting this device"
+			}
+		case localChanged || localDeleted:
+			if direction != Pull {
+				item.Action = pushAction(localDeleted)
+			}
+			item.Reason = "changed on this device"
+			switch {
+			case localDeleted:
+				item.Reason = "deleted on this device"
+			case !hasRemote || remote.Deleted:
+				item.Reason = "new on this device"
+			}
+		case remoteChanged:
+			if direction != Push {
+				item.Action = pullAction(remote)
+			}
+			device := manifest.DeviceName(remote.Device)
+			item.Reason = "changed on " + device
+			switch {
+			case remote.Deleted:
+				item.Reason = "deleted on " + device
+			case !hasLocal:
+				item.Reason = "new on " + device
+			}
+		default:
+			item.Reason = "in sync"
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// pushAction publishes a local change or deletion
+func pushAction(deleted bool) string {
+	if deleted {
+		return ActionDelete
+	}
+	return ActionPush
+}
+
+// pullAction applies a remote change or deletion
+func pullAction(remote Entry) string {
+	if remote.Deleted {
+		return ActionDelete
+	}
+	return ActionPull
+}
+
+// conflictReason explains a conflict
+func conflictReason(localDeleted bool, remote Entry, device string) string {
+	local := "changed on this device"
+	if localDeleted {
+		local = "deleted on this device"
+	}
+	other := "changed"
+	if remote.Deleted {
+		other = "deleted"
+	}
+	return fmt.Sprintf("%s and %s on %s (version %d)", local, other, device, remote.Version)
+}
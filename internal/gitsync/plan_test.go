@@ -0,0 +1,114 @@
+package gitsync
+
+import (
+	"strings"
+	"testing"
+)
+
+// planOne plans a single profile "p1" in the given direction
+func planOne(direction Direction, local *Local, synced *Synced, remote *Entry, force bool) []Item {
+	var locals []Local
+	if local != nil {
+		l := *local
+		l.ID = "p1"
+		locals = append(locals, l)
+	}
+	state := &State{DeviceID: "d1", Profiles: map[string]Synced{}}
+	if synced != nil {
+		state.Profiles["p1"] = *synced
+	}
+	manifest := &Manifest{
+		Profiles: map[string]Entry{},
+		Devices:  map[string]Device{"d1": {Name: "desktop"}, "d2": {Name: "laptop"}},
+	}
+	if remote != nil {
+		manifest.Profiles["p1"] = *remote
+	}
+	return Plan(direction, locals, state, manifest, force)
+}
+
+func TestPlan(t *testing.T) {
+	local := func(hash string) *Local { return &Local{Name: "work", Hash: hash} }
+	synced := func(version int, hash string) *Synced { return &Synced{Version: version, Hash: hash, Name: "work"} }
+	remote := func(version int, hash string) *Entry { return &Entry{Version: version, Device: "d2", Hash: hash} }
+	deleted := func(version int) *Entry { return &Entry{Version: version, Device: "d2", Deleted: true} }
+
+	tests := []struct {
+		name      string
+		direction Direction
+		force     bool
+		local     *Local
+		synced    *Synced
+		remote    *Entry
+		action    string
+		reason    string
+	}{
+		{"in sync", Push, false, local("h1"), synced(1, "h1"), remote(1, "h1"), ActionNone, "in sync"},
+		{"same content elsewhere", Pull, false, local("h2"), nil, remote(3, "h2"), ActionNone, "in sync"},
+
+		{"new here, push", Push, false, local("h1"), nil, nil, ActionPush, "new on this device"},
+		{"changed here, push", Push, false, local("h2"), synced(1, "h1"), remote(1, "h1"), ActionPush, "changed on this device"},
+		{"changed here, pull", Pull, false, local("h2"), synced(1, "h1"), remote(1, "h1"), ActionNone, "changed on this device"},
+		{"changed here, status", Status, false, local("h2"), synced(1, "h1"), remote(1, "h1"), ActionPush, "changed on this device"},
+		{"deleted here, push", Push, false, nil, synced(1, "h1"), remote(1, "h1"), ActionDelete, "deleted on this device"},
+		{"recreated here after a remote deletion", Push, false, local("h2"), nil, deleted(2), ActionConflict, "deleted on laptop"},
+
+		{"new elsewhere, pull", Pull, false, nil, nil, remote(1, "h1"), ActionPull, "new on laptop"},
+		{"new elsewhere, push", Push, false, nil, nil, remote(1, "h1"), ActionNone, "new on laptop"},
+		{"changed elsewhere, pull", Pull, false, local("h1"), synced(1, "h1"), remote(2, "h2"), ActionPull, "changed on laptop"},
+		{"changed elsewhere, push", Push, false, local("h1"), synced(1, "h1"), remote(2, "h2"), ActionNone, "changed on laptop"},
+		{"deleted elsewhere, pull", Pull, false, local("h1"), synced(1, "h1"), deleted(2), ActionDelete, "deleted on laptop"},
+
+		{"deleted everywhere", Pull, false, nil, synced(1, "h1"), deleted(2), ActionNone, "deleted everywhere"},
+
+		{"conflict", Push, false, local("h2"), synced(1, "h1"), remote(2, "h3"), ActionConflict,
+			"changed on this device and changed on laptop (version 2)"},
+		{"conflict, status with force", Status, true, local("h2"), synced(1, "h1"), remote(2, "h3"), ActionConflict, "changed on laptop"},
+		{"conflict, forced push", Push, true, local("h2"), synced(1, "h1"), remote(2, "h3"), ActionPush, "overwriting the repository"},
+		{"conflict, forced pull", Pull, true, local("h2"), synced(1, "h1"), remote(2, "h3"), ActionPull, "overwriting this device"},
+		{"deleted here, changed elsewhere", Pull, false, nil, synced(1, "h1"), remote(2, "h2"), ActionConflict,
+			"deleted on this device and changed on laptop"},
+		{"changed here, deleted elsewhere, forced pull", Pull, true, local("h2"), synced(1, "h1"), deleted(2), ActionDelete,
+			"changed on this device and deleted on laptop"},
+		{"deleted here, changed elsewhere, forced push", Push, true, nil, synced(1, "h1"), remote(2, "h2"), ActionDelete,
+			"overwriting the repository"},
+	}
+	for _, tt := range tests {
+		items := planOne(tt.direction, tt.local, tt.synced, tt.remote, tt.force)
+		if len(items) != 1 {
+			t.Errorf("%s: %d items, want 1", tt.name, len(items))
+			continue
+		}
+		item := items[0]
+		if item.ID != "p1" || item.Action != tt.action || !strings.Contains(item.Reason, tt.reason) {
+			t.Errorf("%s: got %s (%q), want %s (%q)", tt.name, item.Action, item.Reason, tt.action, tt.reason)
+		}
+		if item.Name == "" && (tt.local != nil || tt.synced != nil) {
+			t.Errorf("%s: the item has no name", tt.name)
+		}
+		if (item.Remote != nil) != (tt.remote != nil) {
+			t.Errorf("%s: remote entry %+v, want one: %v", tt.name, item.Remote, tt.remote != nil)
+		}
+	}
+}
+
+func TestPlanSkipsUnknownTombstones(t *testing.T) {
+	if items := planOne(Pull, nil, nil, &Entry{Version: 3, Device: "d2", Deleted: true}, false); len(items) != 0 {
+		t.Errorf("a tombstone for a profile this device never had was planned: %+v", items)
+	}
+}
+
+func TestPlanOrder(t *testing.T) {
+	local := []Local{{ID: "c", Name: "zeta", Hash: "h"}, {ID: "b", Name: "alpha", Hash: "h"}}
+	manifest := &Manifest{Profiles: map[string]Entry{"a": {Version: 1, Device: "d2", Hash: "h"}}}
+	items := Plan(Push, local, &State{Profiles: map[string]Synced{}}, manifest, false)
+
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	// Profiles this device does not have yet have no name and come first
+	if got := strings.Join(ids, ","); got != "a,b,c" {
+		t.Errorf("planned %s, want a,b,c", got)
+	}
+}
@@ -0,0 +1,179 @@
+// Package gitsync keeps encrypted copies of stored profiles in a git
+// repository the user provides, so several machines can share one account
+// set. Profiles are encrypted with age or GPG before they are committed; a
+// plaintext manifest records each profile's version counter and the device
+// that last wrote it, which is how conflicting edits are detected.
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// DefaultBranch is the branch synced when none is configured
+const DefaultBranch = "main"
+
+// ErrPushRejected is returned when another device pushed first
+var ErrPushRejected = errors.New("the sync repository changed while pushing; run `cflip sync pull`, then push again")
+
+// Repo is the local clone of the sync repository
+type Repo struct {
+	Dir    string
+	URL    string
+	Branch string
+}
+
+// Open clones url into dir unless a clone of it is already there
+func Open(dir, url, branch string) (*Repo, error) {
+	if url == "" {
+		return nil, fmt.Errorf("no sync repository configured; set settings.git_sync.repo")
+	}
+	if branch == "" {
+		branch = DefaultBranch
+	}
+	repo := &Repo{Dir: dir, URL: url, Branch: branch}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		origin, err := repo.git("remote", "get-url", "origin")
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(origin)) != url {
+			// The repository setting changed: start over from the new one
+			if err := os.RemoveAll(dir); err != nil {
+				return nil, fmt.Errorf("failed to remove the old sync clone: %w", err)
+			}
+		} else {
+			return repo, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(dir), err)
+	}
+	if _, err := runGit("", nil, "clone", "--quiet", url, dir); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	return repo, nil
+}
+
+// Fetch brings the clone to the remote branch, discarding anything left
+// over from an interrupted run. A repository without the branch yet (a
+// fresh one) leaves an empty tree to push the first commit from.
+func (r *Repo) Fetch() error {
+	if _, err := r.git("fetch", "--quiet", "origin"); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", r.URL, err)
+	}
+
+	remote := "origin/" + r.Branch
+	if _, err := r.git("rev-parse", "--verify", "--quiet", remote); err != nil {
+		if _, err := r.git("symbolic-ref", "HEAD", "refs/heads/"+r.Branch); err != nil {
+			return err
+		}
+		return nil
+	}
+	if _, err := r.git("checkout", "--quiet", "-B", r.Branch, remote); err != nil {
+		return err
+	}
+	if _, err := r.git("reset", "--quiet", "--hard", remote); err != nil {
+		return err
+	}
+	_, err := r.git("clean", "--quiet", "-fd")
+	return err
+}
+
+// CommitAll commits every change in the clone, reporting whether there
+// was anything to commit
+func (r *Repo) CommitAll(author, message string) (bool, error) {
+	if _, err := r.git("add", "--all"); err != nil {
+		return false, err
+	}
+	status, err := r.git("status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(status)) == 0 {
+		return false, nil
+	}
+
+	// Commits are cflip's, not the user's, whatever their git identity
+	identity := []string{
+		"GIT_AUTHOR_NAME=" + author, "GIT_AUTHOR_EMAIL=cflip@localhost",
+		"GIT_COMMITTER_NAME=" + author, "GIT_COMMITTER_EMAIL=cflip@localhost",
+	}
+	_, err = runGit(r.Dir, identity, "commit", "--quiet", "--no-verify", "-m", message)
+	return err == nil, err
+}
+
+// Push publishes the local branch, failing with ErrPushRejected when the
+// remote moved on since Fetch
+func (r *Repo) Push() error {
+	_, err := r.git("push", "--quiet", "origin", "HEAD:refs/heads/"+r.Branch)
+	if err != nil && strings.Contains(err.Error(), "rejected") {
+		return ErrPushRejected
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push to %s: %w", r.URL, err)
+	}
+	return nil
+}
+
+// Path returns the absolute path of a file in the clone
+func (r *Repo) Path(name string) string {
+	return filepath.Join(r.Dir, filepath.FromSlash(name))
+}
+
+// ReadFile reads a file from the clone
+func (r *Repo) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(r.Path(name))
+}
+
+// WriteFile writes a file into the clone, creating its directory
+func (r *Repo) WriteFile(name string, data []byte) error {
+	path := r.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	return fsutil.WriteFileAtomic(path, data, 0o600)
+}
+
+// RemoveFile deletes a file from the clone; a missing file is not an error
+func (r *Repo) RemoveFile(name string) error {
+	if err := os.Remove(r.Path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// git runs a git command in the clone
+func (r *Repo) git(args ...string) ([]byte, error) {
+	return runGit(r.Dir, nil, args...)
+}
+
+// runGit runs git in dir (the current directory when empty) with env
+// added to the environment, never asking for credentials on a terminal
+func runGit(dir string, env []string, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("git not found in PATH: %w", err)
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w (output: %s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
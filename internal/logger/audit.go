@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuditEvent is one persisted audit record
+type AuditEvent struct {
+	Time   time.Time         `json:"time"`
+	Action string            `json:"action"`
+	Attrs  map[string]string `json:"attrs,omitempty"`
+}
+
+// auditLogPath is where audit events are appended (empty disables persistence)
+var auditLogPath string
+
+// maxAuditLogSize is the size at which the audit log is rotated to
+// audit.log.1, replacing the previous one, so it stays bounded on machines
+// that switch from cron or a status line
+const maxAuditLogSize = 4 << 20
+
+// SetAuditLogPath enables persisting audit events as JSON lines to path
+func SetAuditLogPath(path string) {
+	auditLogPath = path
+}
+
+// AuditLogPath returns the configured audit log location
+func AuditLogPath() string {
+	return auditLogPath
+}
+
+// appendAuditEvent writes an audit event to the audit log file, if configured
+func appendAuditEvent(action string, attrs []slog.Attr) error {
+	if auditLogPath == "" {
+		return nil
+	}
+
+	event := AuditEvent{
+		Time:   time.Now(),
+		Action: action,
+		Attrs:  make(map[string]string, len(attrs)),
+	}
+	for _, attr := range attrs {
+		event.Attrs[attr.Key] = MaskSecrets(attr.Value.String())
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(auditLogPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	if info, err := os.Stat(auditLogPath); err == nil && info.Size()+int64(len(data)) >= maxAuditLogSize {
+		if err := os.Rename(auditLogPath, rotatedAuditLogPath()); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(auditLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// rotatedAuditLogPath is where the previous audit log is kept
+func rotatedAuditLogPath() string {
+	return auditLogPath + ".1"
+}
+
+// ReadAuditLog loads all audit events from the configured audit log and the
+// one rotated before it, oldest first. Malformed lines are skipped. A
+// missing log yields no events.
+func ReadAuditLog() ([]AuditEvent, error) {
+	if auditLogPath == "" {
+		return nil, nil
+	}
+
+	events, err := readAuditFile(rotatedAuditLogPath())
+	if err != nil {
+		return nil, err
+	}
+	current, err := readAuditFile(auditLogPath)
+	if err != nil {
+		return nil, err
+	}
+	return append(events, current...), nil
+}
+
+// readAuditFile loads the events of one audit log file
+func readAuditFile(path string) ([]AuditEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue // Skip malformed lines
+		}
+		events = append(events, event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return events, nil
+}
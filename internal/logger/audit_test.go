@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	SetAuditLogPath(path)
+	t.Cleanup(func() { SetAuditLogPath("") })
+
+	big := strings.Repeat("x", 40<<10)
+	for i := 0; i < 200; i++ {
+		if err := appendAuditEvent("switch", []slog.Attr{slog.String("note", big)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > maxAuditLogSize {
+			t.Errorf("%s is %d bytes, over the %d byte limit", filepath.Base(p), info.Size(), maxAuditLogSize)
+		}
+	}
+
+	events, err := ReadAuditLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 200 {
+		t.Errorf("read %d events across the current and rotated logs, want 200", len(events))
+	}
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"os"
+	"strings"
+)
+
+// ANSI color codes for user-facing output
+var ansiColors = map[string]string{
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+}
+
+// Theme controls colored terminal output
+type Theme struct {
+	// Color is "auto" (default), "always", or "never"
+	Color string `json:"color,omitempty"`
+	// Accent colors informational and progress messages (default "cyan")
+	Accent string `json:"accent,omitempty"`
+}
+
+// active color state, resolved by SetTheme
+var (
+	colorEnabled = detectColor("auto")
+	accentCode   = ansiColors["cyan"]
+)
+
+// SetTheme applies a color theme, honoring NO_COLOR and CLICOLOR conventions
+func SetTheme(theme Theme) {
+	themeColor = theme.Color
+	colorEnabled = detectColor(theme.Color)
+	if code, ok := ansiColors[strings.ToLower(theme.Accent)]; ok {
+		accentCode = code
+	}
+}
+
+// ColorEnabled reports whether user-facing output is colorized
+func ColorEnabled() bool {
+	return colorEnabled
+}
+
+// detectColor decides whether to emit ANSI colors. NO_COLOR always wins
+// (https://no-color.org), then the theme mode, then CLICOLOR/CLICOLOR_FORCE,
+// then automation, and finally whether stdout is a terminal.
+func detectColor(mode string) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	switch strings.ToLower(mode) {
+	case "never", "off", "false":
+		return false
+	case "always", "on", "true":
+		return true
+	}
+
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return false
+	}
+	if automation {
+		return false
+	}
+
+	return isTerminal(os.Stdout)
+}
+
+// StdoutIsTerminal reports whether stdout is a terminal
+func StdoutIsTerminal() bool {
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether f is attached to a character device (a TTY)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in the given ANSI color code when colors are enabled
+func colorize(code, s string) string {
+	if !colorEnabled || code == "" {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
@@ -3,10 +3,12 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Logger wraps slog.Logger with additional convenience methods
@@ -87,12 +89,12 @@ func New(config *LogConfig) (*Logger, error) {
 	}
 
 	// Determine output destination
-	var output *os.File
+	var output io.Writer
 	switch config.Output {
 	case "stdout":
 		output = os.Stdout
 	case "stderr":
-		output = os.Stderr
+		output = diagnosticOutput
 	default:
 		// Assume it's a file path
 		if config.Output != "" {
@@ -108,7 +110,7 @@ func New(config *LogConfig) (*Logger, error) {
 			}
 			output = file
 		} else {
-			output = os.Stderr
+			output = diagnosticOutput
 		}
 	}
 
@@ -120,7 +122,7 @@ func New(config *LogConfig) (*Logger, error) {
 		handler = slog.NewTextHandler(output, opts)
 	}
 
-	logger := slog.New(handler)
+	logger := slog.New(redactHandler{handler})
 
 	return &Logger{
 		Logger: logger,
@@ -138,64 +140,71 @@ func NewDefault() *Logger {
 
 // Success prints a success message with green checkmark
 func (l *Logger) Success(msg string, args ...any) {
-	formatted := fmt.Sprintf("✅ "+msg, args...)
-	fmt.Println(formatted)
+	formatted := decorate("✅ ", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(userOutput, colorize(ansiColors["green"], formatted))
 	l.Info("Success: " + strings.TrimPrefix(formatted, "✅ "))
 }
 
 // Info prints an info message with blue info icon
 func (l *Logger) InfoMsg(msg string, args ...any) {
-	formatted := fmt.Sprintf("📋 "+msg, args...)
-	fmt.Println(formatted)
+	formatted := decorate("📋 ", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(userOutput, colorize(accentCode, formatted))
 	l.Info("Info: " + strings.TrimPrefix(formatted, "📋 "))
 }
 
 // Progress prints a progress message with spinner
 func (l *Logger) Progress(msg string, args ...any) {
-	formatted := fmt.Sprintf("🔄 "+msg, args...)
-	fmt.Println(formatted)
+	formatted := decorate("🔄 ", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(userOutput, colorize(accentCode, formatted))
 	l.Info("Progress: " + strings.TrimPrefix(formatted, "🔄 "))
 }
 
 // Warning prints a warning message with yellow warning icon
 func (l *Logger) Warning(msg string, args ...any) {
-	formatted := fmt.Sprintf("⚠️  "+msg, args...)
-	fmt.Println(formatted)
+	formatted := decorate("⚠️  ", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(userOutput, colorize(ansiColors["yellow"], formatted))
 	l.Warn("Warning: " + strings.TrimPrefix(formatted, "⚠️  "))
 }
 
+// Notice prints a warning to stderr so it never mixes with command output
+func (l *Logger) Notice(msg string, args ...any) {
+	formatted := decorate("⚠️  ", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(diagnosticOutput, colorize(ansiColors["yellow"], formatted))
+	l.Warn("Notice: " + strings.TrimPrefix(formatted, "⚠️  "))
+}
+
 // Error prints an error message with red X
 func (l *Logger) ErrorMsg(msg string, args ...any) {
-	formatted := fmt.Sprintf("❌ "+msg, args...)
-	fmt.Fprintln(os.Stderr, formatted)
+	formatted := decorate("❌ ", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(errorOutput, colorize(ansiColors["red"], formatted))
 	l.Error("Error: " + strings.TrimPrefix(formatted, "❌ "))
 }
 
 // Question prints a question/prompt message
 func (l *Logger) Question(msg string, args ...any) {
-	formatted := fmt.Sprintf("❓ "+msg, args...)
-	fmt.Print(formatted)
+	formatted := decorate("❓ ", fmt.Sprintf(msg, args...))
+	fmt.Fprint(userOutput, formatted)
 	l.Debug("Question: " + strings.TrimPrefix(formatted, "❓ "))
 }
 
 // Plain prints a message without icons (for normal output)
 func (l *Logger) Plain(msg string, args ...any) {
-	formatted := fmt.Sprintf(msg, args...)
-	fmt.Println(formatted)
+	formatted := decorate("", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(userOutput, formatted)
 	l.Debug("Plain: " + formatted)
 }
 
 // Bullet prints a bulleted list item
 func (l *Logger) Bullet(msg string, args ...any) {
-	formatted := fmt.Sprintf("  • "+msg, args...)
-	fmt.Println(formatted)
+	formatted := "  • " + decorate("", fmt.Sprintf(msg, args...))
+	fmt.Fprintln(userOutput, formatted)
 	l.Debug("Bullet: " + strings.TrimPrefix(formatted, "  • "))
 }
 
 // Header prints a header message
 func (l *Logger) Header(msg string, args ...any) {
-	formatted := fmt.Sprintf(msg, args...)
-	fmt.Printf("\n%s\n", formatted)
+	formatted := decorate("", fmt.Sprintf(msg, args...))
+	fmt.Fprintf(userOutput, "\n%s\n", colorize("1;"+accentCode, formatted))
 	l.Info("Header: " + formatted)
 }
 
@@ -251,6 +260,10 @@ func (l *Logger) Operation(name string, fn func() error) error {
 
 // Audit logs an audit event (always logged regardless of level)
 func (l *Logger) Audit(action string, attrs ...slog.Attr) {
+	if err := appendAuditEvent(action, attrs); err != nil {
+		l.Debug("Failed to persist audit event", "action", action, "error", err)
+	}
+
 	// Force audit logs to always be written
 	oldLevel := l.level
 	if l.level > LevelInfo {
@@ -286,9 +299,36 @@ func (l *Logger) AccountRemoved(email string) {
 	l.Audit("account_removed", slog.String("email", email))
 }
 
-// AccountSwitched logs when accounts are switched
-func (l *Logger) AccountSwitched(fromEmail, toEmail string) {
+// AccountSwitched logs when accounts are switched. toProfile is the
+// profile name, which tells apart organizations sharing an email.
+func (l *Logger) AccountSwitched(fromEmail, toEmail, toProfile string) {
 	l.Audit("account_switched",
+		slog.String("from_email", fromEmail),
+		slog.String("to_email", toEmail),
+		slog.String("to_profile", toProfile))
+}
+
+// TokenRefreshed logs when a stored account's OAuth tokens are refreshed
+func (l *Logger) TokenRefreshed(email string) {
+	l.Audit("token_refreshed", slog.String("email", email))
+}
+
+// CooldownSet logs when an account is recorded as hitting its usage limit
+func (l *Logger) CooldownSet(email string, resetsAt time.Time) {
+	l.Audit("cooldown_set",
+		slog.String("email", email),
+		slog.Time("resets_at", resetsAt))
+}
+
+// CooldownCleared logs when an account's recorded usage limit is cleared
+func (l *Logger) CooldownCleared(email string) {
+	l.Audit("cooldown_cleared", slog.String("email", email))
+}
+
+// RemoteAccountSwitched logs when accounts are switched on a remote host
+func (l *Logger) RemoteAccountSwitched(host, fromEmail, toEmail string) {
+	l.Audit("remote_account_switched",
+		slog.String("host", host),
 		slog.String("from_email", fromEmail),
 		slog.String("to_email", toEmail))
 }
@@ -301,6 +341,130 @@ func (l *Logger) AccountRenamed(email, oldAlias, newAlias string) {
 		slog.String("new_alias", newAlias))
 }
 
+// AccountMoved logs when an account is given a new number in the list
+func (l *Logger) AccountMoved(email string, position int) {
+	l.Audit("account_moved",
+		slog.String("email", email),
+		slog.Int("position", position))
+}
+
+// AccountGrouped logs when an account is added to a group
+func (l *Logger) AccountGrouped(email, group string) {
+	l.Audit("account_grouped",
+		slog.String("email", email),
+		slog.String("group", group))
+}
+
+// AccountUngrouped logs when an account is removed from a group
+func (l *Logger) AccountUngrouped(email, group string) {
+	l.Audit("account_ungrouped",
+		slog.String("email", email),
+		slog.String("group", group))
+}
+
+// AccountTagged logs when an account's tags change
+func (l *Logger) AccountTagged(email string, tags []string) {
+	l.Audit("account_tagged",
+		slog.String("email", email),
+		slog.String("tags", strings.Join(tags, ",")))
+}
+
+// SecretsRevealed logs when an account's tokens are printed unmasked
+func (l *Logger) SecretsRevealed(email string) {
+	l.Audit("secrets_revealed", slog.String("email", email))
+}
+
+// AccountsExported logs when accounts are sealed into a transfer archive
+func (l *Logger) AccountsExported(emails []string) {
+	l.Audit("accounts_exported", slog.String("emails", strings.Join(emails, ",")))
+}
+
+// AccountImported logs when an account is imported from a transfer archive
+func (l *Logger) AccountImported(email string, replaced bool) {
+	l.Audit("account_imported",
+		slog.String("email", email),
+		slog.Bool("replaced", replaced))
+}
+
+// ProfileSynced logs when live Claude Code state is synced into a profile
+func (l *Logger) ProfileSynced(email string, fields []string) {
+	l.Audit("profile_synced",
+		slog.String("email", email),
+		slog.String("fields", strings.Join(fields, ",")))
+}
+
+// GitSyncPushed logs when `cflip sync push` publishes profile changes
+func (l *Logger) GitSyncPushed(repo string, count int) {
+	l.Audit("git_sync_pushed",
+		slog.String("repo", repo),
+		slog.Int("count", count))
+}
+
+// GitSyncPulled logs when `cflip sync pull` applies profile changes
+func (l *Logger) GitSyncPulled(repo string, count int) {
+	l.Audit("git_sync_pulled",
+		slog.String("repo", repo),
+		slog.Int("count", count))
+}
+
+// VaultUnlocked logs when `cflip unlock` opens the credential vault
+func (l *Logger) VaultUnlocked(timeout time.Duration) {
+	l.Audit("vault_unlocked", slog.Duration("timeout", timeout))
+}
+
+// VaultLocked logs when `cflip lock` closes the credential vault
+func (l *Logger) VaultLocked() {
+	l.Audit("vault_locked")
+}
+
+// VaultPassphraseChanged logs when `cflip passwd` sets, rotates or removes
+// the vault passphrase
+func (l *Logger) VaultPassphraseChanged(enabled bool, files int) {
+	l.Audit("vault_passphrase_changed",
+		slog.Bool("enabled", enabled),
+		slog.Int("files", files))
+}
+
+// VaultHardwareKeyChanged logs when `cflip passwd` starts or stops
+// wrapping the vault key with a hardware key
+func (l *Logger) VaultHardwareKeyChanged(recipient string, files int) {
+	l.Audit("vault_hardware_key_changed",
+		slog.String("recipient", recipient),
+		slog.Int("files", files))
+}
+
+// BackupCreated logs when `cflip backup` writes a backup
+func (l *Logger) BackupCreated(path string, encrypted bool) {
+	l.Audit("backup_created",
+		slog.String("path", path),
+		slog.Bool("encrypted", encrypted))
+}
+
+// BackupRestored logs when a backup is restored, with the backup taken of
+// the state it replaced
+func (l *Logger) BackupRestored(path, previous string) {
+	l.Audit("backup_restored",
+		slog.String("path", path),
+		slog.String("previous", previous))
+}
+
+// StorageMigrated logs when `cflip storage migrate` moves profile credentials
+func (l *Logger) StorageMigrated(from, to string, count int) {
+	l.Audit("storage_migrated",
+		slog.String("from", from),
+		slog.String("to", to),
+		slog.Int("count", count))
+}
+
+// ClaudeSession logs a claude CLI run made through the cflip wrapper
+func (l *Logger) ClaudeSession(email string, duration time.Duration, exitCode int, rateLimited bool) {
+	l.Audit("claude_session",
+		slog.String("email", email),
+		slog.String("duration", duration.Round(time.Second).String()),
+		slog.Int("exit_code", exitCode),
+		slog.Bool("rate_limited", rateLimited))
+}
+
 // Helper function to convert slog.Attr to []any
 func attrsToAny(attrs []slog.Attr) []any {
 	args := make([]any, 0, len(attrs)*2)
@@ -339,6 +503,11 @@ func Warning(msg string, args ...any) {
 	defaultLogger.Warning(msg, args...)
 }
 
+// Notice prints a stderr warning using the default logger
+func Notice(msg string, args ...any) {
+	defaultLogger.Notice(msg, args...)
+}
+
 // ErrorMsg logs an error message using the default logger
 func ErrorMsg(msg string, args ...any) {
 	defaultLogger.ErrorMsg(msg, args...)
@@ -349,6 +518,11 @@ func Question(msg string, args ...any) {
 	defaultLogger.Question(msg, args...)
 }
 
+// Header prints a header using the default logger
+func Header(msg string, args ...any) {
+	defaultLogger.Header(msg, args...)
+}
+
 // Plain logs a plain message using the default logger
 func Plain(msg string, args ...any) {
 	defaultLogger.Plain(msg, args...)
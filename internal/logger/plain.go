@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// automation is set for CI and other non-interactive runs, where icons and
+// color only add noise to captured logs. structured is set when stdout
+// carries machine-readable output.
+var (
+	automation   bool
+	structured   bool
+	emojiEnabled           = true
+	themeColor             = "auto"
+	userOutput   io.Writer = os.Stdout
+	errorOutput  io.Writer = os.Stderr
+	// diagnosticOutput receives log records and notices
+	diagnosticOutput io.Writer = os.Stderr
+)
+
+// SetAutomation adapts user-facing output for unattended runs: emoji are
+// dropped and color is off unless forced with the theme or CLICOLOR_FORCE
+func SetAutomation(enabled bool) {
+	automation = enabled
+	emojiEnabled = !automation && !structured
+	colorEnabled = detectColor(themeColor)
+}
+
+// SetStructuredOutput reserves stdout for machine-readable output such as
+// JSON: user-facing messages move to stderr, without icons
+func SetStructuredOutput(enabled bool) {
+	structured = enabled
+	emojiEnabled = !automation && !structured
+	userOutput = os.Stdout
+	if enabled {
+		userOutput = os.Stderr
+	}
+}
+
+// SetEmbedded adapts output for cflip embedded as a library: user-facing
+// messages are dropped, and log records and notices go to w (nil discards
+// them). Audit events are still persisted to the audit log.
+func SetEmbedded(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	userOutput = io.Discard
+	errorOutput = io.Discard
+	diagnosticOutput = w
+	emojiEnabled = false
+	colorEnabled = false
+	defaultLogger = NewDefault()
+}
+
+// decorate prefixes msg with its icon, or strips emoji from it in automation
+func decorate(icon, msg string) string {
+	if emojiEnabled {
+		return icon + msg
+	}
+	return stripEmoji(msg)
+}
+
+// stripEmoji removes emoji and the spacing that followed them
+func stripEmoji(s string) string {
+	var b strings.Builder
+	skipSpace := false
+	for _, r := range s {
+		if isEmoji(r) {
+			skipSpace = true
+			continue
+		}
+		if skipSpace && r == ' ' {
+			continue
+		}
+		skipSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isEmoji reports whether r is a pictograph. Text symbols such as arrows,
+// bullets and check marks are kept since they carry meaning.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport
+		return true
+	case r >= 0x2600 && r <= 0x26FF: // miscellaneous symbols (⚠ ⚡)
+		return true
+	case r == 0x2705 || r == 0x274C || r == 0x2753 || r == 0x2757 || r == 0x2728:
+		return true
+	case r == 0xFE0F || r == 0x200D: // variation selector, zero-width joiner
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+)
+
+// secretPattern matches Anthropic OAuth and API tokens
+var secretPattern = regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]+`)
+
+// MaskSecret hides a credential, keeping its first 8 and last 4 characters
+// so different tokens can still be told apart
+func MaskSecret(value string) string {
+	switch {
+	case value == "":
+		return ""
+	case len(value) < 20:
+		return "****"
+	}
+	return value[:8] + "…" + value[len(value)-4:]
+}
+
+// MaskSecrets masks every token found inside s
+func MaskSecrets(s string) string {
+	return secretPattern.ReplaceAllStringFunc(s, MaskSecret)
+}
+
+// redactHandler masks tokens in log records before they are written, so a
+// message that quotes a credential never reaches a log file
+type redactHandler struct {
+	slog.Handler
+}
+
+// Handle masks the record's message and attributes
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	masked := slog.NewRecord(r.Time, r.Level, MaskSecrets(r.Message), r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		masked.AddAttrs(maskAttr(attr))
+		return true
+	})
+	return h.Handler.Handle(ctx, masked)
+}
+
+// WithAttrs masks attributes added to every record
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	for i, attr := range attrs {
+		attrs[i] = maskAttr(attr)
+	}
+	return redactHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps masking records in the group
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
+
+// maskAttr masks tokens in an attribute's value, including errors and
+// other values rendered as text
+func maskAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		masked := make([]any, len(group))
+		for i, child := range group {
+			masked[i] = maskAttr(child)
+		}
+		return slog.Group(attr.Key, masked...)
+	case slog.KindString, slog.KindAny:
+		if s := value.String(); secretPattern.MatchString(s) {
+			return slog.String(attr.Key, MaskSecrets(s))
+		}
+	}
+	return attr
+}
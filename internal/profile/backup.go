@@ -0,0 +1,687 @@
+package profile
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+	"github.com/phathdt/claude-flip/internal/storage"
+	"github.com/phathdt/claude-flip/internal/transfer"
+)
+
+// BackupDirName is the data directory subfolder holding `cflip backup`
+// archives. It is never included in a backup or replaced by a restore.
+const BackupDirName = "backups"
+
+// BackupFormat labels passphrase-encrypted backups
+const BackupFormat = "cflip-backup"
+
+// DefaultBackupKeep is how many backups are kept when settings.backup.keep
+// is unset
+const DefaultBackupKeep = 10
+
+// backupLabel binds backups sealed for this machine to their use
+const backupLabel = "backup"
+
+func init() {
+	storage.RegisterSealedItems(backupSealedItems)
+}
+
+// Backup file names are cflip-backup-<UTC timestamp> plus one of these
+const (
+	backupPrefix       = "cflip-backup-"
+	backupExt          = ".tar.gz"
+	encryptedBackupExt = ".tar.gz.enc"
+	backupTimeFormat   = "20060102T150405.000000000Z"
+)
+
+// Sections of a backup archive
+const (
+	backupManifestName = "manifest.json"
+	backupDataDir      = "data"
+	backupConfigDir    = "config"
+	backupSecretsDir   = "secrets"
+	backupClaudeConfig = "claude/claude.json"
+	backupCredentials  = "claude/credentials.json"
+)
+
+// auditLogName is the audit log in the data directory. It is backed up
+// but never rolled back by a restore.
+const auditLogName = "audit.log"
+
+// BackupSettings controls `cflip backup` retention
+type BackupSettings struct {
+	// Keep is how many backups are kept, oldest deleted first (default 10)
+	Keep int `json:"keep,omitempty"`
+}
+
+// KeepCount returns the configured number of backups to keep
+func (b BackupSettings) KeepCount() int {
+	if b.Keep <= 0 {
+		return DefaultBackupKeep
+	}
+	return b.Keep
+}
+
+// BackupManifest describes what a backup holds
+type BackupManifest struct {
+	CreatedAt     time.Time `json:"created_at"`
+	Version       string    `json:"cflip_version"`
+	Host          string    `json:"host,omitempty"`
+	ActiveProfile string    `json:"active_profile,omitempty"`
+	Accounts      []string  `json:"accounts"`
+	// Secrets counts profile credentials copied out of secure storage
+	Secrets int `json:"secrets"`
+}
+
+// Backup is one archive in the backup directory
+type Backup struct {
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+	Encrypted bool      `json:"encrypted"`
+	// Manifest is nil for encrypted backups, which need the passphrase
+	Manifest *BackupManifest `json:"manifest,omitempty"`
+}
+
+// BackupDir returns the directory backups are written to
+func BackupDir() (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, BackupDirName), nil
+}
+
+// CreateBackup archives cflip's data and config directories, Claude Code's
+// ~/.claude.json and live credentials, and the profile credentials kept in
+// secure storage. With a passphrase the archive is encrypted with it, and
+// otherwise with the key of the credential files. Backups beyond
+// keep (settings.backup.keep when keep <= 0) are deleted, oldest first.
+func (s *Switcher) CreateBackup(passphrase string, keep int) (*Backup, error) {
+	unlock, err := s.profileManager.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if keep <= 0 {
+		settings, err := s.profileManager.LoadSettings()
+		if err != nil {
+			return nil, err
+		}
+		keep = settings.Backup.KeepCount()
+	}
+
+	data, manifest, err := s.profileManager.packBackup()
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{CreatedAt: manifest.CreatedAt, Encrypted: passphrase != ""}
+	name := backupPrefix + manifest.CreatedAt.UTC().Format(backupTimeFormat) + backupExt
+	if backup.Encrypted {
+		if data, err = transfer.SealData(BackupFormat, data, passphrase); err != nil {
+			return nil, err
+		}
+		name = strings.TrimSuffix(name, backupExt) + encryptedBackupExt
+	} else {
+		if data, err = storage.SealLocal(backupLabel, data); err != nil {
+			return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		backup.Manifest = manifest
+	}
+
+	dir := filepath.Join(s.profileManager.profilesDir, BackupDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	backup.Path = filepath.Join(dir, name)
+	if err := fsutil.WriteFileAtomic(backup.Path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	backup.Size = int64(len(data))
+
+	pruneBackups(dir, keep)
+	return backup, nil
+}
+
+// packBackup builds the gzipped tar of everything a backup holds
+func (pm *ProfileManager) packBackup() ([]byte, *BackupManifest, error) {
+	cfg, err := pm.LoadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest := &BackupManifest{
+		CreatedAt:     time.Now(),
+		Version:       writerVersion,
+		ActiveProfile: cfg.ActiveProfile,
+		Accounts:      []string{},
+	}
+	manifest.Host, _ = os.Hostname()
+	for name := range cfg.Profiles {
+		manifest.Accounts = append(manifest.Accounts, name)
+	}
+	sort.Strings(manifest.Accounts)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	files := make(map[string][]byte)
+	if err := collectBackupDir(pm.profilesDir, backupDataDir, files); err != nil {
+		return nil, nil, err
+	}
+	// On macOS config.json lives in the data directory and is already in
+	configDir := filepath.Dir(pm.configPath)
+	if configDir != pm.profilesDir {
+		if err := collectBackupDir(configDir, backupConfigDir, files); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if configPath, err := claudeConfigPath(); err != nil {
+		return nil, nil, err
+	} else if data, err := os.ReadFile(configPath); err == nil {
+		files[backupClaudeConfig] = data
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	raw, err := storage.NewSecureStorage().Capture()
+	switch {
+	case err == nil:
+		files[backupCredentials] = []byte(raw)
+	case !errors.Is(err, storage.ErrNotFound):
+		return nil, nil, fmt.Errorf("failed to read live credentials: %w", err)
+	}
+
+	secrets, err := collectProfileSecrets(pm.profilesDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, value := range secrets {
+		files[path.Join(backupSecretsDir, key+".json")] = []byte(value)
+	}
+	manifest.Secrets = len(secrets)
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	// The manifest goes first so listing reads no further
+	if err := writeTarFile(tw, backupManifestName, encoded); err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeTarFile(tw, name, files[name]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), manifest, nil
+}
+
+// collectBackupDir reads every file under dir into files, keyed by its
+// path below prefix. The backup directory and lock files are skipped.
+func collectBackupDir(dir, prefix string, files map[string][]byte) error {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel == BackupDirName || rel == GitSyncDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".lock") {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[path.Join(prefix, filepath.ToSlash(rel))] = data
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return nil
+}
+
+// collectProfileSecrets reads the secure storage items of every profile
+// file under dir, including archived and trashed copies
+func collectProfileSecrets(dir string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	store := profileSecrets()
+	if store == nil {
+		return secrets, nil
+	}
+
+	keys, err := secureProfileKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		value, err := store.Retrieve(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials of %s: %w", key, err)
+		}
+		secrets[key] = value
+	}
+	return secrets, nil
+}
+
+// secureProfileKeys lists the secure storage keys of every profile file
+// under dir that keeps its credentials there, including archived and
+// trashed copies
+func secureProfileKeys(dir string) ([]string, error) {
+	seen := make(map[string]bool)
+	var keys []string
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".profile" {
+			return err
+		}
+		key := credentialKey(p)
+		if seen[key] {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+		plain, err := decodeProfileData(p, data)
+		if err != nil {
+			return nil // Files sops cannot decrypt here keep their tokens inline
+		}
+		var profile Profile
+		if json.Unmarshal(plain, &profile) != nil || profile.CredentialStore != CredentialStoreSecure {
+			return nil
+		}
+
+		seen[key] = true
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// writeTarFile adds one private file to a backup archive
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s to backup: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to backup: %w", name, err)
+	}
+	return nil
+}
+
+// ListBackups returns the backups in the backup directory, newest first
+func ListBackups() ([]*Backup, error) {
+	dir, err := BackupDir()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := backupFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]*Backup, 0, len(names))
+	for i := len(names) - 1; i >= 0; i-- {
+		backup, err := readBackupInfo(filepath.Join(dir, names[i]))
+		if err != nil {
+			continue // Skip damaged backups
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+// readBackupInfo describes a backup file, reading the manifest of those
+// without a passphrase. It is left out while the vault is locked.
+func readBackupInfo(path string) (*Backup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{Path: path, Size: int64(len(data))}
+	if stamp, ok := backupTimestamp(filepath.Base(path)); ok {
+		backup.CreatedAt = stamp
+	}
+	if transfer.IsSealed(BackupFormat, data) {
+		backup.Encrypted = true
+		return backup, nil
+	}
+	data, err = storage.OpenLocal(backupLabel, data)
+	if errors.Is(err, storage.ErrLocked) {
+		return backup, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := unpackBackup(data, true)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Manifest, err = parseBackupManifest(files); err != nil {
+		return nil, err
+	}
+	backup.CreatedAt = backup.Manifest.CreatedAt
+	return backup, nil
+}
+
+// backupFiles lists backup file names oldest first; their timestamped
+// names sort chronologically
+func backupFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if _, ok := backupTimestamp(entry.Name()); ok && !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// backupTimestamp parses the creation time out of a backup file name
+func backupTimestamp(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, backupPrefix) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimPrefix(name, backupPrefix)
+	switch {
+	case strings.HasSuffix(stamp, encryptedBackupExt):
+		stamp = strings.TrimSuffix(stamp, encryptedBackupExt)
+	case strings.HasSuffix(stamp, backupExt):
+		stamp = strings.TrimSuffix(stamp, backupExt)
+	default:
+		return time.Time{}, false
+	}
+	created, err := time.Parse(backupTimeFormat, stamp)
+	return created, err == nil
+}
+
+// pruneBackups deletes the oldest backups beyond keep
+func pruneBackups(dir string, keep int) {
+	names, err := backupFiles(dir)
+	if err != nil {
+		return
+	}
+	for len(names) > keep {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+}
+
+// OpenBackup reads a backup, decrypting it with passphrase when it is
+// encrypted, and returns its manifest and files
+func OpenBackup(path, passphrase string) (*BackupManifest, map[string][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if transfer.IsSealed(BackupFormat, data) {
+		if passphrase == "" {
+			return nil, nil, fmt.Errorf("%s is encrypted; a passphrase is needed", filepath.Base(path))
+		}
+		if data, err = transfer.OpenData(BackupFormat, data, passphrase); err != nil {
+			return nil, nil, err
+		}
+	} else if data, err = storage.OpenLocal(backupLabel, data); err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt %s: %w", filepath.Base(path), err)
+	}
+
+	files, err := unpackBackup(data, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := parseBackupManifest(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, files, nil
+}
+
+// backupSealedItems lists the backups without a passphrase for
+// re-encryption, including plaintext ones from older versions
+func backupSealedItems() ([]storage.SealedItem, error) {
+	dir, err := BackupDir()
+	if err != nil {
+		return nil, err
+	}
+	names, err := backupFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []storage.SealedItem
+	for _, name := range names {
+		backupPath := filepath.Join(dir, name)
+		data, err := os.ReadFile(backupPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		if transfer.IsSealed(BackupFormat, data) {
+			continue
+		}
+		items = append(items, storage.SealedItem{
+			Label: backupLabel,
+			Data:  data,
+			Replace: func(sealed []byte) error {
+				if err := fsutil.WriteFileAtomic(backupPath, sealed, 0o600); err != nil {
+					return fmt.Errorf("failed to write backup: %w", err)
+				}
+				return nil
+			},
+		})
+	}
+	return items, nil
+}
+
+// IsEncryptedBackup reports whether the backup at path needs a passphrase
+func IsEncryptedBackup(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read backup: %w", err)
+	}
+	return transfer.IsSealed(BackupFormat, data), nil
+}
+
+// unpackBackup reads the files of a gzipped tar backup, stopping after the
+// manifest when manifestOnly is set. Names that would escape the restore
+// directories are rejected.
+func unpackBackup(data []byte, manifestOnly bool) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a cflip backup: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupted backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("backup contains an unsafe path: %s", header.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted backup: %w", err)
+		}
+		files[name] = content
+
+		if manifestOnly && name == backupManifestName {
+			break
+		}
+	}
+	return files, nil
+}
+
+// parseBackupManifest decodes a backup's manifest.json
+func parseBackupManifest(files map[string][]byte) (*BackupManifest, error) {
+	data, ok := files[backupManifestName]
+	if !ok {
+		return nil, fmt.Errorf("not a cflip backup: no %s", backupManifestName)
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// RestoreBackup replaces cflip's state, Claude Code's config and live
+// credentials, and the stored profile credentials with the contents of a
+// backup. The current state is backed up first (encrypted with the same
+// passphrase), so a restore can itself be undone.
+func (s *Switcher) RestoreBackup(backupPath, passphrase string) (*BackupManifest, *Backup, error) {
+	// The safety backup below runs under the lock taken here
+	s, unlock, err := s.locked()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	// Read the backup before the safety backup, which may prune it
+	manifest, files, err := OpenBackup(backupPath, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	safety, err := s.CreateBackup(passphrase, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to back up the current state: %w", err)
+	}
+
+	dataDir := s.profileManager.profilesDir
+	if err := clearDataDir(dataDir); err != nil {
+		return nil, nil, err
+	}
+
+	configDir := filepath.Dir(s.profileManager.configPath)
+	store := profileSecrets()
+	for name, content := range files {
+		var target string
+		switch {
+		case name == path.Join(backupDataDir, auditLogName):
+			continue
+		case strings.HasPrefix(name, backupDataDir+"/"):
+			target = filepath.Join(dataDir, filepath.FromSlash(strings.TrimPrefix(name, backupDataDir+"/")))
+		case strings.HasPrefix(name, backupConfigDir+"/"):
+			target = filepath.Join(configDir, filepath.FromSlash(strings.TrimPrefix(name, backupConfigDir+"/")))
+		case strings.HasPrefix(name, backupSecretsDir+"/"):
+			if store == nil {
+				continue
+			}
+			key := strings.TrimSuffix(strings.TrimPrefix(name, backupSecretsDir+"/"), ".json")
+			if err := store.Store(key, string(content)); err != nil {
+				return nil, nil, fmt.Errorf("failed to restore credentials of %s: %w", key, err)
+			}
+			continue
+		case name == backupClaudeConfig:
+			if target, err = claudeConfigPath(); err != nil {
+				return nil, nil, err
+			}
+		case name == backupCredentials:
+			if err := writeLiveCredentials(content); err != nil {
+				return nil, nil, fmt.Errorf("failed to restore credentials: %w", err)
+			}
+			continue
+		default:
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return nil, nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+		if err := fsutil.WriteFileAtomic(target, content, 0o600); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore %s: %w", target, err)
+		}
+	}
+
+	return manifest, safety, nil
+}
+
+// clearDataDir removes everything in the data directory but backups, the
+// sync clone, the audit log and lock files, so a restore leaves no profiles
+// the backup did not have
+func clearDataDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		// A running daemon's socket stays too
+		if entry.Name() == BackupDirName || entry.Name() == GitSyncDirName || entry.Name() == auditLogName || strings.HasSuffix(entry.Name(), ".lock") || entry.Type()&fs.ModeSocket != 0 {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", dir, err)
+		}
+	}
+	return nil
+}
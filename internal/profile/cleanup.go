@@ -0,0 +1,32 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+)
+
+// removeArtifacts deletes everything cflip keeps about an account outside
+// its profile file, so a permanent removal leaves no stray copies of its
+// tokens behind. Every backend that stores per-account secrets must be
+// cleaned up here. Artifacts of the same email in other organizations
+// belong to other profiles and are kept.
+func removeArtifacts(profile *Profile) error {
+	if profile.Email == "" {
+		return nil
+	}
+
+	points, err := ListRestorePoints()
+	if err != nil {
+		return err
+	}
+	for _, point := range points {
+		if !point.belongsTo(profile) {
+			continue
+		}
+		if err := os.Remove(point.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove restore point %s: %w", point.Path, err)
+		}
+	}
+
+	return nil
+}
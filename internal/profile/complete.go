@@ -0,0 +1,81 @@
+package profile
+
+import (
+	"sort"
+	"strings"
+)
+
+// setProfileAlias records a profile's alias in the config index
+func setProfileAlias(config *Config, name, alias string) {
+	if config.ProfileAliases == nil {
+		config.ProfileAliases = make(map[string]string)
+	}
+	if alias == "" {
+		delete(config.ProfileAliases, name)
+		return
+	}
+	config.ProfileAliases[name] = alias
+}
+
+// CompleteAccounts returns the aliases and emails starting with prefix
+// (case-insensitive), sorted. It reads only the config.json index, so it
+// stays fast with many or encrypted profiles. An email stored for several
+// organizations is ambiguous, so those profiles are offered by name.
+func (pm *ProfileManager) CompleteAccounts(prefix string) ([]string, error) {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.ProfileAliases == nil {
+		// Configs written before the alias index existed: build it once
+		if config, err = pm.indexAliases(config); err != nil {
+			return nil, err
+		}
+	}
+
+	emailCount := make(map[string]int)
+	for _, email := range config.Profiles {
+		emailCount[email]++
+	}
+
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(candidate string) {
+		if candidate == "" || seen[candidate] {
+			return
+		}
+		if !strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) {
+			return
+		}
+		seen[candidate] = true
+		candidates = append(candidates, candidate)
+	}
+
+	for name, email := range config.Profiles {
+		add(config.ProfileAliases[name])
+		if emailCount[email] > 1 {
+			add(name)
+		} else {
+			add(email)
+		}
+	}
+
+	sort.Strings(candidates)
+	return candidates, nil
+}
+
+// indexAliases fills the alias index from the profile files
+func (pm *ProfileManager) indexAliases(config *Config) (*Config, error) {
+	profiles, err := pm.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	config.ProfileAliases = make(map[string]string)
+	for _, profile := range profiles {
+		if _, ok := config.Profiles[profile.Name]; ok {
+			setProfileAlias(config, profile.Name, profile.Alias)
+		}
+	}
+	return config, pm.SaveConfig(config)
+}
@@ -0,0 +1,163 @@
+package profile
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultCooldown is how long an account is skipped after hitting its usage
+// limit when the reset time is unknown: Claude subscriptions meter usage
+// over rolling 5-hour windows
+const DefaultCooldown = 5 * time.Hour
+
+// Cooldown records that an account hit its usage limit
+type Cooldown struct {
+	LimitedAt time.Time `json:"limited_at"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// Active reports whether the account is still limited at now
+func (c Cooldown) Active(now time.Time) bool {
+	return now.Before(c.ResetsAt)
+}
+
+// Cooldowns returns the accounts still cooling down after a usage limit,
+// keyed by profile name
+func (pm *ProfileManager) Cooldowns() (map[string]Cooldown, error) {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make(map[string]Cooldown)
+	for name, cooldown := range config.Cooldowns {
+		if cooldown.Active(now) {
+			active[name] = cooldown
+		}
+	}
+	return active, nil
+}
+
+// SetCooldown records that the profile hit its usage limit at limitedAt and
+// is skipped by `switch --available` until resetsAt (DefaultCooldown after
+// limitedAt when zero)
+func (s *Switcher) SetCooldown(identifier string, limitedAt, resetsAt time.Time) (*Profile, *Cooldown, error) {
+	if resetsAt.IsZero() {
+		resetsAt = limitedAt.Add(DefaultCooldown)
+	}
+	if !resetsAt.After(limitedAt) {
+		return nil, nil, fmt.Errorf("the limit must reset after %s", limitedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, nil, err
+	}
+	cooldown := Cooldown{LimitedAt: limitedAt.UTC(), ResetsAt: resetsAt.UTC()}
+	err = s.updateCooldowns(func(cooldowns map[string]Cooldown) {
+		cooldowns[profile.Name] = cooldown
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return profile, &cooldown, nil
+}
+
+// ClearCooldown forgets a profile's usage limit, reporting whether one was
+// recorded
+func (s *Switcher) ClearCooldown(identifier string) (*Profile, bool, error) {
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	var cleared bool
+	err = s.updateCooldowns(func(cooldowns map[string]Cooldown) {
+		_, cleared = cooldowns[profile.Name]
+		delete(cooldowns, profile.Name)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return profile, cleared, nil
+}
+
+// updateCooldowns changes the recorded cooldowns under the lock, dropping
+// those that have already reset
+func (s *Switcher) updateCooldowns(update func(map[string]Cooldown)) error {
+	pm := s.profileManager
+	unlock, err := pm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if config.Cooldowns == nil {
+		config.Cooldowns = make(map[string]Cooldown)
+	}
+	update(config.Cooldowns)
+
+	now := time.Now()
+	for name, cooldown := range config.Cooldowns {
+		if !cooldown.Active(now) {
+			delete(config.Cooldowns, name)
+		}
+	}
+	return pm.SaveConfig(config)
+}
+
+// NextAvailableProfile returns the first profile after the active one, in
+// list order and wrapping around, that is not cooling down after a usage
+// limit. The active profile itself is never returned.
+func (s *Switcher) NextAvailableProfile() (*Profile, error) {
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	cooldowns, err := s.profileManager.Cooldowns()
+	if err != nil {
+		return nil, err
+	}
+
+	currentIndex := -1
+	if activeProfile, err := s.profileManager.GetActiveProfile(); err == nil {
+		for i, profile := range profiles {
+			if profile.Name == activeProfile.Name {
+				currentIndex = i
+				break
+			}
+		}
+	}
+
+	var soonest time.Time
+	for i := 1; i <= len(profiles); i++ {
+		index := (currentIndex + i) % len(profiles)
+		if index == currentIndex {
+			break
+		}
+		profile := profiles[index]
+		if cooldown, ok := cooldowns[profile.Name]; ok {
+			if soonest.IsZero() || cooldown.ResetsAt.Before(soonest) {
+				soonest = cooldown.ResetsAt
+			}
+			continue
+		}
+		return profile, nil
+	}
+
+	if soonest.IsZero() {
+		return nil, fmt.Errorf("no other account to switch to")
+	}
+	return nil, fmt.Errorf("every other account is cooling down after a usage limit; the first resets at %s",
+		soonest.Local().Format("2006-01-02 15:04"))
+}
+
+// Cooldowns returns the accounts still cooling down after a usage limit,
+// keyed by profile name
+func (s *Switcher) Cooldowns() (map[string]Cooldown, error) {
+	return s.profileManager.Cooldowns()
+}
@@ -0,0 +1,55 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// CopySettings copies non-credential config sections from one stored
+// profile to another. The active account is read from and written to
+// Claude Code's live config, so the copy survives the next switch.
+func (s *Switcher) CopySettings(from, to string, sections []string) error {
+	source, err := s.profileManager.LoadProfile(from)
+	if err != nil {
+		return fmt.Errorf("failed to load source profile: %w", err)
+	}
+	target, err := s.profileManager.LoadProfile(to)
+	if err != nil {
+		return fmt.Errorf("failed to load target profile: %w", err)
+	}
+	if source.Email == target.Email {
+		return fmt.Errorf("source and target are the same account")
+	}
+	if source.ClaudeConfig == nil || target.ClaudeConfig == nil {
+		return fmt.Errorf("both profiles need a Claude configuration snapshot")
+	}
+
+	sourceConfig := source.ClaudeConfig
+	if s.isActiveProfile(source) {
+		if live, err := config.LoadClaudeConfig(); err == nil && live.GetUserEmail() == source.Email {
+			sourceConfig = live
+		}
+	}
+
+	if err := config.CopySections(*sourceConfig, *target.ClaudeConfig, sections); err != nil {
+		return err
+	}
+
+	if s.isActiveProfile(target) {
+		live, err := config.LoadClaudeConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load Claude Code configuration: %w", err)
+		}
+		if live.GetUserEmail() == target.Email {
+			if err := config.CopySections(*sourceConfig, *live, sections); err != nil {
+				return err
+			}
+			if err := config.SaveClaudeConfig(live); err != nil {
+				return fmt.Errorf("failed to update Claude Code configuration: %w", err)
+			}
+		}
+	}
+
+	return s.profileManager.SaveProfile(target)
+}
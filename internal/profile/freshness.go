@@ -0,0 +1,113 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/auth"
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// SwitchCheck is the outcome of verifying the credentials a switch wrote
+type SwitchCheck struct {
+	Captured  bool `json:"captured"`  // Claude Code had already rotated the tokens; the new ones were stored
+	Pinged    bool `json:"pinged"`    // the access token was checked against the API
+	Refreshed bool `json:"refreshed"` // the API rejected the access token and it was refreshed
+}
+
+// VerifySwitch reads back the credentials just applied for profile. When
+// Claude Code has already replaced them with refreshed tokens, those are
+// captured into the stored profile so it stays current. With ping, the
+// live access token is also checked against the API and refreshed once if
+// it is rejected.
+func (s *Switcher) VerifySwitch(profile *Profile, ping bool) (*SwitchCheck, error) {
+	check := &SwitchCheck{}
+
+	live, err := s.loadCredentials()
+	if err != nil {
+		return check, fmt.Errorf("credentials were not written: %w", err)
+	}
+
+	local := profile.Source == ""
+	if local && tokensRotated(profile.Credentials, live) && s.liveIsAccount(profile) {
+		profile.Credentials = live
+		if err := s.profileManager.SaveProfile(profile); err != nil {
+			return check, fmt.Errorf("failed to store refreshed tokens: %w", err)
+		}
+		check.Captured = true
+	}
+
+	if !ping {
+		return check, nil
+	}
+
+	check.Pinged = true
+	account, err := auth.FetchProfile(live.ClaudeAiOauth.AccessToken)
+	switch {
+	case errors.Is(err, auth.ErrTokenRejected) && local:
+		if _, err := s.RefreshProfile(profile.Name); err != nil {
+			return check, fmt.Errorf("access token was rejected and could not be refreshed: %w", err)
+		}
+		check.Refreshed = true
+		return check, nil
+	case err != nil:
+		return check, err
+	}
+
+	if profile.AccountUuid != "" && account.Account.UUID != "" && account.Account.UUID != profile.AccountUuid {
+		return check, fmt.Errorf("credentials belong to %s, not %s", account.Account.Email, profile.Email)
+	}
+	return check, nil
+}
+
+// tokensRotated reports whether the live tokens differ from the stored ones
+// and are at least as fresh
+func tokensRotated(stored, live *config.Credentials) bool {
+	if stored == nil || live == nil || live.ClaudeAiOauth.AccessToken == "" {
+		return false
+	}
+	if live.ClaudeAiOauth.AccessToken == stored.ClaudeAiOauth.AccessToken &&
+		live.ClaudeAiOauth.RefreshToken == stored.ClaudeAiOauth.RefreshToken {
+		return false
+	}
+	return live.ClaudeAiOauth.ExpiresAt >= stored.ClaudeAiOauth.ExpiresAt
+}
+
+// liveIsAccount reports whether the live Claude config is signed in as
+// profile's account, so live credentials can be attributed to it
+func (s *Switcher) liveIsAccount(profile *Profile) bool {
+	live, err := config.LoadClaudeConfig()
+	if err != nil {
+		return false
+	}
+	if uuid := live.GetAccountUuid(); uuid != "" && profile.AccountUuid != "" {
+		return uuid == profile.AccountUuid
+	}
+	return live.GetUserEmail() == profile.Email
+}
+
+// CaptureLiveTokens stores the live credentials in the profile of the
+// account Claude Code is logged in as, matched by account UUID rather than
+// the active pointer, when they are fresher than the stored copy. It
+// returns the updated profile, or nil when nothing changed.
+func (s *Switcher) CaptureLiveTokens() (*Profile, error) {
+	live, err := config.LoadClaudeConfig()
+	if err != nil {
+		return nil, err
+	}
+	credentials, ok := live.GetCredentials()
+	if !ok {
+		return nil, nil
+	}
+
+	profile := s.liveLocalProfile(live)
+	if profile == nil || !tokensRotated(profile.Credentials, credentials) {
+		return nil, nil
+	}
+
+	profile.Credentials = credentials
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to store refreshed tokens for %s: %w", profile.Name, err)
+	}
+	return profile, nil
+}
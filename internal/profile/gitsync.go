@@ -0,0 +1,43 @@
+package profile
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// GitSyncDirName is the clone of the sync repository in the data
+// directory. It is neither backed up nor cleared by a restore: the
+// repository itself is the copy.
+const GitSyncDirName = "git-sync"
+
+// gitSyncStateName records this device's identity and what it last synced
+const gitSyncStateName = "git-sync.json"
+
+// GitSyncSettings configures `cflip sync push/pull`: profiles encrypted
+// with age or GPG in a git repository shared between machines
+type GitSyncSettings struct {
+	// Repo is the git URL (or path) of the sync repository
+	Repo string `json:"repo,omitempty"`
+	// Branch is the branch synced (default "main")
+	Branch string `json:"branch,omitempty"`
+	// Encryption is "age" (default) or "gpg"
+	Encryption string `json:"encryption,omitempty"`
+	// Recipients are the age public keys or GPG key IDs profiles are
+	// encrypted for; list every device's key
+	Recipients []string `json:"recipients,omitempty"`
+	// Identity is the age identity file to decrypt with (default
+	// ~/.config/age/keys.txt)
+	Identity string `json:"identity,omitempty"`
+}
+
+// StorageKey identifies the account a profile holds the same way on every
+// machine: its email and organization, as in its file name
+func (p *Profile) StorageKey() string {
+	return strings.TrimSuffix(p.filename(), ".profile")
+}
+
+// GitSyncPaths returns the sync repository clone and the sync state file
+func (s *Switcher) GitSyncPaths() (clone, state string) {
+	dir := s.profileManager.profilesDir
+	return filepath.Join(dir, GitSyncDirName), filepath.Join(dir, gitSyncStateName)
+}
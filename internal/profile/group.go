@@ -0,0 +1,125 @@
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Group is a named set of stored profiles, e.g. "work" or "client-x"
+type Group struct {
+	Name     string   `json:"name"`
+	Profiles []string `json:"profiles"`
+}
+
+// InGroup reports whether the profile belongs to group (case-insensitive)
+func (p *Profile) InGroup(group string) bool {
+	return slices.ContainsFunc(p.Groups, func(g string) bool {
+		return strings.EqualFold(g, group)
+	})
+}
+
+// checkLabel rejects group names and tags that would not survive the
+// command line or a comma-separated list
+func checkLabel(kind, label string) error {
+	if label == "" {
+		return fmt.Errorf("%s cannot be empty", kind)
+	}
+	if strings.ContainsAny(label, " \t,") {
+		return fmt.Errorf("invalid %s %q: use letters, digits, - or _", kind, label)
+	}
+	return nil
+}
+
+// SelectGroup limits the next switch to the next account in sequence to
+// profiles in group; empty rotates through every profile
+func (s *Switcher) SelectGroup(group string) {
+	s.group = group
+}
+
+// AddToGroup puts a stored profile in a group, reporting whether it was
+// not in it already
+func (s *Switcher) AddToGroup(identifier, group string) (*Profile, bool, error) {
+	if err := checkLabel("group name", group); err != nil {
+		return nil, false, err
+	}
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	if profile.InGroup(group) {
+		return profile, false, nil
+	}
+
+	profile.Groups = append(profile.Groups, group)
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, false, fmt.Errorf("failed to save profile: %w", err)
+	}
+	return profile, true, nil
+}
+
+// RemoveFromGroup takes a stored profile out of a group, reporting whether
+// it was in it
+func (s *Switcher) RemoveFromGroup(identifier, group string) (*Profile, bool, error) {
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	if !profile.InGroup(group) {
+		return profile, false, nil
+	}
+
+	profile.Groups = slices.DeleteFunc(profile.Groups, func(g string) bool {
+		return strings.EqualFold(g, group)
+	})
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, false, fmt.Errorf("failed to save profile: %w", err)
+	}
+	return profile, true, nil
+}
+
+// Groups lists every group with its profiles in list order, sorted by name.
+// Group names differing only in case are one group, named as first seen.
+func (s *Switcher) Groups() ([]Group, error) {
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	index := make(map[string]int)
+	var groups []Group
+	for _, profile := range profiles {
+		for _, name := range profile.Groups {
+			key := strings.ToLower(name)
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, Group{Name: name})
+			}
+			if !slices.Contains(groups[i].Profiles, profile.Name) {
+				groups[i].Profiles = append(groups[i].Profiles, profile.Name)
+			}
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	return groups, nil
+}
+
+// filterGroup keeps the profiles in group, failing when there are none
+func filterGroup(profiles []*Profile, group string) ([]*Profile, error) {
+	var members []*Profile
+	for _, profile := range profiles {
+		if profile.InGroup(group) {
+			members = append(members, profile)
+		}
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no accounts in group %q", group)
+	}
+	return members, nil
+}
@@ -0,0 +1,132 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// historyFileName is the switch history in the data directory
+const historyFileName = "history.json"
+
+// DefaultHistoryEntries is how many switches history.json keeps by default
+const DefaultHistoryEntries = 500
+
+// InitiatorSwitch is recorded for switches made with `cflip switch`
+const InitiatorSwitch = "switch"
+
+// HistorySettings bounds the switch history
+type HistorySettings struct {
+	// MaxEntries is how many switches are kept, oldest dropped first
+	// (default 500)
+	MaxEntries int `json:"max_entries,omitempty"`
+}
+
+// HistoryEntry is one recorded switch
+type HistoryEntry struct {
+	Time      time.Time `json:"time"`
+	From      string    `json:"from,omitempty"` // profile name; empty when no account was active
+	FromEmail string    `json:"from_email,omitempty"`
+	To        string    `json:"to"`
+	ToEmail   string    `json:"to_email"`
+	// Initiator is what asked for the switch: switch, undo, ui, exec,
+	// project, rotate, monitor, claude, recommend or recover
+	Initiator string `json:"initiator"`
+}
+
+// historyPath returns the path of history.json
+func historyPath() (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, historyFileName), nil
+}
+
+// LoadHistory reads the switch history, oldest first. A missing file is
+// an empty history.
+func LoadHistory() ([]HistoryEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read switch history: %w", err)
+	}
+
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// recordHistory appends a switch to history.json, dropping the oldest
+// entries beyond the configured limit. Callers hold the data directory lock.
+func (pm *ProfileManager) recordHistory(entry HistoryEntry) error {
+	entries, err := LoadHistory()
+	if err != nil {
+		// A damaged history must not block switching; start a new one
+		entries = nil
+	}
+	entries = append(entries, entry)
+
+	limit := DefaultHistoryEntries
+	if settings, err := pm.LoadSettings(); err == nil && settings.History.MaxEntries > 0 {
+		limit = settings.History.MaxEntries
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal switch history: %w", err)
+	}
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write switch history: %w", err)
+	}
+	return nil
+}
+
+// SetInitiator names what asks for the following switches in the history
+func (s *Switcher) SetInitiator(initiator string) {
+	s.initiator = initiator
+}
+
+// PreviousProfile returns the name of the account active before the
+// current one, for `cflip undo`
+func (s *Switcher) PreviousProfile() (string, error) {
+	config, err := s.profileManager.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	if config.PreviousProfile == "" {
+		return "", fmt.Errorf("no previous account to go back to")
+	}
+	return config.PreviousProfile, nil
+}
+
+// SetPreviousProfile sets the account `cflip undo` goes back to, e.g. to
+// forget a temporary switch made by `cflip exec`
+func (s *Switcher) SetPreviousProfile(name string) error {
+	config, err := s.profileManager.LoadConfig()
+	if err != nil {
+		return err
+	}
+	config.PreviousProfile = name
+	return s.profileManager.SaveConfig(config)
+}
@@ -0,0 +1,65 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindStored returns the stored profile of the same account and
+// organization as p, or nil when there is none
+func (s *Switcher) FindStored(p *Profile) (*Profile, error) {
+	profilePath := filepath.Join(s.profileManager.profilesDir, p.filename())
+	if _, err := os.Stat(profilePath); err != nil {
+		return nil, nil
+	}
+	return s.profileManager.LoadProfile(strings.TrimSuffix(p.filename(), ".profile"))
+}
+
+// ImportProfile stores a profile brought over from another machine. With
+// replace, the stored copy of the same account is overwritten but keeps its
+// name, so the active pointer and aliases stay valid.
+func (s *Switcher) ImportProfile(p *Profile, replace bool) (*Profile, error) {
+	if p.Email == "" {
+		return nil, fmt.Errorf("imported profile has no email")
+	}
+	p.Source = ""
+	p.Tampered = false
+	p.CredentialStore = ""
+
+	settings, err := s.profileManager.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAdmission(settings.Retention, p); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.FindStored(p)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case existing != nil && !replace:
+		return nil, fmt.Errorf("%s is already stored", p.Email)
+	case existing != nil:
+		p.Name = existing.Name
+	default:
+		if p.Name == "" {
+			p.Name = p.Email
+		}
+		if p.Name, err = s.profileManager.uniqueName(p); err != nil {
+			return nil, err
+		}
+	}
+
+	// Timestamps travel with the profile, so write without SaveProfile
+	if err := s.profileManager.writeProfile(p); err != nil {
+		return nil, err
+	}
+	if err := s.profileManager.updateConfig(p.Name, p.Email, p.Alias); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
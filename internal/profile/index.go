@@ -0,0 +1,71 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// IndexProblems compares the profile files with config.json's index:
+// files config.json does not list, entries without a file, duplicates, and
+// files that cannot be read. `cflip validate --fix` rebuilds the index.
+func (s *Switcher) IndexProblems() ([]string, error) {
+	pm := s.profileManager
+	cfg, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(pm.profilesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var problems []string
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".profile" {
+			continue
+		}
+		profilePath := filepath.Join(pm.profilesDir, entry.Name())
+
+		data, err := os.ReadFile(profilePath)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: unreadable (%v)", entry.Name(), err))
+			continue
+		}
+		plain, err := decodeProfileData(profilePath, data)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: cannot be decrypted (%v)", entry.Name(), err))
+			continue
+		}
+		var profile Profile
+		if err := json.Unmarshal(plain, &profile); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid JSON (%v)", entry.Name(), err))
+			continue
+		}
+
+		if found[profile.Name] {
+			problems = append(problems, fmt.Sprintf("%s: another file also holds %s", entry.Name(), profile.Name))
+			continue
+		}
+		found[profile.Name] = true
+		if _, ok := cfg.Profiles[profile.Name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s is not listed in config.json", entry.Name(), profile.Name))
+		}
+	}
+
+	var missing []string
+	for name := range cfg.Profiles {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		problems = append(problems, fmt.Sprintf("%s: listed in config.json but has no profile file", name))
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,182 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// filename returns the profile's file name, keyed by email and, when the
+// account was captured in an organization, that organization's UUID
+func (p *Profile) filename() string {
+	key := p.Email
+	if p.OrganizationUuid != "" {
+		key += "." + p.OrganizationUuid
+	}
+	return sanitizeFilename(key) + ".profile"
+}
+
+// sameKey reports whether two profiles are stored in the same file
+func (p *Profile) sameKey(other *Profile) bool {
+	return p.filename() == other.filename()
+}
+
+// OrganizationName returns the name of the organization the profile is keyed to
+func (p *Profile) OrganizationName() string {
+	for _, membership := range p.Organizations {
+		if membership.UUID == p.OrganizationUuid {
+			return membership.Name
+		}
+	}
+	if p.ClaudeConfig != nil && p.ClaudeConfig.GetOrganizationUuid() == p.OrganizationUuid {
+		return p.ClaudeConfig.GetOrganizationName()
+	}
+	return ""
+}
+
+// uniqueName makes a new profile's name distinct from profiles stored under
+// other keys, by qualifying it with the organization (e.g. "me@x.com/acme")
+func (pm *ProfileManager) uniqueName(profile *Profile) (string, error) {
+	profiles, err := pm.ListProfiles()
+	if err != nil {
+		return "", err
+	}
+
+	taken := func(name string) bool {
+		for _, existing := range profiles {
+			if existing.Name == name && !existing.sameKey(profile) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !taken(profile.Name) {
+		return profile.Name, nil
+	}
+
+	qualifier := strings.ToLower(strings.Join(strings.Fields(profile.OrganizationName()), "-"))
+	if qualifier == "" && profile.OrganizationUuid != "" {
+		qualifier = profile.OrganizationUuid[:min(8, len(profile.OrganizationUuid))]
+	}
+	if qualifier == "" {
+		qualifier = "personal"
+	}
+	base := profile.Name + "/" + qualifier
+	name := base
+	for i := 2; taken(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name, nil
+}
+
+// FindProfileByAccount returns the stored profile for an email in an
+// organization (empty for personal accounts)
+func (s *Switcher) FindProfileByAccount(email, organizationUuid string) (*Profile, error) {
+	key := &Profile{Email: email, OrganizationUuid: organizationUuid}
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		if profile.sameKey(key) {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("profile not found: %s", email)
+}
+
+// matchLiveProfile finds the stored profile for the live account. The
+// active pointer wins when it names the live account, since `switch --org`
+// can leave a profile's live organization different from its key; then an
+// exact organization match; then the only profile for the account.
+func matchLiveProfile(profiles []*Profile, pointer *Profile, state *LiveState, organizationUuid string) *Profile {
+	if pointer != nil && matchesLive(pointer, state) {
+		return pointer
+	}
+
+	var candidates []*Profile
+	for _, profile := range profiles {
+		if !matchesLive(profile, state) {
+			continue
+		}
+		if profile.OrganizationUuid == organizationUuid {
+			return profile
+		}
+		candidates = append(candidates, profile)
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// migrateProfileKeys renames profiles stored under the older email-only
+// file names to the email+organization scheme. Profiles modified outside
+// cflip are left alone so their changes are not silently accepted.
+func (pm *ProfileManager) migrateProfileKeys() error {
+	entries, err := os.ReadDir(pm.profilesDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".profile" {
+			continue
+		}
+
+		oldPath := filepath.Join(pm.profilesDir, entry.Name())
+		data, err := os.ReadFile(oldPath)
+		if err != nil {
+			continue
+		}
+		var profile Profile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			continue // Skip invalid files
+		}
+
+		if profile.OrganizationUuid != "" || profile.ClaudeConfig == nil {
+			continue
+		}
+		profile.OrganizationUuid = profile.ClaudeConfig.GetOrganizationUuid()
+		if profile.OrganizationUuid == "" {
+			continue
+		}
+
+		if ok, _ := pm.verifyContent(oldPath, data); !ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(pm.profilesDir, profile.filename())); err == nil {
+			continue // Never overwrite a profile already stored under the new key
+		}
+
+		if err := attachCredentials(oldPath, &profile); err != nil {
+			return err
+		}
+		if err := pm.writeProfile(&profile); err != nil {
+			return err
+		}
+		if err := os.Remove(oldPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+		}
+		if profile.CredentialStore == CredentialStoreSecure {
+			if err := deleteCredentials(oldPath); err != nil {
+				return err
+			}
+		}
+
+		config, err := pm.LoadConfig()
+		if err != nil {
+			return err
+		}
+		delete(config.Registry, entry.Name())
+		if err := pm.SaveConfig(config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
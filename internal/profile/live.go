@@ -0,0 +1,120 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// LiveState compares the account Claude Code is logged in with against the
+// active pointer in config.json, which goes stale when users log out and in
+// through Claude Code itself
+type LiveState struct {
+	Email       string
+	AccountUuid string
+	// Match is the stored profile for the live account, nil when unmanaged
+	Match *Profile
+	// Pointer is the profile config.json marks active, nil when unset
+	Pointer *Profile
+}
+
+// LoggedIn reports whether Claude Code has a live account at all
+func (l *LiveState) LoggedIn() bool {
+	return l.Email != "" || l.AccountUuid != ""
+}
+
+// InSync reports whether the active pointer names the live account
+func (l *LiveState) InSync() bool {
+	if l.Pointer == nil || l.Match == nil {
+		return l.Pointer == nil && !l.LoggedIn()
+	}
+	return l.Pointer.Name == l.Match.Name && l.Pointer.Source == l.Match.Source
+}
+
+// DetectLive reads the live oauthAccount and finds the stored profile for it,
+// matching on accountUuid and falling back to email for profiles saved without one
+func (s *Switcher) DetectLive() (*LiveState, error) {
+	live, err := config.LoadClaudeConfig()
+	if err != nil {
+		live = nil // Not logged in to Claude Code
+	}
+	return s.detectLive(live)
+}
+
+// detectLive is DetectLive for an already loaded live config (nil when
+// Claude Code is not logged in)
+func (s *Switcher) detectLive(live *config.ClaudeConfig) (*LiveState, error) {
+	state := &LiveState{}
+	if pointer, err := s.GetCurrentActiveProfile(); err == nil {
+		state.Pointer = pointer
+	}
+
+	if live == nil {
+		return state, nil
+	}
+	state.Email = live.GetUserEmail()
+	state.AccountUuid = live.GetAccountUuid()
+
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	state.Match = matchLiveProfile(profiles, state.Pointer, state, live.GetOrganizationUuid())
+
+	return state, nil
+}
+
+// matchesLive reports whether a profile belongs to the live account
+func matchesLive(profile *Profile, state *LiveState) bool {
+	if profile.AccountUuid != "" && state.AccountUuid != "" {
+		return profile.AccountUuid == state.AccountUuid
+	}
+	return state.Email != "" && strings.EqualFold(profile.Email, state.Email)
+}
+
+// ReconcilePointer points config.json's active profile at the live account.
+// It fails when the live account is not stored.
+func (s *Switcher) ReconcilePointer() (*Profile, error) {
+	state, err := s.DetectLive()
+	if err != nil {
+		return nil, err
+	}
+	if !state.LoggedIn() {
+		return nil, fmt.Errorf("not logged in to Claude Code")
+	}
+	if state.Match == nil {
+		return nil, fmt.Errorf("%s is not managed by cflip; run `cflip add` first", state.Email)
+	}
+	if state.InSync() {
+		return state.Match, nil
+	}
+
+	if state.Match.Source != "" {
+		err = s.profileManager.SetActiveSharedProfile(state.Match.Name, state.Match.Email, state.Match.Source)
+	} else {
+		err = s.profileManager.SetActiveProfile(state.Match.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update active profile: %w", err)
+	}
+	return state.Match, nil
+}
+
+// AdoptLive stores the live account when it is not managed yet and marks it
+// active. It returns nil when there was nothing to adopt.
+func (s *Switcher) AdoptLive() (*Profile, error) {
+	state, err := s.DetectLive()
+	if err != nil || !state.LoggedIn() || state.Match != nil {
+		return nil, err
+	}
+
+	profile, err := s.SaveCurrentAccount(state.Email, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to adopt %s: %w", state.Email, err)
+	}
+	if err := s.profileManager.SetActiveProfile(profile.Name); err != nil {
+		return nil, fmt.Errorf("failed to update active profile: %w", err)
+	}
+	return profile, nil
+}
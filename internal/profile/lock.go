@@ -0,0 +1,117 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// lockFileName is the advisory lock in the data directory that serializes
+// switches and profile writes across cflip processes
+const lockFileName = "cflip.lock"
+
+// DefaultLockTimeout is how long cflip waits for another cflip to finish
+const DefaultLockTimeout = 10 * time.Second
+
+var (
+	locksMu     sync.Mutex
+	lockTimeout = DefaultLockTimeout
+	// dirLocks excludes goroutines of this process from each other, per lock
+	// file; flock only excludes other processes
+	dirLocks = make(map[string]chan struct{})
+)
+
+// SetLockTimeout configures how long to wait for another cflip process
+func SetLockTimeout(timeout time.Duration) {
+	locksMu.Lock()
+	defer locksMu.Unlock()
+	lockTimeout = timeout
+}
+
+// lock takes the data directory lock, waiting for other goroutines and
+// cflip processes, and returns the function releasing it. The lock is not
+// reentrant: code that already holds it works on the manager returned by
+// locked, whose lock is a no-op.
+func (pm *ProfileManager) lock() (func(), error) {
+	if pm.held {
+		return func() {}, nil
+	}
+	path := filepath.Join(pm.profilesDir, lockFileName)
+
+	locksMu.Lock()
+	timeout := lockTimeout
+	slot := dirLocks[path]
+	if slot == nil {
+		slot = make(chan struct{}, 1)
+		dirLocks[path] = slot
+	}
+	locksMu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case slot <- struct{}{}:
+	case <-timer.C:
+		return nil, lockTimeoutError(timeout, fmt.Errorf("%w %s held by this process", fsutil.ErrLockTimeout, path))
+	}
+
+	lock, err := fsutil.LockFile(path, time.Until(deadline))
+	if errors.Is(err, fsutil.ErrLockTimeout) {
+		<-slot
+		return nil, lockTimeoutError(timeout, err)
+	}
+	if err != nil {
+		<-slot
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			lock.Unlock()
+			<-slot
+		})
+	}, nil
+}
+
+// lockTimeoutError explains a lock that was not released in time
+func lockTimeoutError(timeout time.Duration, err error) error {
+	return fmt.Errorf("another cflip is switching accounts or saving a profile (waited %s): %w; try again, or raise settings.lock_timeout", timeout, err)
+}
+
+// locked takes the data directory lock and returns a copy of the manager
+// for the code holding it
+func (pm *ProfileManager) locked() (*ProfileManager, func(), error) {
+	unlock, err := pm.lock()
+	if err != nil {
+		return nil, nil, err
+	}
+	held := *pm
+	held.held = true
+	return &held, unlock, nil
+}
+
+// locked takes the data directory lock and returns a copy of the switcher
+// whose operations run under it instead of taking it again
+func (s *Switcher) locked() (*Switcher, func(), error) {
+	pm, unlock, err := s.profileManager.locked()
+	if err != nil {
+		return nil, nil, err
+	}
+	held := *s
+	held.profileManager = pm
+	return &held, unlock, nil
+}
+
+// Lock takes the data directory lock for a sequence of switches that must
+// not interleave with other cflip processes, such as `cflip exec` applying
+// an account and restoring the previous one. The sequence runs on the
+// returned switcher, which works under the lock instead of taking it again.
+func (s *Switcher) Lock() (*Switcher, func(), error) {
+	return s.locked()
+}
@@ -0,0 +1,90 @@
+package profile
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+func TestLockExcludesGoroutines(t *testing.T) {
+	pm := &ProfileManager{profilesDir: t.TempDir()}
+
+	var mu sync.Mutex
+	inside, most := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := pm.lock()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			inside++
+			most = max(most, inside)
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			inside--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if most != 1 {
+		t.Fatalf("%d goroutines held the lock at once", most)
+	}
+}
+
+func TestLockTimesOutInProcess(t *testing.T) {
+	SetLockTimeout(100 * time.Millisecond)
+	t.Cleanup(func() { SetLockTimeout(DefaultLockTimeout) })
+
+	pm := &ProfileManager{profilesDir: t.TempDir()}
+	unlock, err := pm.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	other := &ProfileManager{profilesDir: pm.profilesDir}
+	if _, err := other.lock(); !errors.Is(err, fsutil.ErrLockTimeout) {
+		t.Fatalf("second lock: got %v, want ErrLockTimeout", err)
+	}
+}
+
+func TestLockedManagerDoesNotRelock(t *testing.T) {
+	SetLockTimeout(100 * time.Millisecond)
+	t.Cleanup(func() { SetLockTimeout(DefaultLockTimeout) })
+
+	pm := &ProfileManager{profilesDir: t.TempDir()}
+	held, unlock, err := pm.locked()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nested, err := held.lock()
+	if err != nil {
+		t.Fatalf("lock under the held lock: %v", err)
+	}
+	nested()
+
+	// Releasing the nested lock must leave the outer one held
+	if _, err := pm.lock(); !errors.Is(err, fsutil.ErrLockTimeout) {
+		t.Fatalf("lock after the nested release: got %v, want ErrLockTimeout", err)
+	}
+
+	unlock()
+	again, err := pm.lock()
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	again()
+}
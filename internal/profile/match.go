@@ -0,0 +1,41 @@
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// HasIdentifierPrefix reports whether any non-empty value starts with
+// identifier, ignoring case
+func HasIdentifierPrefix(identifier string, values ...string) bool {
+	if identifier == "" {
+		return false
+	}
+	identifier = strings.ToLower(identifier)
+	for _, value := range values {
+		if value != "" && strings.HasPrefix(strings.ToLower(value), identifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// AmbiguousIdentifierError lists the candidates a prefix could mean
+func AmbiguousIdentifierError(identifier string, candidates []string) error {
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+	return fmt.Errorf("ambiguous identifier %q, candidates: %s", identifier, strings.Join(sorted, ", "))
+}
+
+// ProfilesWithPrefix returns the profiles whose name, email or alias starts
+// with identifier, so long emails need not be typed in full
+func ProfilesWithPrefix(profiles []*Profile, identifier string) []*Profile {
+	var matches []*Profile
+	for _, profile := range profiles {
+		if HasIdentifierPrefix(identifier, profile.Name, profile.Email, profile.Alias) {
+			matches = append(matches, profile)
+		}
+	}
+	return matches
+}
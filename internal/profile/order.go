@@ -0,0 +1,127 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// sortByOrder sorts profiles by their recorded position, keeping profiles
+// missing from the order after the others in their current (file name)
+// order
+func sortByOrder(profiles []*Profile, order []string) {
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+	rank := func(p *Profile) int {
+		if i, ok := position[p.Name]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return rank(profiles[i]) < rank(profiles[j])
+	})
+}
+
+// appendOrder gives a newly stored profile the last number; call it before
+// adding the profile to config.Profiles. Configs written before the order
+// was recorded are first numbered as they used to be listed, so existing
+// numbers do not move.
+func (pm *ProfileManager) appendOrder(config *Config, name string) {
+	if slices.Contains(config.Order, name) {
+		return
+	}
+	_, stored := config.Profiles[name]
+	if config.Order == nil {
+		for _, existing := range pm.profileNames() {
+			if existing != name || stored {
+				config.Order = append(config.Order, existing)
+			}
+		}
+	}
+	if !slices.Contains(config.Order, name) {
+		config.Order = append(config.Order, name)
+	}
+}
+
+// removeOrder drops a profile from the order; later profiles move up
+func removeOrder(config *Config, name string) {
+	config.Order = slices.DeleteFunc(config.Order, func(n string) bool {
+		return n == name
+	})
+}
+
+// profileNames returns the names of the profiles on disk in file name
+// order, reading no credentials
+func (pm *ProfileManager) profileNames() []string {
+	entries, err := os.ReadDir(pm.profilesDir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".profile" {
+			continue
+		}
+		profilePath := filepath.Join(pm.profilesDir, entry.Name())
+		data, err := os.ReadFile(profilePath)
+		if err != nil {
+			continue
+		}
+		plain, err := decodeProfileData(profilePath, data)
+		if err != nil {
+			continue
+		}
+		var profile struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(plain, &profile); err == nil && profile.Name != "" {
+			names = append(names, profile.Name)
+		}
+	}
+	return names
+}
+
+// MoveProfile gives a profile the 1-based position in the list, shifting
+// the profiles between its old and new position by one
+func (s *Switcher) MoveProfile(identifier string, position int) (*Profile, error) {
+	pm := s.profileManager
+	unlock, err := pm.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	profile, err := pm.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := pm.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	if position < 1 || position > len(profiles) {
+		return nil, fmt.Errorf("invalid position: %d (only %d accounts available)", position, len(profiles))
+	}
+
+	// Record every profile's number, including those never ordered before
+	var order []string
+	for _, p := range profiles {
+		if p.Name != profile.Name {
+			order = append(order, p.Name)
+		}
+	}
+	config.Order = slices.Insert(order, position-1, profile.Name)
+	return profile, pm.SaveConfig(config)
+}
@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrgMembership is one organization/workspace an account belongs to
+type OrgMembership struct {
+	UUID             string `json:"uuid"`
+	Name             string `json:"name,omitempty"`
+	OrganizationRole string `json:"organization_role,omitempty"`
+	WorkspaceRole    string `json:"workspace_role,omitempty"`
+}
+
+// rememberOrganization records the organization in the profile's current
+// oauthAccount block as a membership, so every org an account has been seen
+// in can be switched to later
+func (p *Profile) rememberOrganization() {
+	if p.ClaudeConfig == nil {
+		return
+	}
+	account, ok := (*p.ClaudeConfig)["oauthAccount"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	uuid, _ := account["organizationUuid"].(string)
+	if uuid == "" {
+		return
+	}
+
+	membership := OrgMembership{UUID: uuid}
+	membership.Name, _ = account["organizationName"].(string)
+	membership.OrganizationRole, _ = account["organizationRole"].(string)
+	membership.WorkspaceRole, _ = account["workspaceRole"].(string)
+
+	for i, existing := range p.Organizations {
+		if existing.UUID == uuid {
+			p.Organizations[i] = membership
+			return
+		}
+	}
+	p.Organizations = append(p.Organizations, membership)
+}
+
+// FindOrganization looks up a membership by name (case-insensitive) or UUID
+func (p *Profile) FindOrganization(query string) (*OrgMembership, error) {
+	for i, membership := range p.Organizations {
+		if membership.UUID == query || strings.EqualFold(membership.Name, query) {
+			return &p.Organizations[i], nil
+		}
+	}
+
+	var names []string
+	for _, membership := range p.Organizations {
+		names = append(names, fmt.Sprintf("%q", membership.Name))
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s has no known organizations; add one with `cflip org add`", p.Email)
+	}
+	return nil, fmt.Errorf("%s is not a member of %q (known: %s)", p.Email, query, strings.Join(names, ", "))
+}
+
+// selectOrganization points the profile's oauthAccount block at membership
+func (p *Profile) selectOrganization(membership *OrgMembership) error {
+	if p.ClaudeConfig == nil {
+		return fmt.Errorf("profile has no Claude configuration")
+	}
+	account, ok := (*p.ClaudeConfig)["oauthAccount"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("profile has no OAuth account information")
+	}
+
+	account["organizationUuid"] = membership.UUID
+	setOrDelete(account, "organizationName", membership.Name)
+	setOrDelete(account, "organizationRole", membership.OrganizationRole)
+	setOrDelete(account, "workspaceRole", membership.WorkspaceRole)
+	return nil
+}
+
+// setOrDelete sets a string field, removing it when empty
+func setOrDelete(m map[string]interface{}, key, value string) {
+	if value == "" {
+		delete(m, key)
+		return
+	}
+	m[key] = value
+}
+
+// SelectOrganization makes the next switch apply the named organization
+func (s *Switcher) SelectOrganization(query string) {
+	s.organization = query
+}
+
+// AddOrganization records an organization membership on a stored profile
+func (s *Switcher) AddOrganization(identifier string, membership OrgMembership) (*Profile, error) {
+	if membership.UUID == "" {
+		return nil, fmt.Errorf("organization UUID is required")
+	}
+
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	replaced := false
+	for i, existing := range profile.Organizations {
+		if existing.UUID == membership.UUID {
+			profile.Organizations[i] = membership
+			replaced = true
+		}
+	}
+	if !replaced {
+		profile.Organizations = append(profile.Organizations, membership)
+	}
+
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+	return profile, nil
+}
@@ -0,0 +1,109 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// overlayStateFile records the overlay currently applied to settings.json
+const overlayStateFile = "settings-overlay.json"
+
+// overlayState is persisted so the next switch can revert the overlay
+type overlayState struct {
+	Profile string             `json:"profile"`
+	Undo    config.OverlayUndo `json:"undo"`
+}
+
+// overlayStatePath returns where the applied-overlay record lives
+func overlayStatePath() (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, overlayStateFile), nil
+}
+
+// revertSettingsOverlay undoes whichever profile overlay is currently applied
+func revertSettingsOverlay() error {
+	path, err := overlayStatePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read overlay state: %w", err)
+	}
+
+	var state overlayState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse overlay state: %w", err)
+	}
+
+	if err := config.RevertSettingsOverlay(state.Undo); err != nil {
+		return fmt.Errorf("failed to revert settings overlay of %s: %w", state.Profile, err)
+	}
+
+	return os.Remove(path)
+}
+
+// applySettingsOverlay reverts the previous overlay and applies profile's,
+// including its proxy settings
+func applySettingsOverlay(profile *Profile) error {
+	if err := revertSettingsOverlay(); err != nil {
+		return err
+	}
+	overlay := profile.effectiveOverlay()
+	if len(overlay) == 0 {
+		return nil
+	}
+
+	undo, err := config.ApplySettingsOverlay(overlay)
+	if err != nil {
+		return fmt.Errorf("failed to apply settings overlay: %w", err)
+	}
+
+	data, err := json.MarshalIndent(overlayState{Profile: profile.Name, Undo: undo}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal overlay state: %w", err)
+	}
+
+	path, err := overlayStatePath()
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write overlay state: %w", err)
+	}
+	return nil
+}
+
+// SetSettingsOverlay stores a profile's settings overlay; nil clears it.
+// The active profile's overlay is re-applied immediately.
+func (s *Switcher) SetSettingsOverlay(identifier string, overlay config.SettingsOverlay) (*Profile, error) {
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.SettingsOverlay = overlay
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	if s.isActiveProfile(profile) {
+		if err := applySettingsOverlay(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,154 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+)
+
+// appDirName is the directory name cflip uses under XDG base directories
+const appDirName = "cflip"
+
+// Legacy data directory names, checked in order for migration
+var legacyDirNames = []string{".cflip", ".claude-flip"}
+
+// DataDir returns the directory where cflip keeps its profiles and state.
+// On Linux this is $XDG_DATA_HOME/cflip (default ~/.local/share/cflip);
+// elsewhere it is ~/.cflip.
+func DataDir() (string, error) {
+	dataDir, _, err := resolveDirs()
+	return dataDir, err
+}
+
+// ConfigDir returns the directory holding cflip's config.json.
+// On Linux this is $XDG_CONFIG_HOME/cflip (default ~/.config/cflip);
+// elsewhere it is ~/.cflip.
+func ConfigDir() (string, error) {
+	_, configDir, err := resolveDirs()
+	return configDir, err
+}
+
+// resolveDirs determines the data and config directories, migrating a legacy
+// ~/.cflip or ~/.claude-flip directory to the XDG locations on first use.
+// If migration is impossible the legacy directory keeps being used.
+func resolveDirs() (string, string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	if runtime.GOOS != "linux" {
+		legacy := filepath.Join(home, legacyDirNames[0])
+		return legacy, legacy, nil
+	}
+
+	dataDir := filepath.Join(xdgDir("XDG_DATA_HOME", filepath.Join(home, ".local", "share")), appDirName)
+	configDir := filepath.Join(xdgDir("XDG_CONFIG_HOME", filepath.Join(home, ".config")), appDirName)
+
+	var legacies []string
+	for _, name := range legacyDirNames {
+		legacy := filepath.Join(home, name)
+		if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+			legacies = append(legacies, legacy)
+		}
+	}
+
+	if _, err := os.Stat(dataDir); err == nil {
+		warnLegacyDirs(legacies, dataDir)
+		return dataDir, configDir, nil
+	}
+	if len(legacies) == 0 {
+		return dataDir, configDir, nil
+	}
+
+	// The first legacy directory is migrated; any other is left alone
+	warnLegacyDirs(legacies[1:], dataDir)
+	if err := migrateLegacyDir(legacies[0], dataDir, configDir); err != nil {
+		// Keep using whatever did not move
+		return existingDir(dataDir, legacies[0]), existingConfigDir(configDir, legacies[0]), nil
+	}
+	return dataDir, configDir, nil
+}
+
+// legacyWarning makes sure leftover legacy directories are reported once
+var legacyWarning sync.Once
+
+// warnLegacyDirs reports legacy directories cflip does not read, such as a
+// ~/.claude-flip left next to a migrated ~/.cflip
+func warnLegacyDirs(legacies []string, dataDir string) {
+	if len(legacies) == 0 {
+		return
+	}
+	legacyWarning.Do(func() {
+		logger.Warning("%s is not used: cflip keeps its data in %s. Move any profiles you still need there, then remove it",
+			strings.Join(legacies, " and "), dataDir)
+	})
+}
+
+// existingDir returns dir when it exists and fallback otherwise
+func existingDir(dir, fallback string) string {
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	return fallback
+}
+
+// existingConfigDir returns the directory config.json is in after a failed
+// migration: configDir once it was moved there, legacy otherwise
+func existingConfigDir(configDir, legacy string) string {
+	if _, err := os.Stat(filepath.Join(configDir, "config.json")); err == nil {
+		return configDir
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "config.json")); err == nil {
+		return legacy
+	}
+	return existingDir(configDir, legacy)
+}
+
+// xdgDir returns the value of an XDG environment variable, or fallback when
+// it is unset or not absolute (as the spec requires)
+func xdgDir(envVar, fallback string) string {
+	if dir := os.Getenv(envVar); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	return fallback
+}
+
+// migrateLegacyDir moves a legacy cflip directory to the XDG data directory
+// and its config.json to the XDG config directory. config.json moves first
+// and is moved back when the data directory cannot be, so a failure leaves
+// the legacy layout intact.
+func migrateLegacyDir(legacy, dataDir, configDir string) error {
+	if err := os.MkdirAll(filepath.Dir(dataDir), 0o700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir, 0o700); err != nil {
+		return err
+	}
+
+	legacyConfig := filepath.Join(legacy, "config.json")
+	config := filepath.Join(configDir, "config.json")
+	movedConfig := false
+	if _, err := os.Stat(legacyConfig); err == nil {
+		if err := os.Rename(legacyConfig, config); err != nil {
+			return err
+		}
+		movedConfig = true
+	}
+
+	if err := os.Rename(legacy, dataDir); err != nil {
+		if movedConfig {
+			if rollbackErr := os.Rename(config, legacyConfig); rollbackErr != nil {
+				return errors.Join(err, rollbackErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+//go:build linux
+
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupLegacyHome creates a home directory with a legacy ~/.cflip holding a
+// profile and config.json
+func setupLegacyHome(t *testing.T, name string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	legacy := filepath.Join(home, name)
+	if err := os.MkdirAll(filepath.Join(legacy, "profiles"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(legacy, "profiles", "me@x.com.json"), "{}")
+	writeTestFile(t, filepath.Join(legacy, "config.json"), `{"current_profile":"me@x.com"}`)
+	return home
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("%s is missing: %v", path, err)
+	}
+}
+
+func TestResolveDirsMigratesLegacyDir(t *testing.T) {
+	home := setupLegacyHome(t, ".cflip")
+
+	dataDir, configDir, err := resolveDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".local", "share", "cflip"); dataDir != want {
+		t.Errorf("data dir = %s, want %s", dataDir, want)
+	}
+	if want := filepath.Join(home, ".config", "cflip"); configDir != want {
+		t.Errorf("config dir = %s, want %s", configDir, want)
+	}
+	assertExists(t, filepath.Join(dataDir, "profiles", "me@x.com.json"))
+	assertExists(t, filepath.Join(configDir, "config.json"))
+	if _, err := os.Stat(filepath.Join(home, ".cflip")); !os.IsNotExist(err) {
+		t.Errorf("legacy dir still exists: %v", err)
+	}
+}
+
+func TestResolveDirsKeepsLegacyDirWhenConfigCannotMove(t *testing.T) {
+	home := setupLegacyHome(t, ".claude-flip")
+	// A regular file where the config home should be makes the move fail
+	blocker := filepath.Join(home, "not-a-dir")
+	writeTestFile(t, blocker, "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(blocker, "config"))
+
+	dataDir, configDir, err := resolveDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(home, ".claude-flip")
+	if dataDir != legacy || configDir != legacy {
+		t.Errorf("dirs = %s, %s, want both %s", dataDir, configDir, legacy)
+	}
+	assertExists(t, filepath.Join(legacy, "profiles", "me@x.com.json"))
+	assertExists(t, filepath.Join(legacy, "config.json"))
+}
+
+func TestResolveDirsRollsBackConfigWhenDataCannotMove(t *testing.T) {
+	home := setupLegacyHome(t, ".cflip")
+	blocker := filepath.Join(home, "not-a-dir")
+	writeTestFile(t, blocker, "")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(blocker, "data"))
+
+	dataDir, configDir, err := resolveDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(home, ".cflip")
+	if dataDir != legacy || configDir != legacy {
+		t.Errorf("dirs = %s, %s, want both %s", dataDir, configDir, legacy)
+	}
+	assertExists(t, filepath.Join(legacy, "config.json"))
+	if _, err := os.Stat(filepath.Join(home, ".config", "cflip", "config.json")); !os.IsNotExist(err) {
+		t.Errorf("config.json left in the config dir: %v", err)
+	}
+}
+
+func TestResolveDirsMigratesFirstOfTwoLegacyDirs(t *testing.T) {
+	home := setupLegacyHome(t, ".cflip")
+	other := filepath.Join(home, ".claude-flip")
+	if err := os.MkdirAll(other, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(other, "config.json"), "{}")
+
+	dataDir, _, err := resolveDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertExists(t, filepath.Join(dataDir, "profiles", "me@x.com.json"))
+	// The second one is reported, not merged or removed
+	assertExists(t, filepath.Join(other, "config.json"))
+}
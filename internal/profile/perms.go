@@ -0,0 +1,159 @@
+package profile
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// Permissions cflip expects on everything that can hold tokens
+const (
+	privateDirMode  os.FileMode = 0o700
+	privateFileMode os.FileMode = 0o600
+)
+
+// PermissionIssue describes a file or directory with unsafe permissions or
+// ownership
+type PermissionIssue struct {
+	Path    string `json:"path"`
+	Problem string `json:"problem"`
+	// Secret is set when the path holds credentials readable by others
+	Secret bool `json:"secret"`
+	Fixed  bool `json:"fixed"`
+}
+
+// permTarget is a path cflip audits, optionally walked recursively
+type permTarget struct {
+	path    string
+	walk    bool
+	secrets bool
+}
+
+// permissionTargets lists cflip's directories and Claude Code's auth files
+func permissionTargets() ([]permTarget, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	dataDir, configDir, err := resolveDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []permTarget{
+		{path: dataDir, walk: true, secrets: true},
+		{path: filepath.Join(home, ".claude.json"), secrets: true},
+		{path: filepath.Join(home, ".claude.json.backup"), secrets: true},
+	}
+	if credentialsPath, err := storage.CredentialsPath(); err == nil {
+		targets = append(targets, permTarget{path: credentialsPath, secrets: true})
+	}
+	if configDir != dataDir {
+		targets = append(targets, permTarget{path: configDir, walk: true})
+	}
+
+	// Credentials LinuxFileStorage keeps next to Claude Code's own
+	stored, _ := filepath.Glob(filepath.Join(home, ".claude", ".cflip_*.json"))
+	for _, path := range stored {
+		targets = append(targets, permTarget{path: path, secrets: true})
+	}
+
+	return targets, nil
+}
+
+// AuditPermissions checks that cflip's directories are 0700 and every file
+// under them, plus ~/.claude.json and .credentials.json, is 0600 and owned by
+// the current user. With fix, loose modes are tightened; ownership problems
+// always need manual action.
+func AuditPermissions(fix bool) ([]PermissionIssue, error) {
+	targets, err := permissionTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []PermissionIssue
+	for _, target := range targets {
+		if _, err := os.Lstat(target.path); os.IsNotExist(err) {
+			continue
+		}
+
+		if !target.walk {
+			info, err := os.Lstat(target.path)
+			if err != nil {
+				return issues, err
+			}
+			issues = append(issues, checkPermissions(target.path, info, target.secrets, fix)...)
+			continue
+		}
+
+		err := filepath.WalkDir(target.path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			issues = append(issues, checkPermissions(path, info, target.secrets, fix)...)
+			return nil
+		})
+		if err != nil {
+			return issues, fmt.Errorf("failed to audit %s: %w", target.path, err)
+		}
+	}
+
+	return issues, nil
+}
+
+// checkPermissions reports the mode and ownership problems of one path
+func checkPermissions(path string, info os.FileInfo, secrets, fix bool) []PermissionIssue {
+	// Symlinks are left alone; their targets are what matters
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	var issues []PermissionIssue
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		issues = append(issues, PermissionIssue{
+			Path:    path,
+			Problem: fmt.Sprintf("owned by uid %d instead of you (uid %d); run `sudo chown %d %s`", stat.Uid, os.Getuid(), os.Getuid(), path),
+			Secret:  secrets && !info.IsDir(),
+		})
+	}
+
+	want := privateFileMode
+	if info.IsDir() {
+		want = privateDirMode
+	}
+
+	mode := info.Mode().Perm()
+	if mode&0o077 == 0 {
+		return issues
+	}
+
+	issue := PermissionIssue{
+		Path:    path,
+		Problem: fmt.Sprintf("mode %04o, want %04o", mode, want),
+		Secret:  secrets && !info.IsDir() && mode&0o044 != 0,
+	}
+	if mode&0o004 != 0 {
+		issue.Problem += " (world-readable)"
+	} else if mode&0o040 != 0 {
+		issue.Problem += " (group-readable)"
+	}
+
+	if fix {
+		if err := os.Chmod(path, want); err != nil {
+			issue.Problem += fmt.Sprintf("; chmod failed: %v", err)
+		} else {
+			issue.Fixed = true
+		}
+	}
+
+	return append(issues, issue)
+}
@@ -5,58 +5,150 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/fsutil"
+	"github.com/phathdt/claude-flip/internal/storage"
 )
 
 // Profile represents a saved Claude Code account configuration
 type Profile struct {
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Alias        string    `json:"alias,omitempty"`
-	AccountUuid  string    `json:"account_uuid"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	LastActiveAt time.Time `json:"last_active_at,omitempty"`
+	// SchemaVersion is the file layout version, see ProfileSchemaVersion
+	SchemaVersion    int       `json:"schema_version,omitempty"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	Alias            string    `json:"alias,omitempty"`
+	AccountUuid      string    `json:"account_uuid"`
+	OrganizationUuid string    `json:"organization_uuid,omitempty"` // org the account was captured in; an email is stored once per org
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	LastActiveAt     time.Time `json:"last_active_at,omitempty"`
+	SwitchCount      int       `json:"switch_count,omitempty"`
 
 	// Claude Code configuration data
 	ClaudeConfig *config.ClaudeConfig `json:"claude_config"`
 	Credentials  *config.Credentials  `json:"credentials"`
+
+	// Claude Desktop session, captured only when the desktop target is enabled
+	Desktop *config.DesktopSnapshot `json:"desktop,omitempty"`
+
+	// SettingsOverlay is merged into ~/.claude/settings.json while this profile is active
+	SettingsOverlay config.SettingsOverlay `json:"settings_overlay,omitempty"`
+
+	// Organizations lists every organization/workspace the account was seen in
+	Organizations []OrgMembership `json:"organizations,omitempty"`
+
+	// Groups are the named sets the account belongs to, e.g. "work"
+	Groups []string `json:"groups,omitempty"`
+
+	// Tags are free-form labels, e.g. "billing"; Notes is free text
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+
+	// Proxy routes Claude Code through (or explicitly around) a proxy for this account
+	Proxy *ProxySettings `json:"proxy,omitempty"`
+
+	// CredentialStore is "secure" when Credentials are kept in secure
+	// storage instead of this file
+	CredentialStore string `json:"credential_store,omitempty"`
+
+	// Source names the shared source a profile was fetched from (never persisted)
+	Source string `json:"-"`
+
+	// Tampered is set at load when the file no longer matches the registry hash
+	Tampered bool `json:"-"`
+
+	// Locked is set at load when the credentials are behind a locked vault
+	// passphrase (see storage.ErrLocked)
+	Locked bool `json:"-"`
 }
 
 // ProfileManager manages Claude Code account profiles
 type ProfileManager struct {
 	profilesDir string
 	configPath  string
+	// held marks the copy handed to code holding the data directory lock
+	held bool
 }
 
 // Config represents the cflip configuration
 type Config struct {
-	ActiveProfile string            `json:"active_profile,omitempty"`
-	Profiles      map[string]string `json:"profiles"` // profile_name -> email mapping
-	LastUpdated   time.Time         `json:"last_updated"`
+	ActiveProfile string `json:"active_profile,omitempty"`
+	ActiveSource  string `json:"active_source,omitempty"` // shared source of the active profile, if any
+	// ActiveEmail is the email of an active shared profile, which has no
+	// profile file to read it from
+	ActiveEmail string `json:"active_email,omitempty"`
+	// PreviousProfile is the account active before ActiveProfile, for `cflip undo`
+	PreviousProfile string            `json:"previous_profile,omitempty"`
+	Profiles        map[string]string `json:"profiles"` // profile_name -> email mapping
+	// ProfileAliases maps profile names to aliases, so completion can list
+	// accounts without reading (and possibly decrypting) every profile
+	ProfileAliases map[string]string `json:"profile_aliases"`
+	Settings       Settings          `json:"settings"`
+	LastUpdated    time.Time         `json:"last_updated"`
+
+	// Registry maps profile file names to the hash and writer of cflip's last write
+	Registry map[string]RegistryEntry `json:"registry,omitempty"`
+
+	// TokenExpiry maps profile names to their access token expiry, so
+	// `cflip status` can show it without reading credentials
+	TokenExpiry map[string]time.Time `json:"token_expiry,omitempty"`
+
+	// Cooldowns maps profile names to the usage limit they last hit, so
+	// `switch --available` can skip them until the limit resets
+	Cooldowns map[string]Cooldown `json:"cooldowns,omitempty"`
+
+	// Order lists profile names by their account number, so `switch 2`
+	// means the same account between runs
+	Order []string `json:"order,omitempty"`
 }
 
 // NewProfileManager creates a new profile manager
 func NewProfileManager() (*ProfileManager, error) {
-	home, err := os.UserHomeDir()
+	profilesDir, configDir, err := resolveDirs()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
 
-	profilesDir := filepath.Join(home, ".cflip")
-	configPath := filepath.Join(profilesDir, "config.json")
+	configPath := filepath.Join(configDir, "config.json")
 
-	// Create the profiles directory if it doesn't exist
+	// Create the profiles and config directories if they don't exist
 	if err := os.MkdirAll(profilesDir, 0o700); err != nil {
 		return nil, fmt.Errorf("failed to create profiles directory: %w", err)
 	}
+	if err := os.MkdirAll(configDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
 
-	return &ProfileManager{
+	pm := &ProfileManager{
 		profilesDir: profilesDir,
 		configPath:  configPath,
-	}, nil
+	}
+	if settings, err := pm.LoadSettings(); err == nil {
+		if err := storage.SetProfileBackend(settings.StorageBackend); err != nil {
+			return nil, err
+		}
+		storage.SetPassPrefix(settings.PassPrefix)
+	}
+	if _, err := storage.LookupBackend(storage.ProfileBackend()); err != nil {
+		return nil, fmt.Errorf("%s: %w", storage.StorageBackendEnv, err)
+	}
+	if err := pm.migrateProfileKeys(); err != nil {
+		return nil, fmt.Errorf("failed to migrate profiles to organization keys: %w", err)
+	}
+	if err := pm.migrateProfileCredentials(); err != nil {
+		return nil, err
+	}
+	if runtime.GOOS == "linux" {
+		if err := storage.EncryptCredentialFiles(); err != nil {
+			return nil, fmt.Errorf("failed to encrypt stored credentials: %w", err)
+		}
+	}
+
+	return pm, nil
 }
 
 // SaveProfile saves a profile to disk
@@ -65,30 +157,70 @@ func (pm *ProfileManager) SaveProfile(profile *Profile) error {
 		return fmt.Errorf("profile name cannot be empty")
 	}
 
-	// Generate filename based on email (sanitized)
-	filename := sanitizeFilename(profile.Email) + ".profile"
-	profilePath := filepath.Join(pm.profilesDir, filename)
+	unlock, err := pm.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	profile.UpdatedAt = time.Now()
+	profile.rememberOrganization()
 
-	data, err := json.MarshalIndent(profile, "", "  ")
+	if err := pm.writeProfile(profile); err != nil {
+		return err
+	}
+
+	// Update the main config
+	return pm.updateConfig(profile.Name, profile.Email, profile.Alias)
+}
+
+// RecordActivation bumps a profile's LastActiveAt and SwitchCount after a switch
+func (pm *ProfileManager) RecordActivation(identifier string) error {
+	profile, err := pm.LoadProfile(identifier)
 	if err != nil {
-		return fmt.Errorf("failed to marshal profile: %w", err)
+		return err
+	}
+	// Usage stats are not worth refusing a switch to a newer cflip's profile
+	if checkSchemaVersion(profile) != nil {
+		return nil
 	}
 
-	// Write atomically using temporary file
-	tempPath := profilePath + ".tmp"
-	if err := os.WriteFile(tempPath, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write profile file: %w", err)
+	profile.LastActiveAt = time.Now()
+	profile.SwitchCount++
+
+	// Activation is not a content change, so UpdatedAt is left untouched
+	return pm.writeProfile(profile)
+}
+
+// writeProfile atomically writes a profile file without touching timestamps
+func (pm *ProfileManager) writeProfile(profile *Profile) error {
+	if err := checkSchemaVersion(profile); err != nil {
+		return err
 	}
+	profile.SchemaVersion = ProfileSchemaVersion
+	profilePath := filepath.Join(pm.profilesDir, profile.filename())
 
-	if err := os.Rename(tempPath, profilePath); err != nil {
-		os.Remove(tempPath) // Clean up temp file
-		return fmt.Errorf("failed to replace profile file: %w", err)
+	onDisk, err := pm.splitCredentials(profilePath, profile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(onDisk, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	if err := checkSchema(profileSchema, profilePath, data); err != nil {
+		return err
+	}
+	if data, err = pm.encodeProfileData(profilePath, data); err != nil {
+		return err
 	}
 
-	// Update the main config
-	return pm.updateConfig(profile.Name, profile.Email)
+	if err := fsutil.WriteFileAtomic(profilePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write profile file: %w", err)
+	}
+
+	profile.Tampered = false
+	return pm.recordWrite(profile, profilePath, data)
 }
 
 // LoadProfile loads a profile from disk
@@ -102,11 +234,25 @@ func (pm *ProfileManager) LoadProfile(identifier string) (*Profile, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read profile file: %w", err)
 	}
+	plain, err := decodeProfileData(profilePath, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSchema(profileSchema, profilePath, plain); err != nil {
+		return nil, err
+	}
 
 	var profile Profile
-	if err := json.Unmarshal(data, &profile); err != nil {
+	if err := json.Unmarshal(plain, &profile); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
 	}
+	if err := attachCredentials(profilePath, &profile); err != nil {
+		return nil, err
+	}
+
+	if ok, entry := pm.verifyContent(profilePath, data); !ok {
+		markTampered(&profile, profilePath, entry)
+	}
 
 	return &profile, nil
 }
@@ -127,16 +273,30 @@ func (pm *ProfileManager) ListProfiles() ([]*Profile, error) {
 			if err != nil {
 				continue // Skip invalid files
 			}
+			plain, err := decodeProfileData(profilePath, data)
+			if err != nil {
+				continue // Skip files sops cannot decrypt here
+			}
 
 			var profile Profile
-			if err := json.Unmarshal(data, &profile); err != nil {
+			if err := json.Unmarshal(plain, &profile); err != nil {
 				continue // Skip invalid files
 			}
+			if err := attachCredentials(profilePath, &profile); err != nil {
+				return nil, err
+			}
+
+			if ok, entry := pm.verifyContent(profilePath, data); !ok {
+				markTampered(&profile, profilePath, entry)
+			}
 
 			profiles = append(profiles, &profile)
 		}
 	}
 
+	if config, err := pm.LoadConfig(); err == nil {
+		sortByOrder(profiles, config.Order)
+	}
 	return profiles, nil
 }
 
@@ -158,6 +318,13 @@ func (pm *ProfileManager) DeleteProfile(identifier string) error {
 		return fmt.Errorf("failed to remove profile file: %w", err)
 	}
 
+	if err := removeArtifacts(profile); err != nil {
+		return err
+	}
+	if err := deleteCredentials(profilePath); err != nil {
+		return err
+	}
+
 	// Update config to remove profile reference
 	config, err := pm.LoadConfig()
 	if err != nil {
@@ -165,9 +332,17 @@ func (pm *ProfileManager) DeleteProfile(identifier string) error {
 	}
 
 	delete(config.Profiles, profile.Name)
+	delete(config.ProfileAliases, profile.Name)
+	delete(config.TokenExpiry, profile.Name)
+	delete(config.Cooldowns, profile.Name)
+	removeOrder(config, profile.Name)
+	delete(config.Registry, filepath.Base(profilePath))
 	if config.ActiveProfile == profile.Name {
 		config.ActiveProfile = ""
 	}
+	if config.PreviousProfile == profile.Name {
+		config.PreviousProfile = ""
+	}
 
 	return pm.SaveConfig(config)
 }
@@ -199,19 +374,46 @@ func (pm *ProfileManager) SetActiveProfile(identifier string) error {
 		return err
 	}
 
-	config.ActiveProfile = profile.Name
+	config.setActive(profile.Name, "", "")
+	config.LastUpdated = time.Now()
+
+	return pm.SaveConfig(config)
+}
+
+// SetActiveSharedProfile marks a profile from a read-only shared source as active
+func (pm *ProfileManager) SetActiveSharedProfile(name, email, source string) error {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	config.setActive(name, email, source)
 	config.LastUpdated = time.Now()
 
 	return pm.SaveConfig(config)
 }
 
+// setActive marks a profile active, remembering the one it replaces
+func (c *Config) setActive(name, email, source string) {
+	if c.ActiveProfile != "" && c.ActiveProfile != name {
+		c.PreviousProfile = c.ActiveProfile
+	}
+	c.ActiveProfile = name
+	c.ActiveSource = source
+	c.ActiveEmail = ""
+	if source != "" {
+		c.ActiveEmail = email
+	}
+}
+
 // LoadConfig loads the main cflip configuration
 func (pm *ProfileManager) LoadConfig() (*Config, error) {
 	if _, err := os.Stat(pm.configPath); os.IsNotExist(err) {
 		// Return default config if file doesn't exist
 		return &Config{
-			Profiles:    make(map[string]string),
-			LastUpdated: time.Now(),
+			Profiles:       make(map[string]string),
+			ProfileAliases: make(map[string]string),
+			LastUpdated:    time.Now(),
 		}, nil
 	}
 
@@ -219,6 +421,9 @@ func (pm *ProfileManager) LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	if err := checkSchema(configSchema, pm.configPath, data); err != nil {
+		return nil, err
+	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -240,22 +445,19 @@ func (pm *ProfileManager) SaveConfig(config *Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
-
-	// Write atomically using temporary file
-	tempPath := pm.configPath + ".tmp"
-	if err := os.WriteFile(tempPath, data, 0o600); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
+	if err := checkSchema(configSchema, pm.configPath, data); err != nil {
+		return err
 	}
 
-	if err := os.Rename(tempPath, pm.configPath); err != nil {
-		os.Remove(tempPath) // Clean up temp file
-		return fmt.Errorf("failed to replace config file: %w", err)
+	if err := fsutil.WriteFileAtomic(pm.configPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
-// findProfilePath finds the profile file path by name or email
+// findProfilePath finds the profile file path by exact name, email or
+// alias. Prefixes typed by the user are resolved by the service first.
 func (pm *ProfileManager) findProfilePath(identifier string) (string, error) {
 	// First try by sanitized email filename
 	filename := sanitizeFilename(identifier) + ".profile"
@@ -272,9 +474,34 @@ func (pm *ProfileManager) findProfilePath(identifier string) (string, error) {
 	}
 
 	for _, profile := range profiles {
-		if profile.Name == identifier || profile.Email == identifier {
-			filename := sanitizeFilename(profile.Email) + ".profile"
-			return filepath.Join(pm.profilesDir, filename), nil
+		if profile.Name == identifier {
+			return filepath.Join(pm.profilesDir, profile.filename()), nil
+		}
+	}
+
+	// An email alone is ambiguous when it is stored for several organizations
+	var matches []*Profile
+	for _, profile := range profiles {
+		if profile.Email == identifier {
+			matches = append(matches, profile)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		// Not an email; try aliases
+	case 1:
+		return filepath.Join(pm.profilesDir, matches[0].filename()), nil
+	default:
+		names := make([]string, len(matches))
+		for i, match := range matches {
+			names[i] = fmt.Sprintf("%q", match.Name)
+		}
+		return "", fmt.Errorf("%s is stored for several organizations; use one of the profile names %s", identifier, strings.Join(names, ", "))
+	}
+
+	for _, profile := range profiles {
+		if profile.Alias != "" && profile.Alias == identifier {
+			return filepath.Join(pm.profilesDir, profile.filename()), nil
 		}
 	}
 
@@ -282,13 +509,15 @@ func (pm *ProfileManager) findProfilePath(identifier string) (string, error) {
 }
 
 // updateConfig updates the main config with profile information
-func (pm *ProfileManager) updateConfig(name, email string) error {
+func (pm *ProfileManager) updateConfig(name, email, alias string) error {
 	config, err := pm.LoadConfig()
 	if err != nil {
 		return err
 	}
 
+	pm.appendOrder(config, name)
 	config.Profiles[name] = email
+	setProfileAlias(config, name, alias)
 	return pm.SaveConfig(config)
 }
 
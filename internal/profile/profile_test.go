@@ -0,0 +1,144 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// newTestManager creates a profile manager with its home, data and config
+// directories in a temporary directory
+func newTestManager(t *testing.T) *ProfileManager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := os.MkdirAll(filepath.Join(home, ".claude"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	pm, err := NewProfileManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pm
+}
+
+// saveTestProfile stores a profile for email in an organization (empty for
+// a personal account) with placeholder credentials
+func saveTestProfile(t *testing.T, pm *ProfileManager, name, email, organizationUuid string) *Profile {
+	t.Helper()
+	credentials := &config.Credentials{}
+	credentials.ClaudeAiOauth.AccessToken = "token-" + name
+	profile := &Profile{
+		Name:             name,
+		Email:            email,
+		AccountUuid:      "uuid-" + email,
+		OrganizationUuid: organizationUuid,
+		ClaudeConfig:     &config.ClaudeConfig{},
+		Credentials:      credentials,
+	}
+	if err := pm.SaveProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	return profile
+}
+
+func TestLoadProfileMatchesExactly(t *testing.T) {
+	pm := newTestManager(t)
+	saveTestProfile(t, pm, "bob@x.com", "bob@x.com", "")
+
+	// An email that is a prefix of a stored one is a different account
+	if _, err := pm.LoadProfile("bob@x.co"); err == nil || !strings.Contains(err.Error(), "profile not found") {
+		t.Errorf("bob@x.co loaded bob@x.com's profile: %v", err)
+	}
+	if _, err := pm.LoadProfile("bob"); err == nil {
+		t.Error("a name prefix loaded a profile")
+	}
+
+	loaded, err := pm.LoadProfile("bob@x.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Email != "bob@x.com" {
+		t.Errorf("loaded %s", loaded.Email)
+	}
+}
+
+func TestProfilesWithPrefix(t *testing.T) {
+	profiles := []*Profile{
+		{Name: "bob@x.co", Email: "bob@x.co"},
+		{Name: "bob@x.com", Email: "bob@x.com", Alias: "work"},
+	}
+
+	tests := []struct {
+		identifier string
+		want       []string
+	}{
+		{"bob@x.com", []string{"bob@x.com"}},
+		{"bob@x.co", []string{"bob@x.co", "bob@x.com"}},
+		{"BOB", []string{"bob@x.co", "bob@x.com"}},
+		{"wo", []string{"bob@x.com"}},
+		{"alice", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range ProfilesWithPrefix(profiles, tt.identifier) {
+			got = append(got, p.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q matched %v, want %v", tt.identifier, got, tt.want)
+		}
+	}
+}
+
+// writeLiveConfig sets ~/.claude.json to an account in an organization
+func writeLiveConfig(t *testing.T, email, organizationUuid string) {
+	t.Helper()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `{"oauthAccount":{"emailAddress":"` + email + `","organizationUuid":"` + organizationUuid + `"}}`
+	if err := os.WriteFile(filepath.Join(home, ".claude.json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteProfileKeepsOtherOrganizations(t *testing.T) {
+	pm := newTestManager(t)
+	saveTestProfile(t, pm, "me@x.com", "me@x.com", "org-acme")
+	saveTestProfile(t, pm, "me@x.com/beta", "me@x.com", "org-beta")
+
+	for _, org := range []string{"org-acme", "org-beta"} {
+		writeLiveConfig(t, "me@x.com", org)
+		if _, err := CreateRestorePoint("switch"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pm.DeleteProfile("me@x.com/beta"); err != nil {
+		t.Fatal(err)
+	}
+
+	points, err := ListRestorePoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].organizationUuid() != "org-acme" {
+		var orgs []string
+		for _, point := range points {
+			orgs = append(orgs, point.organizationUuid())
+		}
+		t.Fatalf("restore points left for organizations %v, want only org-acme", orgs)
+	}
+	if _, err := pm.LoadProfile("me@x.com"); err != nil {
+		t.Errorf("the acme profile is gone: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package profile
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// proxyEnvKeys are the settings.json env variables a proxy setting controls
+var proxyEnvKeys = []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"}
+
+// ProxySettings is a per-profile proxy written into settings.json's env block
+type ProxySettings struct {
+	// URL is used for both HTTPS_PROXY and HTTP_PROXY
+	URL string `json:"url,omitempty"`
+	// NoProxy lists hosts that bypass the proxy
+	NoProxy string `json:"no_proxy,omitempty"`
+	// Direct removes any proxy variables so the account never uses a proxy
+	Direct bool `json:"direct,omitempty"`
+}
+
+// Validate checks the proxy URL is usable
+func (p *ProxySettings) Validate() error {
+	if p.Direct {
+		return nil
+	}
+	parsed, err := url.Parse(p.URL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q (expected e.g. http://proxy.corp:8080)", p.URL)
+	}
+	return nil
+}
+
+// env returns the settings.json env entries; null entries remove a variable
+func (p *ProxySettings) env() map[string]interface{} {
+	env := make(map[string]interface{}, len(proxyEnvKeys))
+	if p.Direct {
+		for _, key := range proxyEnvKeys {
+			env[key] = nil
+		}
+		return env
+	}
+
+	env["HTTPS_PROXY"] = p.URL
+	env["HTTP_PROXY"] = p.URL
+	if p.NoProxy != "" {
+		env["NO_PROXY"] = p.NoProxy
+	} else {
+		env["NO_PROXY"] = nil
+	}
+	return env
+}
+
+// effectiveOverlay combines the profile's settings overlay with its proxy
+func (p *Profile) effectiveOverlay() config.SettingsOverlay {
+	if p.Proxy == nil {
+		return p.SettingsOverlay
+	}
+
+	overlay := make(config.SettingsOverlay, len(p.SettingsOverlay)+1)
+	for key, value := range p.SettingsOverlay {
+		overlay[key] = value
+	}
+
+	env := p.Proxy.env()
+	if existing, ok := overlay["env"].(map[string]interface{}); ok {
+		for key, value := range existing {
+			if _, set := env[key]; !set {
+				env[key] = value
+			}
+		}
+	}
+	overlay["env"] = env
+
+	return overlay
+}
+
+// SetProxy stores a profile's proxy; nil clears it. The active profile's
+// settings are re-applied immediately.
+func (s *Switcher) SetProxy(identifier string, proxy *ProxySettings) (*Profile, error) {
+	if proxy != nil {
+		if err := proxy.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Proxy = proxy
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	if s.isActiveProfile(profile) {
+		if err := applySettingsOverlay(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,104 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ReconcileAction records one change Reconcile made
+type ReconcileAction struct {
+	Profile string
+	Action  string
+}
+
+// Reconcile brings cflip in line with changes made outside it: the live
+// account is adopted when unknown or its stored snapshot refreshed when
+// stale, the active pointer is fixed, and references to profiles that no
+// longer exist are pruned
+func (s *Switcher) Reconcile() ([]ReconcileAction, error) {
+	var actions []ReconcileAction
+
+	state, err := s.DetectLive()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !state.LoggedIn():
+		// Nothing live to adopt or refresh
+	case state.Match == nil:
+		profile, err := s.SaveCurrentAccount(state.Email, "")
+		if err != nil {
+			return actions, fmt.Errorf("failed to adopt %s: %w", state.Email, err)
+		}
+		actions = append(actions, ReconcileAction{Profile: profile.Name, Action: "adopted the live account"})
+	case state.Match.Source == "":
+		synced, err := s.syncProfile(state.Match, nil)
+		if err != nil {
+			return actions, err
+		}
+		if synced.Changed() {
+			actions = append(actions, ReconcileAction{Profile: state.Match.Name, Action: "updated stale snapshot from the live account"})
+		}
+	}
+
+	// Adoption may already have moved the pointer, so look again
+	if state, err = s.DetectLive(); err != nil {
+		return actions, err
+	}
+	if state.LoggedIn() && !state.InSync() {
+		from := "nothing"
+		if state.Pointer != nil {
+			from = state.Pointer.Name
+		}
+		profile, err := s.ReconcilePointer()
+		if err != nil {
+			return actions, err
+		}
+		actions = append(actions, ReconcileAction{Profile: profile.Name, Action: fmt.Sprintf("marked active (was %s)", from)})
+	}
+
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return actions, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	fixed, err := s.repairConfigIndex(profiles)
+	if err != nil {
+		return actions, err
+	}
+	for _, result := range fixed {
+		actions = append(actions, ReconcileAction{Profile: result.Profile, Action: result.Problem + ": " + result.Action})
+	}
+
+	pruned, err := s.profileManager.pruneRegistry()
+	if err != nil {
+		return actions, err
+	}
+	for _, name := range pruned {
+		actions = append(actions, ReconcileAction{Profile: name, Action: "removed registry entry for a missing profile file"})
+	}
+
+	return actions, nil
+}
+
+// pruneRegistry drops registry entries whose profile file is gone
+func (pm *ProfileManager) pruneRegistry() ([]string, error) {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for name := range config.Registry {
+		if _, err := os.Stat(filepath.Join(pm.profilesDir, name)); os.IsNotExist(err) {
+			delete(config.Registry, name)
+			pruned = append(pruned, name)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+
+	return pruned, pm.SaveConfig(config)
+}
@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+)
+
+// RegistryEntry records the last write cflip made to a profile file, so
+// changes made outside cflip can be detected
+type RegistryEntry struct {
+	SHA256    string    `json:"sha256"`
+	WrittenBy string    `json:"written_by"`
+	WrittenAt time.Time `json:"written_at"`
+}
+
+// writerVersion identifies this cflip build in registry entries
+var writerVersion = "dev"
+
+// SetWriterVersion sets the cflip version recorded with every profile write
+func SetWriterVersion(version string) {
+	writerVersion = version
+}
+
+// warnedTampered avoids repeating the same warning within one command
+var warnedTampered = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+// contentHash returns the hex SHA-256 of a profile file's contents
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordWrite stores the hash and writer of a freshly written profile file,
+// and its token expiry for `cflip status`
+func (pm *ProfileManager) recordWrite(profile *Profile, profilePath string, data []byte) error {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if config.TokenExpiry == nil {
+		config.TokenExpiry = make(map[string]time.Time)
+	}
+	if profile.Credentials != nil && profile.Credentials.ClaudeAiOauth.ExpiresAt != 0 {
+		config.TokenExpiry[profile.Name] = profile.Credentials.ExpiresAtTime().UTC()
+	} else if profile.Credentials != nil {
+		delete(config.TokenExpiry, profile.Name)
+	}
+
+	if config.Registry == nil {
+		config.Registry = make(map[string]RegistryEntry)
+	}
+	config.Registry[filepath.Base(profilePath)] = RegistryEntry{
+		SHA256:    contentHash(data),
+		WrittenBy: "cflip " + writerVersion,
+		WrittenAt: time.Now(),
+	}
+
+	return pm.SaveConfig(config)
+}
+
+// verifyContent reports whether a profile file still matches what cflip last
+// wrote. Files written before the registry existed are trusted.
+func (pm *ProfileManager) verifyContent(profilePath string, data []byte) (bool, *RegistryEntry) {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return true, nil
+	}
+
+	entry, ok := config.Registry[filepath.Base(profilePath)]
+	if !ok {
+		return true, nil
+	}
+	return entry.SHA256 == contentHash(data), &entry
+}
+
+// markTampered flags a profile modified outside cflip and warns once
+func markTampered(profile *Profile, profilePath string, entry *RegistryEntry) {
+	profile.Tampered = true
+
+	name := filepath.Base(profilePath)
+	warnedTampered.Lock()
+	defer warnedTampered.Unlock()
+	if warnedTampered.names[name] {
+		return
+	}
+	warnedTampered.names[name] = true
+
+	logger.Notice("Profile %s was modified outside cflip since %s wrote it on %s; review it, then run `cflip validate --accept-modified`",
+		profile.Email, entry.WrittenBy, entry.WrittenAt.Format("2006-01-02 15:04"))
+}
+
+// AcceptModified re-records the current contents of every profile flagged as
+// modified outside cflip, returning the emails that were accepted
+func (s *Switcher) AcceptModified() ([]string, error) {
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []string
+	for _, profile := range profiles {
+		if !profile.Tampered {
+			continue
+		}
+		if err := s.profileManager.writeProfile(profile); err != nil {
+			return accepted, fmt.Errorf("failed to accept %s: %w", profile.Email, err)
+		}
+		accepted = append(accepted, profile.Email)
+	}
+
+	return accepted, nil
+}
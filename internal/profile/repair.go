@@ -0,0 +1,268 @@
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/auth"
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// RepairResult records one problem found by RepairProfiles and whether it was fixed
+type RepairResult struct {
+	Profile string
+	Problem string
+	Action  string
+	Fixed   bool
+}
+
+// RefreshProfile exchanges a profile's refresh token for new tokens and saves
+// them. When the profile is the active account, Claude Code's live
+// credentials are updated too, since the old refresh token stops working.
+// The lock is held throughout: a refresh token is single-use, so a switch
+// or another refresh in between would lose the new tokens.
+func (s *Switcher) RefreshProfile(identifier string) (*Profile, error) {
+	s, unlock, err := s.locked()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Credentials == nil || profile.Credentials.ClaudeAiOauth.RefreshToken == "" {
+		return nil, fmt.Errorf("profile %s has no refresh token", profile.Name)
+	}
+
+	active := s.isActiveProfile(profile)
+	if active {
+		// Claude Code may have rotated the tokens since they were stored
+		if live, err := config.CaptureCredentials(); err == nil && live.ClaudeAiOauth.RefreshToken != "" {
+			profile.Credentials = live
+		}
+	}
+
+	issued := time.Now()
+	token, err := auth.Refresh(profile.Credentials.ClaudeAiOauth.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Credentials.ClaudeAiOauth.AccessToken = token.AccessToken
+	profile.Credentials.ClaudeAiOauth.RefreshToken = token.RefreshToken
+	profile.Credentials.ClaudeAiOauth.ExpiresAt = token.ExpiresAt(issued).UnixMilli()
+	if scopes := token.Scopes(); len(scopes) > 0 {
+		profile.Credentials.ClaudeAiOauth.Scopes = scopes
+	}
+
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed profile: %w", err)
+	}
+
+	// Claude Code may have logged in as another account during the refresh
+	if active && s.isActiveProfile(profile) && s.liveIsAccount(profile) {
+		if err := s.saveCredentials(profile.Credentials); err != nil {
+			return nil, fmt.Errorf("failed to update Claude Code credentials: %w", err)
+		}
+	}
+
+	return profile, nil
+}
+
+// isActiveProfile reports whether profile is the locally stored active account
+func (s *Switcher) isActiveProfile(profile *Profile) bool {
+	cfg, err := s.profileManager.LoadConfig()
+	return err == nil && cfg.ActiveSource == "" && cfg.ActiveProfile == profile.Name
+}
+
+// RepairProfiles finds and, where possible, fixes problems with stored
+// profiles: expired tokens are refreshed, the active account's oauthAccount
+// block is re-captured from Claude Code, and config.json entries are rebuilt
+// from the profile files
+func (s *Switcher) RepairProfiles() ([]RepairResult, error) {
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	var results []RepairResult
+	for _, profile := range profiles {
+		results = append(results, s.repairProfile(profile)...)
+	}
+
+	configResults, err := s.repairConfigIndex(profiles)
+	if err != nil {
+		return results, err
+	}
+
+	return append(results, configResults...), nil
+}
+
+// repairProfile fixes one profile's account block and tokens
+func (s *Switcher) repairProfile(profile *Profile) []RepairResult {
+	var results []RepairResult
+
+	var oauthAccount map[string]interface{}
+	if profile.ClaudeConfig != nil {
+		oauthAccount, _ = (*profile.ClaudeConfig)["oauthAccount"].(map[string]interface{})
+	}
+	if oauthAccount == nil || profile.ClaudeConfig.GetAccountUuid() == "" {
+		result := RepairResult{Profile: profile.Name, Problem: "missing or incomplete oauthAccount block"}
+		if err := s.recaptureAccount(profile); err != nil {
+			result.Action = err.Error()
+		} else {
+			result.Action = "re-captured from the logged-in Claude Code account"
+			result.Fixed = true
+		}
+		results = append(results, result)
+	}
+
+	if profile.Credentials == nil || profile.Credentials.ClaudeAiOauth.AccessToken == "" {
+		return append(results, RepairResult{
+			Profile: profile.Name,
+			Problem: "no access token",
+			Action:  "log in to this account in Claude Code and run `cflip add`",
+		})
+	}
+
+	if profile.Credentials.IsExpired() {
+		result := RepairResult{
+			Profile: profile.Name,
+			Problem: fmt.Sprintf("access token expired %s", profile.Credentials.ExpiresAtTime().Format("2006-01-02 15:04")),
+		}
+		if refreshed, err := s.RefreshProfile(profile.Name); err != nil {
+			result.Action = fmt.Sprintf("refresh failed (%v); log in again and run `cflip add`", err)
+		} else {
+			result.Action = fmt.Sprintf("refreshed, valid until %s", refreshed.Credentials.ExpiresAtTime().Format("2006-01-02 15:04"))
+			result.Fixed = true
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// recaptureAccount copies the oauthAccount block from Claude Code's live
+// config, which is only safe when the profile is the logged-in account
+func (s *Switcher) recaptureAccount(profile *Profile) error {
+	live, err := config.LoadClaudeConfig()
+	if err != nil {
+		return fmt.Errorf("cannot read Claude Code config: %v", err)
+	}
+	if live.GetUserEmail() != profile.Email || live.GetAccountUuid() == "" {
+		return fmt.Errorf("switch to this account in Claude Code, then rerun `cflip validate --fix`")
+	}
+
+	if profile.ClaudeConfig == nil {
+		delete(*live, "_cflip_credentials")
+		profile.ClaudeConfig = live
+	} else {
+		(*profile.ClaudeConfig)["oauthAccount"] = (*live)["oauthAccount"]
+	}
+	profile.AccountUuid = live.GetAccountUuid()
+
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return fmt.Errorf("failed to save profile: %v", err)
+	}
+	return nil
+}
+
+// repairConfigIndex makes config.json's profile index match the profile files
+func (s *Switcher) repairConfigIndex(profiles []*Profile) ([]RepairResult, error) {
+	cfg, err := s.profileManager.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []RepairResult
+	known := make(map[string]bool)
+	indexChanged := cfg.ProfileAliases == nil
+	if cfg.TokenExpiry == nil {
+		cfg.TokenExpiry = make(map[string]time.Time)
+	}
+	for _, profile := range profiles {
+		known[profile.Name] = true
+		if alias, ok := cfg.ProfileAliases[profile.Name]; alias != profile.Alias || ok != (profile.Alias != "") {
+			setProfileAlias(cfg, profile.Name, profile.Alias)
+			indexChanged = true
+		}
+		if profile.Credentials != nil && profile.Credentials.ClaudeAiOauth.ExpiresAt != 0 {
+			if expiresAt := profile.Credentials.ExpiresAtTime().UTC(); !cfg.TokenExpiry[profile.Name].Equal(expiresAt) {
+				cfg.TokenExpiry[profile.Name] = expiresAt
+				indexChanged = true
+			}
+		}
+		if email, ok := cfg.Profiles[profile.Name]; !ok || email != profile.Email {
+			cfg.Profiles[profile.Name] = profile.Email
+			results = append(results, RepairResult{
+				Profile: profile.Name,
+				Problem: "missing from config.json",
+				Action:  "entry rebuilt from the profile file",
+				Fixed:   true,
+			})
+		}
+	}
+
+	for name := range cfg.ProfileAliases {
+		if !known[name] {
+			delete(cfg.ProfileAliases, name)
+			indexChanged = true
+		}
+	}
+	for name := range cfg.TokenExpiry {
+		if !known[name] {
+			delete(cfg.TokenExpiry, name)
+			indexChanged = true
+		}
+	}
+	for name := range cfg.Cooldowns {
+		if !known[name] {
+			delete(cfg.Cooldowns, name)
+			indexChanged = true
+		}
+	}
+	for _, name := range slices.Clone(cfg.Order) {
+		if !known[name] {
+			removeOrder(cfg, name)
+			indexChanged = true
+		}
+	}
+	if cfg.Order != nil {
+		for _, profile := range profiles {
+			if !slices.Contains(cfg.Order, profile.Name) {
+				cfg.Order = append(cfg.Order, profile.Name)
+				indexChanged = true
+			}
+		}
+	}
+	for name := range cfg.Profiles {
+		if !known[name] {
+			delete(cfg.Profiles, name)
+			results = append(results, RepairResult{
+				Profile: name,
+				Problem: "config.json entry has no profile file",
+				Action:  "stale entry removed",
+				Fixed:   true,
+			})
+		}
+	}
+
+	if cfg.ActiveSource == "" && cfg.ActiveProfile != "" && !known[cfg.ActiveProfile] {
+		results = append(results, RepairResult{
+			Profile: cfg.ActiveProfile,
+			Problem: "active profile no longer exists",
+			Action:  "active marker cleared; run `cflip add` or `cflip switch`",
+			Fixed:   true,
+		})
+		cfg.ActiveProfile = ""
+	}
+
+	if len(results) == 0 && !indexChanged {
+		return nil, nil
+	}
+
+	return results, s.profileManager.SaveConfig(cfg)
+}
@@ -0,0 +1,328 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/fsutil"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// RestorePointDirName is the data directory subfolder holding pre-switch
+// snapshots of Claude Code's live state
+const RestorePointDirName = "restore-points"
+
+// maxRestorePoints bounds how many snapshots are kept; the oldest go first
+const maxRestorePoints = 20
+
+// restorePointLabel binds sealed restore point credentials to their use
+const restorePointLabel = "restore-point"
+
+func init() {
+	storage.RegisterSealedItems(restorePointSealedItems)
+}
+
+// RestorePoint is a verbatim snapshot of ~/.claude.json and the live
+// credentials taken before cflip overwrote them
+type RestorePoint struct {
+	CreatedAt time.Time `json:"created_at"`
+	Email     string    `json:"email,omitempty"`
+	Reason    string    `json:"reason"`
+	// ClaudeConfig and Credentials are kept as raw JSON so fields cflip does
+	// not model survive a restore
+	ClaudeConfig json.RawMessage `json:"claude_config,omitempty"`
+	// Credentials is only written in plaintext by older versions; they are
+	// kept in SealedCredentials, sealed with storage.SealLocal
+	Credentials       json.RawMessage `json:"credentials,omitempty"`
+	SealedCredentials string          `json:"credentials_sealed,omitempty"`
+
+	// Path is the snapshot's file (never persisted)
+	Path string `json:"-"`
+}
+
+// organizationUuid is the organization of the snapshotted account
+func (p *RestorePoint) organizationUuid() string {
+	var claudeConfig config.ClaudeConfig
+	if json.Unmarshal(p.ClaudeConfig, &claudeConfig) != nil {
+		return ""
+	}
+	return claudeConfig.GetOrganizationUuid()
+}
+
+// belongsTo reports whether the point snapshots profile's account in
+// profile's organization
+func (p *RestorePoint) belongsTo(profile *Profile) bool {
+	return profile.sameKey(&Profile{Email: p.Email, OrganizationUuid: p.organizationUuid()})
+}
+
+// restorePointDir returns the restore point directory inside the data dir
+func restorePointDir() (string, error) {
+	dataDir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, RestorePointDirName), nil
+}
+
+// claudeConfigPath is the file SaveClaudeConfig writes
+func claudeConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".claude.json"), nil
+}
+
+// CreateRestorePoint snapshots the live Claude config and credentials. It
+// returns nil without writing anything when there is no live state.
+func CreateRestorePoint(reason string) (*RestorePoint, error) {
+	point := &RestorePoint{CreatedAt: time.Now(), Reason: reason}
+
+	configPath, err := claudeConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(configPath)
+	switch {
+	case err == nil && json.Valid(data):
+		point.ClaudeConfig = data
+	case err != nil && !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+
+	raw, err := storage.NewSecureStorage().Capture()
+	switch {
+	case err == nil && json.Valid([]byte(raw)):
+		point.Credentials = json.RawMessage(raw)
+	case err != nil && !errors.Is(err, storage.ErrNotFound):
+		return nil, fmt.Errorf("failed to read live credentials: %w", err)
+	}
+
+	if point.ClaudeConfig == nil && point.Credentials == nil {
+		return nil, nil
+	}
+	if point.Credentials != nil {
+		sealed, err := storage.SealLocal(restorePointLabel, point.Credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt live credentials: %w", err)
+		}
+		point.SealedCredentials = string(sealed)
+	}
+
+	var live struct {
+		OAuthAccount struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"oauthAccount"`
+	}
+	if json.Unmarshal(point.ClaudeConfig, &live) == nil {
+		point.Email = live.OAuthAccount.EmailAddress
+	}
+
+	dir, err := restorePointDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create restore point directory: %w", err)
+	}
+
+	point.Path = filepath.Join(dir, point.CreatedAt.UTC().Format("20060102T150405.000000000Z")+".json")
+	if err := point.save(); err != nil {
+		return nil, err
+	}
+
+	pruneRestorePoints(dir)
+	return point, nil
+}
+
+// save writes the snapshot to its file, with only the sealed credentials
+func (p *RestorePoint) save() error {
+	onDisk := *p
+	onDisk.Credentials = nil
+	encoded, err := json.MarshalIndent(&onDisk, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal restore point: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(p.Path, encoded, 0o600); err != nil {
+		return fmt.Errorf("failed to write restore point: %w", err)
+	}
+	return nil
+}
+
+// liveCredentials returns the snapshot's credentials, decrypting them
+func (p *RestorePoint) liveCredentials() ([]byte, error) {
+	if p.SealedCredentials == "" {
+		return p.Credentials, nil
+	}
+	return storage.OpenLocal(restorePointLabel, []byte(p.SealedCredentials))
+}
+
+// restorePointSealedItems lists the snapshots' credentials for
+// re-encryption, including plaintext ones from older versions
+func restorePointSealedItems() ([]storage.SealedItem, error) {
+	points, err := ListRestorePoints()
+	if err != nil {
+		return nil, err
+	}
+	var items []storage.SealedItem
+	for _, point := range points {
+		data := []byte(point.SealedCredentials)
+		if point.SealedCredentials == "" {
+			data = point.Credentials
+		}
+		if data == nil {
+			continue
+		}
+		items = append(items, storage.SealedItem{
+			Label: restorePointLabel,
+			Data:  data,
+			Replace: func(sealed []byte) error {
+				point.Credentials, point.SealedCredentials = nil, string(sealed)
+				return point.save()
+			},
+		})
+	}
+	return items, nil
+}
+
+// ListRestorePoints returns the stored snapshots, newest first
+func ListRestorePoints() ([]*RestorePoint, error) {
+	dir, err := restorePointDir()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := restorePointFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []*RestorePoint
+	for i := len(names) - 1; i >= 0; i-- {
+		path := filepath.Join(dir, names[i])
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var point RestorePoint
+		if err := json.Unmarshal(data, &point); err != nil {
+			continue // Skip damaged snapshots
+		}
+		point.Path = path
+		points = append(points, &point)
+	}
+
+	return points, nil
+}
+
+// restorePointFiles lists snapshot file names oldest first; their
+// timestamped names sort chronologically
+func restorePointFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read restore points: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pruneRestorePoints deletes the oldest snapshots beyond maxRestorePoints
+func pruneRestorePoints(dir string) {
+	names, err := restorePointFiles(dir)
+	if err != nil {
+		return
+	}
+	for len(names) > maxRestorePoints {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+}
+
+// RestoreFromPoint writes a snapshot back over the live Claude config and
+// credentials. The current state is snapshotted first, so the restore can
+// itself be undone.
+func (s *Switcher) RestoreFromPoint(point *RestorePoint) error {
+	credentials, err := point.liveCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to decrypt the restore point's credentials: %w", err)
+	}
+	if _, err := CreateRestorePoint("restore-config"); err != nil {
+		return fmt.Errorf("failed to create restore point: %w", err)
+	}
+
+	if point.ClaudeConfig != nil {
+		configPath, err := claudeConfigPath()
+		if err != nil {
+			return err
+		}
+		if err := fsutil.WriteFileAtomic(configPath, point.ClaudeConfig, 0o600); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", configPath, err)
+		}
+	}
+
+	if credentials != nil {
+		if err := writeLiveCredentials(credentials); err != nil {
+			return fmt.Errorf("failed to restore credentials: %w", err)
+		}
+	}
+
+	// Point cflip's active marker at the restored account, if it is stored
+	// under exactly that email and organization
+	if point.Email != "" {
+		if stored, err := s.FindProfileByAccount(point.Email, point.organizationUuid()); err == nil {
+			return s.profileManager.SetActiveProfile(stored.Name)
+		}
+	}
+	cfg, err := s.profileManager.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.ActiveProfile = ""
+	cfg.ActiveSource = ""
+	return s.profileManager.SaveConfig(cfg)
+}
+
+// writeLiveCredentials stores raw credentials JSON where Claude Code reads it
+func writeLiveCredentials(data []byte) error {
+	switch runtime.GOOS {
+	case "darwin":
+		user := os.Getenv("USER")
+		if user == "" {
+			user = "default"
+		}
+		return storage.NewSecureStorage().Store(user, string(data))
+	case "linux":
+		if storage.LinuxCredentialBackend() == storage.BackendSecretService {
+			return storage.SecretServiceStore(storage.CredentialAccount(), string(data))
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		dir := filepath.Join(home, ".claude")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("failed to create credentials directory: %w", err)
+		}
+		return fsutil.WriteFileAtomic(filepath.Join(dir, ".credentials.json"), data, 0o600)
+	default:
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+}
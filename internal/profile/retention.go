@@ -0,0 +1,231 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveDirName is the subdirectory of the profiles directory holding archived profiles
+const ArchiveDirName = "archive"
+
+// ArchivedProfile is a profile moved to the archive by a retention run
+type ArchivedProfile struct {
+	Profile    *Profile
+	ArchivedAt time.Time
+	path       string
+}
+
+// ArchiveProfile moves a profile out of the active store into the archive
+// directory. The file's modification time records when it was archived.
+func (pm *ProfileManager) ArchiveProfile(identifier string) error {
+	_, archivedPath, err := pm.moveProfile(identifier, ArchiveDirName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return os.Chtimes(archivedPath, now, now)
+}
+
+// ListArchive returns the profiles moved to the archive by retention runs
+func (pm *ProfileManager) ListArchive() ([]ArchivedProfile, error) {
+	dir := filepath.Join(pm.profilesDir, ArchiveDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var archived []ArchivedProfile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".profile") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		profile, err := readMovedProfile(path)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			continue
+		}
+		archived = append(archived, ArchivedProfile{Profile: profile, ArchivedAt: info.ModTime(), path: path})
+	}
+	return archived, nil
+}
+
+// UnarchiveProfile moves an archived profile back into the store
+func (pm *ProfileManager) UnarchiveProfile(identifier string) (*Profile, error) {
+	pm, unlock, err := pm.locked()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	archived, err := pm.ListArchive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range archived {
+		profile := item.Profile
+		if profile.Name != identifier && profile.Email != identifier && profile.Alias != identifier {
+			continue
+		}
+		if err := pm.restoreMovedProfile(profile, item.path); err != nil {
+			return nil, err
+		}
+		return profile, nil
+	}
+
+	return nil, fmt.Errorf("no archived account matches %s", identifier)
+}
+
+// moveProfile moves a profile file into a subdirectory of the profiles
+// directory and drops it from config.json, returning the profile and its
+// new path. It runs under the data directory lock, so config.json is not
+// rewritten from a stale copy.
+func (pm *ProfileManager) moveProfile(identifier, dirName string) (*Profile, string, error) {
+	pm, unlock, err := pm.locked()
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	profilePath, err := pm.findProfilePath(identifier)
+	if err != nil {
+		return nil, "", err
+	}
+
+	profile, err := pm.LoadProfile(identifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	dir := filepath.Join(pm.profilesDir, dirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, "", fmt.Errorf("failed to create %s directory: %w", dirName, err)
+	}
+
+	movedPath := filepath.Join(dir, filepath.Base(profilePath))
+	if err := os.Rename(profilePath, movedPath); err != nil {
+		return nil, "", fmt.Errorf("failed to move profile file to %s: %w", dirName, err)
+	}
+
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	delete(config.Profiles, profile.Name)
+	delete(config.ProfileAliases, profile.Name)
+	delete(config.TokenExpiry, profile.Name)
+	delete(config.Cooldowns, profile.Name)
+	removeOrder(config, profile.Name)
+	delete(config.Registry, filepath.Base(profilePath))
+	if config.ActiveProfile == profile.Name {
+		config.ActiveProfile = ""
+	}
+	if config.PreviousProfile == profile.Name {
+		config.PreviousProfile = ""
+	}
+
+	return profile, movedPath, pm.SaveConfig(config)
+}
+
+// lastUsed returns the best available timestamp of when a profile was last used
+func (p *Profile) lastUsed() time.Time {
+	if !p.LastActiveAt.IsZero() {
+		return p.LastActiveAt
+	}
+	if !p.UpdatedAt.IsZero() {
+		return p.UpdatedAt
+	}
+	return p.CreatedAt
+}
+
+// checkAdmission rejects profiles that violate the retention policy before saving
+func checkAdmission(policy RetentionPolicy, profile *Profile) error {
+	if policy.RejectExpiredTokens && profile.Credentials != nil && profile.Credentials.IsExpired() {
+		return fmt.Errorf("refusing to store profile %s: access token already expired (retention.reject_expired_tokens is enabled)", profile.Email)
+	}
+	return nil
+}
+
+// EnforceResult describes what a retention run did (or would do) to one profile
+type EnforceResult struct {
+	Profile *Profile
+	Action  string
+	Reason  string
+}
+
+// EnforceRetention applies the retention policy to all stored profiles.
+// The active profile is never archived. When dryRun is set nothing is changed.
+func (s *Switcher) EnforceRetention(dryRun bool) ([]EnforceResult, error) {
+	settings, err := s.profileManager.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	policy := settings.Retention
+
+	if !dryRun {
+		if err := s.profileManager.PurgeTrash(); err != nil {
+			return nil, err
+		}
+	}
+
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	activeName := ""
+	if active, err := s.profileManager.GetActiveProfile(); err == nil {
+		activeName = active.Name
+	}
+
+	var results []EnforceResult
+	for _, profile := range profiles {
+		if profile.Name == activeName {
+			continue
+		}
+
+		if policy.ArchiveInactiveDays > 0 {
+			cutoff := time.Now().AddDate(0, 0, -policy.ArchiveInactiveDays)
+			if profile.lastUsed().Before(cutoff) {
+				result := EnforceResult{
+					Profile: profile,
+					Action:  "archive",
+					Reason:  fmt.Sprintf("inactive since %s", profile.lastUsed().Format("2006-01-02")),
+				}
+				if !dryRun {
+					if err := s.profileManager.ArchiveProfile(profile.Name); err != nil {
+						return results, fmt.Errorf("failed to archive %s: %w", profile.Email, err)
+					}
+				}
+				results = append(results, result)
+				continue
+			}
+		}
+
+		if policy.RejectExpiredTokens && profile.Credentials != nil && profile.Credentials.IsExpired() {
+			results = append(results, EnforceResult{
+				Profile: profile,
+				Action:  "warn",
+				Reason:  "stored access token has expired",
+			})
+		}
+	}
+
+	return results, nil
+}
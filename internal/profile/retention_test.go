@@ -0,0 +1,108 @@
+package profile
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+func TestEnforceRetentionArchivesInactiveProfiles(t *testing.T) {
+	pm := newTestManager(t)
+	stale := saveTestProfile(t, pm, "old@x.com", "old@x.com", "")
+	stale.LastActiveAt = time.Now().AddDate(0, 0, -100)
+	if err := pm.SaveProfile(stale); err != nil {
+		t.Fatal(err)
+	}
+	saveTestProfile(t, pm, "new@x.com", "new@x.com", "")
+
+	cfg, err := pm.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Settings.Retention.ArchiveInactiveDays = 90
+	if err := pm.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	s := &Switcher{profileManager: pm}
+
+	results, err := s.EnforceRetention(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Action != "archive" || results[0].Profile.Email != "old@x.com" {
+		t.Fatalf("dry run = %+v, want old@x.com archived", results)
+	}
+	if _, err := pm.LoadProfile("old@x.com"); err != nil {
+		t.Fatalf("the dry run archived the profile: %v", err)
+	}
+
+	if _, err := s.EnforceRetention(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.LoadProfile("old@x.com"); err == nil {
+		t.Fatal("the inactive profile is still stored")
+	}
+	if _, err := pm.LoadProfile("new@x.com"); err != nil {
+		t.Fatalf("the recently used profile was archived: %v", err)
+	}
+	cfg, err = pm.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Profiles["old@x.com"]; ok {
+		t.Error("config.json still lists the archived profile")
+	}
+
+	archived, err := pm.ListArchive()
+	if err != nil || len(archived) != 1 || archived[0].Profile.Email != "old@x.com" {
+		t.Fatalf("archive = %v, %v", archived, err)
+	}
+	if _, err := pm.UnarchiveProfile("old@x.com"); err != nil {
+		t.Fatalf("unarchiving: %v", err)
+	}
+	if _, err := pm.LoadProfile("old@x.com"); err != nil {
+		t.Fatalf("the unarchived profile is not stored: %v", err)
+	}
+}
+
+func TestArchiveProfileKeepsConcurrentSaves(t *testing.T) {
+	pm := newTestManager(t)
+	for i := 0; i < 4; i++ {
+		saveTestProfile(t, pm, fmt.Sprintf("old%d@x.com", i), fmt.Sprintf("old%d@x.com", i), "")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := pm.ArchiveProfile(fmt.Sprintf("old%d@x.com", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			email := fmt.Sprintf("new%d@x.com", i)
+			if err := pm.SaveProfile(&Profile{Name: email, Email: email, ClaudeConfig: &config.ClaudeConfig{}, Credentials: &config.Credentials{}}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := pm.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, ok := stored.Profiles[fmt.Sprintf("new%d@x.com", i)]; !ok {
+			t.Errorf("archiving dropped new%d@x.com from config.json", i)
+		}
+		if _, ok := stored.Profiles[fmt.Sprintf("old%d@x.com", i)]; ok {
+			t.Errorf("config.json still lists archived old%d@x.com", i)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RotationSettings defines scheduled account rotation rules
+type RotationSettings struct {
+	// Rules are evaluated in order; the first matching rule decides the target
+	Rules []RotationRule `json:"rules,omitempty"`
+	// Hooks are shell commands run after every rotation. CFLIP_FROM and
+	// CFLIP_TO are set in their environment.
+	Hooks []string `json:"hooks,omitempty"`
+	// Weights overrides the subscription-tier weight of profiles, keyed by
+	// name, alias or email. A weight of 0 keeps a profile out of rotation.
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// Daemon has `cflip daemon` apply the rules every minute, so no cron
+	// job running `cflip rotate` is needed
+	Daemon bool `json:"daemon,omitempty"`
+}
+
+// RotationRule is a single schedule entry. A rule matches when all of its
+// set conditions hold:
+//   - Between: local time window "HH:MM-HH:MM" (may wrap past midnight)
+//   - Days: weekday names ("mon".."sun") the rule applies on
+//   - Every: the active account has been in use at least this long (e.g. "5h")
+//
+// Account names the target; when empty the next account in sequence is used.
+type RotationRule struct {
+	Name    string   `json:"name,omitempty"`
+	Every   string   `json:"every,omitempty"`
+	Between string   `json:"between,omitempty"`
+	Days    []string `json:"days,omitempty"`
+	Account string   `json:"account,omitempty"`
+}
+
+// Label returns a human-readable identifier for the rule
+func (r RotationRule) Label(index int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("rule #%d", index+1)
+}
+
+// Matches reports whether the rule applies at now given the active profile
+func (r RotationRule) Matches(now time.Time, active *Profile) (bool, error) {
+	if len(r.Days) > 0 {
+		today := strings.ToLower(now.Weekday().String()[:3])
+		found := false
+		for _, day := range r.Days {
+			if strings.ToLower(day)[:min(3, len(day))] == today {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if r.Between != "" {
+		inWindow, err := inTimeWindow(r.Between, now)
+		if err != nil {
+			return false, err
+		}
+		if !inWindow {
+			return false, nil
+		}
+	}
+
+	if r.Every != "" {
+		every, err := time.ParseDuration(r.Every)
+		if err != nil {
+			return false, fmt.Errorf("invalid rotation interval %q: %w", r.Every, err)
+		}
+		if active != nil && now.Sub(active.lastUsed()) < every {
+			return false, nil
+		}
+	}
+
+	// An account-pinned rule is satisfied once that account is active
+	if r.Account != "" && active != nil &&
+		(active.Name == r.Account || active.Email == r.Account || active.Alias == r.Account) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// inTimeWindow checks whether now falls within a "HH:MM-HH:MM" window
+func inTimeWindow(window string, now time.Time) (bool, error) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return false, fmt.Errorf("invalid time window %q (expected HH:MM-HH:MM)", window)
+	}
+
+	start, err := time.Parse("15:04", strings.TrimSpace(parts[0]))
+	if err != nil {
+		return false, fmt.Errorf("invalid time window start %q: %w", parts[0], err)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(parts[1]))
+	if err != nil {
+		return false, fmt.Errorf("invalid time window end %q: %w", parts[1], err)
+	}
+
+	current := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from <= to {
+		return current >= from && current < to, nil
+	}
+	// Window wraps past midnight
+	return current >= from || current < to, nil
+}
+
+// RotationDecision is the outcome of evaluating rotation rules
+type RotationDecision struct {
+	Rule   string
+	Target *Profile
+}
+
+// EvaluateRotation returns the profile the schedule wants active right now,
+// or nil when no rule requires a change.
+func (s *Switcher) EvaluateRotation(now time.Time) (*RotationDecision, error) {
+	settings, err := s.profileManager.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	active, _ := s.profileManager.GetActiveProfile()
+
+	for i, rule := range settings.Rotation.Rules {
+		matched, err := rule.Matches(now, active)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rule.Label(i), err)
+		}
+		if !matched {
+			continue
+		}
+
+		var target *Profile
+		if rule.Account != "" {
+			target, err = s.profileManager.LoadProfile(rule.Account)
+		} else {
+			target, err = s.GetNextProfile()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to resolve target account: %w", rule.Label(i), err)
+		}
+
+		if active != nil && target.Name == active.Name {
+			return nil, nil
+		}
+
+		return &RotationDecision{Rule: rule.Label(i), Target: target}, nil
+	}
+
+	return nil, nil
+}
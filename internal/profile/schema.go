@@ -0,0 +1,111 @@
+package profile
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/schema"
+)
+
+//go:embed schema/*.json
+var schemaFiles embed.FS
+
+var (
+	profileSchema = schema.MustParse(mustReadSchema("profile.schema.json"))
+	configSchema  = schema.MustParse(mustReadSchema("config.schema.json"))
+)
+
+// ProfileSchemaVersion is the layout of the .profile files this cflip
+// writes. Files without schema_version are version 1 and read as they are:
+// version 2 only added tags and notes. Files from a newer cflip can be read
+// but are not rewritten, so fields this version does not know survive.
+const ProfileSchemaVersion = 2
+
+// checkSchemaVersion refuses to rewrite a profile read from a newer layout
+func checkSchemaVersion(profile *Profile) error {
+	if profile.SchemaVersion > ProfileSchemaVersion {
+		return fmt.Errorf("%s was written by a newer cflip (profile schema version %d, this cflip writes %d); upgrade cflip before changing it",
+			profile.Email, profile.SchemaVersion, ProfileSchemaVersion)
+	}
+	return nil
+}
+
+// mustReadSchema reads an embedded schema file
+func mustReadSchema(name string) []byte {
+	data, err := schemaFiles.ReadFile("schema/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// ProfileSchema returns the embedded JSON Schema for profile files
+func ProfileSchema() []byte {
+	return mustReadSchema("profile.schema.json")
+}
+
+// ConfigSchema returns the embedded JSON Schema for config.json
+func ConfigSchema() []byte {
+	return mustReadSchema("config.schema.json")
+}
+
+// SchemaReport lists the structural problems found in one file
+type SchemaReport struct {
+	Path       string
+	Violations []schema.Violation
+}
+
+// checkSchema validates data and wraps violations with the file they came from
+func checkSchema(s *schema.Schema, path string, data []byte) error {
+	if err := s.Validate(data); err != nil {
+		return fmt.Errorf("%s failed schema validation: %w (run `cflip validate --schema`)", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// ValidateSchemas checks config.json and every profile file against the
+// embedded schemas, including files cflip would otherwise skip as unreadable
+func (s *Switcher) ValidateSchemas() ([]SchemaReport, error) {
+	pm := s.profileManager
+	var reports []SchemaReport
+
+	check := func(sch *schema.Schema, path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				reports = append(reports, SchemaReport{Path: path, Violations: []schema.Violation{{Path: "$", Message: err.Error()}}})
+			}
+			return
+		}
+
+		if sch == profileSchema {
+			if data, err = decodeProfileData(path, data); err != nil {
+				reports = append(reports, SchemaReport{Path: path, Violations: []schema.Violation{{Path: "$", Message: err.Error()}}})
+				return
+			}
+		}
+
+		var schemaErr *schema.Error
+		if err := sch.Validate(data); errors.As(err, &schemaErr) {
+			reports = append(reports, SchemaReport{Path: path, Violations: schemaErr.Violations})
+		}
+	}
+
+	check(configSchema, pm.configPath)
+
+	entries, err := os.ReadDir(pm.profilesDir)
+	if err != nil {
+		return reports, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".profile") {
+			check(profileSchema, filepath.Join(pm.profilesDir, entry.Name()))
+		}
+	}
+
+	return reports, nil
+}
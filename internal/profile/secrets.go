@@ -0,0 +1,178 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// Where stored profiles keep their credentials (settings.profile_credentials)
+const (
+	// CredentialStoreSecure keeps tokens in the configured storage backend
+	// (see storage.ProfileBackend), leaving only metadata in the profile file
+	CredentialStoreSecure = "secure"
+	// CredentialStoreInline keeps tokens inside the profile file, e.g. when
+	// profiles are encrypted with sops
+	CredentialStoreInline = "inline"
+)
+
+// profileSecrets opens the storage profile credentials are kept in
+var profileSecrets = storage.NewProfileStorage
+
+// credentialKey is the secure storage key for a profile file. Archived and
+// trashed copies keep their file name, so they share the key.
+func credentialKey(profilePath string) string {
+	return strings.TrimSuffix(filepath.Base(profilePath), ".profile")
+}
+
+// credentialStore returns where profile writes keep credentials
+func (pm *ProfileManager) credentialStore() string {
+	settings, err := pm.LoadSettings()
+	if err == nil && settings.ProfileCredentials == CredentialStoreInline {
+		return CredentialStoreInline
+	}
+	return CredentialStoreSecure
+}
+
+// splitCredentials moves a profile's credentials into secure storage and
+// returns the copy to write to disk, or the profile itself when credentials
+// stay inline
+func (pm *ProfileManager) splitCredentials(profilePath string, profile *Profile) (*Profile, error) {
+	secrets := profileSecrets()
+	if pm.credentialStore() == CredentialStoreInline || secrets == nil {
+		if secrets != nil && profile.CredentialStore == CredentialStoreSecure && profile.Credentials != nil {
+			// Written inline from now on; the secure copy would only go stale
+			if err := secrets.Delete(credentialKey(profilePath)); err != nil {
+				return nil, fmt.Errorf("failed to remove credentials from secure storage: %w", err)
+			}
+			profile.CredentialStore = ""
+		}
+		return profile, nil
+	}
+
+	if profile.Credentials == nil {
+		// Credentials that could not be loaded are left where they are
+		return profile, nil
+	}
+
+	data, err := json.Marshal(profile.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	if err := secrets.Store(credentialKey(profilePath), string(data)); err != nil {
+		return nil, fmt.Errorf("failed to store credentials securely: %w", err)
+	}
+
+	profile.CredentialStore = CredentialStoreSecure
+	onDisk := *profile
+	onDisk.Credentials = nil
+	return &onDisk, nil
+}
+
+// attachCredentials loads the credentials of a profile read from
+// profilePath when they are kept in secure storage. A missing item, or one
+// behind the locked vault, leaves Credentials nil, which validation reports.
+func attachCredentials(profilePath string, profile *Profile) error {
+	if profile.CredentialStore != CredentialStoreSecure {
+		return nil
+	}
+	secrets := profileSecrets()
+	if secrets == nil {
+		return fmt.Errorf("%s keeps its credentials in secure storage, but storage backend %s is not available here", profile.Name, storage.ProfileBackend())
+	}
+
+	data, err := secrets.Retrieve(credentialKey(profilePath))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if errors.Is(err, storage.ErrLocked) {
+		profile.Locked = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load credentials for %s: %w", profile.Name, err)
+	}
+
+	var credentials config.Credentials
+	if err := json.Unmarshal([]byte(data), &credentials); err != nil {
+		return fmt.Errorf("failed to unmarshal credentials for %s: %w", profile.Name, err)
+	}
+	profile.Credentials = &credentials
+	return nil
+}
+
+// deleteCredentials removes a profile's credentials from secure storage
+func deleteCredentials(profilePath string) error {
+	secrets := profileSecrets()
+	if secrets == nil {
+		return nil
+	}
+	if err := secrets.Delete(credentialKey(profilePath)); err != nil {
+		return fmt.Errorf("failed to remove credentials from secure storage: %w", err)
+	}
+	return nil
+}
+
+// migrateProfileCredentials moves tokens to where settings want them: out
+// of the profile files into secure storage, or back when
+// profile_credentials is "inline". Profiles modified outside cflip are left
+// alone so their changes are not silently accepted.
+func (pm *ProfileManager) migrateProfileCredentials() error {
+	if profileSecrets() == nil {
+		return nil
+	}
+	secure := pm.credentialStore() == CredentialStoreSecure
+
+	entries, err := os.ReadDir(pm.profilesDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".profile" {
+			continue
+		}
+
+		profilePath := filepath.Join(pm.profilesDir, entry.Name())
+		data, err := os.ReadFile(profilePath)
+		if err != nil {
+			continue
+		}
+		plain, err := decodeProfileData(profilePath, data)
+		if err != nil {
+			continue // Skip files sops cannot decrypt here
+		}
+		var profile Profile
+		if err := json.Unmarshal(plain, &profile); err != nil {
+			continue // Skip invalid files
+		}
+
+		if (profile.CredentialStore == CredentialStoreSecure) == secure {
+			continue
+		}
+		if secure && profile.Credentials == nil {
+			continue
+		}
+		if ok, _ := pm.verifyContent(profilePath, data); !ok {
+			continue
+		}
+		if filepath.Base(profilePath) != profile.filename() {
+			continue // Left for migrateProfileKeys
+		}
+
+		if err := attachCredentials(profilePath, &profile); err != nil {
+			return err
+		}
+		if err := pm.writeProfile(&profile); err != nil {
+			return fmt.Errorf("failed to move credentials of %s: %w", profile.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package profile
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// Settings holds user-tunable cflip options stored under "settings" in config.json
+type Settings struct {
+	// SharedSources lists read-only team profile sources merged into list
+	SharedSources []SharedSource `json:"shared_sources,omitempty"`
+
+	// Retention controls archiving and admission policies for stored profiles
+	Retention RetentionPolicy `json:"retention,omitempty"`
+
+	// Rotation schedules automatic account switches, evaluated by `cflip
+	// rotate` or the daemon
+	Rotation RotationSettings `json:"rotation,omitempty"`
+
+	// Desktop opts in to switching Claude Desktop together with Claude Code
+	Desktop DesktopSettings `json:"desktop,omitempty"`
+
+	// Theme controls colored output (NO_COLOR always disables colors)
+	Theme logger.Theme `json:"theme,omitempty"`
+
+	// Aliases maps custom command names to expansions, e.g. "w": "switch work"
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// ExpiryWarning controls the post-command warning about expiring tokens
+	ExpiryWarning ExpiryWarningSettings `json:"expiry_warning,omitempty"`
+
+	// AutoRefresh refreshes expired or expiring tokens when an account is
+	// switched to or validated
+	AutoRefresh AutoRefreshSettings `json:"auto_refresh,omitempty"`
+
+	// TokenRefresh keeps stored tokens fresh while `cflip monitor` or
+	// `cflip daemon` runs
+	TokenRefresh TokenRefreshSettings `json:"token_refresh,omitempty"`
+
+	// AutoAdopt silently stores the live account before any command when it
+	// is not managed yet
+	AutoAdopt bool `json:"auto_adopt,omitempty"`
+
+	// Sops encrypts profile files with sops when they are written
+	Sops SopsSettings `json:"sops,omitempty"`
+
+	// GitSync shares profiles between machines through a git repository
+	GitSync GitSyncSettings `json:"git_sync,omitempty"`
+
+	// StorageRetry retries keychain and credential file operations that fail transiently
+	StorageRetry StorageRetrySettings `json:"storage_retry,omitempty"`
+
+	// VerifySwitch checks the new access token against the API after every switch
+	VerifySwitch bool `json:"verify_switch,omitempty"`
+
+	// ProfileCredentials is where stored profiles keep their tokens: "secure"
+	// (default, the keychain or cflip's credential files) or "inline" (in
+	// the profile files)
+	ProfileCredentials string `json:"profile_credentials,omitempty"`
+
+	// StorageBackend is the secure storage backend profile credentials are
+	// kept in: "keychain", "file", "secret-service" or "pass" (default the
+	// keychain on macOS and "file" on Linux; CFLIP_STORAGE_BACKEND takes
+	// precedence). Change it with `cflip storage migrate` so stored secrets
+	// move along.
+	StorageBackend string `json:"storage_backend,omitempty"`
+
+	// PassPrefix is the password-store folder the pass backend files
+	// entries in (default "cflip", giving cflip/<profile>)
+	PassPrefix string `json:"pass_prefix,omitempty"`
+
+	// ClaudeCredentials overrides where Claude Code keeps its live credentials
+	ClaudeCredentials ClaudeCredentialSettings `json:"claude_credentials,omitempty"`
+
+	// History bounds the switch history kept for `cflip history` and `cflip undo`
+	History HistorySettings `json:"history,omitempty"`
+
+	// Backup controls how many `cflip backup` archives are kept
+	Backup BackupSettings `json:"backup,omitempty"`
+
+	// LockTimeout is how long to wait for another cflip process that is
+	// switching or saving, as a Go duration (default "10s")
+	LockTimeout string `json:"lock_timeout,omitempty"`
+}
+
+// LockTimeoutDuration returns the configured lock timeout
+func (s *Settings) LockTimeoutDuration() (time.Duration, error) {
+	return parseSettingDuration("lock_timeout", s.LockTimeout, DefaultLockTimeout)
+}
+
+// ClaudeCredentialSettings points cflip at Claude Code's live credentials
+// when they are not where auto-discovery looks (CFLIP_KEYCHAIN_SERVICE and
+// CFLIP_CREDENTIALS_PATH take precedence)
+type ClaudeCredentialSettings struct {
+	// KeychainService is the macOS keychain / Secret Service item name
+	KeychainService string `json:"keychain_service,omitempty"`
+	// Path is the credentials file on Linux; a leading ~/ is expanded
+	Path string `json:"path,omitempty"`
+}
+
+// Locations converts the settings for the storage package
+func (c ClaudeCredentialSettings) Locations() storage.CredentialLocations {
+	return storage.CredentialLocations{KeychainService: c.KeychainService, CredentialsPath: c.Path}
+}
+
+// StorageRetrySettings configures retries of transient storage failures
+type StorageRetrySettings struct {
+	// Attempts is the total number of tries (default 3; 1 disables retries)
+	Attempts int `json:"attempts,omitempty"`
+	// Backoff is the first retry delay as a Go duration, doubled after each
+	// retry (default "200ms")
+	Backoff string `json:"backoff,omitempty"`
+}
+
+// Policy returns the storage retry policy, filling in defaults
+func (r StorageRetrySettings) Policy() (storage.RetryPolicy, error) {
+	backoff, err := parseSettingDuration("storage_retry.backoff", r.Backoff, storage.DefaultRetryBackoff)
+	if err != nil {
+		return storage.RetryPolicy{}, err
+	}
+	attempts := r.Attempts
+	if attempts == 0 {
+		attempts = storage.DefaultRetryAttempts
+	}
+	if attempts < 1 {
+		return storage.RetryPolicy{}, fmt.Errorf("invalid storage_retry.attempts %d: must be at least 1", attempts)
+	}
+	return storage.RetryPolicy{Attempts: attempts, Backoff: backoff}, nil
+}
+
+// AutoRefreshSettings configures refreshing tokens on switch and validate
+type AutoRefreshSettings struct {
+	// Disabled leaves expired tokens for Claude Code to refresh
+	Disabled bool `json:"disabled,omitempty"`
+	// Window also refreshes tokens expiring within this Go duration (default "10m")
+	Window string `json:"window,omitempty"`
+}
+
+// DefaultAutoRefreshWindow is how close to expiry a token is refreshed on use
+const DefaultAutoRefreshWindow = 10 * time.Minute
+
+// WindowDuration returns the configured refresh window
+func (a AutoRefreshSettings) WindowDuration() (time.Duration, error) {
+	return parseSettingDuration("auto_refresh.window", a.Window, DefaultAutoRefreshWindow)
+}
+
+// TokenRefreshSettings configures scheduled background token refresh
+type TokenRefreshSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Window refreshes tokens expiring within this Go duration (default "6h")
+	Window string `json:"window,omitempty"`
+	// Interval is how often to check, before jitter (default "30m")
+	Interval string `json:"interval,omitempty"`
+}
+
+// Defaults for scheduled token refresh
+const (
+	DefaultTokenRefreshWindow   = 6 * time.Hour
+	DefaultTokenRefreshInterval = 30 * time.Minute
+)
+
+// WindowDuration returns the configured refresh window
+func (t TokenRefreshSettings) WindowDuration() (time.Duration, error) {
+	return parseSettingDuration("token_refresh.window", t.Window, DefaultTokenRefreshWindow)
+}
+
+// IntervalDuration returns the configured check interval
+func (t TokenRefreshSettings) IntervalDuration() (time.Duration, error) {
+	return parseSettingDuration("token_refresh.interval", t.Interval, DefaultTokenRefreshInterval)
+}
+
+// parseSettingDuration parses an optional duration setting
+func parseSettingDuration(name, value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", name, value)
+	}
+	return d, nil
+}
+
+// ExpiryWarningSettings configures the expiring-token warning
+type ExpiryWarningSettings struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Window is how far ahead to warn, as a Go duration (default "30m")
+	Window string `json:"window,omitempty"`
+}
+
+// DefaultExpiryWarningWindow is used when no window is configured
+const DefaultExpiryWarningWindow = 30 * time.Minute
+
+// WindowDuration returns the configured warning window
+func (e ExpiryWarningSettings) WindowDuration() (time.Duration, error) {
+	return parseSettingDuration("expiry_warning.window", e.Window, DefaultExpiryWarningWindow)
+}
+
+// DesktopSettings controls the Claude Desktop switching target
+type DesktopSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// RetentionPolicy keeps the profile store tidy, enforced by `cflip enforce`
+type RetentionPolicy struct {
+	// ArchiveInactiveDays archives profiles unused for this many days (0 disables)
+	ArchiveInactiveDays int `json:"archive_inactive_days,omitempty"`
+	// RejectExpiredTokens refuses to store profiles whose access token already expired
+	RejectExpiredTokens bool `json:"reject_expired_tokens,omitempty"`
+	// TrashDays keeps removed profiles restorable for this many days (default 30)
+	TrashDays int `json:"trash_days,omitempty"`
+	// Daemon has `cflip daemon` enforce the policies hourly, as `cflip
+	// enforce` does
+	Daemon bool `json:"daemon,omitempty"`
+}
+
+// DefaultTrashDays is used when no trash retention is configured
+const DefaultTrashDays = 30
+
+// TrashRetention returns how long removed profiles stay in the trash
+func (r RetentionPolicy) TrashRetention() time.Duration {
+	days := r.TrashDays
+	if days <= 0 {
+		days = DefaultTrashDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// LoadSettings returns the settings section of the cflip configuration
+func (pm *ProfileManager) LoadSettings() (*Settings, error) {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Settings, nil
+}
@@ -0,0 +1,186 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/transfer"
+)
+
+// SharedSource describes a read-only profile bundle maintained by a team:
+// an archive written by `cflip export`, encrypted with a passphrase the
+// team shares. Location is either an HTTPS URL or a local path (e.g. a
+// file inside a checked-out git repository).
+type SharedSource struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	// PassphraseEnv names the environment variable holding the bundle's
+	// passphrase (default CFLIP_SHARED_PASSPHRASE)
+	PassphraseEnv string `json:"passphrase_env,omitempty"`
+}
+
+// DefaultSharedPassphraseEnv holds the passphrase of shared sources that
+// do not name their own variable
+const DefaultSharedPassphraseEnv = "CFLIP_SHARED_PASSPHRASE"
+
+// sharedFetchTimeout bounds how long a remote source may take to respond
+const sharedFetchTimeout = 15 * time.Second
+
+// maxSharedBundleSize bounds how much of a remote source is read
+const maxSharedBundleSize = 16 << 20
+
+// passphraseEnv returns the variable holding the source's passphrase
+func (s SharedSource) passphraseEnv() string {
+	if s.PassphraseEnv != "" {
+		return s.PassphraseEnv
+	}
+	return DefaultSharedPassphraseEnv
+}
+
+// FetchSharedProfiles reads and decrypts all profiles published by a
+// shared source
+func FetchSharedProfiles(source SharedSource) ([]*Profile, error) {
+	data, err := readSharedSource(source.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared source %s: %w", source.Name, err)
+	}
+	if !transfer.IsSealed(transfer.Format, data) {
+		return nil, fmt.Errorf("shared source %s is not an encrypted bundle; publish it with `cflip export`", source.Name)
+	}
+	passphrase := os.Getenv(source.passphraseEnv())
+	if passphrase == "" {
+		return nil, fmt.Errorf("shared source %s is encrypted; set %s to its passphrase", source.Name, source.passphraseEnv())
+	}
+	archive, err := transfer.Open(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt shared source %s: %w", source.Name, err)
+	}
+
+	profiles := make([]*Profile, 0, len(archive.Entries))
+	for _, entry := range archive.Entries {
+		var profile Profile
+		if err := json.Unmarshal(entry.Profile, &profile); err != nil {
+			return nil, fmt.Errorf("failed to parse %s from shared source %s: %w", entry.Name, source.Name, err)
+		}
+		if profile.Name == "" {
+			profile.Name = profile.Email
+		}
+		profile.Source = source.Name
+		profiles = append(profiles, &profile)
+	}
+
+	return profiles, nil
+}
+
+// readSharedSource loads raw bundle bytes from a URL or local path
+func readSharedSource(location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") {
+		return nil, fmt.Errorf("refusing to fetch credentials over plain HTTP: %s", location)
+	}
+
+	if !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location)
+	}
+
+	client := &http.Client{Timeout: sharedFetchTimeout}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSharedBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSharedBundleSize {
+		return nil, fmt.Errorf("bundle is larger than %d MiB", maxSharedBundleSize>>20)
+	}
+	return data, nil
+}
+
+// ListSharedProfiles returns profiles from every configured shared source,
+// keyed by the source name they came from. Unreachable sources are skipped
+// and reported through the returned error map.
+func (s *Switcher) ListSharedProfiles() (map[string][]*Profile, map[string]error, error) {
+	settings, err := s.profileManager.LoadSettings()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(map[string][]*Profile)
+	failures := make(map[string]error)
+
+	for _, source := range settings.SharedSources {
+		profiles, err := FetchSharedProfiles(source)
+		if err != nil {
+			failures[source.Name] = err
+			continue
+		}
+		result[source.Name] = profiles
+	}
+
+	return result, failures, nil
+}
+
+// errSharedUnreachable reports shared sources that could not be searched
+var errSharedUnreachable = errors.New("shared sources unreachable")
+
+// findSharedProfile fetches a fresh copy of a shared profile by name or email
+func (s *Switcher) findSharedProfile(identifier string) (*Profile, string, error) {
+	settings, err := s.profileManager.LoadSettings()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var failures []string
+	for _, source := range settings.SharedSources {
+		profiles, err := FetchSharedProfiles(source)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", source.Name, err))
+			continue
+		}
+		for _, profile := range profiles {
+			if profile.Name == identifier || profile.Email == identifier || profile.Alias == identifier {
+				return profile, source.Name, nil
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, "", fmt.Errorf("%w: %s", errSharedUnreachable, strings.Join(failures, "; "))
+	}
+	return nil, "", fmt.Errorf("profile not found: %s", identifier)
+}
+
+// activeSharedProfile describes the active shared profile from config.json
+// and Claude Code's live state, without fetching its source. Its
+// credentials are the live ones, when Claude Code is still logged in as it.
+func (s *Switcher) activeSharedProfile(cfg *Config) *Profile {
+	profile := &Profile{Name: cfg.ActiveProfile, Email: cfg.ActiveEmail, Source: cfg.ActiveSource}
+	if profile.Email == "" {
+		// Recorded by an older version; shared names default to the email
+		profile.Email = profile.Name
+	}
+	live, err := config.LoadClaudeConfig()
+	if err != nil || !strings.EqualFold(live.GetUserEmail(), profile.Email) {
+		return profile
+	}
+	profile.ClaudeConfig = live
+	profile.AccountUuid = live.GetAccountUuid()
+	if credentials, ok := live.GetCredentials(); ok {
+		profile.Credentials = credentials
+	}
+	return profile
+}
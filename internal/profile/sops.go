@@ -0,0 +1,133 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SopsSettings stores profiles as SOPS-encrypted JSON, so existing age, PGP
+// or KMS keys and review workflows cover the seat store
+type SopsSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Config is the .sops.yaml with creation rules; defaults to .sops.yaml
+	// in the data directory, then sops' own lookup
+	Config string `json:"config,omitempty"`
+}
+
+// sopsDocument reports whether a profile file is SOPS-encrypted, returning
+// the input type to decrypt it with
+func sopsDocument(data []byte) (string, bool) {
+	var probe struct {
+		Sops json.RawMessage `json:"sops"`
+	}
+	if err := json.Unmarshal(data, &probe); err == nil {
+		return "json", probe.Sops != nil
+	}
+	// Hand-encrypted YAML profiles are read too; cflip always writes JSON
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "sops:") {
+			return "yaml", true
+		}
+	}
+	return "", false
+}
+
+// decodeProfileData returns a profile file's plaintext JSON, decrypting it
+// with sops when needed
+func decodeProfileData(path string, data []byte) ([]byte, error) {
+	inputType, ok := sopsDocument(data)
+	if !ok {
+		return data, nil
+	}
+
+	plain, err := runSops(nil, "--decrypt", "--input-type", inputType, "--output-type", "json", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s with sops: %w", filepath.Base(path), err)
+	}
+	return plain, nil
+}
+
+// encodeProfileData encrypts a profile's JSON when sops is enabled. The
+// plaintext is passed on stdin so it never touches the disk.
+func (pm *ProfileManager) encodeProfileData(path string, data []byte) ([]byte, error) {
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	settings := config.Settings.Sops
+	if !settings.Enabled {
+		return data, nil
+	}
+
+	args := []string{"--encrypt", "--input-type", "json", "--output-type", "json", "--filename-override", path}
+	if sopsConfig := pm.sopsConfigPath(settings); sopsConfig != "" {
+		args = append([]string{"--config", sopsConfig}, args...)
+	}
+	args = append(args, "/dev/stdin")
+
+	encrypted, err := runSops(bytes.NewReader(data), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s with sops: %w", filepath.Base(path), err)
+	}
+	return encrypted, nil
+}
+
+// sopsConfigPath picks the configured .sops.yaml, falling back to one in
+// the data directory
+func (pm *ProfileManager) sopsConfigPath(settings SopsSettings) string {
+	if settings.Config != "" {
+		return settings.Config
+	}
+	local := filepath.Join(pm.profilesDir, ".sops.yaml")
+	if _, err := os.Stat(local); err == nil {
+		return local
+	}
+	return ""
+}
+
+// runSops runs the sops CLI, feeding it stdin when given
+func runSops(stdin *bytes.Reader, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath("sops")
+	if err != nil {
+		return nil, fmt.Errorf("sops not found in PATH: %w", err)
+	}
+
+	cmd := exec.Command(binary, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// RewriteProfiles rewrites every stored profile with the current sops
+// setting, encrypting plaintext profiles or decrypting encrypted ones
+func (s *Switcher) RewriteProfiles() ([]string, error) {
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var rewritten []string
+	for _, profile := range profiles {
+		if profile.Tampered {
+			return rewritten, fmt.Errorf("%s was modified outside cflip; review it and run `cflip validate --accept-modified` first", profile.Name)
+		}
+		if err := s.profileManager.writeProfile(profile); err != nil {
+			return rewritten, fmt.Errorf("failed to rewrite %s: %w", profile.Name, err)
+		}
+		rewritten = append(rewritten, profile.Name)
+	}
+	return rewritten, nil
+}
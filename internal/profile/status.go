@@ -0,0 +1,44 @@
+package profile
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// ActiveStatus is what shell prompts show about the active account
+type ActiveStatus struct {
+	Profile   string
+	Email     string
+	Alias     string
+	Source    string    // shared source of the active profile, if any
+	ExpiresAt time.Time // zero when unknown
+}
+
+// ReadActiveStatus reads the active account from config.json alone: no
+// profile file, keychain or credentials file is opened, and no migration
+// runs, so it is fast enough for every shell prompt. It returns nil when no
+// account is active.
+func ReadActiveStatus() (*ActiveStatus, error) {
+	profilesDir, configDir, err := resolveDirs()
+	if err != nil {
+		return nil, err
+	}
+	pm := &ProfileManager{profilesDir: profilesDir, configPath: filepath.Join(configDir, "config.json")}
+
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config.json: %w", err)
+	}
+	if config.ActiveProfile == "" {
+		return nil, nil
+	}
+
+	return &ActiveStatus{
+		Profile:   config.ActiveProfile,
+		Email:     config.Profiles[config.ActiveProfile],
+		Alias:     config.ProfileAliases[config.ActiveProfile],
+		Source:    config.ActiveSource,
+		ExpiresAt: config.TokenExpiry[config.ActiveProfile],
+	}, nil
+}
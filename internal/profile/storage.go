@@ -0,0 +1,89 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// StorageMigration is what moving profile credentials between storage
+// backends did
+type StorageMigration struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Migrated []string `json:"migrated"`
+	// Missing lists profiles marked secure whose item was not in the old
+	// backend; they need to be logged in to again either way
+	Missing []string `json:"missing,omitempty"`
+}
+
+// MigrateStorage re-stores the credentials of every stored profile,
+// including archived and trashed ones, from one storage backend into
+// another and makes the new backend settings.storage_backend. Every item is
+// read back from the new backend before the settings change, and the old
+// copies are only deleted after it.
+func (s *Switcher) MigrateStorage(from, to string) (*StorageMigration, error) {
+	if from == to {
+		return nil, fmt.Errorf("credentials are already in %s", to)
+	}
+	source, err := storage.OpenBackend(from, storage.CFlipServiceName)
+	if err != nil {
+		return nil, err
+	}
+	target, err := storage.OpenBackend(to, storage.CFlipServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	pm := s.profileManager
+	unlock, err := pm.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	keys, err := secureProfileKeys(pm.profilesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored profiles: %w", err)
+	}
+
+	result := &StorageMigration{From: from, To: to, Migrated: []string{}}
+	for _, key := range keys {
+		value, err := source.Retrieve(key)
+		if errors.Is(err, storage.ErrNotFound) {
+			result.Missing = append(result.Missing, key)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials of %s from %s: %w", key, from, err)
+		}
+
+		if err := target.Store(key, value); err != nil {
+			return nil, fmt.Errorf("failed to store credentials of %s in %s: %w", key, to, err)
+		}
+		if stored, err := target.Retrieve(key); err != nil || stored != value {
+			return nil, fmt.Errorf("credentials of %s did not read back from %s; nothing was removed from %s", key, to, from)
+		}
+		result.Migrated = append(result.Migrated, key)
+	}
+
+	config, err := pm.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Settings.StorageBackend = to
+	if err := pm.SaveConfig(config); err != nil {
+		return nil, err
+	}
+	if err := storage.SetProfileBackend(to); err != nil {
+		return nil, err
+	}
+
+	for _, key := range result.Migrated {
+		if err := source.Delete(key); err != nil {
+			return result, fmt.Errorf("credentials moved to %s, but the old copy of %s could not be removed from %s: %w", to, key, from, err)
+		}
+	}
+	return result, nil
+}
@@ -1,20 +1,26 @@
 package profile
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"runtime"
 	"time"
 
 	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/fsutil"
+	"github.com/phathdt/claude-flip/internal/logger"
 	"github.com/phathdt/claude-flip/internal/storage"
 )
 
 // Switcher handles switching between Claude Code accounts
 type Switcher struct {
 	profileManager *ProfileManager
+	skipDesktop    bool
+	organization   string // organization to select on the next switch
+	group          string // group the next switch in sequence rotates within
+	initiator      string // recorded in the switch history; InitiatorSwitch when empty
+	lastSync       *SyncResult
 }
 
 // NewSwitcher creates a new account switcher
@@ -29,7 +35,6 @@ func NewSwitcher() (*Switcher, error) {
 	}, nil
 }
 
-// SaveCurrentAccount saves the current Claude Code account as a profile
 // SaveCurrentAccount saves the current Claude Code account as a profile
 func (s *Switcher) SaveCurrentAccount(name, alias string) (*Profile, error) {
 	// Load current Claude Code configuration
@@ -38,6 +43,11 @@ func (s *Switcher) SaveCurrentAccount(name, alias string) (*Profile, error) {
 		return nil, fmt.Errorf("failed to load Claude Code configuration: %w", err)
 	}
 
+	return s.saveLiveAccount(name, alias, claudeConfig)
+}
+
+// saveLiveAccount stores the account in an already loaded live Claude config
+func (s *Switcher) saveLiveAccount(name, alias string, claudeConfig *config.ClaudeConfig) (*Profile, error) {
 	// Validate the configuration
 	if err := config.ValidateConfig(*claudeConfig); err != nil {
 		return nil, fmt.Errorf("invalid Claude Code configuration: %w", err)
@@ -46,6 +56,10 @@ func (s *Switcher) SaveCurrentAccount(name, alias string) (*Profile, error) {
 	// Get credentials from the config (they're already loaded)
 	credentials, ok := claudeConfig.GetCredentials()
 	if !ok {
+		// Re-read to surface why they are missing (e.g. storage.ErrNotFound)
+		if _, err := config.CaptureCredentials(); err != nil {
+			return nil, fmt.Errorf("failed to get credentials: %w", err)
+		}
 		return nil, fmt.Errorf("failed to get credentials from config")
 	}
 
@@ -58,15 +72,73 @@ func (s *Switcher) SaveCurrentAccount(name, alias string) (*Profile, error) {
 	// Create profile
 	now := time.Now()
 	profile := &Profile{
-		Name:         profileName,
-		Email:        claudeConfig.GetUserEmail(),
-		Alias:        alias,
-		AccountUuid:  claudeConfig.GetAccountUuid(),
-		CreatedAt:    now,
-		UpdatedAt:    now,
-		LastActiveAt: now, // Since this is the current account, set as last active
-		ClaudeConfig: claudeConfig,
-		Credentials:  credentials,
+		Name:             profileName,
+		Email:            claudeConfig.GetUserEmail(),
+		Alias:            alias,
+		AccountUuid:      claudeConfig.GetAccountUuid(),
+		OrganizationUuid: claudeConfig.GetOrganizationUuid(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		LastActiveAt:     now, // Since this is the current account, set as last active
+		ClaudeConfig:     claudeConfig,
+		Credentials:      credentials,
+	}
+
+	if s.desktopEnabled() {
+		// Capture Claude Desktop alongside Claude Code when enabled
+		desktop, err := config.CaptureDesktop()
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture Claude Desktop session: %w", err)
+		}
+		profile.Desktop = desktop
+	}
+
+	return s.storeNewProfile(profile)
+}
+
+// ImportAccount registers an account from exported config and credentials
+// without it being logged in to Claude Code
+func (s *Switcher) ImportAccount(name, alias string, claudeConfig *config.ClaudeConfig, credentials *config.Credentials) (*Profile, error) {
+	email := claudeConfig.GetUserEmail()
+	if email == "" {
+		return nil, fmt.Errorf("no email found in configuration")
+	}
+
+	profileName := name
+	if profileName == "" {
+		profileName = email
+	}
+
+	now := time.Now()
+	profile := &Profile{
+		Name:             profileName,
+		Email:            email,
+		Alias:            alias,
+		AccountUuid:      claudeConfig.GetAccountUuid(),
+		OrganizationUuid: claudeConfig.GetOrganizationUuid(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		ClaudeConfig:     claudeConfig,
+		Credentials:      credentials,
+	}
+
+	return s.storeNewProfile(profile)
+}
+
+// storeNewProfile applies admission policies and persists a new profile
+func (s *Switcher) storeNewProfile(profile *Profile) (*Profile, error) {
+	// Apply retention admission policy
+	settings, err := s.profileManager.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAdmission(settings.Retention, profile); err != nil {
+		return nil, err
+	}
+
+	// The same email may already be stored for another organization
+	if profile.Name, err = s.profileManager.uniqueName(profile); err != nil {
+		return nil, err
 	}
 
 	// Save profile
@@ -77,9 +149,23 @@ func (s *Switcher) SaveCurrentAccount(name, alias string) (*Profile, error) {
 	return profile, nil
 }
 
-// SwitchToAccount switches to a specific account profile
 // SwitchToAccount switches to a specific account profile
 func (s *Switcher) SwitchToAccount(identifier string) (*Profile, error) {
+	// Another switch must not interleave its writes of ~/.claude.json,
+	// config.json and the credentials with this one
+	locked, unlock, err := s.locked()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	profile, err := locked.switchToAccount(identifier)
+	s.lastSync = locked.lastSync
+	return profile, err
+}
+
+// switchToAccount is SwitchToAccount for a switcher holding the lock
+func (s *Switcher) switchToAccount(identifier string) (*Profile, error) {
 	var targetProfile *Profile
 	var err error
 
@@ -90,50 +176,85 @@ func (s *Switcher) SwitchToAccount(identifier string) (*Profile, error) {
 			return nil, fmt.Errorf("failed to get next profile: %w", err)
 		}
 	} else {
-		// Load specific target profile
+		// Load specific target profile, falling back to shared sources
 		targetProfile, err = s.profileManager.LoadProfile(identifier)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load target profile: %w", err)
+			shared, _, sharedErr := s.findSharedProfile(identifier)
+			if errors.Is(sharedErr, errSharedUnreachable) {
+				return nil, fmt.Errorf("failed to load target profile: %w (%v)", err, sharedErr)
+			}
+			if sharedErr != nil {
+				return nil, fmt.Errorf("failed to load target profile: %w", err)
+			}
+			targetProfile = shared
+		}
+	}
+
+	// Fail before touching anything when the requested organization is unknown
+	if s.organization != "" {
+		if _, err := targetProfile.FindOrganization(s.organization); err != nil {
+			return nil, err
 		}
 	}
 
-	// Before switching, save current account if it's not already saved
-	currentEmail := ""
-	if currentConfig, err := config.LoadClaudeConfig(); err == nil {
-		currentEmail = currentConfig.GetUserEmail()
+	// Before switching, save current account if it's not already saved.
+	// The live config can be several megabytes, so it is read once and
+	// shared by every step of the backup.
+	currentEmail, previousName := "", ""
+	if cfg, err := s.profileManager.LoadConfig(); err == nil {
+		previousName = cfg.ActiveProfile
+	}
+	liveConfig, err := config.LoadClaudeConfig()
+	if err == nil {
+		currentEmail = liveConfig.GetUserEmail()
 	}
 
 	// Check if current account is already saved
 	shouldSaveCurrentAccount := true
+	s.lastSync = nil
 	if currentEmail != "" {
-		if currentProfile, err := s.profileManager.LoadProfile(currentEmail); err == nil {
-			// Update the existing profile with current state
-			currentClaudeConfig, err := config.LoadClaudeConfig()
-			if err != nil {
-				return nil, fmt.Errorf("failed to load current Claude config for backup: %w", err)
-			}
-
-			currentCredentials, err := s.loadCredentials()
+		if currentProfile := s.liveLocalProfile(liveConfig); currentProfile != nil {
+			// Sync the existing profile with current state, so tokens Claude
+			// Code refreshed since the last switch are not lost
+			synced, err := s.syncProfile(currentProfile, liveConfig)
 			if err != nil {
-				return nil, fmt.Errorf("failed to load current credentials for backup: %w", err)
-			}
-
-			currentProfile.ClaudeConfig = currentClaudeConfig
-			currentProfile.Credentials = currentCredentials
-
-			if err := s.profileManager.SaveProfile(currentProfile); err != nil {
 				return nil, fmt.Errorf("failed to update current profile: %w", err)
 			}
+			s.lastSync = synced
 
 			shouldSaveCurrentAccount = false
 		}
 	}
 
-	if shouldSaveCurrentAccount && currentEmail != "" {
+	// Shared profiles are read-only, so never snapshot them into the local store
+	if shouldSaveCurrentAccount && currentEmail != "" && !s.activeIsShared() {
 		// Auto-save current account with email as name
-		if _, err := s.SaveCurrentAccount(currentEmail, ""); err != nil {
+		if _, err := s.saveLiveAccount(currentEmail, "", liveConfig); err != nil {
 			// Log warning but don't fail the switch
-			fmt.Printf("Warning: failed to backup current account: %v\n", err)
+			logger.Notice("Failed to back up the current account: %v", err)
+		}
+	}
+
+	// The backup above may have refreshed the target itself (e.g. when only
+	// changing organization), so reload it to avoid applying stale state
+	if targetProfile.Source == "" && targetProfile.Email == currentEmail {
+		if reloaded, err := s.profileManager.LoadProfile(targetProfile.Name); err == nil {
+			targetProfile = reloaded
+		}
+	}
+
+	if s.organization != "" {
+		membership, err := targetProfile.FindOrganization(s.organization)
+		if err != nil {
+			return nil, err
+		}
+		if err := targetProfile.selectOrganization(membership); err != nil {
+			return nil, err
+		}
+		if targetProfile.Source == "" {
+			if err := s.profileManager.SaveProfile(targetProfile); err != nil {
+				return nil, fmt.Errorf("failed to save organization selection: %w", err)
+			}
 		}
 	}
 
@@ -143,18 +264,88 @@ func (s *Switcher) SwitchToAccount(identifier string) (*Profile, error) {
 	}
 
 	// Mark as active
-	if err := s.profileManager.SetActiveProfile(targetProfile.Name); err != nil {
+	if targetProfile.Source != "" {
+		err = s.profileManager.SetActiveSharedProfile(targetProfile.Name, targetProfile.Email, targetProfile.Source)
+	} else {
+		err = s.profileManager.SetActiveProfile(targetProfile.Name)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to set active profile: %w", err)
 	}
 
+	// Track usage; shared profiles are read-only so they are not updated
+	if targetProfile.Source == "" {
+		if err := s.profileManager.RecordActivation(targetProfile.Name); err != nil {
+			return nil, fmt.Errorf("failed to record profile activation: %w", err)
+		}
+	}
+
+	initiator := s.initiator
+	if initiator == "" {
+		initiator = InitiatorSwitch
+	}
+	if err := s.profileManager.recordHistory(HistoryEntry{
+		Time:      time.Now().UTC(),
+		From:      previousName,
+		FromEmail: currentEmail,
+		To:        targetProfile.Name,
+		ToEmail:   targetProfile.Email,
+		Initiator: initiator,
+	}); err != nil {
+		return nil, err
+	}
+
 	return targetProfile, nil
 }
 
-// GetCurrentActiveProfile returns the currently active profile
+// GetCurrentActiveProfile returns the currently active profile. A shared
+// one is described from config.json and the live credentials; its source
+// is not fetched.
 func (s *Switcher) GetCurrentActiveProfile() (*Profile, error) {
+	cfg, err := s.profileManager.LoadConfig()
+	if err == nil && cfg.ActiveSource != "" {
+		return s.activeSharedProfile(cfg), nil
+	}
+
 	return s.profileManager.GetActiveProfile()
 }
 
+// activeIsShared reports whether the active profile comes from a shared source
+func (s *Switcher) activeIsShared() bool {
+	cfg, err := s.profileManager.LoadConfig()
+	return err == nil && cfg.ActiveSource != ""
+}
+
+// ProfilePath returns the on-disk path of a stored profile
+func (s *Switcher) ProfilePath(identifier string) (string, error) {
+	return s.profileManager.findProfilePath(identifier)
+}
+
+// ClaudeCredentialLocation describes where Claude Code keeps its live credentials
+func ClaudeCredentialLocation() string {
+	switch runtime.GOOS {
+	case "darwin":
+		user := os.Getenv("USER")
+		if user == "" {
+			user = "default"
+		}
+		return fmt.Sprintf("keychain item %q (account %q)", storage.KeychainService(), user)
+	default:
+		if storage.LinuxCredentialBackend() == storage.BackendSecretService {
+			return fmt.Sprintf("keyring item %q (account %q)", storage.KeychainService(), storage.CredentialAccount())
+		}
+		if path, err := storage.CredentialsPath(); err == nil {
+			return path
+		}
+		return "~/.claude/.credentials.json"
+	}
+}
+
+// LoadProfile loads a stored profile by name or email
+func (s *Switcher) LoadProfile(identifier string) (*Profile, error) {
+	return s.profileManager.LoadProfile(identifier)
+}
+
 // ListProfiles returns all available profiles
 func (s *Switcher) ListProfiles() ([]*Profile, error) {
 	return s.profileManager.ListProfiles()
@@ -165,6 +356,35 @@ func (s *Switcher) DeleteProfile(identifier string) error {
 	return s.profileManager.DeleteProfile(identifier)
 }
 
+// TrashProfile removes a profile into the trash, where it stays restorable
+// until the retention period passes
+func (s *Switcher) TrashProfile(identifier string) error {
+	if err := s.profileManager.PurgeTrash(); err != nil {
+		return err
+	}
+	return s.profileManager.TrashProfile(identifier)
+}
+
+// ListTrash returns removed profiles that can still be undeleted
+func (s *Switcher) ListTrash() ([]TrashedProfile, error) {
+	return s.profileManager.ListTrash()
+}
+
+// UndeleteProfile restores a removed profile from the trash
+func (s *Switcher) UndeleteProfile(identifier string) (*Profile, error) {
+	return s.profileManager.UndeleteProfile(identifier)
+}
+
+// ListArchive returns the profiles archived by retention runs
+func (s *Switcher) ListArchive() ([]ArchivedProfile, error) {
+	return s.profileManager.ListArchive()
+}
+
+// UnarchiveProfile restores an archived profile into the store
+func (s *Switcher) UnarchiveProfile(identifier string) (*Profile, error) {
+	return s.profileManager.UnarchiveProfile(identifier)
+}
+
 // RenameProfile changes a profile's name/alias
 func (s *Switcher) RenameProfile(identifier, newName, newAlias string) error {
 	profile, err := s.profileManager.LoadProfile(identifier)
@@ -187,6 +407,11 @@ func (s *Switcher) ValidateProfile(identifier string) error {
 		return err
 	}
 
+	return CheckProfile(profile)
+}
+
+// CheckProfile checks an already loaded profile for usable credentials
+func CheckProfile(profile *Profile) error {
 	if profile.ClaudeConfig == nil {
 		return fmt.Errorf("profile %s has no Claude configuration", profile.Name)
 	}
@@ -197,25 +422,43 @@ func (s *Switcher) ValidateProfile(identifier string) error {
 		return fmt.Errorf("profile %s has no OAuth account information", profile.Name)
 	}
 
+	if profile.Locked {
+		return fmt.Errorf("profile %s: %w", profile.Name, storage.ErrLocked)
+	}
 	if profile.Credentials == nil || profile.Credentials.ClaudeAiOauth.AccessToken == "" {
 		return fmt.Errorf("profile %s has no access token", profile.Name)
 	}
 
+	if profile.Tampered {
+		return fmt.Errorf("profile %s was modified outside cflip", profile.Name)
+	}
+
 	// TODO: Could add token expiration check here
 	return nil
 }
 
+// Settings returns the user settings from the cflip configuration
+func (s *Switcher) Settings() (*Settings, error) {
+	return s.profileManager.LoadSettings()
+}
+
 // SetActiveProfile marks a profile as active without switching Claude config
 func (s *Switcher) SetActiveProfile(identifier string) error {
 	return s.profileManager.SetActiveProfile(identifier)
 }
 
-// GetNextProfile returns the next profile in sequence for switching
+// GetNextProfile returns the next profile in sequence for switching,
+// within the group chosen by SelectGroup when there is one
 func (s *Switcher) GetNextProfile() (*Profile, error) {
 	profiles, err := s.profileManager.ListProfiles()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list profiles: %w", err)
 	}
+	if s.group != "" {
+		if profiles, err = filterGroup(profiles, s.group); err != nil {
+			return nil, err
+		}
+	}
 
 	if len(profiles) == 0 {
 		return nil, fmt.Errorf("no profiles available")
@@ -225,19 +468,21 @@ func (s *Switcher) GetNextProfile() (*Profile, error) {
 		return profiles[0], nil
 	}
 
-	// Get current active profile
-	activeProfile, err := s.profileManager.GetActiveProfile()
-	if err != nil {
-		// No active profile, return first one
-		return profiles[0], nil
-	}
-
 	// Find current active profile in the list
 	currentIndex := -1
-	for i, profile := range profiles {
-		if profile.Name == activeProfile.Name {
-			currentIndex = i
-			break
+	if activeProfile, err := s.profileManager.GetActiveProfile(); err == nil {
+		for i, profile := range profiles {
+			if profile.Name == activeProfile.Name {
+				currentIndex = i
+				break
+			}
+		}
+	}
+
+	// Prefer higher-weighted seats when profiles are weighted differently
+	if settings, err := s.profileManager.LoadSettings(); err == nil {
+		if next := weightedNext(profiles, currentIndex, settings.Rotation); next != nil {
+			return next, nil
 		}
 	}
 
@@ -250,16 +495,54 @@ func (s *Switcher) GetNextProfile() (*Profile, error) {
 	return profiles[currentIndex+1], nil
 }
 
+// LeastRecentlyUsedProfile returns the profile idle the longest, skipping the
+// active one. Profiles that were never switched to count as idlest.
+func (s *Switcher) LeastRecentlyUsedProfile() (*Profile, error) {
+	profiles, err := s.profileManager.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	activeName := ""
+	if active, err := s.profileManager.GetActiveProfile(); err == nil {
+		activeName = active.Name
+	}
+
+	var lru *Profile
+	for _, profile := range profiles {
+		if profile.Name == activeName {
+			continue
+		}
+		if lru == nil || profile.LastActiveAt.Before(lru.LastActiveAt) {
+			lru = profile
+		}
+	}
+
+	if lru == nil {
+		return nil, fmt.Errorf("no other profiles available")
+	}
+	return lru, nil
+}
+
 // applyProfile applies a profile's configuration to Claude Code
 func (s *Switcher) applyProfile(profile *Profile) error {
 	if profile.ClaudeConfig == nil {
 		return fmt.Errorf("profile has no Claude configuration")
 	}
 
+	if profile.Locked {
+		return storage.ErrLocked
+	}
 	if profile.Credentials == nil {
 		return fmt.Errorf("profile has no credentials")
 	}
 
+	// Snapshot the outgoing config and credentials so a bad switch can be
+	// undone even when the outgoing account was never stored
+	if _, err := CreateRestorePoint("switch to " + profile.Email); err != nil {
+		return fmt.Errorf("failed to create restore point: %w", err)
+	}
+
 	// Update the oauthAccount section with fresh credentials before saving
 	if oauthAccount, ok := (*profile.ClaudeConfig)["oauthAccount"].(map[string]interface{}); ok {
 		// We don't store credentials in the oauthAccount section, they go in a separate file
@@ -277,9 +560,94 @@ func (s *Switcher) applyProfile(profile *Profile) error {
 		return fmt.Errorf("failed to save credentials: %w", err)
 	}
 
+	// Swap the previous profile's settings overlay for this one's
+	if err := applySettingsOverlay(profile); err != nil {
+		return err
+	}
+
+	// Flip Claude Desktop too, when this profile carries a snapshot of it
+	if s.desktopEnabled() && profile.Desktop != nil {
+		if err := config.ApplyDesktop(profile.Desktop); err != nil {
+			return fmt.Errorf("failed to apply Claude Desktop session: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// liveLocalProfile returns the locally stored profile of the account in the
+// loaded live config, or nil when it is not stored
+func (s *Switcher) liveLocalProfile(live *config.ClaudeConfig) *Profile {
+	state, err := s.detectLive(live)
+	if err != nil || state.Match == nil || state.Match.Source != "" {
+		return nil
+	}
+	return state.Match
+}
+
+// captureLive copies the live Claude config, credentials and (when enabled)
+// Claude Desktop session into a stored profile without saving it. A nil
+// liveConfig is loaded from disk.
+func (s *Switcher) captureLive(profile *Profile, liveConfig *config.ClaudeConfig) error {
+	if liveConfig == nil {
+		var err error
+		if liveConfig, err = config.LoadClaudeConfig(); err != nil {
+			return fmt.Errorf("failed to load current Claude config for backup: %w", err)
+		}
+	}
+
+	// The config load already read the credentials; only re-read them to
+	// find out why they are missing
+	liveCredentials, ok := liveConfig.GetCredentials()
+	var err error
+	if !ok {
+		liveCredentials, err = s.loadCredentials()
+	}
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		// Live credentials are gone; keep the stored copy so the switch can restore them
+		liveCredentials = profile.Credentials
+	case err != nil:
+		return fmt.Errorf("failed to load current credentials for backup: %w", err)
+	}
+
+	profile.ClaudeConfig = liveConfig
+	profile.Credentials = liveCredentials
+
+	if s.desktopEnabled() {
+		if desktop, err := config.CaptureDesktop(); err == nil {
+			profile.Desktop = desktop
+		}
+	}
+
+	return nil
+}
+
+// SkipDesktop opts out of the Claude Desktop target for this switcher
+func (s *Switcher) SkipDesktop() {
+	s.skipDesktop = true
+}
+
+// ResetSwitchOptions forgets the organization, group and Claude Desktop
+// choices made for the next switch
+func (s *Switcher) ResetSwitchOptions() {
+	s.organization, s.group, s.skipDesktop = "", "", false
+}
+
+// desktopEnabled reports whether Claude Desktop should be captured and applied
+func (s *Switcher) desktopEnabled() bool {
+	if s.skipDesktop {
+		return false
+	}
+	settings, err := s.profileManager.LoadSettings()
+	return err == nil && settings.Desktop.Enabled
+}
+
+// DesktopEnabled reports whether the Claude Desktop target is active
+func (s *Switcher) DesktopEnabled() bool {
+	return s.desktopEnabled()
+}
+
 // loadCredentials loads the Claude Code credentials
 // LoadCredentials loads Claude Code credentials using platform-specific storage
 func LoadCredentials() (*config.Credentials, error) {
@@ -320,17 +688,17 @@ func loadCredentialsMacOS() (*config.Credentials, error) {
 		return nil, fmt.Errorf("failed to load credentials from keychain: %w", err)
 	}
 
-	var credentials config.Credentials
-	if err := json.Unmarshal([]byte(data), &credentials); err != nil {
+	credentials, err := config.ParseLiveCredentials([]byte(data))
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
 	}
 
-	return &credentials, nil
+	return credentials, nil
 }
 
 // saveCredentialsMacOS saves credentials to macOS Keychain
 func saveCredentialsMacOS(credentials *config.Credentials) error {
-	data, err := json.Marshal(credentials)
+	data, err := config.EncodeLiveCredentials(credentials, false)
 	if err != nil {
 		return fmt.Errorf("failed to marshal credentials: %w", err)
 	}
@@ -350,52 +718,67 @@ func saveCredentialsMacOS(credentials *config.Credentials) error {
 	return nil
 }
 
-// loadCredentialsLinux loads credentials from file system
+// loadCredentialsLinux loads credentials from file system, or from the
+// desktop keyring when Claude Code keeps them there
 func loadCredentialsLinux() (*config.Credentials, error) {
-	home, err := os.UserHomeDir()
+	if storage.LinuxCredentialBackend() == storage.BackendSecretService {
+		data, err := storage.SecretServiceLookup(storage.CredentialAccount())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load credentials from keyring: %w", err)
+		}
+		credentials, err := config.ParseLiveCredentials([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
+		}
+		return credentials, nil
+	}
+
+	credentialsPath, err := storage.CredentialsPath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
 
-	credentialsPath := filepath.Join(home, ".claude", ".credentials.json")
 	data, err := os.ReadFile(credentialsPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", storage.ErrNotFound, credentialsPath)
+		}
 		return nil, fmt.Errorf("failed to read credentials file: %w", err)
 	}
 
-	var credentials config.Credentials
-	if err := json.Unmarshal(data, &credentials); err != nil {
+	credentials, err := config.ParseLiveCredentials(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal credentials: %w", err)
 	}
 
-	return &credentials, nil
+	return credentials, nil
 }
 
-// saveCredentialsLinux saves credentials to file system
+// saveCredentialsLinux saves credentials through the same channel Claude
+// Code reads them from: the credentials file or the desktop keyring
 func saveCredentialsLinux(credentials *config.Credentials) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
+	if storage.LinuxCredentialBackend() == storage.BackendSecretService {
+		data, err := config.EncodeLiveCredentials(credentials, false)
+		if err != nil {
+			return fmt.Errorf("failed to marshal credentials: %w", err)
+		}
+		return storage.SecretServiceStore(storage.CredentialAccount(), string(data))
 	}
 
-	credentialsPath := filepath.Join(home, ".claude", ".credentials.json")
+	credentialsPath, err := storage.CredentialsPath()
+	if err != nil {
+		return err
+	}
 
-	data, err := json.MarshalIndent(credentials, "", "  ")
+	data, err := config.EncodeLiveCredentials(credentials, true)
 	if err != nil {
 		return fmt.Errorf("failed to marshal credentials: %w", err)
 	}
 
-	// Write atomically using temporary file
-	tempPath := credentialsPath + ".tmp"
-	if err := os.WriteFile(tempPath, data, 0o600); err != nil {
+	if err := fsutil.WriteFileAtomic(credentialsPath, data, 0o600); err != nil {
 		return fmt.Errorf("failed to write credentials file: %w", err)
 	}
 
-	if err := os.Rename(tempPath, credentialsPath); err != nil {
-		os.Remove(tempPath) // Clean up temp file
-		return fmt.Errorf("failed to replace credentials file: %w", err)
-	}
-
 	return nil
 }
 
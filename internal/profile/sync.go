@@ -0,0 +1,211 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// SyncChange is one difference a sync copied from the live state into a
+// stored profile
+type SyncChange struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// SyncResult is what syncing the active profile changed
+type SyncResult struct {
+	Profile string       `json:"profile"`
+	Email   string       `json:"email"`
+	Changes []SyncChange `json:"changes"`
+}
+
+// Changed reports whether the sync updated the profile
+func (r *SyncResult) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// Fields lists the names of the changed fields
+func (r *SyncResult) Fields() []string {
+	fields := make([]string, len(r.Changes))
+	for i, change := range r.Changes {
+		fields[i] = change.Field
+	}
+	return fields
+}
+
+// SyncActive captures the live Claude Code credentials and config into
+// the stored profile of the account Claude Code is logged in to, so tokens
+// Claude Code refreshed on its own are not lost by switching away. The
+// profile is only written when something changed.
+func (s *Switcher) SyncActive() (*SyncResult, error) {
+	unlock, err := s.profileManager.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	live, err := config.LoadClaudeConfig()
+	if err != nil {
+		return nil, fmt.Errorf("Claude Code is not logged in: %w", err)
+	}
+	state, err := s.detectLive(live)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !state.LoggedIn():
+		return nil, fmt.Errorf("Claude Code is not logged in")
+	case state.Match == nil:
+		return nil, fmt.Errorf("the live account %s is not stored; run `cflip add` first", state.Email)
+	case state.Match.Source != "":
+		return nil, fmt.Errorf("%s comes from shared source %s, which is read-only", state.Match.Email, state.Match.Source)
+	}
+
+	return s.syncProfile(state.Match, live)
+}
+
+// LastSync returns what the last switch synced into the outgoing account's
+// profile, or nil when that account was not stored
+func (s *Switcher) LastSync() *SyncResult {
+	return s.lastSync
+}
+
+// syncProfile copies the live state into profile and saves it when it
+// changed. A nil live config is loaded from disk.
+func (s *Switcher) syncProfile(profile *Profile, live *config.ClaudeConfig) (*SyncResult, error) {
+	before := *profile
+	if err := s.captureLive(profile, live); err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{Profile: profile.Name, Email: profile.Email, Changes: diffSnapshots(&before, profile)}
+	if !result.Changed() {
+		return result, nil
+	}
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", profile.Name, err)
+	}
+	return result, nil
+}
+
+// diffSnapshots summarizes how the live state captured into after differs
+// from the profile's previous snapshot. Token values are never included.
+func diffSnapshots(before, after *Profile) []SyncChange {
+	var changes []SyncChange
+	add := func(field, format string, args ...interface{}) {
+		changes = append(changes, SyncChange{Field: field, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	switch old, cur := before.Credentials, after.Credentials; {
+	case cur == nil:
+	case old == nil:
+		add("credentials", "captured")
+	default:
+		o, c := old.ClaudeAiOauth, cur.ClaudeAiOauth
+		if o.AccessToken != c.AccessToken {
+			add("access_token", "rotated")
+		}
+		if o.RefreshToken != c.RefreshToken {
+			add("refresh_token", "rotated")
+		}
+		if o.ExpiresAt != c.ExpiresAt {
+			add("expires_at", "%s → %s", formatExpiry(old), formatExpiry(cur))
+		}
+		if o.SubscriptionType != c.SubscriptionType {
+			add("subscription_type", "%s → %s", orNone(o.SubscriptionType), orNone(c.SubscriptionType))
+		}
+		if !slices.Equal(o.Scopes, c.Scopes) {
+			add("scopes", "%s → %s", orNone(strings.Join(o.Scopes, " ")), orNone(strings.Join(c.Scopes, " ")))
+		}
+	}
+
+	if detail := diffClaudeConfig(before.ClaudeConfig, after.ClaudeConfig); detail != "" {
+		add("claude_config", "%s", detail)
+	}
+
+	if !sameJSON(before.Desktop, after.Desktop) {
+		add("desktop", "Claude Desktop session updated")
+	}
+	return changes
+}
+
+// diffClaudeConfig lists the top-level ~/.claude.json keys that were added,
+// removed or changed, or returns "" when the configs match
+func diffClaudeConfig(before, after *config.ClaudeConfig) string {
+	var old, cur config.ClaudeConfig
+	if before != nil {
+		old = *before
+	}
+	if after != nil {
+		cur = *after
+	}
+
+	var added, removed, changed []string
+	for key, value := range cur {
+		previous, ok := old[key]
+		switch {
+		case key == "_cflip_credentials":
+			// The credentials carried along in the config are diffed above
+		case !ok:
+			added = append(added, key)
+		case !sameJSON(previous, value):
+			changed = append(changed, key)
+		}
+	}
+	for key := range old {
+		if _, ok := cur[key]; !ok && key != "_cflip_credentials" {
+			removed = append(removed, key)
+		}
+	}
+
+	var parts []string
+	for _, group := range []struct {
+		label string
+		keys  []string
+	}{{"changed", changed}, {"added", added}, {"removed", removed}} {
+		if len(group.keys) > 0 {
+			sort.Strings(group.keys)
+			parts = append(parts, group.label+" "+strings.Join(group.keys, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sameJSON compares two values by their normalized JSON encoding, since a
+// freshly captured value may hold structs where a loaded one holds maps
+func sameJSON(a, b interface{}) bool {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		var generic interface{}
+		if json.Unmarshal(data, &generic) != nil {
+			return ""
+		}
+		data, _ = json.Marshal(generic)
+		return string(data)
+	}
+	return encode(a) == encode(b)
+}
+
+// formatExpiry renders a token expiry for a sync summary
+func formatExpiry(credentials *config.Credentials) string {
+	if credentials.ClaudeAiOauth.ExpiresAt == 0 {
+		return "unknown"
+	}
+	return credentials.ExpiresAtTime().Local().Format("2006-01-02 15:04")
+}
+
+// orNone renders an empty value in a sync summary
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HasTag reports whether the profile carries tag (case-insensitive)
+func (p *Profile) HasTag(tag string) bool {
+	return slices.ContainsFunc(p.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
+
+// TagProfile adds and removes tags on a stored profile, reporting whether
+// its tags changed. Tags already present are not added twice; removing a
+// missing tag is not an error.
+func (s *Switcher) TagProfile(identifier string, add, remove []string) (*Profile, bool, error) {
+	for _, tag := range append(slices.Clone(add), remove...) {
+		if err := checkLabel("tag", tag); err != nil {
+			return nil, false, err
+		}
+	}
+
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	for _, tag := range remove {
+		if profile.HasTag(tag) {
+			profile.Tags = slices.DeleteFunc(profile.Tags, func(t string) bool {
+				return strings.EqualFold(t, tag)
+			})
+			changed = true
+		}
+	}
+	for _, tag := range add {
+		if !profile.HasTag(tag) {
+			profile.Tags = append(profile.Tags, tag)
+			changed = true
+		}
+	}
+	if !changed {
+		return profile, false, nil
+	}
+
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, false, fmt.Errorf("failed to save profile: %w", err)
+	}
+	return profile, true, nil
+}
+
+// SetNotes replaces a stored profile's notes; empty clears them
+func (s *Switcher) SetNotes(identifier, notes string) (*Profile, error) {
+	profile, err := s.profileManager.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Notes == notes {
+		return profile, nil
+	}
+
+	profile.Notes = notes
+	if err := s.profileManager.SaveProfile(profile); err != nil {
+		return nil, fmt.Errorf("failed to save profile: %w", err)
+	}
+	return profile, nil
+}
@@ -0,0 +1,176 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TrashDirName is the subdirectory of the profiles directory holding removed
+// profiles until they are purged
+const TrashDirName = "trash"
+
+// TrashedProfile is a removed profile that can still be undeleted
+type TrashedProfile struct {
+	Profile   *Profile
+	DeletedAt time.Time
+	PurgeAt   time.Time
+	path      string
+}
+
+// TrashProfile moves a profile into the trash. The file's modification time
+// records when it was removed.
+func (pm *ProfileManager) TrashProfile(identifier string) error {
+	_, trashedPath, err := pm.moveProfile(identifier, TrashDirName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return os.Chtimes(trashedPath, now, now)
+}
+
+// ListTrash returns the profiles in the trash, purging expired ones first
+func (pm *ProfileManager) ListTrash() ([]TrashedProfile, error) {
+	settings, err := pm.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	retention := settings.Retention.TrashRetention()
+
+	dir := filepath.Join(pm.profilesDir, TrashDirName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	var trashed []TrashedProfile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".profile") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		profile, err := readMovedProfile(path)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) > retention {
+			if err := os.Remove(path); err != nil {
+				return trashed, fmt.Errorf("failed to purge %s: %w", entry.Name(), err)
+			}
+			// Keep artifacts while the same account is still stored
+			if _, err := os.Stat(filepath.Join(pm.profilesDir, profile.filename())); err != nil {
+				if err := removeArtifacts(profile); err != nil {
+					return trashed, err
+				}
+				if err := deleteCredentials(path); err != nil {
+					return trashed, err
+				}
+			}
+			continue
+		}
+
+		trashed = append(trashed, TrashedProfile{
+			Profile:   profile,
+			DeletedAt: info.ModTime(),
+			PurgeAt:   info.ModTime().Add(retention),
+			path:      path,
+		})
+	}
+
+	return trashed, nil
+}
+
+// PurgeTrash deletes trashed profiles older than the retention period
+func (pm *ProfileManager) PurgeTrash() error {
+	_, err := pm.ListTrash()
+	return err
+}
+
+// UndeleteProfile moves a trashed profile back into the store
+func (pm *ProfileManager) UndeleteProfile(identifier string) (*Profile, error) {
+	pm, unlock, err := pm.locked()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	trashed, err := pm.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range trashed {
+		profile := item.Profile
+		if profile.Name != identifier && profile.Email != identifier && profile.Alias != identifier {
+			continue
+		}
+		if err := pm.restoreMovedProfile(profile, item.path); err != nil {
+			return nil, err
+		}
+		return profile, nil
+	}
+
+	return nil, fmt.Errorf("no removed account matches %s (trashed accounts are purged after their retention period)", identifier)
+}
+
+// readMovedProfile reads an archived or trashed profile file. Files that are
+// not valid profiles are skipped with a nil profile.
+func readMovedProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+	if data, err = decodeProfileData(path, data); err != nil {
+		return nil, nil
+	}
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, nil
+	}
+	if err := attachCredentials(path, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// restoreMovedProfile moves an archived or trashed profile back into the
+// store, applying the retention admission policy like a new profile
+func (pm *ProfileManager) restoreMovedProfile(profile *Profile, path string) error {
+	if _, err := os.Stat(filepath.Join(pm.profilesDir, profile.filename())); err == nil {
+		return fmt.Errorf("an account for %s is already stored; remove it first", profile.Email)
+	}
+	settings, err := pm.LoadSettings()
+	if err != nil {
+		return err
+	}
+	if err := checkAdmission(settings.Retention, profile); err != nil {
+		return err
+	}
+
+	if err := pm.writeProfile(profile); err != nil {
+		return err
+	}
+	if err := pm.updateConfig(profile.Name, profile.Email, profile.Alias); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s from %s: %w", profile.Email, filepath.Base(filepath.Dir(path)), err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package profile
+
+import "strings"
+
+// TierWeight maps a subscription type to its default rotation weight, so
+// higher-tier seats are chosen more often
+func TierWeight(subscriptionType string) float64 {
+	switch strings.ToLower(subscriptionType) {
+	case "max":
+		return 4
+	case "team", "enterprise":
+		return 2
+	case "pro":
+		return 1
+	default:
+		return 0.5
+	}
+}
+
+// Weight returns a profile's rotation weight: a configured weight when
+// present, otherwise the weight of its subscription tier
+func (r RotationSettings) Weight(profile *Profile) float64 {
+	for _, key := range []string{profile.Name, profile.Alias, profile.Email} {
+		if weight, ok := r.Weights[key]; ok && key != "" {
+			return weight
+		}
+	}
+
+	tier := ""
+	if profile.Credentials != nil {
+		tier = profile.Credentials.ClaudeAiOauth.SubscriptionType
+	}
+	return TierWeight(tier)
+}
+
+// weightedNext picks the next profile so that, over many switches, each one
+// is chosen in proportion to its weight: the profile with the fewest
+// switches per unit of weight wins, ties going to the sequence order after
+// the active profile. It returns nil when all weights are equal, leaving
+// plain round-robin in charge.
+func weightedNext(profiles []*Profile, activeIndex int, rotation RotationSettings) *Profile {
+	weights := make([]float64, len(profiles))
+	uniform := true
+	for i, profile := range profiles {
+		weights[i] = rotation.Weight(profile)
+		if weights[i] != weights[0] {
+			uniform = false
+		}
+	}
+	if uniform {
+		return nil
+	}
+
+	var best *Profile
+	bestStride := 0.0
+	for step := 1; step <= len(profiles); step++ {
+		i := (activeIndex + step) % len(profiles)
+		if i == activeIndex || weights[i] <= 0 {
+			continue
+		}
+
+		stride := float64(profiles[i].SwitchCount+1) / weights[i]
+		if best == nil || stride < bestStride {
+			best, bestStride = profiles[i], stride
+		}
+	}
+	return best
+}
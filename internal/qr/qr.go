@@ -0,0 +1,465 @@
+// Package qr encodes data as QR codes (ISO/IEC 18004) for display in a
+// terminal. It only supports what cflip needs: byte mode, error correction
+// level L and versions 1 to 10, up to MaxBytes bytes per code.
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxBytes is the most data one code holds (version 10, level L)
+const MaxBytes = 271
+
+// version describes the block structure of one version at level L
+type version struct {
+	ecPerBlock int
+	// blocks lists the data codewords of each block
+	blocks    []int
+	alignment []int
+}
+
+var versions = []version{
+	1:  {7, []int{19}, nil},
+	2:  {10, []int{34}, []int{6, 18}},
+	3:  {15, []int{55}, []int{6, 22}},
+	4:  {20, []int{80}, []int{6, 26}},
+	5:  {26, []int{108}, []int{6, 30}},
+	6:  {18, []int{68, 68}, []int{6, 34}},
+	7:  {20, []int{78, 78}, []int{6, 22, 38}},
+	8:  {24, []int{97, 97}, []int{6, 24, 42}},
+	9:  {30, []int{116, 116}, []int{6, 26, 46}},
+	10: {18, []int{68, 68, 69, 69}, []int{6, 28, 50}},
+}
+
+// dataCodewords is the number of data codewords a version holds
+func (v version) dataCodewords() int {
+	total := 0
+	for _, n := range v.blocks {
+		total += n
+	}
+	return total
+}
+
+// Code is an encoded QR code
+type Code struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x and row y is dark. Modules
+// outside the code, such as the quiet zone, are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// String renders the code for a terminal, two rows per line, with the quiet
+// zone around it
+func (c *Code) String() string {
+	const quiet = 4
+	var b strings.Builder
+	for y := -quiet; y < c.Size+quiet; y += 2 {
+		for x := -quiet; x < c.Size+quiet; x++ {
+			// Dark modules are drawn as spaces on a light background, so
+			// the code also scans on terminals with a dark theme
+			top, bottom := !c.Dark(x, y), !c.Dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Encode encodes data in the smallest version that holds it
+func Encode(data []byte) (*Code, error) {
+	for v := 1; v < len(versions); v++ {
+		if len(data) <= capacity(v) {
+			return encode(v, data), nil
+		}
+	}
+	return nil, fmt.Errorf("%d bytes do not fit in a QR code (at most %d)", len(data), MaxBytes)
+}
+
+// countBits is the length of the byte mode character count
+func countBits(v int) int {
+	if v < 10 {
+		return 8
+	}
+	return 16
+}
+
+// capacity is the number of data bytes version v holds
+func capacity(v int) int {
+	return (versions[v].dataCodewords()*8 - 4 - countBits(v)) / 8
+}
+
+// encode builds the code of data in version v, which must hold it
+func encode(v int, data []byte) *Code {
+	codewords := interleave(v, dataCodewords(v, data))
+
+	size := 17 + 4*v
+	m := &matrix{size: size, modules: grid(size), function: grid(size)}
+	m.drawFunctionPatterns(v)
+	m.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormat(mask)
+		if penalty := m.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		m.applyMask(mask) // masking twice undoes it
+	}
+	m.applyMask(best)
+	m.drawFormat(best)
+
+	return &Code{Size: size, modules: m.modules}
+}
+
+// dataCodewords packs data in byte mode and pads it to the version's
+// capacity
+func dataCodewords(v int, data []byte) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits(v))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacityBits := versions[v].dataCodewords() * 8
+	bits.append(0, min(4, capacityBits-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacityBits; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// interleave splits data codewords into the version's blocks, adds each
+// block's error correction and interleaves them as they are placed
+func interleave(v int, data []byte) []byte {
+	ver := versions[v]
+	divisor := rsDivisor(ver.ecPerBlock)
+
+	var blocks, ecBlocks [][]byte
+	longest := 0
+	for _, n := range ver.blocks {
+		block := data[:n]
+		data = data[n:]
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+		longest = max(longest, n)
+	}
+
+	var result []byte
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < ver.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// bitBuffer collects bits, most significant first
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return result
+}
+
+// matrix is a code being built; function marks the modules of patterns
+// that masking and data placement leave alone
+type matrix struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func grid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+// set draws a function module
+func (m *matrix) set(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.function[y][x] = true
+}
+
+func (m *matrix) drawFunctionPatterns(v int) {
+	for i := 0; i < m.size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+
+	positions := versions[v].alignment
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners taken by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format areas; drawFormat fills them in
+	m.drawFormat(0)
+	m.drawVersion(v)
+}
+
+// drawFinder draws a finder pattern and its separator around the center
+func (m *matrix) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= m.size || y >= m.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			m.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (m *matrix) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information for level L and
+// mask, and the dark module
+func (m *matrix) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+// formatBits is the 15-bit format information of level L with mask
+func formatBits(mask int) int {
+	data := 0b01<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits is the 18-bit version information of version v
+func versionBits(v int) int {
+	rem := v
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return v<<12 | rem
+}
+
+// drawVersion draws both copies of the version information of versions 7
+// and up
+func (m *matrix) drawVersion(v int) {
+	if v < 7 {
+		return
+	}
+	bits := versionBits(v)
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the zigzag order of the standard,
+// two columns at a time from the bottom right
+func (m *matrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert // upward
+				}
+				if !m.function[y][x] && i < len(codewords)*8 {
+					m.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if !m.function[y][x] && masked(mask, x, y) {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores how hard the code is to scan, by the four rules of the
+// standard; the mask with the lowest score is used
+func (m *matrix) penalty() int {
+	score := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return m.modules[x][y]
+		}
+		return m.modules[y][x]
+	}
+
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < m.size; y++ {
+			run := 1
+			for x := 1; x <= m.size; x++ {
+				if x < m.size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Finder-like patterns with four light modules on one side
+			for x := 0; x+len(finder) <= m.size; x++ {
+				match := true
+				for i, dark := range finder {
+					if at(x+i, y, vertical) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (m.light(at, x-4, x, y, vertical) || m.light(at, x+7, x+11, y, vertical)) {
+					score += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.modules[y][x]
+				if c == m.modules[y][x+1] && c == m.modules[y+1][x] && c == m.modules[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := m.size * m.size
+	score += (abs(dark*20-total*10)+total-1)/total*10 - 10
+
+	return score
+}
+
+// light reports whether the modules from..to (exclusive) of a line are light
+// or outside the code
+func (m *matrix) light(at func(x, y int, vertical bool) bool, from, to, y int, vertical bool) bool {
+	for x := from; x < to; x++ {
+		if x >= 0 && x < m.size && at(x, y, vertical) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,172 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, the worked example of the standard's
+	// annex
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("error correction = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	if got := formatBits(0); got != 0b111011111000100 {
+		t.Errorf("format bits of L, mask 0 = %015b", got)
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("version bits of 7 = %018b", got)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 17, 32, 53, 78, 106, 134, 154, 192, 230, 250, MaxBytes} {
+		data := bytes.Repeat([]byte("CFLIPQR:1/2:eyJmb3JtYXQiOiJjZmxpcC10cmFuc2Zlci"), MaxBytes)[:n]
+		code, err := Encode(data)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if got := readBack(t, code); !bytes.Equal(got, data) {
+			t.Fatalf("%d bytes: read back %q", n, got)
+		}
+	}
+
+	if _, err := Encode(make([]byte, MaxBytes+1)); err == nil {
+		t.Fatal("data larger than MaxBytes was encoded")
+	}
+}
+
+func TestEncodePicksSmallestVersion(t *testing.T) {
+	for v := 1; v < len(versions); v++ {
+		code, err := Encode(make([]byte, capacity(v)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code.Size != 17+4*v {
+			t.Errorf("%d bytes: size %d, want version %d", capacity(v), code.Size, v)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	code, err := Encode([]byte("cflip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(code.String(), "\n"), "\n")
+	if want := (code.Size + 8 + 1) / 2; len(lines) != want {
+		t.Errorf("%d lines, want %d", len(lines), want)
+	}
+	// The quiet zone is light, drawn as full blocks
+	if !strings.HasPrefix(lines[0], strings.Repeat("█", code.Size+8)) {
+		t.Errorf("first line is not quiet zone: %q", lines[0])
+	}
+}
+
+// readBack decodes a code the way a scanner would: it reads the format,
+// removes the mask, collects the codewords, checks each block's error
+// correction and parses the byte mode segment
+func readBack(t *testing.T, code *Code) []byte {
+	t.Helper()
+	v := (code.Size - 17) / 4
+
+	format := 0
+	bit := func(x, y, i int) {
+		if code.Dark(x, y) {
+			format |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		bit(8, i, i)
+	}
+	bit(8, 7, 6)
+	bit(8, 8, 7)
+	bit(7, 8, 8)
+	for i := 9; i < 15; i++ {
+		bit(14-i, 8, i)
+	}
+	mask := -1
+	for candidate := 0; candidate < 8; candidate++ {
+		if formatBits(candidate) == format {
+			mask = candidate
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("unknown format bits %015b", format)
+	}
+
+	m := &matrix{size: code.Size, modules: grid(code.Size), function: grid(code.Size)}
+	m.drawFunctionPatterns(v)
+	ver := versions[v]
+	total := ver.dataCodewords() + ver.ecPerBlock*len(ver.blocks)
+	codewords := make([]byte, total)
+	i := 0
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < code.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = code.Size - 1 - vert
+				}
+				if m.function[y][x] || i >= total*8 {
+					continue
+				}
+				if code.Dark(x, y) != masked(mask, x, y) {
+					codewords[i/8] |= 0x80 >> (i % 8)
+				}
+				i++
+			}
+		}
+	}
+
+	blocks := make([][]byte, len(ver.blocks))
+	pos := 0
+	for k := 0; k < ver.blocks[len(ver.blocks)-1]; k++ {
+		for b, n := range ver.blocks {
+			if k < n {
+				blocks[b] = append(blocks[b], codewords[pos])
+				pos++
+			}
+		}
+	}
+	ec := make([][]byte, len(ver.blocks))
+	for k := 0; k < ver.ecPerBlock; k++ {
+		for b := range ver.blocks {
+			ec[b] = append(ec[b], codewords[pos])
+			pos++
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		if want := rsRemainder(block, rsDivisor(ver.ecPerBlock)); !bytes.Equal(ec[b], want) {
+			t.Fatalf("block %d: error correction does not match", b)
+		}
+		data = append(data, block...)
+	}
+
+	read := func(from, n int) int {
+		value := 0
+		for k := from; k < from+n; k++ {
+			value = value<<1 | int(data[k/8]>>(7-k%8)&1)
+		}
+		return value
+	}
+	if mode := read(0, 4); mode != 0b0100 {
+		t.Fatalf("mode %04b, want byte mode", mode)
+	}
+	count := read(4, countBits(v))
+	result := make([]byte, count)
+	for k := range result {
+		result[k] = byte(read(4+countBits(v)+8*k, 8))
+	}
+	return result
+}
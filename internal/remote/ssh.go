@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Host runs commands and file operations on a remote machine over SSH
+type Host struct {
+	Target string // user@host or an ssh config alias
+}
+
+// NewHost validates target and returns a remote host handle
+func NewHost(target string) (*Host, error) {
+	if target == "" {
+		return nil, fmt.Errorf("remote host cannot be empty")
+	}
+	// Guard against option injection into the ssh command line
+	if strings.HasPrefix(target, "-") {
+		return nil, fmt.Errorf("invalid remote host: %s", target)
+	}
+
+	return &Host{Target: target}, nil
+}
+
+// Run executes a shell command on the remote host, feeding stdin if provided
+func (h *Host) Run(command string, stdin []byte) ([]byte, error) {
+	cmd := exec.Command("ssh", "-o", "BatchMode=yes", h.Target, command)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w (%s)", h.Target, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+// ReadFile reads a file relative to the remote user's home directory
+func (h *Host) ReadFile(path string) ([]byte, error) {
+	return h.Run(fmt.Sprintf(`cat "$HOME/%s"`, path), nil)
+}
+
+// WriteFile atomically writes a file relative to the remote home directory
+// with 600 permissions, creating its parent directory (700) if needed
+func (h *Host) WriteFile(path string, data []byte) error {
+	script := fmt.Sprintf(`umask 077 && mkdir -p "$(dirname "$HOME/%[1]s")" && cat > "$HOME/%[1]s.tmp" && mv "$HOME/%[1]s.tmp" "$HOME/%[1]s"`, path)
+	_, err := h.Run(script, data)
+	return err
+}
+
+// Forward runs cflip on the remote host with args, attached to the local
+// terminal, and returns the remote exit code
+func (h *Host) Forward(args []string) (int, error) {
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, "cflip")
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	cmd := exec.Command("ssh", "-t", h.Target, strings.Join(quoted, " "))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, fmt.Errorf("failed to run cflip on %s: %w", h.Target, err)
+	}
+
+	return 0, nil
+}
+
+// shellQuote single-quotes s for safe use in a POSIX shell command line
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
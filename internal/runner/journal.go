@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// Journal is a file recording a change that still has to be undone. It is
+// written before the change is applied and removed after it is restored, so
+// finding one means the process running the command died in between.
+type Journal struct {
+	path string
+}
+
+// journalEntry is the journal's on-disk form
+type journalEntry struct {
+	PID       int             `json:"pid"`
+	Command   string          `json:"command"`
+	StartedAt time.Time       `json:"started_at"`
+	State     json.RawMessage `json:"state"`
+}
+
+// NewJournal returns the journal kept at path
+func NewJournal(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// Begin records state, what Restore needs to undo the change
+func (j *Journal) Begin(command string, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal state: %w", err)
+	}
+	entry, err := json.MarshalIndent(journalEntry{
+		PID:       os.Getpid(),
+		Command:   command,
+		StartedAt: time.Now().UTC(),
+		State:     data,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(j.path, entry, 0o600); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Pending reads the state of an unfinished run into state, reporting
+// whether there was one
+func (j *Journal) Pending(state any) (bool, error) {
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var entry journalEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return false, fmt.Errorf("failed to parse journal %s: %w", j.path, err)
+	}
+	if err := json.Unmarshal(entry.State, state); err != nil {
+		return false, fmt.Errorf("failed to parse journal %s: %w", j.path, err)
+	}
+	return true, nil
+}
+
+// Clear removes the journal once the change is undone
+func (j *Journal) Clear() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
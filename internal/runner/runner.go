@@ -0,0 +1,88 @@
+// Package runner runs a command with a temporary change applied around it,
+// such as another account's credentials. The change is undone however the
+// command ends, and a journal lets a later run undo it when cflip itself was
+// killed first.
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// Change is a temporary change and how to undo it
+type Change struct {
+	Apply   func() error
+	Restore func() error
+}
+
+// Run applies change, runs the command with the terminal attached and then
+// restores, also when the command fails, is killed or panics inside cflip.
+// It returns the command's exit code (128+n when killed by signal n).
+func Run(change Change, name string, args ...string) (code int, err error) {
+	binary, err := exec.LookPath(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s not found in PATH: %w", name, err)
+	}
+
+	if err := change.Apply(); err != nil {
+		return 0, err
+	}
+	defer func() {
+		if restoreErr := change.Restore(); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to restore: %w", restoreErr))
+		}
+	}()
+
+	return runAttached(binary, args)
+}
+
+// runAttached runs a binary with cflip's stdio. Ctrl-C and Ctrl-\ already
+// reach the command through the terminal, so cflip only ignores them to
+// survive and restore; SIGTERM and SIGHUP sent to cflip are passed on.
+func runAttached(binary string, args []string) (int, error) {
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 4)
+	signal.Notify(signals, os.Interrupt, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", binary, err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGTERM || sig == syscall.SIGHUP {
+					cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	default:
+		return 0, fmt.Errorf("failed to run %s: %w", binary, err)
+	}
+}
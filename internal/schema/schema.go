@@ -0,0 +1,213 @@
+// Package schema implements the subset of JSON Schema cflip uses to check
+// its own files: type, required, properties, additionalProperties, items,
+// enum, minLength, minimum and the date-time format.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is a parsed JSON Schema document
+type Schema struct {
+	Type                 Types              `json:"type,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Format               string             `json:"format,omitempty"`
+}
+
+// Types accepts either a single type name or a list of them
+type Types []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("schema type must be a string or list of strings")
+	}
+	*t = list
+	return nil
+}
+
+// Violation is one structural problem, located by a JSONPath-like path
+type Violation struct {
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// Error reports every violation found in a document
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Parse reads a schema document
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// MustParse is Parse for embedded schemas that are known to be valid
+func MustParse(data []byte) *Schema {
+	s, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate checks a JSON document against the schema, returning *Error
+// listing every violation
+func (s *Schema) Validate(document []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return &Error{Violations: []Violation{{Path: "$", Message: "not valid JSON: " + err.Error()}}}
+	}
+
+	var violations []Violation
+	s.validate("$", value, &violations)
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, value interface{}, violations *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(value) {
+		report("expected %s, got %s", strings.Join(s.Type, " or "), typeName(value))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("must be one of %v", s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*violations = append(*violations, Violation{Path: path + "." + name, Message: "required field is missing"})
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if property, ok := s.Properties[key]; ok {
+				property.validate(path+"."+key, v[key], violations)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(path+"."+key, v[key], violations)
+			}
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			if *s.MinLength == 1 {
+				report("must not be empty")
+			} else {
+				report("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				report("not an RFC 3339 date-time: %q", v)
+			}
+		}
+
+	case json.Number:
+		if s.Minimum != nil {
+			if f, err := v.Float64(); err == nil && f < *s.Minimum {
+				report("must be at least %v", *s.Minimum)
+			}
+		}
+	}
+}
+
+// matches reports whether value has one of the allowed types
+func (t Types) matches(value interface{}) bool {
+	actual := typeName(value)
+	for _, allowed := range t {
+		if allowed == actual || (allowed == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON Schema type of a decoded value
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// Backup is one `cflip backup` archive
+type Backup = profile.Backup
+
+// BackupManifest describes what a backup holds
+type BackupManifest = profile.BackupManifest
+
+// CreateBackup writes a timestamped backup of cflip's state and Claude
+// Code's live config and credentials, encrypted when passphrase is set.
+// Older backups beyond keep (settings.backup.keep when 0) are deleted.
+func (s *Service) CreateBackup(passphrase string, keep int) (*Backup, error) {
+	return s.switcher.CreateBackup(passphrase, keep)
+}
+
+// ListBackups returns the stored backups, newest first
+func (s *Service) ListBackups() ([]*Backup, error) {
+	return profile.ListBackups()
+}
+
+// FindBackup resolves a backup by its number in ListBackups (1 is the
+// newest) or by file path
+func (s *Service) FindBackup(ref string) (string, error) {
+	if ref == "" {
+		ref = "1"
+	}
+	if number, err := strconv.Atoi(ref); err == nil {
+		backups, err := profile.ListBackups()
+		if err != nil {
+			return "", err
+		}
+		if len(backups) == 0 {
+			return "", fmt.Errorf("no backups found; create one with `cflip backup`")
+		}
+		if number < 1 || number > len(backups) {
+			return "", fmt.Errorf("invalid backup number: %d (only %d available)", number, len(backups))
+		}
+		return backups[number-1].Path, nil
+	}
+
+	if _, err := os.Stat(ref); err != nil {
+		return "", fmt.Errorf("backup not found: %w", err)
+	}
+	return ref, nil
+}
+
+// IsEncryptedBackup reports whether the backup at path needs a passphrase
+func (s *Service) IsEncryptedBackup(path string) (bool, error) {
+	return profile.IsEncryptedBackup(path)
+}
+
+// InspectBackup reads a backup's manifest, decrypting it when needed
+func (s *Service) InspectBackup(path, passphrase string) (*BackupManifest, error) {
+	manifest, _, err := profile.OpenBackup(path, passphrase)
+	return manifest, err
+}
+
+// RestoreBackup replaces cflip's state and Claude Code's live config and
+// credentials with a backup's. The current state is backed up first; that
+// backup is returned so it can be restored to undo.
+func (s *Service) RestoreBackup(path, passphrase string) (*BackupManifest, *Backup, error) {
+	return s.switcher.RestoreBackup(path, passphrase)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DebugBundleOptions controls what a debug bundle contains
+type DebugBundleOptions struct {
+	KeepEmails bool
+	LogLines   int
+}
+
+// WriteDebugBundle writes a zip for attaching to GitHub issues: version
+// and platform details, the permission audit, the redacted export and the
+// last audit log lines. Everything goes through one redactor, so a hashed
+// value is the same in every file.
+func (s *Service) WriteDebugBundle(path string, opts DebugBundleOptions) error {
+	if opts.LogLines <= 0 {
+		opts.LogLines = DefaultExportLogLines
+	}
+	r := NewRedactor(opts.KeepEmails)
+
+	export := s.exportRedacted(r, 0)
+	exportJSON, err := export.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal export: %w", err)
+	}
+
+	auditLog, err := redactedAuditLog(r, opts.LogLines)
+	if err != nil {
+		auditLog = []string{"failed to read audit log: " + r.String(err.Error())}
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"version.txt", []byte(versionReport())},
+		{"doctor.txt", []byte(s.doctorReport(r))},
+		{"export.json", exportJSON},
+		{"audit.log", []byte(strings.Join(auditLog, "\n") + "\n")},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		header := &zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.GeneratedAt}
+		w, err := archive.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", file.name, err)
+		}
+		if _, err := w.Write(file.data); err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// versionReport lists the cflip, Go, platform and Claude Code versions
+func versionReport() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cflip: %s\n", cflipVersion)
+	fmt.Fprintf(&b, "go: %s\n", runtime.Version())
+	fmt.Fprintf(&b, "platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "claude: %s\n", claudeVersion())
+	return b.String()
+}
+
+// claudeVersion asks the claude CLI for its version, giving up quickly
+func claudeVersion() string {
+	binary, err := exec.LookPath("claude")
+	if err != nil {
+		return "not found in PATH"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, binary, "--version").Output()
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// doctorReport renders the read-only permission audit as text
+func (s *Service) doctorReport(r *Redactor) string {
+	issues, err := s.AuditPermissions(false)
+	if err != nil {
+		return "permission audit failed: " + r.String(err.Error()) + "\n"
+	}
+	if len(issues) == 0 {
+		return "permissions: OK\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("permissions:\n")
+	for _, issue := range issues {
+		secret := ""
+		if issue.Secret {
+			secret = " (secrets readable by other users)"
+		}
+		fmt.Fprintf(&b, "  %s: %s%s\n", r.String(issue.Path), issue.Problem, secret)
+	}
+	return b.String()
+}
+
+// marshalReadable indents v as JSON without escaping the <kind:hash>
+// placeholders
+func marshalReadable(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
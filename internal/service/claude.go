@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// ClaudeRunResult describes one wrapped invocation of the claude CLI
+type ClaudeRunResult struct {
+	Email       string        `json:"email"`
+	ExitCode    int           `json:"exit_code"`
+	RateLimited bool          `json:"rate_limited"`
+	Duration    time.Duration `json:"duration"`
+	// Sync is what was saved from Claude Code into the account's profile
+	// after the run, such as tokens it refreshed; nil when nothing was
+	Sync *SyncResult `json:"sync,omitempty"`
+}
+
+// RunClaude runs the claude CLI with args under the currently active account,
+// attaching the terminal. It reports the exit code and whether Claude hit a
+// rate limit during the run (detected from its session logs). Afterwards the
+// live credentials, which claude may have refreshed, are saved back into the
+// account's profile.
+func (s *Service) RunClaude(args []string) (*ClaudeRunResult, error) {
+	binary, err := exec.LookPath("claude")
+	if err != nil {
+		return nil, fmt.Errorf("claude CLI not found in PATH: %w", err)
+	}
+
+	result := &ClaudeRunResult{}
+	if current, err := s.GetCurrentAccount(); err == nil {
+		result.Email = current.Email
+	}
+
+	offsets := make(map[string]int64)
+	if err := scanLogOffsets(offsets, true); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Let claude handle Ctrl+C itself; the wrapper must survive to clean up
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	started := time.Now()
+	runErr := cmd.Run()
+	result.Duration = time.Since(started)
+
+	// claude may have refreshed or replaced its keychain item while running
+	storage.ResetKeychainCache()
+
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+	case errors.As(runErr, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, fmt.Errorf("failed to run claude: %w", runErr)
+	}
+
+	if limited, err := readNewLogLines(offsets, started); err == nil {
+		result.RateLimited = limited
+	}
+	if result.RateLimited {
+		s.recordRateLimit()
+	}
+
+	// Best-effort: claude may have logged out, or in to an unmanaged account
+	if sync, err := s.switcher.SyncActive(); err == nil && sync.Changed() {
+		logSync(sync)
+		result.Sync = sync
+	}
+
+	return result, nil
+}
+
+// NextHealthyAccount returns the best-ranked account other than the active one
+func (s *Service) NextHealthyAccount() (*Recommendation, error) {
+	return s.nextHealthyAccount()
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// Cooldown records that an account hit its usage limit
+type Cooldown = profile.Cooldown
+
+// SetCooldown records that an account (the active one when identifier is
+// empty) hit its usage limit now, so `switch --available` skips it until
+// resetsAt, or for a full usage window when resetsAt is zero
+func (s *Service) SetCooldown(identifier string, resetsAt time.Time) (*ProfileInfo, *Cooldown, error) {
+	identifier, err := s.cooldownTarget(identifier)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, cooldown, err := s.switcher.SetCooldown(identifier, time.Now(), resetsAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.NewDefault().CooldownSet(p.Email, cooldown.ResetsAt)
+
+	info := s.profileToInfo(p, s.isActiveProfile(p.Name))
+	s.applyCooldowns(info)
+	return info, cooldown, nil
+}
+
+// ClearCooldown forgets an account's usage limit (the active account's when
+// identifier is empty), reporting whether one was recorded
+func (s *Service) ClearCooldown(identifier string) (*ProfileInfo, bool, error) {
+	identifier, err := s.cooldownTarget(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p, cleared, err := s.switcher.ClearCooldown(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	if cleared {
+		logger.NewDefault().CooldownCleared(p.Email)
+	}
+	return s.profileToInfo(p, s.isActiveProfile(p.Name)), cleared, nil
+}
+
+// NextAvailableAccount returns the name of the next account after the
+// active one whose usage window has reset
+func (s *Service) NextAvailableAccount() (string, error) {
+	p, err := s.switcher.NextAvailableProfile()
+	if err != nil {
+		return "", err
+	}
+	return p.Name, nil
+}
+
+// recordRateLimit puts the active account on cooldown after a usage limit
+// was detected in Claude Code's session logs. It is best-effort: the switch
+// that usually follows matters more than the bookkeeping.
+func (s *Service) recordRateLimit() {
+	_, _, _ = s.SetCooldown("", time.Time{})
+}
+
+// cooldownTarget resolves identifier to a profile name, defaulting to the
+// active profile
+func (s *Service) cooldownTarget(identifier string) (string, error) {
+	if identifier == "" {
+		current, err := s.GetCurrentAccount()
+		if err != nil {
+			return "", err
+		}
+		return current.Name, nil
+	}
+
+	resolution, err := s.ResolveIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+	return resolution.Profile.Name, nil
+}
+
+// isActiveProfile reports whether name is the active profile
+func (s *Service) isActiveProfile(name string) bool {
+	active, err := s.switcher.GetCurrentActiveProfile()
+	return err == nil && active.Name == name
+}
+
+// applyCooldowns marks the accounts still cooling down after a usage limit
+func (s *Service) applyCooldowns(infos ...*ProfileInfo) {
+	cooldowns, err := s.switcher.Cooldowns()
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		if cooldown, ok := cooldowns[info.Name]; ok && info.Source == "" {
+			info.CooldownUntil = cooldown.ResetsAt.Local().Format("2006-01-02 15:04:05")
+		}
+	}
+}
+
+// CooldownBadge is "LIMITED until ..." while the account is cooling down
+// after a usage limit, or empty
+func (p *ProfileInfo) CooldownBadge() string {
+	if p.CooldownUntil == "" {
+		return ""
+	}
+	resetsAt, err := time.ParseInLocation("2006-01-02 15:04:05", p.CooldownUntil, time.Local)
+	if err != nil {
+		return "LIMITED"
+	}
+	if resetsAt.Format(time.DateOnly) == time.Now().Format(time.DateOnly) {
+		return "LIMITED until " + resetsAt.Format("15:04")
+	}
+	return "LIMITED until " + resetsAt.Format("Jan 2 15:04")
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// maxCrashReports is how many crash reports are kept
+const maxCrashReports = 10
+
+// IssuesURL is where users are asked to report crashes
+const IssuesURL = "https://github.com/phathdt/claude-flip/issues/new"
+
+// WriteCrashReport saves a panic's stack, the cflip version, the platform
+// and the command line to a file in the data directory. Tokens, UUIDs,
+// emails and the home directory are redacted, so the report is safe to
+// attach to an issue. It returns the report's path.
+func WriteCrashReport(recovered interface{}, stack []byte, args []string) (string, error) {
+	r := NewRedactor(false)
+
+	var b strings.Builder
+	b.WriteString("cflip crash report\n\n")
+	fmt.Fprintf(&b, "time: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "version: %s\n", cflipVersion)
+	fmt.Fprintf(&b, "platform: %s/%s (%s)\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	fmt.Fprintf(&b, "command: %s\n", r.String(strings.Join(redactArgs(args), " ")))
+	fmt.Fprintf(&b, "panic: %s\n\n", r.String(fmt.Sprint(recovered)))
+	b.WriteString(r.String(string(stack)))
+
+	dir, err := profile.DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "crash-reports")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create crash report directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s.txt", time.Now().UTC().Format("20060102T150405.000")))
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+
+	pruneCrashReports(dir)
+	return path, nil
+}
+
+// redactArgs drops values of flags that may carry secrets or file contents
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	hideNext := false
+	for i, arg := range args {
+		switch {
+		case hideNext:
+			out[i] = "<redacted>"
+			hideNext = false
+		case isSecretKey(strings.TrimLeft(arg, "-")) && strings.HasPrefix(arg, "-"):
+			name, _, hasValue := strings.Cut(arg, "=")
+			if hasValue {
+				out[i] = name + "=<redacted>"
+			} else {
+				out[i] = arg
+				hideNext = true
+			}
+		default:
+			out[i] = arg
+		}
+	}
+	if len(out) > 0 {
+		out[0] = filepath.Base(out[0])
+	}
+	return out
+}
+
+// pruneCrashReports keeps only the newest reports
+func pruneCrashReports(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "crash-") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > maxCrashReports {
+		os.Remove(filepath.Join(dir, names[0]))
+		names = names[1:]
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+)
+
+// dashboardSwitches is how many recent switches the dashboard lists
+const dashboardSwitches = 5
+
+// DashboardAccount is one account row of `cflip dashboard`
+type DashboardAccount struct {
+	Name         string
+	Email        string
+	Alias        string
+	Organization string
+	Tier         string
+	IsActive     bool
+	ExpiresAt    time.Time // zero when unknown
+	HasToken     bool
+	WindowUsed   time.Duration
+	LastActiveAt time.Time
+}
+
+// DashboardSwitch is one entry of the recent switch history
+type DashboardSwitch struct {
+	Time time.Time
+	From string
+	To   string
+}
+
+// DashboardSnapshot is everything `cflip dashboard` shows at one moment
+type DashboardSnapshot struct {
+	Taken    time.Time
+	Accounts []DashboardAccount
+	Switches []DashboardSwitch // newest first
+}
+
+// Dashboard gathers the account pool's state: token expiry, time used in
+// the current usage window, and recent switches from the audit log
+func (s *Service) Dashboard() (*DashboardSnapshot, error) {
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	now := time.Now()
+	sessions, err := loadSessions(now)
+	if err != nil {
+		return nil, err
+	}
+	used := activeSince(sessions, now.Add(-usageWindow))
+
+	activeName := ""
+	if active, err := s.switcher.GetCurrentActiveProfile(); err == nil {
+		activeName = active.Name
+	}
+
+	snapshot := &DashboardSnapshot{Taken: now}
+	for _, p := range profiles {
+		account := DashboardAccount{
+			Name:         p.Name,
+			Email:        p.Email,
+			Alias:        p.Alias,
+			Organization: p.OrganizationName(),
+			IsActive:     p.Name == activeName,
+			WindowUsed:   usedBy(used, p, profiles),
+			LastActiveAt: p.LastActiveAt,
+		}
+		if account.WindowUsed > usageWindow {
+			account.WindowUsed = usageWindow
+		}
+		if p.Credentials != nil {
+			account.Tier = p.Credentials.ClaudeAiOauth.SubscriptionType
+			account.HasToken = p.Credentials.ClaudeAiOauth.AccessToken != ""
+			if p.Credentials.ClaudeAiOauth.ExpiresAt != 0 {
+				account.ExpiresAt = p.Credentials.ExpiresAtTime()
+			}
+		}
+		snapshot.Accounts = append(snapshot.Accounts, account)
+	}
+
+	events, err := logger.ReadAuditLog()
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.Action == "account_switched" && event.Attrs["to_email"] != "" {
+			snapshot.Switches = append(snapshot.Switches, DashboardSwitch{
+				Time: event.Time,
+				From: event.Attrs["from_email"],
+				To:   event.Attrs["to_email"],
+			})
+		}
+	}
+	sort.Slice(snapshot.Switches, func(i, j int) bool {
+		return snapshot.Switches[i].Time.After(snapshot.Switches[j].Time)
+	})
+	if len(snapshot.Switches) > dashboardSwitches {
+		snapshot.Switches = snapshot.Switches[:dashboardSwitches]
+	}
+
+	return snapshot, nil
+}
+
+// Label is the account's alias with its email, or just the email
+func (a DashboardAccount) Label() string {
+	if a.Alias != "" {
+		return fmt.Sprintf("%s (%s)", a.Alias, a.Email)
+	}
+	return a.Email
+}
+
+// TokenStatus counts down to token expiry as of now
+func (a DashboardAccount) TokenStatus(now time.Time) string {
+	switch {
+	case !a.HasToken:
+		return "no token"
+	case a.ExpiresAt.IsZero():
+		return "expiry unknown"
+	case now.After(a.ExpiresAt):
+		return "expired " + formatDuration(now.Sub(a.ExpiresAt)) + " ago"
+	}
+	return "expires in " + formatCountdown(a.ExpiresAt.Sub(now))
+}
+
+// WindowFraction is the share of the 5-hour usage window already used
+func (a DashboardAccount) WindowFraction() float64 {
+	return float64(a.WindowUsed) / float64(usageWindow)
+}
+
+// WindowLabel renders the time used in the current usage window
+func (a DashboardAccount) WindowLabel() string {
+	if a.WindowUsed < time.Minute {
+		return "0m/5h"
+	}
+	return formatDuration(a.WindowUsed) + "/5h"
+}
+
+// LastUsed renders when the account was last switched to
+func (a DashboardAccount) LastUsed() string {
+	if a.LastActiveAt.IsZero() {
+		return "never"
+	}
+	return humanizeSince(a.LastActiveAt)
+}
+
+// formatCountdown renders a duration with seconds when under an hour, so
+// countdowns visibly tick
+func formatCountdown(d time.Duration) string {
+	if d >= time.Hour {
+		return formatDuration(d)
+	}
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// Doctor check statuses
+const (
+	DoctorPass = "pass"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// DoctorCheck is the outcome of one `cflip doctor` check
+type DoctorCheck struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+// DoctorSummary counts checks by status
+type DoctorSummary struct {
+	Pass int `json:"pass"`
+	Warn int `json:"warn"`
+	Fail int `json:"fail"`
+}
+
+// DoctorReport is everything `cflip doctor` found
+type DoctorReport struct {
+	Platform string        `json:"platform"`
+	Checks   []DoctorCheck `json:"checks"`
+	Summary  DoctorSummary `json:"summary"`
+}
+
+// Healthy reports whether no check failed
+func (r *DoctorReport) Healthy() bool {
+	return r.Summary.Fail == 0
+}
+
+// Doctor checks the platform's capabilities, access to Claude Code's
+// credentials and config, cflip's files, and every stored profile. With
+// fixPerms, loose permissions are tightened while checking.
+func (s *Service) Doctor(fixPerms bool) *DoctorReport {
+	report := &DoctorReport{Platform: runtime.GOOS + "/" + runtime.GOARCH}
+	for _, check := range []func() DoctorCheck{
+		doctorPlatform,
+		doctorCredentials,
+		doctorClaudeConfig,
+		doctorDataDir,
+		func() DoctorCheck { return doctorPermissions(fixPerms) },
+		s.doctorTokens,
+		s.doctorProfileIndex,
+		s.doctorClaudeRunning,
+	} {
+		result := check()
+		switch result.Status {
+		case DoctorPass:
+			report.Summary.Pass++
+		case DoctorWarn:
+			report.Summary.Warn++
+		default:
+			report.Summary.Fail++
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// doctorPlatform checks the OS is supported and the tools cflip calls exist
+func doctorPlatform() DoctorCheck {
+	check := DoctorCheck{Name: "platform", Status: DoctorPass}
+
+	var required, optional []string
+	switch runtime.GOOS {
+	case "darwin":
+		required, optional = []string{"security", "pgrep"}, []string{"claude"}
+	case "linux":
+		required, optional = []string{"pgrep"}, []string{"claude"}
+		if storage.LinuxCredentialBackend() == storage.BackendSecretService {
+			required = append(required, "secret-tool")
+		}
+	default:
+		check.Status, check.Message = DoctorFail, fmt.Sprintf("%s is not supported; cflip runs on macOS and Linux", runtime.GOOS)
+		return check
+	}
+
+	var missing []string
+	for _, tool := range required {
+		if path, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+			check.Details = append(check.Details, tool+": not found")
+		} else {
+			check.Details = append(check.Details, tool+": "+path)
+		}
+	}
+	for _, tool := range optional {
+		if path, err := exec.LookPath(tool); err != nil {
+			check.Details = append(check.Details, tool+": not found (optional)")
+		} else {
+			check.Details = append(check.Details, tool+": "+path)
+		}
+	}
+
+	// Stored profiles' credentials may live in a backend other than the
+	// platform's native one (settings.storage_backend)
+	backendName := storage.ProfileBackend()
+	var backendErr error
+	if backend, err := storage.LookupBackend(backendName); err != nil {
+		backendErr = err
+	} else {
+		backendErr = backend.Available()
+	}
+	if backendErr != nil {
+		check.Details = append(check.Details, fmt.Sprintf("profile credentials: %s (%v)", backendName, backendErr))
+	} else {
+		check.Details = append(check.Details, "profile credentials: "+backendName)
+	}
+
+	check.Message = fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+	if len(missing) > 0 {
+		check.Status = DoctorFail
+		check.Message += fmt.Sprintf(", %d required tool(s) missing", len(missing))
+	}
+	if backendErr != nil {
+		check.Status = DoctorFail
+		check.Message += fmt.Sprintf(", storage backend %s unavailable", backendName)
+	}
+	return check
+}
+
+// doctorCredentials checks Claude Code's live credentials can be reached
+func doctorCredentials() DoctorCheck {
+	check := DoctorCheck{Name: "credentials"}
+	location, err := storage.ProbeLiveCredentials()
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		if errors.Is(err, storage.ErrKeychainLocked) {
+			check.Details = []string{"unlock it with `security unlock-keychain`"}
+		}
+		return check
+	}
+	check.Status, check.Message = DoctorPass, "found in "+location
+	return check
+}
+
+// doctorClaudeConfig checks ~/.claude.json exists and parses
+func doctorClaudeConfig() DoctorCheck {
+	check := DoctorCheck{Name: "claude_config"}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		return check
+	}
+	path := filepath.Join(home, ".claude.json")
+
+	if _, err := os.Stat(path); err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		check.Details = []string{"start Claude Code and log in to create it"}
+		return check
+	}
+	claudeConfig, err := config.LoadClaudeConfigFile(path)
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		return check
+	}
+
+	check.Status, check.Message = DoctorPass, path
+	if account, ok := (*claudeConfig)["oauthAccount"].(map[string]interface{}); ok {
+		if email, ok := account["emailAddress"].(string); ok {
+			check.Message += " (" + email + ")"
+		}
+	}
+	return check
+}
+
+// doctorDataDir checks cflip's data and config directories are usable
+func doctorDataDir() DoctorCheck {
+	check := DoctorCheck{Name: "data_dir", Status: DoctorPass}
+	dataDir, err := profile.DataDir()
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		return check
+	}
+	configDir, err := profile.ConfigDir()
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		return check
+	}
+
+	check.Message = dataDir
+	for _, dir := range []string{dataDir, configDir} {
+		info, err := os.Stat(dir)
+		switch {
+		case err != nil:
+			check.Status = DoctorFail
+			check.Details = append(check.Details, fmt.Sprintf("%s: %v", dir, err))
+		case !info.IsDir():
+			check.Status = DoctorFail
+			check.Details = append(check.Details, dir+": not a directory")
+		default:
+			probe, err := os.CreateTemp(dir, ".doctor-*")
+			if err != nil {
+				check.Status = DoctorFail
+				check.Details = append(check.Details, fmt.Sprintf("%s: not writable (%v)", dir, err))
+				continue
+			}
+			probe.Close()
+			os.Remove(probe.Name())
+		}
+	}
+	return check
+}
+
+// doctorPermissions audits file modes and ownership
+func doctorPermissions(fix bool) DoctorCheck {
+	check := DoctorCheck{Name: "permissions", Status: DoctorPass}
+	issues, err := profile.AuditPermissions(fix)
+	if err != nil {
+		check.Status, check.Message = DoctorFail, fmt.Sprintf("failed to audit permissions: %v", err)
+		return check
+	}
+
+	var open, fixed int
+	for _, issue := range issues {
+		switch {
+		case issue.Fixed:
+			fixed++
+			check.Details = append(check.Details, fmt.Sprintf("%s: %s - fixed", issue.Path, issue.Problem))
+		case issue.Secret:
+			open++
+			check.Status = DoctorFail
+			check.Details = append(check.Details, fmt.Sprintf("%s: %s - secrets readable by other users", issue.Path, issue.Problem))
+		default:
+			open++
+			if check.Status == DoctorPass {
+				check.Status = DoctorWarn
+			}
+			check.Details = append(check.Details, fmt.Sprintf("%s: %s", issue.Path, issue.Problem))
+		}
+	}
+
+	switch {
+	case open > 0 && !fix:
+		check.Message = fmt.Sprintf("%d problem(s); run `cflip doctor --fix-perms`", open)
+	case open > 0:
+		check.Message = fmt.Sprintf("%d problem(s) could not be fixed", open)
+	case fixed > 0:
+		check.Message = fmt.Sprintf("%d problem(s) fixed", fixed)
+	default:
+		check.Message = "files are private to you"
+	}
+	return check
+}
+
+// doctorTokens checks every stored profile has a token that has not expired
+func (s *Service) doctorTokens() DoctorCheck {
+	check := DoctorCheck{Name: "tokens", Status: DoctorPass}
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		return check
+	}
+	if len(profiles) == 0 {
+		check.Status, check.Message = DoctorWarn, "no accounts stored; run `cflip add`"
+		return check
+	}
+
+	now := time.Now()
+	var missing, expired int
+	for _, p := range profiles {
+		name := p.Name
+		if p.Alias != "" {
+			name = p.Alias
+		}
+		switch {
+		case p.Credentials == nil || p.Credentials.ClaudeAiOauth.AccessToken == "":
+			missing++
+			check.Status = DoctorFail
+			check.Details = append(check.Details, name+": no token stored")
+		case p.Credentials.ClaudeAiOauth.ExpiresAt == 0:
+			check.Details = append(check.Details, name+": expiry unknown")
+		case now.After(p.Credentials.ExpiresAtTime()):
+			expired++
+			if check.Status == DoctorPass {
+				check.Status = DoctorWarn
+			}
+			check.Details = append(check.Details, fmt.Sprintf("%s: expired %s ago", name, formatDuration(now.Sub(p.Credentials.ExpiresAtTime()))))
+		default:
+			check.Details = append(check.Details, fmt.Sprintf("%s: expires in %s", name, formatCountdown(p.Credentials.ExpiresAtTime().Sub(now))))
+		}
+	}
+
+	switch {
+	case missing > 0:
+		check.Message = fmt.Sprintf("%d of %d account(s) have no token; log in and `cflip add` again", missing, len(profiles))
+	case expired > 0:
+		check.Message = fmt.Sprintf("%d of %d account(s) expired; `cflip refresh` renews them", expired, len(profiles))
+	default:
+		check.Message = fmt.Sprintf("%d account(s) checked", len(profiles))
+	}
+	return check
+}
+
+// doctorProfileIndex checks profile files and config.json agree
+func (s *Service) doctorProfileIndex() DoctorCheck {
+	check := DoctorCheck{Name: "profile_index", Status: DoctorPass}
+	problems, err := s.switcher.IndexProblems()
+	if err != nil {
+		check.Status, check.Message = DoctorFail, err.Error()
+		return check
+	}
+	if len(problems) == 0 {
+		check.Message = "profile files match config.json"
+		return check
+	}
+	check.Status = DoctorWarn
+	check.Message = fmt.Sprintf("%d problem(s) with profile files; `cflip validate --fix` repairs the index", len(problems))
+	check.Details = problems
+	return check
+}
+
+// doctorClaudeRunning warns when Claude Code is running, since switching
+// then needs --force
+func (s *Service) doctorClaudeRunning() DoctorCheck {
+	check := DoctorCheck{Name: "claude_running", Status: DoctorPass, Message: "Claude Code is not running"}
+	if err := s.checkClaudeCodeNotRunning(); err != nil {
+		check.Status, check.Message = DoctorWarn, err.Error()
+	}
+	return check
+}
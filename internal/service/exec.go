@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/runner"
+)
+
+// execJournalName records, in the data directory, the account `cflip exec`
+// has to switch back to
+const execJournalName = "exec-restore.json"
+
+// ExecResult describes a command run under a temporarily applied account
+type ExecResult struct {
+	Account  string `json:"account"`
+	ExitCode int    `json:"exit_code"`
+	// Restored is the account switched back to afterwards; empty when the
+	// account was already active or no stored account was active before
+	Restored string `json:"restored,omitempty"`
+	// Recovered is the account an earlier, interrupted exec left unrestored
+	// and which was switched back to first
+	Recovered string `json:"recovered,omitempty"`
+}
+
+// execState is what the exec journal records
+type execState struct {
+	Previous string `json:"previous"`
+}
+
+// Exec runs command with identifier's account applied, then switches back
+// to the account active before. The data directory stays locked for the
+// whole run, so other cflip processes cannot switch in between.
+func (s *Service) Exec(identifier string, command []string, force bool) (*ExecResult, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command to run")
+	}
+
+	locked, unlock, err := s.switcher.Lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	// Everything below switches under the lock just taken
+	s = &Service{switcher: locked}
+	s.SetInitiator("exec")
+
+	journal, err := execJournal()
+	if err != nil {
+		return nil, err
+	}
+	result := &ExecResult{}
+	if result.Recovered, err = s.recoverExec(journal); err != nil {
+		return nil, err
+	}
+
+	target, err := s.GetAccountByIdentifier(identifier)
+	if err != nil {
+		return nil, err
+	}
+	result.Account = target.Name
+
+	previous := ""
+	if current, err := s.GetCurrentAccount(); err == nil && current.Name != target.Name {
+		previous = current.Name
+	}
+	// The borrowed account should not become what `cflip undo` returns to
+	undoTarget, _ := s.switcher.PreviousProfile()
+
+	change := runner.Change{Apply: func() error { return nil }, Restore: func() error { return nil }}
+	if !target.IsActive {
+		change.Apply = func() error {
+			if previous != "" {
+				if err := journal.Begin(strings.Join(command, " "), execState{Previous: previous}); err != nil {
+					return err
+				}
+			}
+			if _, err := s.Switch(target.Name, force); err != nil {
+				journal.Clear()
+				return err
+			}
+			return nil
+		}
+		change.Restore = func() error {
+			if previous == "" {
+				return nil
+			}
+			// The command has exited, so Claude Code's safety check would
+			// only keep the borrowed account applied
+			if _, err := s.Switch(previous, true); err != nil {
+				return err
+			}
+			if err := s.switcher.SetPreviousProfile(undoTarget); err != nil {
+				return err
+			}
+			result.Restored = previous
+			return journal.Clear()
+		}
+	}
+
+	result.ExitCode, err = runner.Run(change, command[0], command[1:]...)
+	return result, err
+}
+
+// recoverExec switches back to the account an interrupted exec borrowed
+// from, returning its name
+func (s *Service) recoverExec(journal *runner.Journal) (string, error) {
+	var state execState
+	pending, err := journal.Pending(&state)
+	if err != nil || !pending {
+		return "", err
+	}
+	if state.Previous != "" {
+		if _, err := s.Switch(state.Previous, true); err != nil {
+			return "", fmt.Errorf("failed to restore %s after an interrupted exec: %w", state.Previous, err)
+		}
+	}
+	return state.Previous, journal.Clear()
+}
+
+// execJournal returns the journal of the account exec switches back to
+func execJournal() (*runner.Journal, error) {
+	dataDir, err := profile.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return runner.NewJournal(filepath.Join(dataDir, execJournalName)), nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// DefaultExportLogLines is how many audit log lines a redacted export keeps
+const DefaultExportLogLines = 200
+
+// RedactedExport is a sanitized snapshot of cflip's state for bug reports.
+// Tokens and UUIDs are always hashed; emails unless KeepEmails was set.
+type RedactedExport struct {
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Version      string            `json:"cflip_version"`
+	Platform     PlatformInfo      `json:"platform"`
+	Config       interface{}       `json:"config,omitempty"`
+	ClaudeConfig map[string]string `json:"claude_config_structure,omitempty"`
+	OAuthAccount interface{}       `json:"oauth_account,omitempty"`
+	Profiles     interface{}       `json:"profiles"`
+	AuditLog     []string          `json:"audit_log,omitempty"`
+	Errors       map[string]string `json:"errors,omitempty"`
+}
+
+// PlatformInfo describes the machine an export was taken on
+type PlatformInfo struct {
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	GoVersion  string `json:"go_version"`
+	DataDir    string `json:"data_dir"`
+	ConfigDir  string `json:"config_dir"`
+	Storage    string `json:"credential_storage"`
+	ClaudeHome string `json:"claude_home"`
+}
+
+// ExportOptions controls what a redacted export keeps
+type ExportOptions struct {
+	KeepEmails bool
+	LogLines   int
+}
+
+// ExportRedacted collects config structure, profile metadata, platform
+// info and recent audit log lines with every secret hashed. Sections that
+// cannot be read are reported under Errors rather than failing the export.
+func (s *Service) ExportRedacted(opts ExportOptions) *RedactedExport {
+	if opts.LogLines <= 0 {
+		opts.LogLines = DefaultExportLogLines
+	}
+	return s.exportRedacted(NewRedactor(opts.KeepEmails), opts.LogLines)
+}
+
+// exportRedacted builds the export with r, so other outputs redacted with
+// the same redactor hash values identically
+func (s *Service) exportRedacted(r *Redactor, logLines int) *RedactedExport {
+	export := &RedactedExport{
+		GeneratedAt: time.Now().UTC(),
+		Version:     cflipVersion,
+		Platform:    platformInfo(r),
+		Errors:      make(map[string]string),
+	}
+
+	// cflip's own config, including settings and the profile index
+	if dir, err := profile.ConfigDir(); err != nil {
+		export.Errors["config"] = err.Error()
+	} else if value, err := readJSONFile(filepath.Join(dir, "config.json")); err != nil {
+		export.Errors["config"] = r.String(err.Error())
+	} else {
+		export.Config = r.Value(value)
+	}
+
+	// Claude Code's config holds project history, so only its shape and
+	// the account block are exported
+	if home, err := os.UserHomeDir(); err != nil {
+		export.Errors["claude_config"] = err.Error()
+	} else if value, err := readJSONFile(filepath.Join(home, ".claude.json")); err != nil {
+		export.Errors["claude_config"] = r.String(err.Error())
+	} else if fields, ok := value.(map[string]interface{}); ok {
+		export.ClaudeConfig = make(map[string]string, len(fields))
+		for key, field := range fields {
+			export.ClaudeConfig[r.String(key)] = jsonType(field)
+		}
+		export.OAuthAccount = r.Value(fields["oauthAccount"])
+	}
+
+	if profiles, err := s.ListProfiles(); err != nil {
+		export.Errors["profiles"] = r.String(err.Error())
+	} else if value, err := toJSONValue(profiles); err != nil {
+		export.Errors["profiles"] = err.Error()
+	} else {
+		export.Profiles = r.Value(value)
+	}
+
+	if logLines > 0 {
+		lines, err := redactedAuditLog(r, logLines)
+		if err != nil {
+			export.Errors["audit_log"] = r.String(err.Error())
+		}
+		export.AuditLog = lines
+	}
+
+	if len(export.Errors) == 0 {
+		export.Errors = nil
+	}
+	return export
+}
+
+// JSON renders the export indented, keeping the <kind:hash> placeholders
+// readable
+func (e *RedactedExport) JSON() ([]byte, error) {
+	return marshalReadable(e)
+}
+
+// redactedAuditLog returns the last n audit log lines, redacted. A missing
+// log yields no lines.
+func redactedAuditLog(r *Redactor, n int) ([]string, error) {
+	path, err := AuditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	lines, err := tailLines(path, n)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i, line := range lines {
+		lines[i] = r.String(line)
+	}
+	return lines, nil
+}
+
+// platformInfo reports the OS, build and storage locations in use
+func platformInfo(r *Redactor) PlatformInfo {
+	info := PlatformInfo{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		info.Storage = "keychain"
+	case "linux":
+		info.Storage = storage.LinuxCredentialBackend()
+	}
+	if dir, err := profile.DataDir(); err == nil {
+		info.DataDir = r.String(dir)
+	}
+	if dir, err := profile.ConfigDir(); err == nil {
+		info.ConfigDir = r.String(dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		info.ClaudeHome = r.String(filepath.Join(home, ".claude"))
+	}
+	return info
+}
+
+// readJSONFile decodes a JSON file into generic values
+func readJSONFile(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// toJSONValue round-trips v through JSON so it can be redacted generically
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// jsonType names the JSON type of a decoded value, with object sizes
+func jsonType(v interface{}) string {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("object (%d keys)", len(value))
+	case []interface{}:
+		return fmt.Sprintf("array (%d items)", len(value))
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// tailLines returns the last n lines of a file
+func tailLines(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
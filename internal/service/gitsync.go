@@ -0,0 +1,317 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/gitsync"
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// GitSyncItem is what a sync push, pull or status does to one profile
+type GitSyncItem = gitsync.Item
+
+// GitSyncResult is the outcome of `cflip sync push/pull/status`
+type GitSyncResult struct {
+	Repo   string        `json:"repo"`
+	Device string        `json:"device"`
+	Items  []GitSyncItem `json:"items"`
+}
+
+// Conflicts returns the profiles left unsynced because they changed on
+// both sides
+func (r *GitSyncResult) Conflicts() []GitSyncItem {
+	var conflicts []GitSyncItem
+	for _, item := range r.Items {
+		if item.Action == gitsync.ActionConflict {
+			conflicts = append(conflicts, item)
+		}
+	}
+	return conflicts
+}
+
+// gitSyncRun is the state one sync command works on
+type gitSyncRun struct {
+	repo      *gitsync.Repo
+	cipher    gitsync.Cipher
+	manifest  *gitsync.Manifest
+	state     *gitsync.State
+	statePath string
+	// profiles and payloads are keyed by profile ID
+	profiles map[string]*profile.Profile
+	payloads map[string][]byte
+	local    []gitsync.Local
+}
+
+// openGitSync fetches the sync repository and gathers the local profiles.
+// With decrypt, the configured encryption tool must be usable.
+func (s *Service) openGitSync(decrypt bool) (*gitSyncRun, error) {
+	settings, err := s.switcher.Settings()
+	if err != nil {
+		return nil, err
+	}
+	cfg := settings.GitSync
+	run := &gitSyncRun{
+		cipher:   gitsync.Cipher{Tool: cfg.Encryption, Recipients: cfg.Recipients, Identity: cfg.Identity},
+		profiles: make(map[string]*profile.Profile),
+		payloads: make(map[string][]byte),
+	}
+	if decrypt {
+		if err := run.cipher.Check(); err != nil {
+			return nil, err
+		}
+	}
+
+	clone, statePath := s.switcher.GitSyncPaths()
+	if run.repo, err = gitsync.Open(clone, cfg.Repo, cfg.Branch); err != nil {
+		return nil, err
+	}
+	if err := run.repo.Fetch(); err != nil {
+		return nil, err
+	}
+	if run.manifest, err = run.repo.LoadManifest(); err != nil {
+		return nil, err
+	}
+	run.statePath = statePath
+	if run.state, err = gitsync.LoadState(statePath); err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		// Without its credentials the profile would look changed
+		if p.Locked {
+			return nil, fmt.Errorf("%s: %w", p.Name, storage.ErrLocked)
+		}
+		payload, err := syncPayload(p)
+		if err != nil {
+			return nil, err
+		}
+		id := gitsync.ProfileID(p.StorageKey())
+		run.profiles[id] = p
+		run.payloads[id] = payload
+		run.local = append(run.local, gitsync.Local{ID: id, Name: p.Name, Hash: gitsync.Hash(payload)})
+	}
+	return run, nil
+}
+
+// result wraps planned or applied items
+func (r *gitSyncRun) result(items []GitSyncItem) *GitSyncResult {
+	if items == nil {
+		items = []GitSyncItem{}
+	}
+	return &GitSyncResult{Repo: r.repo.URL, Device: r.state.DeviceName, Items: items}
+}
+
+// recordInSync notes the profiles both sides already agree on, and forgets
+// those deleted everywhere
+func (r *gitSyncRun) recordInSync(items []GitSyncItem) {
+	for _, item := range items {
+		if item.Action != gitsync.ActionNone {
+			continue
+		}
+		switch payload, ok := r.payloads[item.ID]; {
+		case ok && item.Remote != nil && !item.Remote.Deleted && item.Remote.Hash == gitsync.Hash(payload):
+			r.state.Profiles[item.ID] = gitsync.Synced{Version: item.Remote.Version, Hash: item.Remote.Hash, Name: item.Name}
+		case !ok && (item.Remote == nil || item.Remote.Deleted):
+			delete(r.state.Profiles, item.ID)
+		}
+	}
+}
+
+// syncPayload is the profile as it is synced: without the fields that
+// only describe this machine
+func syncPayload(p *profile.Profile) ([]byte, error) {
+	synced := *p
+	synced.CredentialStore = ""
+	synced.LastActiveAt = time.Time{}
+	synced.SwitchCount = 0
+	data, err := json.Marshal(&synced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", p.Name, err)
+	}
+	return data, nil
+}
+
+// GitSyncStatus compares the local profiles with the sync repository
+// without changing either
+func (s *Service) GitSyncStatus() (*GitSyncResult, error) {
+	run, err := s.openGitSync(false)
+	if err != nil {
+		return nil, err
+	}
+	return run.result(gitsync.Plan(gitsync.Status, run.local, run.state, run.manifest, false)), nil
+}
+
+// GitSyncPush encrypts the profiles changed on this device into the sync
+// repository and pushes it. Profiles that also changed on another device
+// are left as conflicts unless force overwrites the repository's copy.
+func (s *Service) GitSyncPush(force bool) (*GitSyncResult, error) {
+	run, err := s.openGitSync(true)
+	if err != nil {
+		return nil, err
+	}
+	items := gitsync.Plan(gitsync.Push, run.local, run.state, run.manifest, force)
+	run.recordInSync(items)
+
+	now := time.Now().UTC()
+	synced := make(map[string]gitsync.Synced)
+	changed := 0
+	for i, item := range items {
+		if item.Action != gitsync.ActionPush && item.Action != gitsync.ActionDelete {
+			continue
+		}
+		entry := gitsync.Entry{Device: run.state.DeviceID, UpdatedAt: now}
+		if item.Remote != nil {
+			entry.Version = item.Remote.Version
+			if item.Remote.File != "" {
+				if err := run.repo.RemoveFile(item.Remote.File); err != nil {
+					return nil, err
+				}
+			}
+		}
+		entry.Version++
+
+		if item.Action == gitsync.ActionPush {
+			payload := run.payloads[item.ID]
+			encrypted, err := run.cipher.Encrypt(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt %s: %w", item.Name, err)
+			}
+			entry.File = gitsync.BlobName(item.ID, run.cipher.Ext())
+			entry.Hash = gitsync.Hash(payload)
+			if err := run.repo.WriteFile(entry.File, encrypted); err != nil {
+				return nil, err
+			}
+			synced[item.ID] = gitsync.Synced{Version: entry.Version, Hash: entry.Hash, Name: item.Name}
+		} else {
+			entry.Deleted = true
+		}
+		run.manifest.Profiles[item.ID] = entry
+		items[i].Remote = &entry
+		changed++
+	}
+
+	if changed > 0 {
+		run.manifest.Devices[run.state.DeviceID] = gitsync.Device{Name: run.state.DeviceName, LastPush: now}
+		if err := run.repo.SaveManifest(run.manifest); err != nil {
+			return nil, err
+		}
+		message := fmt.Sprintf("Sync %d profile(s) from %s", changed, run.state.DeviceName)
+		if _, err := run.repo.CommitAll("cflip ("+run.state.DeviceName+")", message); err != nil {
+			return nil, err
+		}
+		if err := run.repo.Push(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only a published change counts as synced
+	for _, item := range items {
+		switch item.Action {
+		case gitsync.ActionPush:
+			run.state.Profiles[item.ID] = synced[item.ID]
+		case gitsync.ActionDelete:
+			delete(run.state.Profiles, item.ID)
+		}
+	}
+	if err := run.state.Save(run.statePath); err != nil {
+		return nil, err
+	}
+	if changed > 0 {
+		logger.NewDefault().GitSyncPushed(run.repo.URL, changed)
+	}
+	return run.result(items), nil
+}
+
+// GitSyncPull applies the profiles changed on other devices from the sync
+// repository. Profiles that also changed here are left as conflicts unless
+// force overwrites the local copy.
+func (s *Service) GitSyncPull(force bool) (*GitSyncResult, error) {
+	run, err := s.openGitSync(true)
+	if err != nil {
+		return nil, err
+	}
+	items := gitsync.Plan(gitsync.Pull, run.local, run.state, run.manifest, force)
+	run.recordInSync(items)
+
+	applied := 0
+	for i := range items {
+		item := &items[i]
+		switch item.Action {
+		case gitsync.ActionPull:
+			name, hash, err := s.pullProfile(run, item)
+			if err != nil {
+				// Keep what was applied so far from being pulled again
+				_ = run.state.Save(run.statePath)
+				return nil, err
+			}
+			item.Name = name
+			run.state.Profiles[item.ID] = gitsync.Synced{Version: item.Remote.Version, Hash: hash, Name: name}
+		case gitsync.ActionDelete:
+			// Into the trash, like `cflip remove`, so it stays restorable
+			if err := s.switcher.TrashProfile(item.Name); err != nil {
+				_ = run.state.Save(run.statePath)
+				return nil, fmt.Errorf("failed to remove %s: %w", item.Name, err)
+			}
+			delete(run.state.Profiles, item.ID)
+		default:
+			continue
+		}
+		applied++
+	}
+
+	if err := run.state.Save(run.statePath); err != nil {
+		return nil, err
+	}
+	if applied > 0 {
+		logger.NewDefault().GitSyncPulled(run.repo.URL, applied)
+	}
+	return run.result(items), nil
+}
+
+// pullProfile decrypts a profile from the repository into the store,
+// returning its local name and the hash to record as synced
+func (s *Service) pullProfile(run *gitSyncRun, item *GitSyncItem) (string, string, error) {
+	data, err := run.repo.ReadFile(item.Remote.File)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s from the sync repository: %w", item.Remote.File, err)
+	}
+	plain, err := run.cipher.Decrypt(data)
+	if err != nil {
+		return "", "", err
+	}
+	if gitsync.Hash(plain) != item.Remote.Hash {
+		return "", "", fmt.Errorf("%s in the sync repository does not match its manifest entry", item.Remote.File)
+	}
+
+	var p profile.Profile
+	if err := json.Unmarshal(plain, &p); err != nil {
+		return "", "", fmt.Errorf("failed to parse %s: %w", item.Remote.File, err)
+	}
+	// Usage stats stay this machine's own
+	if local, ok := run.profiles[item.ID]; ok {
+		p.LastActiveAt, p.SwitchCount = local.LastActiveAt, local.SwitchCount
+	}
+	if _, err := s.switcher.ImportProfile(&p, true); err != nil {
+		return "", "", fmt.Errorf("failed to store %s: %w", p.Email, err)
+	}
+
+	// The store may differ from the payload, e.g. in the profile's name,
+	// so hash what it now holds
+	stored, err := s.switcher.FindStored(&p)
+	if err != nil || stored == nil {
+		return "", "", fmt.Errorf("failed to reload %s after pulling it", p.Email)
+	}
+	payload, err := syncPayload(stored)
+	if err != nil {
+		return "", "", err
+	}
+	return stored.Name, gitsync.Hash(payload), nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// Group is a named set of stored accounts
+type Group = profile.Group
+
+// InGroup reports whether the account belongs to group (case-insensitive)
+func (p *ProfileInfo) InGroup(group string) bool {
+	return slices.ContainsFunc(p.Groups, func(g string) bool {
+		return strings.EqualFold(g, group)
+	})
+}
+
+// SelectGroup limits switching to the next account in sequence to the
+// accounts in group
+func (s *Service) SelectGroup(group string) {
+	s.switcher.SelectGroup(group)
+}
+
+// AddToGroup puts an account in a group, reporting whether it was not in
+// it already
+func (s *Service) AddToGroup(identifier, group string) (*ProfileInfo, bool, error) {
+	name, err := s.localProfileName(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	p, added, err := s.switcher.AddToGroup(name, group)
+	if err != nil {
+		return nil, false, err
+	}
+	if added {
+		logger.NewDefault().AccountGrouped(p.Email, group)
+	}
+	return s.profileToInfo(p, s.isActiveProfile(p.Name)), added, nil
+}
+
+// RemoveFromGroup takes an account out of a group, reporting whether it
+// was in it
+func (s *Service) RemoveFromGroup(identifier, group string) (*ProfileInfo, bool, error) {
+	name, err := s.localProfileName(identifier)
+	if err != nil {
+		return nil, false, err
+	}
+	p, removed, err := s.switcher.RemoveFromGroup(name, group)
+	if err != nil {
+		return nil, false, err
+	}
+	if removed {
+		logger.NewDefault().AccountUngrouped(p.Email, group)
+	}
+	return s.profileToInfo(p, s.isActiveProfile(p.Name)), removed, nil
+}
+
+// Groups lists every group with the names of its accounts
+func (s *Service) Groups() ([]Group, error) {
+	return s.switcher.Groups()
+}
+
+// localProfileName resolves identifier to a stored profile's name; shared
+// profiles are read-only
+func (s *Service) localProfileName(identifier string) (string, error) {
+	resolution, err := s.ResolveIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+	if resolution.Profile.Source != "" {
+		return "", fmt.Errorf("%s comes from shared source %s, which is read-only", resolution.Profile.Email, resolution.Profile.Source)
+	}
+	return resolution.Profile.Name, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// HistoryEntry is one recorded switch
+type HistoryEntry = profile.HistoryEntry
+
+// SetInitiator names what asks for the following switches in the switch
+// history, e.g. "ui" or "exec"
+func (s *Service) SetInitiator(initiator string) {
+	s.switcher.SetInitiator(initiator)
+}
+
+// History returns the most recent switches, newest first; limit <= 0
+// returns all of them
+func (s *Service) History(limit int) ([]HistoryEntry, error) {
+	entries, err := profile.LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	newest := make([]HistoryEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0 && (limit <= 0 || len(newest) < limit); i-- {
+		newest = append(newest, entries[i])
+	}
+	return newest, nil
+}
+
+// Undo switches back to the account active before the current one.
+// Undoing twice returns to where you started.
+func (s *Service) Undo(force bool) (*SwitchResult, error) {
+	previous, err := s.switcher.PreviousProfile()
+	if err != nil {
+		return nil, err
+	}
+	s.SetInitiator("undo")
+	return s.Switch(previous, force)
+}
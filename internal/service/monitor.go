@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// monitorCooldown ignores further rate-limit hits right after a switch so a
+// burst of errors from the old account does not cascade through the pool
+const monitorCooldown = 2 * time.Minute
+
+// MonitorEvent reports something the monitor noticed or did
+type MonitorEvent struct {
+	Time      time.Time
+	Kind      string // "rate_limit", "switched", "captured", "error"
+	FromEmail string
+	ToEmail   string
+	ToProfile string // profile name of ToEmail
+	Err       error
+}
+
+// Monitor tails Claude Code's session logs and, when a rate-limit/usage-cap
+// error appears, switches to the healthiest other account and sends a desktop
+// notification. It runs until ctx is cancelled.
+func (s *Service) Monitor(ctx context.Context, interval time.Duration, onEvent func(MonitorEvent)) error {
+	offsets := make(map[string]int64)
+	started := time.Now()
+	var lastSwitch time.Time
+	var lastToken string
+
+	// Start at the end of existing logs; only new lines matter
+	if err := scanLogOffsets(offsets, true); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// Claude Code may have refreshed its credentials since the last cycle
+		storage.ResetKeychainCache()
+		s.captureRefreshedTokens(&lastToken, onEvent)
+
+		hit, err := readNewLogLines(offsets, started)
+		if err != nil {
+			onEvent(MonitorEvent{Time: time.Now(), Kind: "error", Err: err})
+			continue
+		}
+		if !hit || time.Since(lastSwitch) < monitorCooldown {
+			continue
+		}
+
+		event := MonitorEvent{Time: time.Now(), Kind: "rate_limit"}
+		if current, err := s.GetCurrentAccount(); err == nil {
+			event.FromEmail = current.Email
+		}
+		onEvent(event)
+		s.recordRateLimit()
+
+		target, err := s.nextHealthyAccount()
+		if err != nil {
+			onEvent(MonitorEvent{Time: time.Now(), Kind: "error", Err: err})
+			continue
+		}
+
+		// Claude Code is necessarily running here, so skip the process check
+		s.SetInitiator("monitor")
+		if err := s.SwitchToAccount(target.Name, true); err != nil {
+			onEvent(MonitorEvent{Time: time.Now(), Kind: "error", Err: err})
+			continue
+		}
+		lastSwitch = time.Now()
+
+		onEvent(MonitorEvent{Time: lastSwitch, Kind: "switched", FromEmail: event.FromEmail, ToEmail: target.Email, ToProfile: target.Name})
+		notifyDesktop("cflip", fmt.Sprintf("Usage limit hit, switched to %s", target.Email))
+	}
+}
+
+// captureRefreshedTokens stores tokens Claude Code refreshed during the
+// session in the matching profile, so they survive switching away. The
+// full live config is only read when the access token has changed.
+func (s *Service) captureRefreshedTokens(lastToken *string, onEvent func(MonitorEvent)) {
+	credentials, err := profile.LoadCredentials()
+	if err != nil || credentials.ClaudeAiOauth.AccessToken == *lastToken {
+		return
+	}
+
+	captured, err := s.switcher.CaptureLiveTokens()
+	if err != nil {
+		onEvent(MonitorEvent{Time: time.Now(), Kind: "error", Err: err})
+		return
+	}
+	*lastToken = credentials.ClaudeAiOauth.AccessToken
+	if captured != nil {
+		onEvent(MonitorEvent{Time: time.Now(), Kind: "captured", ToEmail: captured.Email})
+	}
+}
+
+// nextHealthyAccount picks the best-ranked account other than the active one
+func (s *Service) nextHealthyAccount() (*Recommendation, error) {
+	recommendations, err := s.Recommend()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recommendations {
+		if !rec.IsActive && rec.Score > 0 {
+			return rec, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no healthy account available to switch to")
+}
+
+// scanLogOffsets records the current size of every session log. When
+// initial is false, newly discovered files start from the beginning.
+func scanLogOffsets(offsets map[string]int64, initial bool) error {
+	dirs, err := config.UsageLogDirs()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+				return nil
+			}
+			if _, known := offsets[path]; known {
+				return nil
+			}
+			if !initial {
+				offsets[path] = 0
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				offsets[path] = info.Size()
+			}
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// readNewLogLines reads lines appended since the last poll and reports whether
+// any of them is a rate-limit error logged after the monitor started
+func readNewLogLines(offsets map[string]int64, started time.Time) (bool, error) {
+	if err := scanLogOffsets(offsets, false); err != nil {
+		return false, err
+	}
+
+	hit := false
+	for path, offset := range offsets {
+		file, err := os.Open(path)
+		if err != nil {
+			delete(offsets, path)
+			continue
+		}
+
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			continue
+		}
+
+		reader := bufio.NewReader(file)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				break // Partial trailing line is re-read on the next poll
+			}
+			offset += int64(len(line))
+
+			if limited, at := config.DetectRateLimit(line); limited && !at.Before(started) {
+				hit = true
+			}
+		}
+		offsets[path] = offset
+		file.Close()
+	}
+
+	return hit, nil
+}
+
+// notifyDesktop sends a best-effort desktop notification
+func notifyDesktop(title, message string) {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return
+	}
+
+	_ = cmd.Run()
+}
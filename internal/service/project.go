@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// ProjectBinding is the account a directory tree is bound to by a .cflip file
+type ProjectBinding = config.ProjectBinding
+
+// ProjectSwitch is the outcome of switching to a project's account
+type ProjectSwitch struct {
+	Binding *ProjectBinding `json:"binding"`
+	File    string          `json:"file"`
+	// Switch is nil when the bound account was already active
+	Switch *SwitchResult `json:"switch,omitempty"`
+}
+
+// BindProject writes a .cflip file in dir binding it to an account, after
+// checking the account exists
+func (s *Service) BindProject(dir, identifier, org string) (string, *ProfileInfo, error) {
+	account, err := s.GetAccountByIdentifier(identifier)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Record the alias when there is one: it reads better in a shared repo
+	// and survives the account being re-added
+	binding := &ProjectBinding{Account: account.DisplayName(), Org: org}
+	path, err := config.SaveProjectBinding(dir, binding)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, account, nil
+}
+
+// SwitchToProject switches to the account bound to dir by the nearest
+// .cflip file, returning nil when there is none. Nothing is switched when
+// that account is already active.
+func (s *Service) SwitchToProject(dir string, force bool) (*ProjectSwitch, error) {
+	binding, err := config.FindProjectBinding(dir)
+	if err != nil || binding == nil {
+		return nil, err
+	}
+	result := &ProjectSwitch{Binding: binding, File: binding.Path}
+
+	account, err := s.GetAccountByIdentifier(binding.Account)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", binding.Path, err)
+	}
+	if account.IsActive && (binding.Org == "" || strings.EqualFold(binding.Org, account.Organization)) {
+		return result, nil
+	}
+
+	if binding.Org != "" {
+		s.SelectOrganization(binding.Org)
+	}
+	s.SetInitiator("project")
+	if result.Switch, err = s.Switch(account.Name, force); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
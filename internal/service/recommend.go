@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// usageWindow is the rolling window Claude subscriptions meter usage over
+const usageWindow = 5 * time.Hour
+
+// Recommendation ranks one account by estimated remaining capacity
+type Recommendation struct {
+	// Name is the profile name, which tells apart organizations of one email
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	Alias       string  `json:"alias,omitempty"`
+	Tier        string  `json:"tier,omitempty"`
+	WindowUsed  string  `json:"window_used"`
+	TokenStatus string  `json:"token_status"`
+	Score       float64 `json:"score"`
+	IsActive    bool    `json:"is_active"`
+	// CooldownUntil is when a recorded usage limit resets
+	CooldownUntil string `json:"cooldown_until,omitempty"`
+}
+
+// Recommend ranks all managed accounts by estimated remaining capacity right
+// now, combining subscription tier, time used in the current usage window,
+// and access token health. The best candidate comes first.
+func (s *Service) Recommend() ([]*Recommendation, error) {
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	now := time.Now()
+	sessions, err := loadSessions(now)
+	if err != nil {
+		return nil, err
+	}
+	used := activeSince(sessions, now.Add(-usageWindow))
+
+	settings, err := s.switcher.Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	cooldowns, err := s.switcher.Cooldowns()
+	if err != nil {
+		return nil, err
+	}
+
+	activeName := ""
+	if active, err := s.switcher.GetCurrentActiveProfile(); err == nil {
+		activeName = active.Name
+	}
+
+	var recommendations []*Recommendation
+	for _, p := range profiles {
+		rec := &Recommendation{
+			Name:     p.Name,
+			Email:    p.Email,
+			Alias:    p.Alias,
+			IsActive: p.Name == activeName,
+		}
+
+		health := 1.0
+		switch {
+		case p.Credentials == nil || p.Credentials.ClaudeAiOauth.AccessToken == "":
+			rec.TokenStatus = "missing"
+			health = 0
+		case p.Credentials.IsExpired():
+			// Claude can usually refresh it, but the seat may need a re-login
+			rec.TokenStatus = "expired"
+			health = 0.5
+		case time.Until(p.Credentials.ExpiresAtTime()) < time.Hour && p.Credentials.ClaudeAiOauth.ExpiresAt != 0:
+			rec.TokenStatus = "expiring"
+			health = 0.9
+		default:
+			rec.TokenStatus = "ok"
+		}
+
+		if p.Credentials != nil {
+			rec.Tier = p.Credentials.ClaudeAiOauth.SubscriptionType
+		}
+
+		windowUsed := usedBy(used, p, profiles)
+		if windowUsed > usageWindow {
+			windowUsed = usageWindow
+		}
+		rec.WindowUsed = windowUsed.Round(time.Minute).String()
+
+		remaining := 1 - float64(windowUsed)/float64(usageWindow)
+		// A recorded usage limit outranks the estimate from session time
+		if cooldown, ok := cooldowns[p.Name]; ok {
+			remaining = 0
+			rec.CooldownUntil = cooldown.ResetsAt.Local().Format("2006-01-02 15:04:05")
+		}
+		rec.Score = settings.Rotation.Weight(p) * remaining * health
+
+		recommendations = append(recommendations, rec)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+
+	return recommendations, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// IsCredentialsMissing reports whether err was caused by Claude Code's
+// credentials (keychain item or credentials file) not existing
+func IsCredentialsMissing(err error) bool {
+	return errors.Is(err, storage.ErrNotFound)
+}
+
+// ImportCredentialsFile installs a saved .credentials.json as Claude Code's
+// live credentials, validating its structure first
+func (s *Service) ImportCredentialsFile(path string) error {
+	credentials, err := config.LoadCredentialsFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := profile.SaveCredentials(credentials); err != nil {
+		return fmt.Errorf("failed to install credentials: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	uuidPattern  = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	tokenPattern = regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]+`)
+
+	// Profile file names embed the sanitized email
+	profileFilePattern = regexp.MustCompile(`[A-Za-z0-9._\-]+\.profile\b`)
+)
+
+// secretKeyParts mark JSON keys whose values are credentials
+var secretKeyParts = []string{"token", "secret", "password", "apikey", "api_key", "cookie", "session_key", "authorization"}
+
+// Redactor replaces tokens, UUIDs and (optionally) emails with salted
+// hashes. The same input always maps to the same hash within one
+// redactor, so values can still be correlated across a bundle.
+type Redactor struct {
+	salt       []byte
+	keepEmails bool
+	home       string
+}
+
+// NewRedactor creates a redactor with a fresh random salt
+func NewRedactor(keepEmails bool) *Redactor {
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+	home, _ := os.UserHomeDir()
+	return &Redactor{salt: salt, keepEmails: keepEmails, home: home}
+}
+
+// hash returns a short salted digest labelled with the kind of value
+func (r *Redactor) hash(kind, value string) string {
+	h := sha256.New()
+	h.Write(r.salt)
+	h.Write([]byte(value))
+	return "<" + kind + ":" + hex.EncodeToString(h.Sum(nil))[:12] + ">"
+}
+
+// String redacts every token, UUID and email found inside s, and replaces
+// the home directory with ~ so paths don't reveal the user name
+func (r *Redactor) String(s string) string {
+	s = tokenPattern.ReplaceAllStringFunc(s, func(m string) string { return r.hash("token", m) })
+	s = uuidPattern.ReplaceAllStringFunc(s, func(m string) string { return r.hash("uuid", m) })
+	if !r.keepEmails {
+		s = emailPattern.ReplaceAllStringFunc(s, func(m string) string { return r.hash("email", strings.ToLower(m)) })
+		s = profileFilePattern.ReplaceAllStringFunc(s, func(m string) string { return r.hash("file", m) + ".profile" })
+	}
+	if r.home != "" && r.home != "/" {
+		s = strings.ReplaceAll(s, r.home, "~")
+	}
+	return s
+}
+
+// Value redacts a decoded JSON value. Strings under credential-like or
+// UUID keys are hashed whole; all other strings and map keys go through
+// String.
+func (r *Redactor) Value(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			if s, ok := child.(string); ok && isSecretKey(key) && s != "" {
+				out[r.String(key)] = r.hash("token", s)
+				continue
+			}
+			if s, ok := child.(string); ok && strings.HasSuffix(strings.ToLower(key), "uuid") && s != "" {
+				out[r.String(key)] = r.hash("uuid", s)
+				continue
+			}
+			out[r.String(key)] = r.Value(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, child := range value {
+			out[i] = r.Value(child)
+		}
+		return out
+	case string:
+		return r.String(value)
+	default:
+		return value
+	}
+}
+
+// isSecretKey reports whether a JSON key names a credential
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
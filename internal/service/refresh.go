@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// DefaultRefreshWindow is how close to expiry a token must be for refresh --all
+const DefaultRefreshWindow = time.Hour
+
+// RefreshResult reports what happened to one account during a refresh
+type RefreshResult struct {
+	Email     string    `json:"email"`
+	Alias     string    `json:"alias,omitempty"`
+	Refreshed bool      `json:"refreshed"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Skipped   string    `json:"skipped,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// RefreshAccount refreshes one stored account's OAuth tokens without switching
+func (s *Service) RefreshAccount(identifier string) (*RefreshResult, error) {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.switcher.RefreshProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefreshResult{
+		Email:     profile.Email,
+		Alias:     profile.Alias,
+		Refreshed: true,
+		ExpiresAt: profile.Credentials.ExpiresAtTime(),
+	}, nil
+}
+
+// RefreshAccounts refreshes every stored account whose token expires within
+// the window (or has no known expiry). With force every account is refreshed.
+func (s *Service) RefreshAccounts(within time.Duration, force bool) ([]RefreshResult, error) {
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	deadline := time.Now().Add(within)
+	var results []RefreshResult
+	for _, p := range profiles {
+		result := RefreshResult{Email: p.Email, Alias: p.Alias}
+
+		if p.Credentials == nil || p.Credentials.ClaudeAiOauth.RefreshToken == "" {
+			result.Skipped = "no refresh token"
+			results = append(results, result)
+			continue
+		}
+
+		expiresAt := p.Credentials.ExpiresAtTime()
+		if !force && p.Credentials.ClaudeAiOauth.ExpiresAt != 0 && expiresAt.After(deadline) {
+			result.ExpiresAt = expiresAt
+			result.Skipped = fmt.Sprintf("valid for %s", formatDuration(time.Until(expiresAt)))
+			results = append(results, result)
+			continue
+		}
+
+		refreshed, err := s.switcher.RefreshProfile(p.Name)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Refreshed = true
+			result.ExpiresAt = refreshed.Credentials.ExpiresAtTime()
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// refreshOnUse refreshes p's tokens when they are expired or expire within
+// settings.auto_refresh.window, returning nil when nothing was due or
+// auto-refresh is disabled
+func (s *Service) refreshOnUse(p *profile.Profile) *RefreshResult {
+	if p.Source != "" || p.Credentials == nil || p.Credentials.ClaudeAiOauth.RefreshToken == "" || p.Credentials.ClaudeAiOauth.ExpiresAt == 0 {
+		return nil
+	}
+
+	settings, err := s.switcher.Settings()
+	if err != nil || settings.AutoRefresh.Disabled {
+		return nil
+	}
+	result := &RefreshResult{Email: p.Email, Alias: p.Alias}
+	window, err := settings.AutoRefresh.WindowDuration()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if p.Credentials.ExpiresAtTime().After(time.Now().Add(window)) {
+		return nil
+	}
+
+	refreshed, err := s.switcher.RefreshProfile(p.Name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	*p = *refreshed
+	result.Refreshed = true
+	result.ExpiresAt = refreshed.Credentials.ExpiresAtTime()
+	logger.NewDefault().TokenRefreshed(p.Email)
+	return result
+}
+
+// maxRefreshBackoff caps how long a repeatedly failing account is left alone
+const maxRefreshBackoff = 6 * time.Hour
+
+// RefreshSchedule configures AutoRefresh
+type RefreshSchedule struct {
+	Window   time.Duration
+	Interval time.Duration
+}
+
+// AutoRefresh periodically refreshes every stored account whose token
+// expires within the window. Checks are jittered so several machines sharing
+// accounts do not refresh in lockstep, and an account that fails is retried
+// with exponential backoff. It runs until ctx is cancelled.
+func (s *Service) AutoRefresh(ctx context.Context, schedule RefreshSchedule, onResult func(RefreshResult)) error {
+	failures := make(map[string]int)
+	retryAt := make(map[string]time.Time)
+
+	for {
+		storage.ResetKeychainCache()
+		s.refreshDue(schedule.Window, failures, retryAt, onResult)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(jitter(schedule.Interval)):
+		}
+	}
+}
+
+// refreshDue refreshes accounts nearing expiry that are not backing off
+func (s *Service) refreshDue(window time.Duration, failures map[string]int, retryAt map[string]time.Time, onResult func(RefreshResult)) {
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		onResult(RefreshResult{Error: fmt.Sprintf("failed to list profiles: %v", err)})
+		return
+	}
+
+	now := time.Now()
+	for _, p := range profiles {
+		if p.Credentials == nil || p.Credentials.ClaudeAiOauth.RefreshToken == "" || p.Credentials.ClaudeAiOauth.ExpiresAt == 0 {
+			continue
+		}
+		if p.Credentials.ExpiresAtTime().After(now.Add(window)) || now.Before(retryAt[p.Email]) {
+			continue
+		}
+
+		result := RefreshResult{Email: p.Email, Alias: p.Alias}
+		refreshed, err := s.switcher.RefreshProfile(p.Name)
+		if err != nil {
+			failures[p.Email]++
+			backoff := time.Minute << min(failures[p.Email], 20)
+			if backoff > maxRefreshBackoff {
+				backoff = maxRefreshBackoff
+			}
+			retryAt[p.Email] = now.Add(backoff)
+			result.Error = fmt.Sprintf("%v (retrying in %s)", err, formatDuration(backoff))
+		} else {
+			delete(failures, p.Email)
+			delete(retryAt, p.Email)
+			result.Refreshed = true
+			result.ExpiresAt = refreshed.Credentials.ExpiresAtTime()
+		}
+		onResult(result)
+	}
+}
+
+// jitter spreads an interval by up to ±10%
+func jitter(interval time.Duration) time.Duration {
+	spread := int64(interval) / 5
+	if spread <= 0 {
+		return interval
+	}
+	return interval - time.Duration(spread/2) + time.Duration(rand.Int64N(spread))
+}
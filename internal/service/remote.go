@@ -0,0 +1,103 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/remote"
+)
+
+// Remote paths of Claude Code's files, relative to the remote home directory
+const (
+	remoteClaudeConfigPath = ".claude.json"
+	remoteCredentialsPath  = ".claude/.credentials.json"
+)
+
+// SwitchRemote applies a locally stored profile to Claude Code on a remote
+// host over SSH. Only the oauthAccount block of the remote ~/.claude.json is
+// replaced so the remote machine keeps its own settings; the credentials
+// file is written wholesale. The remote must use file-based credentials.
+func (s *Service) SwitchRemote(target, identifier string) (*ProfileInfo, error) {
+	host, err := remote.NewHost(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if identifier, err = s.accountName(identifier); err != nil {
+		return nil, err
+	}
+	p, err := s.switcher.LoadProfile(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+	if p.ClaudeConfig == nil || p.Credentials == nil {
+		return nil, fmt.Errorf("profile %s is incomplete", p.Name)
+	}
+
+	remoteConfig := make(config.ClaudeConfig)
+	if data, err := host.ReadFile(remoteClaudeConfigPath); err == nil {
+		if err := json.Unmarshal(data, &remoteConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse remote Claude config: %w", err)
+		}
+		// Keep a backup on the remote before modifying it
+		if err := host.WriteFile(remoteClaudeConfigPath+".backup", data); err != nil {
+			return nil, fmt.Errorf("failed to back up remote Claude config: %w", err)
+		}
+	}
+
+	remoteConfig["oauthAccount"] = (*p.ClaudeConfig)["oauthAccount"]
+
+	configData, err := json.MarshalIndent(remoteConfig, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal remote config: %w", err)
+	}
+	credentialsData, err := json.MarshalIndent(p.Credentials, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	if err := host.WriteFile(remoteCredentialsPath, credentialsData); err != nil {
+		return nil, fmt.Errorf("failed to write remote credentials: %w", err)
+	}
+	if err := host.WriteFile(remoteClaudeConfigPath, configData); err != nil {
+		return nil, fmt.Errorf("failed to write remote Claude config: %w", err)
+	}
+
+	return s.profileToInfo(p, false), nil
+}
+
+// RemoteCurrentEmail reads which account Claude Code on a remote host uses
+func (s *Service) RemoteCurrentEmail(target string) (string, error) {
+	host, err := remote.NewHost(target)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := host.ReadFile(remoteClaudeConfigPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read remote Claude config: %w", err)
+	}
+
+	var remoteConfig config.ClaudeConfig
+	if err := json.Unmarshal(data, &remoteConfig); err != nil {
+		return "", fmt.Errorf("failed to parse remote Claude config: %w", err)
+	}
+
+	email := remoteConfig.GetUserEmail()
+	if email == "" {
+		return "", fmt.Errorf("no account is logged in on %s", target)
+	}
+
+	return email, nil
+}
+
+// ForwardRemote runs cflip with args on a remote host and returns its exit code
+func ForwardRemote(target string, args []string) (int, error) {
+	host, err := remote.NewHost(target)
+	if err != nil {
+		return 1, err
+	}
+
+	return host.Forward(args)
+}
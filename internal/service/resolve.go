@@ -0,0 +1,174 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// Resolution explains how an account identifier was matched to a profile
+type Resolution struct {
+	Identifier  string       `json:"identifier"`
+	Rule        string       `json:"rule"`
+	Profile     *ProfileInfo `json:"profile"`
+	ProfilePath string       `json:"profile_path,omitempty"`
+	Credentials string       `json:"credentials"`
+}
+
+// ResolveIdentifier resolves an account identifier the same way commands do
+// and reports which rule matched: index, name, email, alias, prefix, or
+// shared.
+func (s *Service) ResolveIdentifier(identifier string) (*Resolution, error) {
+	if identifier == "" {
+		return nil, fmt.Errorf("identifier cannot be empty")
+	}
+
+	profiles, err := s.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	resolution := &Resolution{Identifier: identifier}
+
+	if index, err := strconv.Atoi(identifier); err == nil && index > 0 {
+		if index > len(profiles) {
+			return nil, fmt.Errorf("invalid account number: %d (only %d accounts available)", index, len(profiles))
+		}
+		resolution.Rule = "index"
+		resolution.Profile = profiles[index-1]
+	} else {
+		// Same precedence as profile lookup: name, then email, then alias
+		for _, rule := range []string{"name", "email", "alias"} {
+			for _, p := range profiles {
+				if (rule == "name" && p.Name == identifier) ||
+					(rule == "email" && p.Email == identifier) ||
+					(rule == "alias" && p.Alias != "" && p.Alias == identifier) {
+					resolution.Rule = rule
+					resolution.Profile = p
+					break
+				}
+			}
+			if resolution.Profile != nil {
+				break
+			}
+		}
+	}
+
+	if resolution.Profile == nil {
+		var candidates []string
+		for _, p := range profiles {
+			if profile.HasIdentifierPrefix(identifier, p.Name, p.Email, p.Alias) {
+				resolution.Rule = "prefix"
+				resolution.Profile = p
+				candidates = append(candidates, p.Name)
+			}
+		}
+		if len(candidates) > 1 {
+			return nil, profile.AmbiguousIdentifierError(identifier, candidates)
+		}
+	}
+
+	// Shared sources are only fetched when no local account matches, by
+	// name, email, or alias like on switch
+	if resolution.Profile == nil {
+		shared, failures, err := s.ListSharedProfiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range shared {
+			if p.Name == identifier || p.Email == identifier || (p.Alias != "" && p.Alias == identifier) {
+				resolution.Rule = "name"
+				if p.Email == identifier {
+					resolution.Rule = "email"
+				} else if p.Name != identifier {
+					resolution.Rule = "alias"
+				}
+				resolution.Profile = p
+				break
+			}
+		}
+		if resolution.Profile == nil && len(failures) > 0 {
+			return nil, fmt.Errorf("profile not found: %s (%s)", identifier, describeSharedFailures(failures))
+		}
+	}
+
+	if resolution.Profile == nil {
+		return nil, fmt.Errorf("profile not found: %s", identifier)
+	}
+
+	if resolution.Profile.Source != "" {
+		resolution.Rule = "shared:" + resolution.Rule
+		resolution.Credentials = fmt.Sprintf("fetched from shared source %q on switch", resolution.Profile.Source)
+		return resolution, nil
+	}
+
+	path, err := s.switcher.ProfilePath(resolution.Profile.Name)
+	if err != nil {
+		return nil, err
+	}
+	resolution.ProfilePath = path
+	resolution.Credentials = describeCredentialStorage(path)
+
+	return resolution, nil
+}
+
+// accountName turns an identifier typed by the user into the name of the
+// stored profile it means, accepting a unique prefix of a name, email or
+// alias. Exact identifiers, and ones that match no stored profile (shared
+// profiles, or typos the lookup reports), are returned unchanged. Internal
+// callers pass exact names and emails to the switcher instead.
+func (s *Service) accountName(identifier string) (string, error) {
+	if identifier == "" {
+		return "", nil
+	}
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return "", fmt.Errorf("failed to list profiles: %w", err)
+	}
+	for _, p := range profiles {
+		if p.Name == identifier || p.Email == identifier || (p.Alias != "" && p.Alias == identifier) {
+			return identifier, nil
+		}
+	}
+
+	matches := profile.ProfilesWithPrefix(profiles, identifier)
+	switch len(matches) {
+	case 0:
+		return identifier, nil
+	case 1:
+		return matches[0].Name, nil
+	default:
+		return "", profile.AmbiguousIdentifierError(identifier, profileNames(matches))
+	}
+}
+
+// profileNames lists the names of profiles
+func profileNames(profiles []*profile.Profile) []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// describeCredentialStorage explains where a profile's tokens are kept
+func describeCredentialStorage(profilePath string) string {
+	return fmt.Sprintf("inline in %s (applied to %s)", profilePath, profile.ClaudeCredentialLocation())
+}
+
+// describeSharedFailures lists the shared sources that could not be fetched
+func describeSharedFailures(failures map[string]error) string {
+	names := make([]string, 0, len(failures))
+	for name := range failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("shared source %q: %v", name, failures[name])
+	}
+	return strings.Join(parts, "; ")
+}
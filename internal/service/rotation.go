@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// RotationResult describes a scheduled rotation decision
+type RotationResult struct {
+	Rule      string `json:"rule"`
+	FromEmail string `json:"from_email,omitempty"`
+	ToEmail   string `json:"to_email"`
+	ToProfile string `json:"to_profile"`
+	Applied   bool   `json:"applied"`
+}
+
+// Rotate evaluates the rotation schedule and switches accounts if a rule
+// requires it. It returns nil when the schedule is already satisfied.
+func (s *Service) Rotate(dryRun, force bool) (*RotationResult, error) {
+	decision, err := s.switcher.EvaluateRotation(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate rotation rules: %w", err)
+	}
+	if decision == nil {
+		return nil, nil
+	}
+
+	result := &RotationResult{
+		Rule:      decision.Rule,
+		ToEmail:   decision.Target.Email,
+		ToProfile: decision.Target.Name,
+	}
+	if current, err := s.switcher.GetCurrentActiveProfile(); err == nil {
+		result.FromEmail = current.Email
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	s.SetInitiator("rotate")
+	if err := s.SwitchToAccount(decision.Target.Name, force); err != nil {
+		return result, err
+	}
+	result.Applied = true
+
+	if err := s.runRotationHooks(result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// runRotationHooks executes the configured post-rotation shell hooks
+func (s *Service) runRotationHooks(result *RotationResult) error {
+	settings, err := s.switcher.Settings()
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range settings.Rotation.Hooks {
+		cmd := exec.Command("sh", "-c", hook)
+		cmd.Env = append(os.Environ(),
+			"CFLIP_FROM="+result.FromEmail,
+			"CFLIP_TO="+result.ToEmail,
+			"CFLIP_RULE="+result.Rule,
+		)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("rotation hook %q failed: %w", hook, err)
+		}
+	}
+
+	return nil
+}
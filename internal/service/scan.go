@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+)
+
+// maxBackupFileSize skips files too large to be a Claude Code backup
+const maxBackupFileSize = 16 << 20
+
+// BackupCandidate is one distinct account found by ScanBackups
+type BackupCandidate struct {
+	Email           string
+	AccountUuid     string
+	Organization    string
+	ConfigPath      string
+	CredentialsPath string
+	ExpiresAt       time.Time
+	Managed         bool
+}
+
+// ScanResult lists importable accounts and credentials that could not be
+// matched to an account
+type ScanResult struct {
+	Candidates []*BackupCandidate
+	Unmatched  []string
+}
+
+// ScanBackups walks dir for saved .credentials.json / .claude.json files,
+// pairs credentials with a config from the same directory, and returns one
+// candidate per accountUuid, preferring the backup whose token expires last
+func (s *Service) ScanBackups(dir string) (*ScanResult, error) {
+	configs := make(map[string][]string)     // directory -> config files
+	credentials := make(map[string][]string) // directory -> credentials files
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxBackupFileSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var probe config.ClaudeConfig
+		if json.Unmarshal(data, &probe) != nil {
+			return nil
+		}
+
+		// Any layout Claude Code has used counts, not just the current one
+		parent := filepath.Dir(path)
+		if _, version, err := config.ParseCredentials(data); err == nil && version != config.FormatUnknown {
+			credentials[parent] = append(credentials[parent], path)
+		} else if probe.ConfigFormat() != config.FormatUnknown {
+			configs[parent] = append(configs[parent], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	result := &ScanResult{}
+	byAccount := make(map[string]*BackupCandidate)
+
+	for parent, credentialPaths := range credentials {
+		var claudeConfig *config.ClaudeConfig
+		var configPath string
+		for _, path := range configs[parent] {
+			if loaded, err := config.LoadClaudeConfigFile(path); err == nil {
+				claudeConfig, configPath = loaded, path
+				break
+			}
+		}
+
+		for _, path := range credentialPaths {
+			creds, err := config.LoadCredentialsFile(path)
+			if err != nil || claudeConfig == nil {
+				result.Unmatched = append(result.Unmatched, path)
+				continue
+			}
+
+			uuid := claudeConfig.GetAccountUuid()
+			if existing, ok := byAccount[uuid]; ok && !creds.ExpiresAtTime().After(existing.ExpiresAt) {
+				continue
+			}
+
+			byAccount[uuid] = &BackupCandidate{
+				Email:           claudeConfig.GetUserEmail(),
+				AccountUuid:     uuid,
+				Organization:    claudeConfig.GetOrganizationName(),
+				ConfigPath:      configPath,
+				CredentialsPath: path,
+				ExpiresAt:       creds.ExpiresAtTime(),
+			}
+		}
+	}
+
+	// Managed means stored under exactly this email, in any organization
+	stored := make(map[string]bool)
+	if profiles, err := s.switcher.ListProfiles(); err == nil {
+		for _, p := range profiles {
+			stored[p.Email] = true
+		}
+	}
+	for _, candidate := range byAccount {
+		candidate.Managed = stored[candidate.Email]
+		result.Candidates = append(result.Candidates, candidate)
+	}
+
+	sort.Slice(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].Email < result.Candidates[j].Email
+	})
+	sort.Strings(result.Unmatched)
+
+	return result, nil
+}
@@ -1,12 +1,21 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/phathdt/claude-flip/internal/auth"
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/logger"
 	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
 )
 
 // Service provides the main business logic for Claude Flip
@@ -26,6 +35,50 @@ func NewService() (*Service, error) {
 	}, nil
 }
 
+// LoadSettings reads the user settings without initializing a full service
+func LoadSettings() (*profile.Settings, error) {
+	pm, err := profile.NewProfileManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize profile manager: %w", err)
+	}
+
+	return pm.LoadSettings()
+}
+
+// ApplySettings applies the process-wide settings: storage retries, where
+// Claude Code's live credentials are, and the lock timeout
+func ApplySettings(settings *profile.Settings) error {
+	policy, err := settings.StorageRetry.Policy()
+	if err != nil {
+		return err
+	}
+	storage.SetRetryPolicy(policy)
+	storage.SetCredentialLocations(settings.ClaudeCredentials.Locations())
+	lockTimeout, err := settings.LockTimeoutDuration()
+	if err != nil {
+		return err
+	}
+	SetLockTimeout(lockTimeout)
+	return nil
+}
+
+// SetLockTimeout configures how long switches and profile saves wait for
+// another cflip process to finish
+func SetLockTimeout(timeout time.Duration) {
+	profile.SetLockTimeout(timeout)
+}
+
+// CompleteAccounts lists the account aliases and emails starting with
+// prefix, for shell completion and launcher scripts
+func CompleteAccounts(prefix string) ([]string, error) {
+	pm, err := profile.NewProfileManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize profile manager: %w", err)
+	}
+
+	return pm.CompleteAccounts(prefix)
+}
+
 // ProfileInfo represents profile information for the CLI
 type ProfileInfo struct {
 	Name         string `json:"name"`
@@ -36,6 +89,26 @@ type ProfileInfo struct {
 	CreatedAt    string `json:"created_at"`
 	UpdatedAt    string `json:"updated_at"`
 	LastActiveAt string `json:"last_active_at,omitempty"`
+	LastUsed     string `json:"last_used,omitempty"`
+	SwitchCount  int    `json:"switch_count"`
+	Source       string `json:"source,omitempty"`
+	Modified     bool   `json:"modified_outside_cflip,omitempty"`
+	// Locked is set when the credentials are behind the locked vault
+	Locked bool `json:"locked,omitempty"`
+
+	Organization     string `json:"organization,omitempty"`
+	SubscriptionType string `json:"subscription_type,omitempty"`
+	ExpiresAt        string `json:"expires_at,omitempty"`
+	// ExpiresIn counts down to token expiry when the profile was read
+	ExpiresIn string `json:"expires_in,omitempty"`
+	Expired   bool   `json:"expired,omitempty"`
+	// CooldownUntil is when the account's usage limit resets, set while it
+	// is cooling down
+	CooldownUntil string `json:"cooldown_until,omitempty"`
+	// Groups are the named sets the account belongs to
+	Groups []string `json:"groups,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Notes  string   `json:"notes,omitempty"`
 }
 
 // AddCurrentAccount adds the current Claude Code account to managed profiles
@@ -64,28 +137,192 @@ func (s *Service) AddCurrentAccount(alias string) (*ProfileInfo, error) {
 	return s.profileToInfo(profile, true), nil
 }
 
-// ListAccounts returns all managed profiles
+// AddAccountFromFiles registers an account from an exported credentials file
+// and, optionally, an exported Claude Code config. Without a config the live
+// Claude Code config is used as the base and email identifies the account.
+func (s *Service) AddAccountFromFiles(credentialsPath, configPath, email, alias string) (*ProfileInfo, error) {
+	credentials, err := config.LoadCredentialsFile(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var claudeConfig *config.ClaudeConfig
+	if configPath != "" {
+		claudeConfig, err = config.LoadClaudeConfigFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		if email != "" && !strings.EqualFold(email, claudeConfig.GetUserEmail()) {
+			return nil, fmt.Errorf("--email %s does not match %s in %s", email, claudeConfig.GetUserEmail(), configPath)
+		}
+	} else {
+		if email == "" {
+			return nil, fmt.Errorf("--email is required when no --config file is given")
+		}
+		claudeConfig, err = baseClaudeConfig(map[string]interface{}{"emailAddress": email})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.importAccount(alias, claudeConfig, credentials)
+}
+
+// ManualAccount holds hand-entered account details for AddManualAccount
+type ManualAccount struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	Email        string
+	Organization string
+}
+
+// AddManualAccount verifies hand-entered tokens against the live API and
+// registers the account they belong to
+func (s *Service) AddManualAccount(entry ManualAccount, alias string) (*ProfileInfo, error) {
+	if entry.AccessToken == "" || entry.RefreshToken == "" {
+		return nil, fmt.Errorf("access token and refresh token are both required")
+	}
+
+	live, err := auth.FetchProfile(entry.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify access token: %w", err)
+	}
+	if entry.Email != "" && !strings.EqualFold(entry.Email, live.Account.Email) {
+		return nil, fmt.Errorf("token belongs to %s, not %s", live.Account.Email, entry.Email)
+	}
+	if entry.Organization != "" && live.Organization.Name != "" && !strings.EqualFold(entry.Organization, live.Organization.Name) {
+		return nil, fmt.Errorf("token belongs to organization %q, not %q", live.Organization.Name, entry.Organization)
+	}
+
+	claudeConfig, err := baseClaudeConfig(map[string]interface{}{
+		"emailAddress":     live.Account.Email,
+		"accountUuid":      live.Account.UUID,
+		"organizationUuid": live.Organization.UUID,
+		"organizationName": live.Organization.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &config.Credentials{}
+	credentials.ClaudeAiOauth.AccessToken = entry.AccessToken
+	credentials.ClaudeAiOauth.RefreshToken = entry.RefreshToken
+	credentials.ClaudeAiOauth.SubscriptionType = strings.TrimPrefix(live.Organization.OrganizationType, "claude_")
+	if !entry.ExpiresAt.IsZero() {
+		credentials.ClaudeAiOauth.ExpiresAt = entry.ExpiresAt.UnixMilli()
+	}
+
+	return s.importAccount(alias, claudeConfig, credentials)
+}
+
+// baseClaudeConfig builds a config for an account that is not logged in,
+// keeping the live Claude Code settings but swapping in its oauthAccount
+func baseClaudeConfig(oauthAccount map[string]interface{}) (*config.ClaudeConfig, error) {
+	claudeConfig, err := config.LoadClaudeConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Claude Code configuration as a base: %w", err)
+	}
+	delete(*claudeConfig, "_cflip_credentials")
+	claudeConfig.SetOAuthAccount(oauthAccount)
+	return claudeConfig, nil
+}
+
+// importAccount stores an account that is not the one logged in to Claude Code
+func (s *Service) importAccount(alias string, claudeConfig *config.ClaudeConfig, credentials *config.Credentials) (*ProfileInfo, error) {
+	if _, err := s.switcher.FindProfileByAccount(claudeConfig.GetUserEmail(), claudeConfig.GetOrganizationUuid()); err == nil {
+		return nil, fmt.Errorf("account %s is already managed", claudeConfig.GetUserEmail())
+	}
+
+	profile, err := s.switcher.ImportAccount(alias, alias, claudeConfig, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import account: %w", err)
+	}
+
+	return s.profileToInfo(profile, false), nil
+}
+
+// ListAccounts returns all managed profiles. Accounts of shared sources are
+// listed by ListSharedProfiles, so listing never reaches the network.
 func (s *Service) ListProfiles() ([]*ProfileInfo, error) {
+	profileInfos, _, err := s.listLocalProfiles()
+	if err != nil {
+		return nil, err
+	}
+	s.applyCooldowns(profileInfos...)
+	return profileInfos, nil
+}
+
+// ListSharedProfiles fetches the accounts of the configured shared sources
+// that have no local copy, sorted by source. Sources that could not be
+// fetched are returned by name with their error.
+func (s *Service) ListSharedProfiles() ([]*ProfileInfo, map[string]error, error) {
+	local, activeProfile, err := s.listLocalProfiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	localEmails := make(map[string]bool)
+	for _, info := range local {
+		localEmails[info.Email] = true
+	}
+
+	shared, failures, err := s.switcher.ListSharedProfiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	sourceNames := make([]string, 0, len(shared))
+	for name := range shared {
+		sourceNames = append(sourceNames, name)
+	}
+	sort.Strings(sourceNames)
+
+	var profileInfos []*ProfileInfo
+	for _, name := range sourceNames {
+		for _, profile := range shared[name] {
+			// Local copies take precedence
+			if localEmails[profile.Email] {
+				continue
+			}
+			isActive := activeProfile != nil && activeProfile.Source == profile.Source &&
+				profile.Name == activeProfile.Name
+			profileInfos = append(profileInfos, s.profileToInfo(profile, isActive))
+		}
+	}
+	s.applyCooldowns(profileInfos...)
+
+	return profileInfos, failures, nil
+}
+
+// listLocalProfiles converts the stored profiles, also returning the
+// active profile
+func (s *Service) listLocalProfiles() ([]*ProfileInfo, *profile.Profile, error) {
 	profiles, err := s.switcher.ListProfiles()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list profiles: %w", err)
+		return nil, nil, fmt.Errorf("failed to list profiles: %w", err)
 	}
 
-	// Get active profile
 	activeProfile, _ := s.switcher.GetCurrentActiveProfile()
 	activeProfileName := ""
 	if activeProfile != nil {
 		activeProfileName = activeProfile.Name
 	}
 
-	// Convert to ProfileInfo slice
 	var profileInfos []*ProfileInfo
 	for _, profile := range profiles {
 		isActive := profile.Name == activeProfileName
 		profileInfos = append(profileInfos, s.profileToInfo(profile, isActive))
 	}
+	return profileInfos, activeProfile, nil
+}
 
-	return profileInfos, nil
+// LeastRecentlyUsedAccount returns the account idle the longest, other than
+// the active one
+func (s *Service) LeastRecentlyUsedAccount() (*ProfileInfo, error) {
+	profile, err := s.switcher.LeastRecentlyUsedProfile()
+	if err != nil {
+		return nil, err
+	}
+	return s.profileToInfo(profile, false), nil
 }
 
 // GetCurrentAccount returns the currently active profile
@@ -95,57 +332,699 @@ func (s *Service) GetCurrentAccount() (*ProfileInfo, error) {
 		return nil, fmt.Errorf("no active profile found: %w", err)
 	}
 
+	info := s.profileToInfo(profile, true)
+	s.applyCooldowns(info)
+	return info, nil
+}
+
+// LiveStatus compares the account Claude Code is logged in with against
+// cflip's active pointer
+type LiveStatus struct {
+	LiveEmail string `json:"live_email,omitempty"`
+	LiveUuid  string `json:"live_account_uuid,omitempty"`
+	// Managed is the stored profile for the live account, nil when unmanaged
+	Managed *ProfileInfo `json:"managed,omitempty"`
+	// Pointer is the profile config.json marks active, nil when unset
+	Pointer *ProfileInfo `json:"pointer,omitempty"`
+	InSync  bool         `json:"in_sync"`
+}
+
+// DetectActive cross-checks the live oauthAccount against stored profiles
+func (s *Service) DetectActive() (*LiveStatus, error) {
+	state, err := s.switcher.DetectLive()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &LiveStatus{
+		LiveEmail: state.Email,
+		LiveUuid:  state.AccountUuid,
+		InSync:    state.InSync(),
+	}
+	if state.Match != nil {
+		status.Managed = s.profileToInfo(state.Match, status.InSync)
+	}
+	if state.Pointer != nil {
+		status.Pointer = s.profileToInfo(state.Pointer, true)
+	}
+	return status, nil
+}
+
+// AutoAdopt stores the live account when settings.auto_adopt is on and it is
+// not managed yet. It returns nil when nothing was adopted.
+func (s *Service) AutoAdopt() (*ProfileInfo, error) {
+	settings, err := s.switcher.Settings()
+	if err != nil || !settings.AutoAdopt {
+		return nil, err
+	}
+
+	adopted, err := s.switcher.AdoptLive()
+	if err != nil || adopted == nil {
+		return nil, err
+	}
+	return s.profileToInfo(adopted, true), nil
+}
+
+// ReconcileActive points cflip's active pointer at the live account
+func (s *Service) ReconcileActive() (*ProfileInfo, error) {
+	profile, err := s.switcher.ReconcilePointer()
+	if err != nil {
+		return nil, err
+	}
 	return s.profileToInfo(profile, true), nil
 }
 
+// ReconcileAction describes one change made by Reconcile
+type ReconcileAction struct {
+	Account string `json:"account"`
+	Action  string `json:"action"`
+}
+
+// Reconcile compares the live Claude state to all stored profiles and fixes
+// what changed outside cflip, reporting every action taken
+func (s *Service) Reconcile() ([]ReconcileAction, error) {
+	actions, err := s.switcher.Reconcile()
+
+	var results []ReconcileAction
+	for _, action := range actions {
+		results = append(results, ReconcileAction{Account: action.Profile, Action: action.Action})
+	}
+	return results, err
+}
+
+// CheckCurrent verifies that there is an active managed account, that it is
+// the one Claude Code is logged in with, and that its token has not expired.
+// It makes no network calls, so it is cheap enough for shell prompts.
+func (s *Service) CheckCurrent() error {
+	active, err := s.switcher.GetCurrentActiveProfile()
+	if err != nil {
+		return fmt.Errorf("no active managed account")
+	}
+
+	live, err := config.LoadClaudeConfig()
+	if err != nil {
+		return fmt.Errorf("cannot read Claude Code config: %w", err)
+	}
+	if liveEmail := live.GetUserEmail(); !strings.EqualFold(liveEmail, active.Email) {
+		if liveEmail == "" {
+			return fmt.Errorf("not logged in to Claude Code (cflip expects %s)", active.Email)
+		}
+		return fmt.Errorf("logged in to Claude Code as %s but cflip expects %s", liveEmail, active.Email)
+	}
+
+	// Claude Code refreshes its live tokens, so prefer them over the stored copy
+	credentials, ok := live.GetCredentials()
+	if !ok {
+		credentials = active.Credentials
+	}
+	if credentials == nil || credentials.ClaudeAiOauth.AccessToken == "" {
+		return fmt.Errorf("%s has no access token", active.Email)
+	}
+	if credentials.IsExpired() {
+		return fmt.Errorf("token for %s expired %s ago", active.Email, formatDuration(time.Since(credentials.ExpiresAtTime())))
+	}
+
+	return nil
+}
+
 // SwitchToAccount switches to a specific profile
 func (s *Service) SwitchToAccount(identifier string, force bool) error {
+	_, err := s.switchToAccount(context.Background(), identifier, force)
+	return err
+}
+
+// switchToAccount switches to a profile (the next one when identifier is
+// empty), first refreshing its tokens when they are expired or about to
+// expire. The refresh outcome is nil when none was due. ctx is checked
+// between the steps that come before anything is written.
+func (s *Service) switchToAccount(ctx context.Context, identifier string, force bool) (*RefreshResult, error) {
 	if !force {
 		if err := s.checkClaudeCodeNotRunning(); err != nil {
-			return err
+			return nil, err
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// A failed refresh does not block the switch; Claude Code retries it
+	var refresh *RefreshResult
+	var target *profile.Profile
+	var err error
+	if identifier == "" {
+		target, err = s.switcher.GetNextProfile()
+	} else if identifier, err = s.accountName(identifier); err != nil {
+		return nil, err
+	} else {
+		target, err = s.switcher.LoadProfile(identifier)
+	}
+	if err == nil {
+		refresh = s.refreshOnUse(target)
+	}
+	if err := ctx.Err(); err != nil {
+		return refresh, err
+	}
 
 	// Switch to the target profile
-	_, err := s.switcher.SwitchToAccount(identifier)
+	if _, err := s.switcher.SwitchToAccount(identifier); err != nil {
+		return nil, fmt.Errorf("failed to switch to profile: %w", err)
+	}
+	logSync(s.switcher.LastSync())
+
+	return refresh, nil
+}
+
+// SwitchResult is what a completed switch changed
+type SwitchResult struct {
+	FromEmail string         `json:"from_email,omitempty"` // empty when no account was active
+	To        *ProfileInfo   `json:"to"`
+	Refresh   *RefreshResult `json:"refresh,omitempty"` // set when the tokens were due for a refresh
+	// Sync is what was captured from Claude Code into the outgoing
+	// account's profile before switching, nil when it was not stored
+	Sync *SyncResult `json:"sync,omitempty"`
+}
+
+// Switch switches to identifier (the next account when empty) and records
+// the switch in the audit log. It is the switch shared by the CLI commands
+// and `cflip ui`.
+func (s *Service) Switch(identifier string, force bool) (*SwitchResult, error) {
+	return s.SwitchContext(context.Background(), identifier, force)
+}
+
+// SwitchContext is Switch, giving up when ctx is done before Claude Code's
+// files are written; from then on the switch runs to completion
+func (s *Service) SwitchContext(ctx context.Context, identifier string, force bool) (*SwitchResult, error) {
+	result := &SwitchResult{}
+	if current, err := s.GetCurrentAccount(); err == nil {
+		result.FromEmail = current.Email
+	}
+
+	refresh, err := s.switchToAccount(ctx, identifier, force)
 	if err != nil {
-		return fmt.Errorf("failed to switch to profile: %w", err)
+		return nil, fmt.Errorf("failed to switch account: %w", err)
 	}
+	result.Refresh = refresh
+	result.Sync = s.switcher.LastSync()
 
-	return nil
+	to, err := s.GetCurrentAccount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current account: %w", err)
+	}
+	result.To = to
+
+	logger.NewDefault().AccountSwitched(result.FromEmail, to.Email, to.Name)
+	return result, nil
+}
+
+// DisplayName is the account's alias, or its email without one
+func (p *ProfileInfo) DisplayName() string {
+	if p.Alias != "" {
+		return p.Alias
+	}
+	return p.Email
+}
+
+// ExpiryBadge is "EXPIRED" or "expires in ..." for the stored token, or
+// empty when its expiry is unknown
+func (p *ProfileInfo) ExpiryBadge() string {
+	switch {
+	case p.Expired:
+		return "EXPIRED"
+	case p.ExpiresIn != "":
+		return "expires in " + p.ExpiresIn
+	}
+	return ""
+}
+
+// SwitchCheck is the outcome of verifying a switch's credentials
+type SwitchCheck = profile.SwitchCheck
+
+// VerifySwitch checks the credentials the last switch wrote for the active
+// account, capturing tokens Claude Code already refreshed. The API is pinged
+// when ping is set or settings.verify_switch is on.
+func (s *Service) VerifySwitch(ping bool) (*SwitchCheck, error) {
+	if !ping {
+		settings, err := s.switcher.Settings()
+		if err != nil {
+			return nil, err
+		}
+		ping = settings.VerifySwitch
+	}
+
+	active, err := s.switcher.GetCurrentActiveProfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load active profile: %w", err)
+	}
+	return s.switcher.VerifySwitch(active, ping)
+}
+
+// SelectOrganization makes the next switch apply the named organization
+// (name or UUID) from the target account's memberships
+func (s *Service) SelectOrganization(query string) {
+	s.switcher.SelectOrganization(query)
+}
+
+// OrgMembership is one organization/workspace an account belongs to
+type OrgMembership = profile.OrgMembership
+
+// Organizations lists an account's known organization memberships
+func (s *Service) Organizations(identifier string) ([]OrgMembership, string, error) {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return nil, "", err
+	}
+	p, err := s.switcher.LoadProfile(identifier)
+	if err != nil {
+		return nil, "", err
+	}
+
+	current := ""
+	if p.ClaudeConfig != nil {
+		if account, ok := (*p.ClaudeConfig)["oauthAccount"].(map[string]interface{}); ok {
+			current, _ = account["organizationUuid"].(string)
+		}
+	}
+	return p.Organizations, current, nil
+}
+
+// AddOrganization records an organization membership for an account
+func (s *Service) AddOrganization(identifier string, membership OrgMembership) error {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
+	_, err = s.switcher.AddOrganization(identifier, membership)
+	return err
 }
 
-// RemoveAccount removes a profile from management
+// SkipDesktop opts out of switching Claude Desktop for this service instance
+func (s *Service) SkipDesktop() {
+	s.switcher.SkipDesktop()
+}
+
+// ResetSwitchOptions forgets the organization, group and Claude Desktop
+// choices made for the next switch
+func (s *Service) ResetSwitchOptions() {
+	s.switcher.ResetSwitchOptions()
+}
+
+// ExpiryWarning returns a warning when the active account's token expires
+// within the configured window, or "" when no warning is due
+func (s *Service) ExpiryWarning() (string, error) {
+	settings, err := s.switcher.Settings()
+	if err != nil {
+		return "", err
+	}
+	if settings.ExpiryWarning.Disabled {
+		return "", nil
+	}
+
+	window, err := settings.ExpiryWarning.WindowDuration()
+	if err != nil {
+		return "", err
+	}
+
+	active, err := s.switcher.GetCurrentActiveProfile()
+	if err != nil || active.Credentials == nil || active.Credentials.ClaudeAiOauth.ExpiresAt == 0 {
+		return "", nil
+	}
+
+	remaining := time.Until(active.Credentials.ExpiresAtTime())
+	switch {
+	case remaining <= 0:
+		return fmt.Sprintf("Token for %s expired %s ago; refresh or switch accounts", active.Email,
+			formatDuration(-remaining)), nil
+	case remaining <= window:
+		return fmt.Sprintf("Token for %s expires in %s; refresh or switch accounts soon", active.Email,
+			formatDuration(remaining)), nil
+	}
+
+	return "", nil
+}
+
+// RemoveAccount moves a profile to the trash, where `undelete` can restore
+// it until the retention period passes
 func (s *Service) RemoveAccount(identifier string) error {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
+	return s.switcher.TrashProfile(identifier)
+}
+
+// PurgeAccount deletes a profile immediately, bypassing the trash
+func (s *Service) PurgeAccount(identifier string) error {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
 	return s.switcher.DeleteProfile(identifier)
 }
 
+// RemovedAccount is a profile in the trash
+type RemovedAccount struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Alias     string    `json:"alias,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// ListRemovedAccounts returns the accounts that can still be undeleted
+func (s *Service) ListRemovedAccounts() ([]RemovedAccount, error) {
+	trashed, err := s.switcher.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]RemovedAccount, 0, len(trashed))
+	for _, item := range trashed {
+		removed = append(removed, RemovedAccount{
+			Name:      item.Profile.Name,
+			Email:     item.Profile.Email,
+			Alias:     item.Profile.Alias,
+			DeletedAt: item.DeletedAt,
+			PurgeAt:   item.PurgeAt,
+		})
+	}
+	return removed, nil
+}
+
+// UndeleteAccount restores a removed account from the trash
+func (s *Service) UndeleteAccount(identifier string) (*ProfileInfo, error) {
+	restored, err := s.switcher.UndeleteProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return s.profileToInfo(restored, false), nil
+}
+
+// ArchivedAccount is a profile moved to the archive by `cflip enforce`
+type ArchivedAccount struct {
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	Alias      string    `json:"alias,omitempty"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// ListArchivedAccounts returns the accounts that can be unarchived
+func (s *Service) ListArchivedAccounts() ([]ArchivedAccount, error) {
+	archive, err := s.switcher.ListArchive()
+	if err != nil {
+		return nil, err
+	}
+
+	archived := make([]ArchivedAccount, 0, len(archive))
+	for _, item := range archive {
+		archived = append(archived, ArchivedAccount{
+			Name:       item.Profile.Name,
+			Email:      item.Profile.Email,
+			Alias:      item.Profile.Alias,
+			ArchivedAt: item.ArchivedAt,
+		})
+	}
+	return archived, nil
+}
+
+// UnarchiveAccount restores an archived account into the store
+func (s *Service) UnarchiveAccount(identifier string) (*ProfileInfo, error) {
+	restored, err := s.switcher.UnarchiveProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return s.profileToInfo(restored, false), nil
+}
+
 // RenameAccount changes the name/alias of a profile
 func (s *Service) RenameAccount(identifier, newAlias string) error {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
 	return s.switcher.RenameProfile(identifier, "", newAlias)
 }
 
-// ValidateAccounts validates all stored profiles
-func (s *Service) ValidateAccounts() map[string]error {
-	profiles, err := s.switcher.ListProfiles()
+// MoveAccount gives an account a new number in the list; the accounts in
+// between shift by one
+func (s *Service) MoveAccount(identifier string, position int) (*ProfileInfo, error) {
+	resolution, err := s.ResolveIdentifier(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if resolution.Profile.Source != "" {
+		return nil, fmt.Errorf("%s comes from shared source %s, which is read-only and has no number", resolution.Profile.Email, resolution.Profile.Source)
+	}
+
+	p, err := s.switcher.MoveProfile(resolution.Profile.Name, position)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewDefault().AccountMoved(p.Email, position)
+	return s.profileToInfo(p, resolution.Profile.IsActive), nil
+}
+
+// ValidateAccount validates a single stored profile, first refreshing
+// tokens that are expired or about to expire
+func (s *Service) ValidateAccount(identifier string) error {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
+	if p, err := s.switcher.LoadProfile(identifier); err == nil {
+		s.refreshOnUse(p)
+	}
+	return s.switcher.ValidateProfile(identifier)
+}
+
+// cflipVersion is the running cflip version, reported in exports
+var cflipVersion = "dev"
+
+// SetVersion records the cflip version stamped on every profile write
+func SetVersion(version string) {
+	cflipVersion = version
+	profile.SetWriterVersion(version)
+}
+
+// RewriteProfiles rewrites every stored profile with the current sops setting
+func (s *Service) RewriteProfiles() ([]string, error) {
+	return s.switcher.RewriteProfiles()
+}
+
+// SopsEnabled reports whether profiles are written encrypted with sops
+func (s *Service) SopsEnabled() bool {
+	settings, err := s.switcher.Settings()
+	return err == nil && settings.Sops.Enabled
+}
+
+// AcceptModified re-records the hashes of profiles modified outside cflip
+func (s *Service) AcceptModified() ([]string, error) {
+	return s.switcher.AcceptModified()
+}
+
+// RestorePoint is a pre-switch snapshot of Claude Code's live state
+type RestorePoint = profile.RestorePoint
+
+// ListRestorePoints returns the pre-switch snapshots, newest first
+func (s *Service) ListRestorePoints() ([]*RestorePoint, error) {
+	return profile.ListRestorePoints()
+}
+
+// RestoreConfig restores ~/.claude.json and the credentials from a restore
+// point: 1 is the newest. It works without any stored profile.
+func (s *Service) RestoreConfig(number int) (*RestorePoint, error) {
+	points, err := profile.ListRestorePoints()
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no restore points found; one is created before every switch")
+	}
+	if number < 1 || number > len(points) {
+		return nil, fmt.Errorf("invalid restore point: %d (only %d available)", number, len(points))
+	}
+
+	point := points[number-1]
+	if err := s.switcher.RestoreFromPoint(point); err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// PermissionIssue describes a file or directory with unsafe permissions
+type PermissionIssue = profile.PermissionIssue
+
+// AuditPermissions checks cflip's files and Claude Code's auth files for
+// loose permissions or wrong ownership, tightening modes when fix is set
+func (s *Service) AuditPermissions(fix bool) ([]PermissionIssue, error) {
+	return profile.AuditPermissions(fix)
+}
+
+// DefaultCopySections are the sections copy-settings copies by default
+var DefaultCopySections = config.DefaultCopySections
+
+// CopySettings copies non-credential config sections between stored
+// accounts and returns the sections copied. claude_md is skipped with a
+// note, since every account already shares it.
+func (s *Service) CopySettings(from, to string, sections []string) ([]string, []string, error) {
+	var copied, notes []string
+	for _, section := range sections {
+		if section == config.SectionClaudeMD {
+			notes = append(notes, fmt.Sprintf("skipped %s: %v", section, config.ErrSharedSection))
+			continue
+		}
+		copied = append(copied, section)
+	}
+	if len(copied) == 0 {
+		return nil, notes, nil
+	}
+
+	from, err := s.accountName(from)
 	if err != nil {
-		return map[string]error{
-			"list_error": err,
+		return nil, notes, err
+	}
+	if to, err = s.accountName(to); err != nil {
+		return nil, notes, err
+	}
+	if err := s.switcher.CopySettings(from, to, copied); err != nil {
+		return nil, notes, err
+	}
+	return copied, notes, nil
+}
+
+// SettingsOverlay returns an account's settings.json overlay as indented JSON
+func (s *Service) SettingsOverlay(identifier string) ([]byte, error) {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.switcher.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if len(profile.SettingsOverlay) == 0 {
+		return nil, nil
+	}
+	return json.MarshalIndent(profile.SettingsOverlay, "", "  ")
+}
+
+// SetSettingsOverlay stores a JSON object to merge into ~/.claude/settings.json
+// while the account is active; empty data clears the overlay
+func (s *Service) SetSettingsOverlay(identifier string, data []byte) error {
+	var overlay config.SettingsOverlay
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &overlay); err != nil {
+			return fmt.Errorf("overlay must be a JSON object: %w", err)
 		}
 	}
 
-	errors := make(map[string]error)
-	for _, profile := range profiles {
-		if err := s.switcher.ValidateProfile(profile.Name); err != nil {
-			displayName := profile.Alias
-			if displayName == "" {
-				displayName = profile.Email
-			}
-			errors[displayName] = err
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
+	_, err = s.switcher.SetSettingsOverlay(identifier, overlay)
+	return err
+}
+
+// ProxySettings is a per-account proxy applied through settings.json
+type ProxySettings = profile.ProxySettings
+
+// Proxy returns an account's proxy settings, or nil when none are stored
+func (s *Service) Proxy(identifier string) (*ProxySettings, error) {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.switcher.LoadProfile(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return p.Proxy, nil
+}
+
+// SetProxy stores an account's proxy; nil clears it
+func (s *Service) SetProxy(identifier string, proxy *ProxySettings) error {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return err
+	}
+	_, err = s.switcher.SetProxy(identifier, proxy)
+	return err
+}
+
+// SchemaProblem is one structural problem in a stored file
+type SchemaProblem struct {
+	File    string `json:"file"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidateSchemas checks config.json and every profile file against the
+// embedded JSON Schemas
+func (s *Service) ValidateSchemas() ([]SchemaProblem, error) {
+	reports, err := s.switcher.ValidateSchemas()
+
+	var problems []SchemaProblem
+	for _, report := range reports {
+		for _, violation := range report.Violations {
+			problems = append(problems, SchemaProblem{File: report.Path, Path: violation.Path, Message: violation.Message})
 		}
 	}
+	return problems, err
+}
 
-	return errors
+// RepairResult describes one problem found by RepairAccounts
+type RepairResult struct {
+	Account string `json:"account"`
+	Problem string `json:"problem"`
+	Action  string `json:"action"`
+	Fixed   bool   `json:"fixed"`
+}
+
+// RepairAccounts attempts to fix problems with stored profiles and reports
+// what was fixed versus what needs manual action
+func (s *Service) RepairAccounts() ([]RepairResult, error) {
+	results, err := s.switcher.RepairProfiles()
+
+	var repairs []RepairResult
+	for _, result := range results {
+		repairs = append(repairs, RepairResult{
+			Account: result.Profile,
+			Problem: result.Problem,
+			Action:  result.Action,
+			Fixed:   result.Fixed,
+		})
+	}
+
+	return repairs, err
+}
+
+// EnforcementAction describes one retention policy action for the CLI
+type EnforcementAction struct {
+	Email  string `json:"email"`
+	Alias  string `json:"alias,omitempty"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// EnforcePolicies applies the configured retention policies to stored profiles
+func (s *Service) EnforcePolicies(dryRun bool) ([]EnforcementAction, error) {
+	results, err := s.switcher.EnforceRetention(dryRun)
+
+	var actions []EnforcementAction
+	for _, result := range results {
+		actions = append(actions, EnforcementAction{
+			Email:  result.Profile.Email,
+			Alias:  result.Profile.Alias,
+			Action: result.Action,
+			Reason: result.Reason,
+		})
+	}
+
+	if err != nil {
+		return actions, fmt.Errorf("failed to enforce retention policies: %w", err)
+	}
+
+	return actions, nil
 }
 
 // GetAccountByIdentifier gets a profile by identifier (for internal use)
@@ -170,7 +1049,16 @@ func (s *Service) GetAccountByIdentifier(identifier string) (*ProfileInfo, error
 		}
 	}
 
-	return nil, fmt.Errorf("profile not found: %s", identifier)
+	// Fall back to a unique prefix of a name, email or alias
+	matches := profile.ProfilesWithPrefix(profiles, identifier)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("profile not found: %s", identifier)
+	case 1:
+		return s.profileToInfo(matches[0], matches[0].Name == activeProfileName), nil
+	default:
+		return nil, profile.AmbiguousIdentifierError(identifier, profileNames(matches))
+	}
 }
 
 // profileToInfo converts a profile.Profile to ProfileInfo
@@ -181,17 +1069,91 @@ func (s *Service) profileToInfo(p *profile.Profile, isActive bool) *ProfileInfo
 		Alias:       p.Alias,
 		AccountUuid: p.AccountUuid,
 		IsActive:    isActive,
+		Source:      p.Source,
+		Modified:    p.Tampered,
+		Locked:      p.Locked,
 		CreatedAt:   p.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:   p.UpdatedAt.Format("2006-01-02 15:04:05"),
+		SwitchCount: p.SwitchCount,
+		Groups:      p.Groups,
+		Tags:        p.Tags,
+		Notes:       p.Notes,
 	}
 
 	if !p.LastActiveAt.IsZero() {
 		info.LastActiveAt = p.LastActiveAt.Format("2006-01-02 15:04:05")
+		info.LastUsed = humanizeSince(p.LastActiveAt)
+	}
+
+	info.Organization = p.OrganizationName()
+	if info.Organization == "" && p.ClaudeConfig != nil {
+		info.Organization = p.ClaudeConfig.GetOrganizationName()
+	}
+
+	if p.Credentials != nil {
+		info.SubscriptionType = p.Credentials.ClaudeAiOauth.SubscriptionType
+		if p.Credentials.ClaudeAiOauth.ExpiresAt != 0 {
+			expiresAt := p.Credentials.ExpiresAtTime()
+			info.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+			if remaining := time.Until(expiresAt); remaining > 0 {
+				info.ExpiresIn = formatCountdown(remaining)
+			} else {
+				info.Expired = true
+			}
+		}
 	}
 
 	return info
 }
 
+// formatDuration renders d compactly at minute precision, e.g. "1h53m" or "12m"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case hours >= 48:
+		return fmt.Sprintf("%dd", hours/24)
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// humanizeSince renders the time elapsed since t as "5 minutes ago", "2 days ago", etc.
+func humanizeSince(t time.Time) string {
+	d := time.Since(t)
+	if d < time.Minute {
+		return "just now"
+	}
+
+	var n int
+	var unit string
+	switch {
+	case d < time.Hour:
+		n, unit = int(d.Minutes()), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d.Hours()), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d.Hours()/24), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d.Hours()/(24*30)), "month"
+	default:
+		n, unit = int(d.Hours()/(24*365)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
+
 // checkClaudeCodeNotRunning checks if Claude Code is currently running
 func (s *Service) checkClaudeCodeNotRunning() error {
 	var processNames []string
@@ -211,6 +1173,16 @@ func (s *Service) checkClaudeCodeNotRunning() error {
 		}
 	}
 
+	if s.switcher.DesktopEnabled() {
+		desktopProcess := "Claude.app"
+		if runtime.GOOS == "linux" {
+			desktopProcess = "claude-desktop"
+		}
+		if isProcessRunning(desktopProcess) {
+			return fmt.Errorf("Claude Desktop is currently running. Please quit it before switching accounts (or use --no-desktop)")
+		}
+	}
+
 	return nil
 }
 
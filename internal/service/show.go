@@ -0,0 +1,76 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+)
+
+// AccountDetails is everything stored for an account, with its tokens
+// masked unless they were explicitly revealed
+type AccountDetails struct {
+	*ProfileInfo
+	OrganizationUuid string `json:"organization_uuid,omitempty"`
+	// CredentialStore is "secure" when the tokens live in secure storage
+	// instead of the profile file
+	CredentialStore string   `json:"credential_store,omitempty"`
+	Scopes          []string `json:"scopes,omitempty"`
+	AccessToken     string   `json:"access_token,omitempty"`
+	RefreshToken    string   `json:"refresh_token,omitempty"`
+	Revealed        bool     `json:"revealed"`
+	// ConfigKeys are the top-level ~/.claude.json keys captured in the profile
+	ConfigKeys []string `json:"config_keys,omitempty"`
+	// OverlayKeys are the settings.json keys the account's overlay sets
+	OverlayKeys []string       `json:"overlay_keys,omitempty"`
+	Proxy       *ProxySettings `json:"proxy,omitempty"`
+	HasDesktop  bool           `json:"has_desktop,omitempty"`
+}
+
+// ShowAccount returns everything stored for an account. Tokens are masked
+// unless reveal is set, which is recorded in the audit log.
+func (s *Service) ShowAccount(identifier string, reveal bool) (*AccountDetails, error) {
+	name, err := s.localProfileName(identifier)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.switcher.LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := s.profileToInfo(p, s.isActiveProfile(p.Name))
+	s.applyCooldowns(info)
+	details := &AccountDetails{
+		ProfileInfo:      info,
+		OrganizationUuid: p.OrganizationUuid,
+		CredentialStore:  p.CredentialStore,
+		Revealed:         reveal,
+		Proxy:            p.Proxy,
+		HasDesktop:       p.Desktop != nil,
+	}
+	if p.Credentials != nil {
+		oauth := p.Credentials.ClaudeAiOauth
+		details.Scopes = oauth.Scopes
+		details.AccessToken = oauth.AccessToken
+		details.RefreshToken = oauth.RefreshToken
+		if !reveal {
+			details.AccessToken = logger.MaskSecret(oauth.AccessToken)
+			details.RefreshToken = logger.MaskSecret(oauth.RefreshToken)
+		}
+	}
+	if p.ClaudeConfig != nil {
+		for key := range *p.ClaudeConfig {
+			details.ConfigKeys = append(details.ConfigKeys, key)
+		}
+	}
+	sort.Strings(details.ConfigKeys)
+	for key := range p.SettingsOverlay {
+		details.OverlayKeys = append(details.OverlayKeys, key)
+	}
+	sort.Strings(details.OverlayKeys)
+
+	if reveal {
+		logger.NewDefault().SecretsRevealed(p.Email)
+	}
+	return details, nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/config"
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// AuditLogPath returns the location of the persisted audit log
+func AuditLogPath() (string, error) {
+	dir, err := profile.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// AccountStats summarizes how a single account has been used
+type AccountStats struct {
+	Email           string         `json:"email"`
+	Alias           string         `json:"alias,omitempty"`
+	Switches        int            `json:"switches"`
+	SwitchesPerWeek map[string]int `json:"switches_per_week"`
+	TotalActive     string         `json:"total_active"`
+	AverageSession  string         `json:"average_session"`
+	ClaudeSessions  int            `json:"claude_sessions"`
+	Usage           *UsageTotals   `json:"usage,omitempty"`
+
+	totalActive time.Duration
+}
+
+// UsageTotals aggregates token usage parsed from Claude Code's session logs
+type UsageTotals struct {
+	Messages            int     `json:"messages"`
+	InputTokens         int64   `json:"input_tokens"`
+	OutputTokens        int64   `json:"output_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CostUSD             float64 `json:"cost_usd"`
+}
+
+// add accumulates a single usage entry
+func (u *UsageTotals) add(entry config.UsageEntry) {
+	u.Messages++
+	u.InputTokens += entry.InputTokens
+	u.OutputTokens += entry.OutputTokens
+	u.CacheCreationTokens += entry.CacheCreationInputTokens
+	u.CacheReadTokens += entry.CacheReadInputTokens
+	u.CostUSD += entry.CostUSD
+}
+
+// StatsReport is the aggregated usage report produced by `cflip stats`
+type StatsReport struct {
+	Since     time.Time       `json:"since"`
+	Until     time.Time       `json:"until"`
+	Accounts  []*AccountStats `json:"accounts"`
+	MostUsed  string          `json:"most_used,omitempty"`
+	LeastUsed string          `json:"least_used,omitempty"`
+
+	// Unattributed is usage logged while no switch history covers the time
+	Unattributed *UsageTotals `json:"unattributed,omitempty"`
+}
+
+// accountSession is a contiguous period during which one account was active
+type accountSession struct {
+	Email string
+	// Profile is the profile switched to; empty for switches logged by
+	// versions that recorded only the email
+	Profile string
+	Start   time.Time
+	End     time.Time
+}
+
+// loadSessions reconstructs account sessions from switch events in the audit log.
+// A session runs from a switch onto an account until the next switch (or now).
+func loadSessions(now time.Time) ([]accountSession, error) {
+	events, err := logger.ReadAuditLog()
+	if err != nil {
+		return nil, err
+	}
+
+	var switches []logger.AuditEvent
+	for _, event := range events {
+		if event.Action == "account_switched" && event.Attrs["to_email"] != "" {
+			switches = append(switches, event)
+		}
+	}
+	sort.Slice(switches, func(i, j int) bool { return switches[i].Time.Before(switches[j].Time) })
+
+	sessions := make([]accountSession, 0, len(switches))
+	for i, event := range switches {
+		end := now
+		if i+1 < len(switches) {
+			end = switches[i+1].Time
+		}
+		sessions = append(sessions, accountSession{
+			Email:   event.Attrs["to_email"],
+			Profile: event.Attrs["to_profile"],
+			Start:   event.Time,
+			End:     end,
+		})
+	}
+
+	return sessions, nil
+}
+
+// activeSince sums how long each profile was active between since and now,
+// keyed by profile name, or by email for sessions that did not record one
+// (see usedBy)
+func activeSince(sessions []accountSession, since time.Time) map[string]time.Duration {
+	totals := make(map[string]time.Duration)
+	for _, session := range sessions {
+		if session.End.Before(since) {
+			continue
+		}
+		start := session.Start
+		if start.Before(since) {
+			start = since
+		}
+		key := session.Profile
+		if key == "" {
+			key = session.Email
+		}
+		totals[key] += session.End.Sub(start)
+	}
+	return totals
+}
+
+// usedBy returns how long p was active according to activeSince. Sessions
+// keyed by email count for p only when no other stored profile shares the
+// email, since organizations of one login are separate seats.
+func usedBy(used map[string]time.Duration, p *profile.Profile, profiles []*profile.Profile) time.Duration {
+	total := used[p.Name]
+	if p.Name == p.Email {
+		return total
+	}
+	for _, other := range profiles {
+		if other.Email == p.Email && other.Name != p.Name {
+			return total
+		}
+	}
+	return total + used[p.Email]
+}
+
+// sessionAt returns the account active at t, or "" when history does not cover it
+func sessionAt(sessions []accountSession, t time.Time) string {
+	i := sort.Search(len(sessions), func(i int) bool { return sessions[i].End.After(t) })
+	if i < len(sessions) && !sessions[i].Start.After(t) {
+		return sessions[i].Email
+	}
+	return ""
+}
+
+// Stats aggregates switch events from the audit log into a usage report.
+// Time spent on an account is measured from a switch onto it until the next switch.
+func (s *Service) Stats(since time.Time) (*StatsReport, error) {
+	now := time.Now()
+	sessions, err := loadSessions(now)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmail := make(map[string]*AccountStats)
+	sessionCounts := make(map[string]int)
+
+	// Seed with managed profiles so unused accounts appear as least used
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	for _, p := range profiles {
+		byEmail[p.Email] = &AccountStats{Email: p.Email, Alias: p.Alias, SwitchesPerWeek: map[string]int{}}
+	}
+
+	for _, session := range sessions {
+		if session.End.Before(since) {
+			continue
+		}
+
+		stats, ok := byEmail[session.Email]
+		if !ok {
+			stats = &AccountStats{Email: session.Email, SwitchesPerWeek: map[string]int{}}
+			byEmail[session.Email] = stats
+		}
+
+		start := session.Start
+		if start.Before(since) {
+			start = since
+		} else {
+			year, week := session.Start.ISOWeek()
+			stats.Switches++
+			stats.SwitchesPerWeek[fmt.Sprintf("%d-W%02d", year, week)]++
+		}
+
+		stats.totalActive += session.End.Sub(start)
+		sessionCounts[session.Email]++
+	}
+
+	report := &StatsReport{Since: since, Until: now}
+
+	// Count claude CLI runs made through `cflip claude`
+	events, err := logger.ReadAuditLog()
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.Action != "claude_session" || event.Time.Before(since) || event.Attrs["email"] == "" {
+			continue
+		}
+		stats, ok := byEmail[event.Attrs["email"]]
+		if !ok {
+			stats = &AccountStats{Email: event.Attrs["email"], SwitchesPerWeek: map[string]int{}}
+			byEmail[stats.Email] = stats
+		}
+		stats.ClaudeSessions++
+	}
+
+	// Attribute Claude Code token usage to whichever account was active
+	entries, err := config.LoadUsageEntries(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Claude Code usage logs: %w", err)
+	}
+	for _, entry := range entries {
+		email := sessionAt(sessions, entry.Timestamp)
+		if email == "" {
+			if report.Unattributed == nil {
+				report.Unattributed = &UsageTotals{}
+			}
+			report.Unattributed.add(entry)
+			continue
+		}
+
+		stats, ok := byEmail[email]
+		if !ok {
+			stats = &AccountStats{Email: email, SwitchesPerWeek: map[string]int{}}
+			byEmail[email] = stats
+		}
+		if stats.Usage == nil {
+			stats.Usage = &UsageTotals{}
+		}
+		stats.Usage.add(entry)
+	}
+
+	for email, stats := range byEmail {
+		stats.TotalActive = stats.totalActive.Round(time.Minute).String()
+		stats.AverageSession = "0s"
+		if sessionCounts[email] > 0 {
+			stats.AverageSession = (stats.totalActive / time.Duration(sessionCounts[email])).Round(time.Minute).String()
+		}
+		report.Accounts = append(report.Accounts, stats)
+	}
+
+	// Most used first; ties broken by time spent, then email for stable output
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		a, b := report.Accounts[i], report.Accounts[j]
+		if a.Switches != b.Switches {
+			return a.Switches > b.Switches
+		}
+		if a.totalActive != b.totalActive {
+			return a.totalActive > b.totalActive
+		}
+		return a.Email < b.Email
+	})
+
+	if len(report.Accounts) > 0 {
+		report.MostUsed = report.Accounts[0].Email
+		report.LeastUsed = report.Accounts[len(report.Accounts)-1].Email
+	}
+
+	return report, nil
+}
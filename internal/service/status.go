@@ -0,0 +1,59 @@
+package service
+
+import (
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// DefaultStatusFormat is the `cflip status` line, e.g. "work ✔ 2h left"
+const DefaultStatusFormat = "{{.Name}} {{.Mark}}{{if .Expiry}} {{.Expiry}}{{end}}"
+
+// PromptStatus is the active account as shell prompts show it. Its fields
+// are what `cflip status --format` templates can use.
+type PromptStatus struct {
+	Name      string     `json:"name"` // alias, or email without one
+	Profile   string     `json:"profile"`
+	Email     string     `json:"email"`
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at"` // null when unknown
+	// Expiry is e.g. "2h left" or "expired"; empty when unknown
+	Expiry  string `json:"expiry,omitempty"`
+	Expired bool   `json:"expired"`
+	// Mark is ✔ while the token is valid, ✘ once expired, ? when unknown
+	Mark string `json:"mark"`
+}
+
+// ReadStatus returns the active account for shell prompts, reading only
+// config.json, or nil when no account is active
+func ReadStatus(now time.Time) (*PromptStatus, error) {
+	active, err := profile.ReadActiveStatus()
+	if err != nil || active == nil {
+		return nil, err
+	}
+
+	status := &PromptStatus{
+		Name:    active.Email,
+		Profile: active.Profile,
+		Email:   active.Email,
+		Alias:   active.Alias,
+		Mark:    "?",
+	}
+	switch {
+	case active.Alias != "":
+		status.Name = active.Alias
+	case active.Email == "":
+		status.Name = active.Profile
+	}
+
+	if !active.ExpiresAt.IsZero() {
+		expiresAt := active.ExpiresAt
+		status.ExpiresAt = &expiresAt
+		if now.After(expiresAt) {
+			status.Expired, status.Mark, status.Expiry = true, "✘", "expired"
+		} else {
+			status.Mark, status.Expiry = "✔", formatDuration(expiresAt.Sub(now))+" left"
+		}
+	}
+	return status, nil
+}
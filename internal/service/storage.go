@@ -0,0 +1,46 @@
+package service
+
+import (
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// StorageMigration is what `cflip storage migrate` moved
+type StorageMigration = profile.StorageMigration
+
+// StorageBackend describes a credential storage backend
+type StorageBackend struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Available   bool   `json:"available"`
+	// Unavailable explains why the backend cannot be used here
+	Unavailable string `json:"unavailable,omitempty"`
+	Active      bool   `json:"active"`
+}
+
+// StorageBackends lists the registered backends, marking the one stored
+// profiles keep their credentials in
+func (s *Service) StorageBackends() []StorageBackend {
+	active := storage.ProfileBackend()
+	var list []StorageBackend
+	for _, backend := range storage.Backends() {
+		info := StorageBackend{Name: backend.Name, Description: backend.Description, Available: true, Active: backend.Name == active}
+		if err := backend.Available(); err != nil {
+			info.Available = false
+			info.Unavailable = err.Error()
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+// MigrateStorage moves every stored profile's credentials from one backend
+// into another and makes it settings.storage_backend
+func (s *Service) MigrateStorage(from, to string) (*StorageMigration, error) {
+	result, err := s.switcher.MigrateStorage(from, to)
+	if result != nil {
+		logger.NewDefault().StorageMigrated(from, to, len(result.Migrated))
+	}
+	return result, err
+}
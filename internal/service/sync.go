@@ -0,0 +1,32 @@
+package service
+
+import (
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// SyncResult is what syncing the active profile from Claude Code changed
+type SyncResult = profile.SyncResult
+
+// SyncChange is one field a sync updated
+type SyncChange = profile.SyncChange
+
+// Sync captures the live Claude Code credentials and config, including
+// tokens Claude Code refreshed on its own, into the active account's
+// profile. Every switch does the same for the outgoing account first.
+func (s *Service) Sync() (*SyncResult, error) {
+	result, err := s.switcher.SyncActive()
+	if err != nil {
+		return nil, err
+	}
+	logSync(result)
+	return result, nil
+}
+
+// logSync records a sync that changed a profile in the audit log
+func logSync(result *SyncResult) {
+	if result == nil || !result.Changed() {
+		return
+	}
+	logger.NewDefault().ProfileSynced(result.Email, result.Fields())
+}
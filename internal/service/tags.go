@@ -0,0 +1,47 @@
+package service
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+)
+
+// HasTags reports whether the account carries every tag (case-insensitive)
+func (p *ProfileInfo) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(p.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// TagAccount adds and removes tags on an account
+func (s *Service) TagAccount(identifier string, add, remove []string) (*ProfileInfo, error) {
+	name, err := s.localProfileName(identifier)
+	if err != nil {
+		return nil, err
+	}
+	p, changed, err := s.switcher.TagProfile(name, add, remove)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		logger.NewDefault().AccountTagged(p.Email, p.Tags)
+	}
+	return s.profileToInfo(p, s.isActiveProfile(p.Name)), nil
+}
+
+// SetNotes replaces an account's notes; empty clears them
+func (s *Service) SetNotes(identifier, notes string) (*ProfileInfo, error) {
+	name, err := s.localProfileName(identifier)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.switcher.SetNotes(name, notes)
+	if err != nil {
+		return nil, err
+	}
+	return s.profileToInfo(p, s.isActiveProfile(p.Name)), nil
+}
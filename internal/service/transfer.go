@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/profile"
+	"github.com/phathdt/claude-flip/internal/storage"
+	"github.com/phathdt/claude-flip/internal/transfer"
+)
+
+// ExportAccounts seals the given accounts (all when none are named),
+// credentials included, into a passphrase-encrypted transfer archive and
+// returns it with the entries it holds
+func (s *Service) ExportAccounts(identifiers []string, passphrase string) ([]byte, []transfer.Entry, error) {
+	var profiles []*profile.Profile
+	if len(identifiers) == 0 {
+		all, err := s.switcher.ListProfiles()
+		if err != nil {
+			return nil, nil, err
+		}
+		profiles = all
+	}
+	for _, identifier := range identifiers {
+		identifier, err := s.accountName(identifier)
+		if err != nil {
+			return nil, nil, err
+		}
+		p, err := s.switcher.LoadProfile(identifier)
+		if err != nil {
+			return nil, nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	if len(profiles) == 0 {
+		return nil, nil, fmt.Errorf("no accounts to export")
+	}
+
+	archive := &transfer.Archive{CreatedAt: time.Now().UTC()}
+	archive.Source, _ = os.Hostname()
+
+	for _, p := range profiles {
+		if p.Locked {
+			return nil, nil, fmt.Errorf("%s: %w", p.Name, storage.ErrLocked)
+		}
+		if p.Credentials == nil {
+			return nil, nil, fmt.Errorf("%s has no credentials to export", p.Name)
+		}
+		exported := *p
+		exported.CredentialStore = ""
+		data, err := json.Marshal(&exported)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal %s: %w", p.Name, err)
+		}
+		archive.Entries = append(archive.Entries, transfer.Entry{
+			Name:      p.Name,
+			Email:     p.Email,
+			UpdatedAt: p.UpdatedAt,
+			Profile:   data,
+		})
+	}
+
+	sealed, err := transfer.Seal(archive, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealed, archive.Entries, nil
+}
+
+// ImportAccounts decrypts a transfer archive and merges its accounts into
+// the store according to policy. With dryRun nothing is written.
+func (s *Service) ImportAccounts(data []byte, passphrase string, policy transfer.CollisionPolicy, dryRun bool) ([]transfer.Planned, error) {
+	archive, err := transfer.Open(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := transfer.PlanImport(archive.Entries, func(entry transfer.Entry) (time.Time, bool, error) {
+		p, err := decodeEntry(entry)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		stored, err := s.switcher.FindStored(p)
+		if err != nil || stored == nil {
+			return time.Time{}, false, err
+		}
+		return stored.UpdatedAt, true, nil
+	}, policy)
+	if err != nil || dryRun {
+		return plan, err
+	}
+
+	for i, item := range plan {
+		if item.Action == transfer.ActionSkip {
+			continue
+		}
+		p, err := decodeEntry(item.Entry)
+		if err != nil {
+			return plan[:i], err
+		}
+		if _, err := s.switcher.ImportProfile(p, item.Action == transfer.ActionReplace); err != nil {
+			return plan[:i], fmt.Errorf("failed to import %s: %w", item.Entry.Name, err)
+		}
+	}
+	return plan, nil
+}
+
+// decodeEntry parses the profile carried by an archive entry
+func decodeEntry(entry transfer.Entry) (*profile.Profile, error) {
+	var p profile.Profile
+	if err := json.Unmarshal(entry.Profile, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s from archive: %w", entry.Name, err)
+	}
+	return &p, nil
+}
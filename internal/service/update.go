@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/claude-flip/internal/update"
+)
+
+// UpdateResult describes what a self-update found or did
+type UpdateResult struct {
+	Current string
+	Latest  string
+	Path    string
+	Updated bool
+}
+
+// SelfUpdate replaces the running binary with the latest release after
+// verifying its signed checksums. With checkOnly it only reports whether a
+// newer release exists; force reinstalls even when already up to date.
+func SelfUpdate(checkOnly, force bool) (*UpdateResult, error) {
+	release, err := update.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpdateResult{Current: cflipVersion, Latest: release.Tag}
+	if checkOnly || (!force && !release.Newer(cflipVersion)) {
+		return result, nil
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate the running binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	result.Path = path
+
+	binary, err := release.DownloadVerified()
+	if err != nil {
+		return nil, err
+	}
+	if err := update.Install(path, binary); err != nil {
+		return nil, fmt.Errorf("failed to install %s: %w", release.Tag, err)
+	}
+
+	result.Updated = true
+	return result, nil
+}
+
+// UpdateAvailable reports whether result's latest release is newer
+func (r *UpdateResult) UpdateAvailable() bool {
+	return (&update.Release{Tag: r.Latest}).Newer(r.Current)
+}
+
+// UpgradePlan describes how `cflip upgrade` upgrades this install
+type UpgradePlan struct {
+	Method  string
+	Package string
+	Path    string
+	// Command is the package manager command to run; nil means self-update
+	Command []string
+}
+
+// PlanUpgrade detects how cflip was installed and how to upgrade it
+func PlanUpgrade() (*UpgradePlan, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot locate the running binary: %w", err)
+	}
+
+	method := update.DetectInstall(path)
+	return &UpgradePlan{
+		Method:  method.Method,
+		Package: method.Package,
+		Path:    method.Path,
+		Command: method.UpgradeCommand(),
+	}, nil
+}
+
+// RunUpgrade runs the plan's package manager command attached to the
+// terminal. Manual installs go through SelfUpdate instead.
+func (p *UpgradePlan) RunUpgrade() error {
+	if len(p.Command) == 0 {
+		return fmt.Errorf("no package manager command for a %s install", p.Method)
+	}
+
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", strings.Join(p.Command, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/auth"
+	"github.com/phathdt/claude-flip/internal/profile"
+)
+
+// DefaultValidateWorkers is how many accounts `validate` checks at once
+const DefaultValidateWorkers = 4
+
+// Validation statuses reported by ValidationResult. The last three come
+// only from online checks: the token expired (and could not be refreshed),
+// the API refused it, or the API could not be reached or failed to answer.
+const (
+	ValidationValid        = "valid"
+	ValidationInvalid      = "invalid"
+	ValidationExpired      = "expired"
+	ValidationRevoked      = "revoked"
+	ValidationNetworkError = "network_error"
+)
+
+// ValidationResult is the outcome of validating one stored account
+type ValidationResult struct {
+	// Account is the alias, or the profile name when there is none
+	Account   string     `json:"account"`
+	Email     string     `json:"email"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at"` // access token expiry; null when unknown
+	// Refresh is set when expired or expiring tokens were refreshed first
+	Refresh *RefreshResult `json:"refresh,omitempty"`
+	// Online is set when the token was checked against the Anthropic API,
+	// which also reports the organization and subscription it belongs to
+	Online       bool   `json:"online,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Subscription string `json:"subscription,omitempty"`
+	Err          error  `json:"-"`
+}
+
+// ValidateAccounts validates every stored profile with at most workers
+// checks in flight, refreshing tokens that are expired or about to expire
+// first. onResult, when set, is called as each check finishes
+// (never concurrently) with the number done so far. Results are returned
+// in list order.
+func (s *Service) ValidateAccounts(workers int, onResult func(done, total int, result ValidationResult)) ([]ValidationResult, error) {
+	return s.validateAccounts(workers, false, onResult)
+}
+
+// ValidateAccountsOnline validates every stored profile like
+// ValidateAccounts, then confirms each valid token with an authenticated
+// request to the Anthropic API
+func (s *Service) ValidateAccountsOnline(workers int, onResult func(done, total int, result ValidationResult)) ([]ValidationResult, error) {
+	return s.validateAccounts(workers, true, onResult)
+}
+
+// ValidateAccountOnline validates one stored profile like ValidateAccount,
+// then confirms its token against the Anthropic API
+func (s *Service) ValidateAccountOnline(identifier string) (ValidationResult, error) {
+	identifier, err := s.accountName(identifier)
+	if err != nil {
+		return ValidationResult{}, err
+	}
+	p, err := s.switcher.LoadProfile(identifier)
+	if err != nil {
+		return ValidationResult{}, err
+	}
+	refresh := s.refreshOnUse(p)
+	result := validateProfile(p)
+	result.Refresh = refresh
+	if result.Err == nil {
+		checkOnline(p, &result)
+	}
+	return result, nil
+}
+
+func (s *Service) validateAccounts(workers int, online bool, onResult func(done, total int, result ValidationResult)) ([]ValidationResult, error) {
+	profiles, err := s.switcher.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]ValidationResult, len(profiles))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var reportMu, refreshMu sync.Mutex
+	done := 0
+
+	for w := 0; w < workers && w < len(profiles); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Refreshes save profiles and config.json, so take turns
+				refreshMu.Lock()
+				refresh := s.refreshOnUse(profiles[i])
+				refreshMu.Unlock()
+
+				results[i] = validateProfile(profiles[i])
+				results[i].Refresh = refresh
+				if online && results[i].Err == nil {
+					checkOnline(profiles[i], &results[i])
+				}
+				if onResult != nil {
+					reportMu.Lock()
+					done++
+					onResult(done, len(profiles), results[i])
+					reportMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range profiles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, nil
+}
+
+// validateProfile checks one loaded profile
+func validateProfile(p *profile.Profile) ValidationResult {
+	account := p.Alias
+	if account == "" {
+		account = p.Name
+	}
+	result := ValidationResult{Account: account, Email: p.Email, Status: ValidationValid}
+	if p.Credentials != nil && p.Credentials.ClaudeAiOauth.ExpiresAt != 0 {
+		expiresAt := p.Credentials.ExpiresAtTime().UTC()
+		result.ExpiresAt = &expiresAt
+	}
+	if err := profile.CheckProfile(p); err != nil {
+		result.Status = ValidationInvalid
+		result.Error = err.Error()
+		result.Err = err
+	}
+	return result
+}
+
+// checkOnline confirms a profile's access token with a lightweight
+// authenticated request, telling an expired token from a revoked one and
+// both from a failure to reach the API
+func checkOnline(p *profile.Profile, result *ValidationResult) {
+	result.Online = true
+	if p.Credentials.IsExpired() {
+		result.fail(ValidationExpired, fmt.Errorf("access token expired at %s",
+			p.Credentials.ExpiresAtTime().Local().Format("2006-01-02 15:04")))
+		return
+	}
+
+	account, err := auth.FetchProfile(p.Credentials.ClaudeAiOauth.AccessToken)
+	switch {
+	case errors.Is(err, auth.ErrTokenRejected):
+		result.fail(ValidationRevoked, fmt.Errorf("access token was revoked: %w", err))
+		return
+	case err != nil:
+		// Unreachable, timed out, or a server error: says nothing about the token
+		result.fail(ValidationNetworkError, err)
+		return
+	}
+
+	result.Organization = account.Organization.Name
+	result.Subscription = account.Subscription()
+	if account.Account.UUID != "" && p.AccountUuid != "" && account.Account.UUID != p.AccountUuid {
+		result.fail(ValidationInvalid, fmt.Errorf("access token belongs to %s (user ID %s), not the stored user ID %s",
+			account.Account.Email, account.Account.UUID, p.AccountUuid))
+	}
+}
+
+// fail records why the account did not validate
+func (r *ValidationResult) fail(status string, err error) {
+	r.Status = status
+	r.Error = err.Error()
+	r.Err = err
+}
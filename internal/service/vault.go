@@ -0,0 +1,65 @@
+package service
+
+import (
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// VaultStatus describes the passphrase protecting stored credentials
+type VaultStatus = storage.VaultStatus
+
+// VaultStatus reports whether stored credentials are passphrase-protected
+// and unlocked
+func (s *Service) VaultStatus() VaultStatus {
+	return storage.GetVaultStatus()
+}
+
+// UnlockVault opens the credential vault for timeout (0 until LockVault)
+func (s *Service) UnlockVault(passphrase string, timeout time.Duration) error {
+	if err := storage.UnlockVault(passphrase, timeout); err != nil {
+		return err
+	}
+	logger.NewDefault().VaultUnlocked(timeout)
+	return nil
+}
+
+// LockVault closes the credential vault
+func (s *Service) LockVault() error {
+	if err := storage.LockVault(); err != nil {
+		return err
+	}
+	logger.NewDefault().VaultLocked()
+	return nil
+}
+
+// ChangeVaultPassphrase sets, rotates or (with an empty newPassphrase)
+// removes the vault passphrase, re-encrypting every stored credential
+// file. It returns how many files were re-encrypted.
+func (s *Service) ChangeVaultPassphrase(oldPassphrase, newPassphrase string, timeout time.Duration) (int, error) {
+	count, err := storage.ChangeVaultPassphrase(oldPassphrase, newPassphrase, timeout)
+	if err != nil {
+		return 0, err
+	}
+	logger.NewDefault().VaultPassphraseChanged(newPassphrase != "", count)
+	return count, nil
+}
+
+// HardwareKey is an age recipient backed by a hardware security key
+type HardwareKey = storage.HardwareKey
+
+// SetVaultHardwareKey makes unlocking the vault also need a hardware key,
+// or with a nil key stops it, re-encrypting every stored credential file
+func (s *Service) SetVaultHardwareKey(passphrase string, key *HardwareKey, timeout time.Duration) (int, error) {
+	count, err := storage.SetVaultHardwareKey(passphrase, key, timeout)
+	if err != nil {
+		return 0, err
+	}
+	recipient := ""
+	if key != nil {
+		recipient = key.Recipient
+	}
+	logger.NewDefault().VaultHardwareKeyChanged(recipient, count)
+	return count, nil
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+)
+
+// encryptedPrefix starts every credential file cflip has encrypted; files
+// without it are plaintext from older versions
+const encryptedPrefix = "cflip-enc:v1:"
+
+// machineIDPaths hold the machine's stable identifier, checked in order
+var machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// fallbackKeyName is a random per-user secret in ~/.claude used instead of
+// the machine id where there is none (e.g. minimal containers)
+const fallbackKeyName = ".cflip.key"
+
+// ErrUndecryptable is returned when a credential file does not decrypt:
+// it was encrypted on another machine, the machine id changed, or it was
+// copied from another profile or modified
+var ErrUndecryptable = errors.New("cannot decrypt credentials (encrypted on another machine, or the file was replaced); log in and `cflip add` the account again")
+
+var fileKey struct {
+	sync.Mutex
+	aead cipher.AEAD
+}
+
+// encrypt seals data for the credential file of key, with the vault key
+// when a vault passphrase is set and the machine-derived key otherwise. The
+// key name is bound to the ciphertext, so files cannot be swapped between
+// profiles.
+func encrypt(key string, data []byte) ([]byte, error) {
+	prefix, keyCipher := encryptedPrefix, fileCipher
+	if VaultEnabled() {
+		prefix, keyCipher = vaultPrefix, vaultCipher
+	}
+	aead, err := keyCipher()
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, data, prefix, aead)
+}
+
+// seal encrypts data for the credential file of key with aead, marked with
+// the prefix naming that cipher
+func seal(key string, data []byte, prefix string, aead cipher.AEAD) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, data, []byte(key))
+	return []byte(prefix + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// decrypt opens a credential file's content, reporting whether it is
+// sealed the way encrypt would seal it now. Plaintext, and files sealed
+// with the key in use before a vault passphrase was set or removed, are
+// returned for the caller to re-encrypt.
+func decrypt(key string, content []byte) ([]byte, bool, error) {
+	var vault cipher.AEAD
+	if bytes.HasPrefix(content, []byte(vaultPrefix)) {
+		var err error
+		if vault, err = vaultCipher(); err != nil {
+			return nil, false, err
+		}
+	}
+	plain, err := openCredentials(key, content, vault)
+	if err != nil {
+		return nil, false, err
+	}
+	current := bytes.HasPrefix(content, []byte(encryptedPrefix))
+	if VaultEnabled() {
+		current = bytes.HasPrefix(content, []byte(vaultPrefix))
+	}
+	return plain, current, nil
+}
+
+// openCredentials opens a credential file's content with the cipher its
+// prefix names: vault for vault-sealed files, the machine-derived key for
+// the others. Plaintext is returned unchanged.
+func openCredentials(key string, content []byte, vault cipher.AEAD) ([]byte, error) {
+	var prefix string
+	aead := vault
+	switch {
+	case bytes.HasPrefix(content, []byte(vaultPrefix)):
+		prefix = vaultPrefix
+		if aead == nil {
+			return nil, ErrLocked
+		}
+	case bytes.HasPrefix(content, []byte(encryptedPrefix)):
+		prefix = encryptedPrefix
+		var err error
+		if aead, err = fileCipher(); err != nil {
+			return nil, err
+		}
+	default:
+		return content, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content[len(prefix):])))
+	if err != nil {
+		return nil, fmt.Errorf("corrupted credentials file for %s: %w", key, err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("corrupted credentials file for %s", key)
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUndecryptable, key)
+	}
+	return plain, nil
+}
+
+// fileCipher returns the AES-256-GCM cipher keyed for this machine and user
+func fileCipher() (cipher.AEAD, error) {
+	fileKey.Lock()
+	defer fileKey.Unlock()
+	if fileKey.aead != nil {
+		return fileKey.aead, nil
+	}
+
+	secret, err := machineSecret()
+	if err != nil {
+		return nil, err
+	}
+	key, err := hkdf.Key(sha256.New, secret, []byte("cflip credential files"), strconv.Itoa(os.Getuid()), 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive credentials key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if fileKey.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return fileKey.aead, nil
+}
+
+// machineSecret returns the machine id, or the per-user fallback key,
+// creating it on first use
+func machineSecret() ([]byte, error) {
+	for _, path := range machineIDPaths {
+		if id, err := os.ReadFile(path); err == nil && len(bytes.TrimSpace(id)) > 0 {
+			return bytes.TrimSpace(id), nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	path := filepath.Join(home, ".claude", fallbackKeyName)
+	if secret, err := os.ReadFile(path); err == nil && len(secret) > 0 {
+		return secret, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, secret, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write credentials key: %w", err)
+	}
+	return secret, nil
+}
+
+// EncryptCredentialFiles encrypts the credential files older versions of
+// cflip wrote in plaintext. Files that cannot be rewritten, or cannot be
+// while the vault is locked, are left for Retrieve to migrate later.
+func EncryptCredentialFiles() error {
+	paths, err := credentialFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.HasPrefix(data, []byte(encryptedPrefix)) || bytes.HasPrefix(data, []byte(vaultPrefix)) {
+			continue
+		}
+		sealed, err := encrypt(credentialFileKey(path), data)
+		if errors.Is(err, ErrLocked) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fsutil.WriteFileAtomic(path, sealed, 0o600)
+	}
+	return nil
+}
+
+// credentialFiles lists the credential files of the file backend
+func credentialFiles() ([]string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Glob(filepath.Join(home, ".claude", "."+CFlipServiceName+"_*.json"))
+}
+
+// credentialFileKey is the storage key a credential file was written for
+func credentialFileKey(path string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "."+CFlipServiceName+"_"), ".json")
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useMachineID makes the file key derive from id, or from the per-user
+// fallback key when id is empty
+func useMachineID(t *testing.T, id string) {
+	t.Helper()
+	saved := machineIDPaths
+	t.Cleanup(func() {
+		machineIDPaths = saved
+		resetFileKey()
+	})
+
+	machineIDPaths = nil
+	if id != "" {
+		path := filepath.Join(t.TempDir(), "machine-id")
+		if err := os.WriteFile(path, []byte(id+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		machineIDPaths = []string{path}
+	}
+	resetFileKey()
+}
+
+// resetFileKey forgets the derived file key
+func resetFileKey() {
+	fileKey.Lock()
+	fileKey.aead = nil
+	fileKey.Unlock()
+}
+
+func TestCredentialEncryptionRoundTrip(t *testing.T) {
+	setupVaultHome(t)
+	useMachineID(t, "0123456789abcdef")
+
+	secret := []byte(`{"claudeAiOauth":{"accessToken":"sk-ant-oat01-secret"}}`)
+	sealed, err := encrypt("work", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(sealed, []byte(encryptedPrefix)) {
+		t.Fatalf("sealed content %q lacks the %s prefix", sealed, encryptedPrefix)
+	}
+	if bytes.Contains(sealed, []byte("sk-ant")) {
+		t.Fatal("sealed content contains the token")
+	}
+
+	plain, current, err := decrypt("work", sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, secret) || !current {
+		t.Errorf("decrypted %q (current %v), want %q", plain, current, secret)
+	}
+
+	again, err := encrypt("work", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again, sealed) {
+		t.Error("encrypting twice produced identical output")
+	}
+}
+
+func TestCredentialEncryptionRejectsTampering(t *testing.T) {
+	setupVaultHome(t)
+	useMachineID(t, "0123456789abcdef")
+
+	sealed, err := encrypt("work", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files cannot be swapped between profiles
+	if _, _, err := decrypt("personal", sealed); !errors.Is(err, ErrUndecryptable) {
+		t.Errorf("opening under another key: got %v, want ErrUndecryptable", err)
+	}
+
+	raw := []byte(strings.TrimPrefix(string(sealed), encryptedPrefix))
+	flipped := append([]byte{}, raw...)
+	if flipped[20] == 'A' {
+		flipped[20] = 'B'
+	} else {
+		flipped[20] = 'A'
+	}
+	if _, _, err := decrypt("work", append([]byte(encryptedPrefix), flipped...)); !errors.Is(err, ErrUndecryptable) {
+		t.Errorf("modified ciphertext: got %v, want ErrUndecryptable", err)
+	}
+
+	for _, content := range []string{encryptedPrefix + "not base64!", encryptedPrefix + "AAAA"} {
+		if _, _, err := decrypt("work", []byte(content)); err == nil || !strings.Contains(err.Error(), "corrupted credentials file") {
+			t.Errorf("%q: got %v, want a corrupted file error", content, err)
+		}
+	}
+}
+
+func TestCredentialEncryptionIsBoundToMachine(t *testing.T) {
+	setupVaultHome(t)
+	useMachineID(t, "0123456789abcdef")
+	sealed, err := encrypt("work", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useMachineID(t, "fedcba9876543210")
+	if _, _, err := decrypt("work", sealed); !errors.Is(err, ErrUndecryptable) {
+		t.Errorf("opening on another machine: got %v, want ErrUndecryptable", err)
+	}
+}
+
+func TestCredentialEncryptionFallbackKey(t *testing.T) {
+	home := setupVaultHome(t)
+	useMachineID(t, "")
+
+	sealed, err := encrypt("work", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(home, ".claude", fallbackKeyName))
+	if err != nil {
+		t.Fatalf("fallback key was not created: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("fallback key mode %v, want 0600", info.Mode().Perm())
+	}
+
+	// A later run reads the same key back
+	resetFileKey()
+	if plain, _, err := decrypt("work", sealed); err != nil || string(plain) != "secret" {
+		t.Errorf("decrypted %q, %v after reloading the fallback key", plain, err)
+	}
+}
+
+func TestEncryptCredentialFilesMigratesPlaintext(t *testing.T) {
+	home := setupVaultHome(t)
+	useMachineID(t, "0123456789abcdef")
+
+	plainPath := filepath.Join(home, ".claude", "."+CFlipServiceName+"_old.json")
+	if err := os.WriteFile(plainPath, []byte(`{"token":"legacy"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sealedPath := writeCredentialFile(t, home, "new", []byte("already sealed"))
+	before, err := os.ReadFile(sealedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Plaintext from older versions is returned as is, marked for rewriting
+	plain, current, err := readCredentialFileCurrent(t, plainPath)
+	if err != nil || string(plain) != `{"token":"legacy"}` || current {
+		t.Fatalf("plaintext file: got %q (current %v), %v", plain, current, err)
+	}
+
+	if err := EncryptCredentialFiles(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(plainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(content, []byte(encryptedPrefix)) {
+		t.Fatalf("plaintext file was not encrypted: %q", content)
+	}
+	if plain, err := readCredentialFile(t, plainPath); err != nil || string(plain) != `{"token":"legacy"}` {
+		t.Errorf("migrated file holds %q, %v", plain, err)
+	}
+
+	after, err := os.ReadFile(sealedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("an already encrypted file was rewritten")
+	}
+}
+
+// readCredentialFileCurrent opens a credential file, reporting whether it
+// is sealed the current way
+func readCredentialFileCurrent(t *testing.T, path string) ([]byte, bool, error) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decrypt(credentialFileKey(path), content)
+}
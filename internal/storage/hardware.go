@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// HardwareKey is an age recipient backed by a hardware security key, such
+// as one from age-plugin-yubikey (PIV) or age-plugin-fido2-hmac, and the
+// identity file that decrypts for it. Wrapping the vault key with it means
+// unlocking needs the key plugged in, and touched when its policy says so.
+type HardwareKey struct {
+	Recipient string `json:"recipient"`
+	Identity  string `json:"identity"`
+}
+
+// hardwareWrap is the vault header's hardware part: a random secret
+// encrypted to the recipient, mixed into the key derived from the
+// passphrase
+type hardwareWrap struct {
+	HardwareKey
+	Secret []byte `json:"secret"`
+}
+
+// hardwareSecretSize is the length of the secret a hardware key wraps
+const hardwareSecretSize = 32
+
+// wrapHardwareSecret creates a secret and encrypts it to the key's
+// recipient. Encrypting needs no touch; only unlocking does.
+func wrapHardwareSecret(key HardwareKey) (*hardwareWrap, []byte, error) {
+	if !strings.HasPrefix(key.Recipient, "age1") {
+		return nil, nil, fmt.Errorf("%q is not an age recipient", key.Recipient)
+	}
+	if _, err := os.Stat(key.Identity); err != nil {
+		return nil, nil, fmt.Errorf("identity file of the hardware key: %w", err)
+	}
+
+	secret := make([]byte, hardwareSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := runAge(secret, "--encrypt", "--recipient", key.Recipient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap the vault key for the hardware key: %w", err)
+	}
+	return &hardwareWrap{HardwareKey: key, Secret: wrapped}, secret, nil
+}
+
+// unwrap decrypts the secret with the hardware key, which asks for a touch
+// or PIN through the age plugin
+func (w *hardwareWrap) unwrap() ([]byte, error) {
+	secret, err := runAge(w.Secret, "--decrypt", "--identity", w.Identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap the vault key with the hardware key (%s): %w", w.Recipient, err)
+	}
+	if len(secret) != hardwareSecretSize {
+		return nil, fmt.Errorf("the hardware key returned a secret of the wrong size")
+	}
+	return secret, nil
+}
+
+// mixHardwareSecret combines the passphrase-derived key with the hardware
+// secret, so the vault opens only with both
+func mixHardwareSecret(key, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(vaultFormat))
+	mac.Write(key)
+	return mac.Sum(nil)
+}
+
+// runAge runs the age CLI on input. Its plugins prompt for a touch or PIN
+// on the terminal, so stderr is passed through.
+func runAge(input []byte, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath("age")
+	if err != nil {
+		return nil, fmt.Errorf("age not found in PATH: %w", err)
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
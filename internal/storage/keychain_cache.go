@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"sync"
+)
+
+// keychainCache remembers keychain items read or written during this
+// invocation. A single switch reads the live credentials several times
+// (config load, backup, restore point); each uncached read spawns
+// `security` and may show an access prompt.
+var keychainCache = struct {
+	sync.Mutex
+	items map[string]cachedItem
+	// unlockErr records a failed unlock so later calls don't prompt again
+	unlockErr error
+}{items: make(map[string]cachedItem)}
+
+// cachedItem is a keychain lookup result; err is nil or wraps ErrNotFound
+type cachedItem struct {
+	data string
+	err  error
+}
+
+// cacheKey identifies an item within the configured keychain
+func cacheKey(service, account string) string {
+	return keychainOptions.Path + "\x00" + service + "\x00" + account
+}
+
+// cachedRetrieve returns a cached lookup result, if any
+func cachedRetrieve(service, account string) (cachedItem, bool) {
+	keychainCache.Lock()
+	defer keychainCache.Unlock()
+	item, ok := keychainCache.items[cacheKey(service, account)]
+	return item, ok
+}
+
+// cacheResult stores a lookup or write result. Only definite answers are
+// kept; transient failures are retried on the next call.
+func cacheResult(service, account, data string, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return
+	}
+	keychainCache.Lock()
+	defer keychainCache.Unlock()
+	keychainCache.items[cacheKey(service, account)] = cachedItem{data: data, err: err}
+}
+
+// ResetKeychainCache forgets everything read from the keychain. Long-running
+// commands call it before each cycle so they see changes made by Claude Code.
+func ResetKeychainCache() {
+	keychainCache.Lock()
+	defer keychainCache.Unlock()
+	keychainCache.items = make(map[string]cachedItem)
+	keychainCache.unlockErr = nil
+}
@@ -0,0 +1,79 @@
+//go:build darwin && cgo
+
+package storage
+
+/*
+#cgo LDFLAGS: -framework CoreFoundation -framework Security
+#include <stdlib.h>
+#include <CoreFoundation/CoreFoundation.h>
+#include <Security/Security.h>
+
+// cflipFindGenericPassword reads the data of a generic password item from
+// the default keychain search list. The caller releases *data.
+static OSStatus cflipFindGenericPassword(const char *service, const char *account, CFDataRef *data) {
+	CFStringRef s = CFStringCreateWithCString(NULL, service, kCFStringEncodingUTF8);
+	CFStringRef a = CFStringCreateWithCString(NULL, account, kCFStringEncodingUTF8);
+	if (s == NULL || a == NULL) {
+		if (s != NULL) CFRelease(s);
+		if (a != NULL) CFRelease(a);
+		return errSecParam;
+	}
+
+	const void *keys[] = {kSecClass, kSecAttrService, kSecAttrAccount, kSecReturnData, kSecMatchLimit};
+	const void *values[] = {kSecClassGenericPassword, s, a, kCFBooleanTrue, kSecMatchLimitOne};
+	CFDictionaryRef query = CFDictionaryCreate(NULL, keys, values, 5,
+		&kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+
+	CFTypeRef result = NULL;
+	OSStatus status = SecItemCopyMatching(query, &result);
+	CFRelease(query);
+	CFRelease(s);
+	CFRelease(a);
+
+	if (status == errSecSuccess) {
+		if (result == NULL || CFGetTypeID(result) != CFDataGetTypeID()) {
+			if (result != NULL) CFRelease(result);
+			return errSecDecode;
+		}
+		*data = (CFDataRef)result;
+	}
+	return status;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+func init() {
+	nativeKeychainRetrieve = secItemRetrieve
+}
+
+// secItemRetrieve reads a keychain item through Security.framework, in
+// this process, so integrations that read Claude Code's item on every
+// prompt do not spawn `security` each time
+func secItemRetrieve(service, account string) (string, error) {
+	cService := C.CString(service)
+	defer C.free(unsafe.Pointer(cService))
+	cAccount := C.CString(account)
+	defer C.free(unsafe.Pointer(cAccount))
+
+	var data C.CFDataRef
+	switch status := C.cflipFindGenericPassword(cService, cAccount, &data); status {
+	case C.errSecSuccess:
+		defer C.CFRelease(C.CFTypeRef(data))
+		length := C.CFDataGetLength(data)
+		if length == 0 {
+			return "", nil
+		}
+		return C.GoStringN((*C.char)(unsafe.Pointer(C.CFDataGetBytePtr(data))), C.int(length)), nil
+	case C.errSecItemNotFound:
+		return "", fmt.Errorf("%w: keychain item %q for account %s", ErrNotFound, service, account)
+	case C.errSecInteractionNotAllowed, C.errSecAuthFailed:
+		return "", fmt.Errorf("%w: Security.framework status %d", ErrKeychainLocked, int(status))
+	default:
+		return "", fmt.Errorf("failed to retrieve from keychain: Security.framework status %d", int(status))
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrKeychainLocked is returned when the macOS keychain is locked and could
+// not be unlocked automatically
+var ErrKeychainLocked = errors.New("keychain is locked")
+
+// KeychainPasswordEnv supplies the keychain password for non-interactive unlocks
+const KeychainPasswordEnv = "CFLIP_KEYCHAIN_PASSWORD"
+
+// KeychainOptions controls how cflip reaches the macOS keychain
+type KeychainOptions struct {
+	// Path selects a dedicated keychain file instead of the default search list
+	Path string
+	// NonInteractive forbids terminal prompts, for CI and other automation
+	NonInteractive bool
+}
+
+var keychainOptions KeychainOptions
+
+// SetKeychainOptions configures keychain access for subsequent operations
+func SetKeychainOptions(opts KeychainOptions) {
+	keychainOptions = opts
+}
+
+// lockedMarkers are messages `security` prints when the keychain is locked
+var lockedMarkers = []string{
+	"User interaction is not allowed",
+	"The user name or passphrase you entered is not correct",
+	"keychain is locked",
+}
+
+// runSecurity runs the macOS `security` tool. When the keychain turns out to
+// be locked it unlocks it (with CFLIP_KEYCHAIN_PASSWORD, or by prompting on
+// the terminal) and retries once.
+func runSecurity(args ...string) ([]byte, error) {
+	return runSecurityInput(nil, args...)
+}
+
+// runSecurityInput is runSecurity with input on stdin, for commands that
+// read a secret from their prompt. Such commands end in a bare -w, which
+// must stay last, so the keychain path goes before it.
+func runSecurityInput(input []byte, args ...string) ([]byte, error) {
+	if keychainOptions.Path != "" {
+		if n := len(args); n > 0 && args[n-1] == "-w" {
+			args = append(args[:n-1:n-1], keychainOptions.Path, "-w")
+		} else {
+			args = append(args, keychainOptions.Path)
+		}
+	}
+
+	output, err := execSecurity(input, args...)
+	if !errors.Is(err, ErrKeychainLocked) {
+		return output, err
+	}
+
+	// Prompt for the password at most once per invocation
+	keychainCache.Lock()
+	defer keychainCache.Unlock()
+	if keychainCache.unlockErr != nil {
+		return nil, keychainCache.unlockErr
+	}
+
+	if unlockErr := UnlockKeychain(os.Getenv(KeychainPasswordEnv)); unlockErr != nil {
+		keychainCache.unlockErr = fmt.Errorf("%w; unlock it with `security unlock-keychain` or set %s (%v)",
+			ErrKeychainLocked, KeychainPasswordEnv, unlockErr)
+		return nil, keychainCache.unlockErr
+	}
+
+	return execSecurity(input, args...)
+}
+
+// execSecurity runs `security` once, classifying locked-keychain failures
+func execSecurity(input []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("security", args...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err == nil {
+		return output, nil
+	}
+
+	message := strings.TrimSpace(stderr.String())
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 36 {
+		return nil, fmt.Errorf("%w: %s", ErrKeychainLocked, message)
+	}
+	for _, marker := range lockedMarkers {
+		if strings.Contains(message, marker) {
+			return nil, fmt.Errorf("%w: %s", ErrKeychainLocked, message)
+		}
+	}
+
+	return output, fmt.Errorf("%w (output: %s)", err, message)
+}
+
+// UnlockKeychain unlocks the configured keychain (the default one unless a
+// dedicated path is set). With a password it runs non-interactively; without
+// one it prompts on the terminal, failing in non-interactive mode or when no
+// terminal is available.
+func UnlockKeychain(password string) error {
+	var target []string
+	if keychainOptions.Path != "" {
+		target = []string{keychainOptions.Path}
+	}
+
+	if password != "" {
+		cmd := exec.Command("security", append([]string{"unlock-keychain", "-p", password}, target...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to unlock keychain: %w (output: %s)", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	if keychainOptions.NonInteractive {
+		return fmt.Errorf("non-interactive mode: no keychain password supplied")
+	}
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("no terminal available to prompt for the keychain password")
+	}
+
+	fmt.Fprintln(os.Stderr, "🔐 The keychain is locked; enter your password to unlock it.")
+	cmd := exec.Command("security", append([]string{"unlock-keychain"}, target...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to unlock keychain: %w", err)
+	}
+
+	return nil
+}
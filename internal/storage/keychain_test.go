@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSecurity puts a `security` stand-in on PATH that records its
+// arguments and stdin in dir
+func fakeSecurity(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+printf '%s\n' "$@" > "` + dir + `/args"
+cat > "` + dir + `/stdin"
+`
+	if err := os.WriteFile(filepath.Join(dir, "security"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Cleanup(ResetKeychainCache)
+	return dir
+}
+
+func TestKeychainStoreKeepsSecretOffArgv(t *testing.T) {
+	for _, path := range []string{"", "/tmp/ci.keychain"} {
+		dir := fakeSecurity(t)
+		SetKeychainOptions(KeychainOptions{Path: path})
+		t.Cleanup(func() { SetKeychainOptions(KeychainOptions{}) })
+
+		secret := "{\n  \"accessToken\": \"sk-ant-oat01-secret\"\n}"
+		if err := (&MacOSKeychain{Service: "cflip"}).Store("work", secret); err != nil {
+			t.Fatal(err)
+		}
+
+		args, _ := os.ReadFile(filepath.Join(dir, "args"))
+		if strings.Contains(string(args), "sk-ant") {
+			t.Fatalf("the secret is on the command line: %q", args)
+		}
+		lines := strings.Split(strings.TrimSpace(string(args)), "\n")
+		if lines[len(lines)-1] != "-w" {
+			t.Fatalf("-w is not the last argument: %q", lines)
+		}
+		if path != "" && lines[len(lines)-2] != path {
+			t.Fatalf("the keychain path is not before -w: %q", lines)
+		}
+
+		stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+		want := `{"accessToken":"sk-ant-oat01-secret"}`
+		if string(stdin) != want+"\n"+want+"\n" {
+			t.Fatalf("stdin = %q, want the compacted secret twice", stdin)
+		}
+	}
+}
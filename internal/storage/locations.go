@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Environment overrides for where Claude Code keeps its live credentials
+const (
+	KeychainServiceEnv = "CFLIP_KEYCHAIN_SERVICE"
+	CredentialsPathEnv = "CFLIP_CREDENTIALS_PATH"
+)
+
+// KnownKeychainServices are the keychain/keyring service names Claude Code
+// builds have used, probed in order when none is configured
+var KnownKeychainServices = []string{
+	ClaudeCodeKeychainService,
+	"Claude Code-staging-credentials",
+	"Claude Code Beta-credentials",
+}
+
+// CredentialLocations overrides where Claude Code's live credentials are
+// looked for. Empty fields are discovered.
+type CredentialLocations struct {
+	// KeychainService is the macOS keychain / Secret Service item name
+	KeychainService string
+	// CredentialsPath is the credentials file used on Linux
+	CredentialsPath string
+}
+
+var (
+	locationsMu     sync.Mutex
+	configured      CredentialLocations
+	resolvedService string
+	resolvedPath    string
+)
+
+// SetCredentialLocations configures where live credentials are looked for.
+// Environment variables still take precedence.
+func SetCredentialLocations(locations CredentialLocations) {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	configured = locations
+	resolvedService, resolvedPath = "", ""
+}
+
+// KeychainService is the keychain service name Claude Code's credentials
+// are filed under: $CFLIP_KEYCHAIN_SERVICE, then the configured name, then
+// the first of KnownKeychainServices that holds an item
+func KeychainService() string {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	if resolvedService == "" {
+		resolvedService = discoverKeychainService()
+	}
+	return resolvedService
+}
+
+// CredentialsPath is Claude Code's credentials file: $CFLIP_CREDENTIALS_PATH,
+// then the configured path, then the first existing candidate
+// ($CLAUDE_CONFIG_DIR/.credentials.json, ~/.claude/.credentials.json,
+// ~/.config/claude/.credentials.json)
+func CredentialsPath() (string, error) {
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	if resolvedPath == "" {
+		path, err := discoverCredentialsPath()
+		if err != nil {
+			return "", err
+		}
+		resolvedPath = path
+	}
+	return resolvedPath, nil
+}
+
+// discoverKeychainService implements KeychainService
+func discoverKeychainService() string {
+	if service := os.Getenv(KeychainServiceEnv); service != "" {
+		return service
+	}
+	if configured.KeychainService != "" {
+		return configured.KeychainService
+	}
+	for _, service := range KnownKeychainServices {
+		if keychainItemExists(service) {
+			return service
+		}
+	}
+	return ClaudeCodeKeychainService
+}
+
+// discoverCredentialsPath implements CredentialsPath
+func discoverCredentialsPath() (string, error) {
+	if path := os.Getenv(CredentialsPathEnv); path != "" {
+		return expandHome(path), nil
+	}
+	if configured.CredentialsPath != "" {
+		return expandHome(configured.CredentialsPath), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	var candidates []string
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		candidates = append(candidates, filepath.Join(expandHome(dir), ".credentials.json"))
+	}
+	candidates = append(candidates,
+		filepath.Join(home, ".claude", ".credentials.json"),
+		filepath.Join(home, ".config", "claude", ".credentials.json"),
+	)
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return candidates[0], nil
+}
+
+// keychainItemExists reports whether Claude Code's item exists under
+// service, without reading (and so without unlocking) the secret
+func keychainItemExists(service string) bool {
+	switch runtime.GOOS {
+	case "darwin":
+		args := []string{"find-generic-password", "-s", service, "-a", CredentialAccount()}
+		if keychainOptions.Path != "" {
+			args = append(args, keychainOptions.Path)
+		}
+		_, err := execSecurity(nil, args...)
+		return err == nil
+	case "linux":
+		output, err := runSecretTool(nil, "search", "service", service, "account", CredentialAccount())
+		return err == nil && len(strings.TrimSpace(string(output))) > 0
+	default:
+		return false
+	}
+}
+
+// ProbeLiveCredentials reports where Claude Code's live credentials are,
+// without reading (and so without unlocking) keychain secrets. The error
+// explains why they are missing or unreachable.
+func ProbeLiveCredentials() (string, error) {
+	if runtime.GOOS == "darwin" {
+		var target []string
+		if keychainOptions.Path != "" {
+			target = []string{keychainOptions.Path}
+		}
+		if _, err := execSecurity(nil, append([]string{"show-keychain-info"}, target...)...); err != nil {
+			return "", err
+		}
+	}
+
+	if runtime.GOOS == "darwin" || runtime.GOOS == "linux" && LinuxCredentialBackend() == BackendSecretService {
+		service := KeychainService()
+		if !keychainItemExists(service) {
+			return "", fmt.Errorf("no %q item found; log in to Claude Code", service)
+		}
+		return fmt.Sprintf("keychain item %q", service), nil
+	}
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	file.Close()
+	return path, nil
+}
+
+// expandHome expands a leading ~/ to the home directory
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// BackendPass keeps secrets in password-store (`pass`), one entry per item
+const BackendPass = "pass"
+
+var (
+	passPrefixMu sync.Mutex
+	// passPrefix is settings.pass_prefix; empty files entries under the
+	// backend's service name
+	passPrefix string
+)
+
+func init() {
+	RegisterBackend(Backend{
+		Name:        BackendPass,
+		Description: "password-store (pass), entries under <prefix>/<profile>",
+		Available: func() error {
+			if _, err := exec.LookPath("pass"); err != nil {
+				return fmt.Errorf("pass not found (install password-store)")
+			}
+			dir, err := passStoreDir()
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(filepath.Join(dir, ".gpg-id")); err != nil {
+				return fmt.Errorf("the password store in %s is not initialized (run `pass init <gpg-id>`)", dir)
+			}
+			return nil
+		},
+		New: func(service string) SecureStorage {
+			if service == "" {
+				service = KeychainService()
+			}
+			passPrefixMu.Lock()
+			defer passPrefixMu.Unlock()
+			prefix := passPrefix
+			if prefix == "" {
+				prefix = service
+			}
+			return &PassStorage{Prefix: prefix}
+		},
+	})
+}
+
+// SetPassPrefix configures the folder the pass backend files entries in
+// (settings.pass_prefix); empty uses the service name, e.g. "cflip"
+func SetPassPrefix(prefix string) {
+	passPrefixMu.Lock()
+	defer passPrefixMu.Unlock()
+	passPrefix = strings.Trim(prefix, "/")
+}
+
+// passStoreDir is where pass keeps its entries: $PASSWORD_STORE_DIR or
+// ~/.password-store
+func passStoreDir() (string, error) {
+	if dir := os.Getenv("PASSWORD_STORE_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".password-store"), nil
+}
+
+// PassStorage implements SecureStorage with entries in password-store,
+// named <Prefix>/<key> and encrypted by pass with the store's GPG key
+type PassStorage struct {
+	Prefix string
+}
+
+// entry names the pass entry holding key
+func (p *PassStorage) entry(key string) string {
+	return p.Prefix + "/" + key
+}
+
+// cacheService keeps pass entries apart from keychain items in the cache
+func (p *PassStorage) cacheService() string {
+	return "pass:" + p.Prefix
+}
+
+// Store saves data in the password store, replacing any existing entry
+func (p *PassStorage) Store(key, data string) error {
+	if _, err := runPass(strings.NewReader(data), "insert", "--multiline", "--force", p.entry(key)); err != nil {
+		return fmt.Errorf("failed to store in pass: %w", err)
+	}
+
+	cacheResult(p.cacheService(), key, data, nil)
+	return nil
+}
+
+// Retrieve gets data from the password store
+func (p *PassStorage) Retrieve(key string) (string, error) {
+	service := p.cacheService()
+	if item, ok := cachedRetrieve(service, key); ok {
+		return item.data, item.err
+	}
+
+	output, err := runPass(nil, "show", p.entry(key))
+	if err != nil {
+		if strings.Contains(err.Error(), "is not in the password store") {
+			err = fmt.Errorf("%w: pass entry %s", ErrNotFound, p.entry(key))
+			cacheResult(service, key, "", err)
+			return "", err
+		}
+		return "", fmt.Errorf("failed to retrieve from pass: %w", err)
+	}
+
+	data := strings.TrimSuffix(string(output), "\n")
+	cacheResult(service, key, data, nil)
+	return data, nil
+}
+
+// Delete removes data from the password store; a missing entry is not an
+// error
+func (p *PassStorage) Delete(key string) error {
+	if _, err := runPass(nil, "rm", "--force", p.entry(key)); err != nil {
+		if !strings.Contains(err.Error(), "is not in the password store") {
+			return fmt.Errorf("failed to delete from pass: %w", err)
+		}
+	}
+
+	cacheResult(p.cacheService(), key, "", fmt.Errorf("%w: pass entry %s", ErrNotFound, p.entry(key)))
+	return nil
+}
+
+// Capture reads Claude Code's live credentials from its native storage;
+// Claude Code itself never uses pass
+func (p *PassStorage) Capture() (string, error) {
+	if runtime.GOOS == "darwin" {
+		return (&MacOSKeychain{}).Capture()
+	}
+	return (&LinuxFileStorage{}).Capture()
+}
+
+// runPass runs `pass`, feeding it stdin when given
+func runPass(stdin *strings.Reader, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath("pass")
+	if err != nil {
+		return nil, fmt.Errorf("pass not found (install password-store): %w", err)
+	}
+
+	cmd := exec.Command(binary, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
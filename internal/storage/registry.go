@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BackendKeychain keeps secrets in the macOS keychain
+const BackendKeychain = "keychain"
+
+// StorageBackendEnv selects the backend stored profiles keep their
+// credentials in, overriding settings.storage_backend
+const StorageBackendEnv = "CFLIP_STORAGE_BACKEND"
+
+// Backend is a place cflip can keep secrets in
+type Backend struct {
+	Name        string
+	Description string
+	// Available returns why the backend cannot be used on this machine, or
+	// nil when it can
+	Available func() error
+	// New opens the backend with items filed under service; an empty
+	// service means Claude Code's own (see KeychainService)
+	New func(service string) SecureStorage
+}
+
+var (
+	backendsMu sync.Mutex
+	backends   = make(map[string]Backend)
+	// profileBackend is settings.storage_backend; empty for the default
+	profileBackend string
+)
+
+func init() {
+	RegisterBackend(Backend{
+		Name:        BackendKeychain,
+		Description: "macOS keychain",
+		Available: func() error {
+			if runtime.GOOS != "darwin" {
+				return fmt.Errorf("the keychain is only available on macOS")
+			}
+			return nil
+		},
+		New: func(service string) SecureStorage { return &MacOSKeychain{Service: service} },
+	})
+	RegisterBackend(Backend{
+		Name:        BackendFile,
+		Description: "files in ~/.claude encrypted with a machine-derived key, or a passphrase (cflip passwd)",
+		Available:   func() error { return nil },
+		New:         func(string) SecureStorage { return &LinuxFileStorage{} },
+	})
+	RegisterBackend(Backend{
+		Name:        BackendSecretService,
+		Description: "desktop keyring (GNOME Keyring, KWallet) through secret-tool",
+		Available: func() error {
+			if runtime.GOOS != "linux" {
+				return fmt.Errorf("the Secret Service keyring is only used on Linux")
+			}
+			if _, err := exec.LookPath("secret-tool"); err != nil {
+				return fmt.Errorf("secret-tool not found (install libsecret-tools)")
+			}
+			return nil
+		},
+		New: func(service string) SecureStorage {
+			if service == "" {
+				service = KeychainService()
+			}
+			return &SecretServiceStorage{Service: service}
+		},
+	})
+}
+
+// RegisterBackend makes a backend selectable by name, replacing any
+// backend registered under the same name
+func RegisterBackend(backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[backend.Name] = backend
+}
+
+// Backends returns the registered backends sorted by name
+func Backends() []Backend {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	list := make([]Backend, 0, len(backends))
+	for _, backend := range backends {
+		list = append(list, backend)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupBackend returns the backend registered under name
+func LookupBackend(name string) (Backend, error) {
+	backendsMu.Lock()
+	backend, ok := backends[name]
+	backendsMu.Unlock()
+	if ok {
+		return backend, nil
+	}
+
+	names := make([]string, 0)
+	for _, backend := range Backends() {
+		names = append(names, backend.Name)
+	}
+	return Backend{}, fmt.Errorf("unknown storage backend %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// OpenBackend opens a backend with items filed under service, failing when
+// it cannot be used here. Operations are retried on transient failures.
+func OpenBackend(name, service string) (SecureStorage, error) {
+	backend, err := LookupBackend(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Available(); err != nil {
+		return nil, fmt.Errorf("storage backend %s is not available: %w", name, err)
+	}
+	return &retryingStorage{inner: backend.New(service)}, nil
+}
+
+// DefaultBackend is the platform's native backend: the keychain on macOS
+// and encrypted files elsewhere
+func DefaultBackend() string {
+	if runtime.GOOS == "darwin" {
+		return BackendKeychain
+	}
+	return BackendFile
+}
+
+// SetProfileBackend configures the backend stored profiles keep their
+// credentials in (settings.storage_backend); empty selects the default
+func SetProfileBackend(name string) error {
+	if name != "" {
+		if _, err := LookupBackend(name); err != nil {
+			return fmt.Errorf("settings.storage_backend: %w", err)
+		}
+	}
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	profileBackend = name
+	return nil
+}
+
+// ProfileBackend is the backend stored profiles keep their credentials in:
+// $CFLIP_STORAGE_BACKEND, then settings.storage_backend, then the default
+func ProfileBackend() string {
+	if name := os.Getenv(StorageBackendEnv); name != "" {
+		return name
+	}
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if profileBackend != "" {
+		return profileBackend
+	}
+	return DefaultBackend()
+}
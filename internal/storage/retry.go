@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Defaults for retrying transient storage failures
+const (
+	DefaultRetryAttempts = 3
+	DefaultRetryBackoff  = 200 * time.Millisecond
+	maxRetryBackoff      = 5 * time.Second
+)
+
+// RetryPolicy controls how storage operations are retried when they fail
+// transiently, such as a busy keychain or a flaky NFS home directory
+type RetryPolicy struct {
+	// Attempts is the total number of tries, including the first
+	Attempts int
+	// Backoff is the delay before the first retry; it doubles after each one
+	Backoff time.Duration
+}
+
+var retryPolicy = RetryPolicy{Attempts: DefaultRetryAttempts, Backoff: DefaultRetryBackoff}
+
+// SetRetryPolicy configures retries for every SecureStorage operation
+func SetRetryPolicy(policy RetryPolicy) {
+	if policy.Attempts < 1 {
+		policy.Attempts = 1
+	}
+	retryPolicy = policy
+}
+
+// permanentMarkers are `security` and `secret-tool` messages that retrying
+// cannot fix
+var permanentMarkers = []string{
+	"User canceled the operation",
+	"exit status 128", // the user denied the keychain access dialog
+	"corrupted credentials file",
+}
+
+// retryingStorage retries a SecureStorage's operations with exponential
+// backoff, returning the last error only after the attempts run out
+type retryingStorage struct {
+	inner SecureStorage
+}
+
+// Store saves data, retrying transient failures
+func (r *retryingStorage) Store(key, data string) error {
+	return withRetry("store", func() error {
+		return r.inner.Store(key, data)
+	})
+}
+
+// Retrieve reads data, retrying transient failures
+func (r *retryingStorage) Retrieve(key string) (string, error) {
+	var data string
+	err := withRetry("retrieve", func() (err error) {
+		data, err = r.inner.Retrieve(key)
+		return err
+	})
+	return data, err
+}
+
+// Delete removes data, retrying transient failures
+func (r *retryingStorage) Delete(key string) error {
+	return withRetry("delete", func() error {
+		return r.inner.Delete(key)
+	})
+}
+
+// Capture reads Claude Code's credentials, retrying transient failures
+func (r *retryingStorage) Capture() (string, error) {
+	var data string
+	err := withRetry("capture", func() (err error) {
+		data, err = r.inner.Capture()
+		return err
+	})
+	return data, err
+}
+
+// withRetry runs fn until it succeeds, fails permanently, or the policy's
+// attempts are exhausted
+func withRetry(operation string, fn func() error) error {
+	policy := retryPolicy
+	delay := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransient(err) {
+			return err
+		}
+		if attempt >= policy.Attempts {
+			if attempt == 1 {
+				return err
+			}
+			return fmt.Errorf("credential %s failed after %d attempts: %w", operation, attempt, err)
+		}
+
+		time.Sleep(delay)
+		if delay *= 2; delay > maxRetryBackoff {
+			delay = maxRetryBackoff
+		}
+	}
+}
+
+// isTransient reports whether an error may go away on a retry. Missing
+// items, locked keychains or vaults, permissions and missing tools never do.
+func isTransient(err error) bool {
+	switch {
+	case errors.Is(err, ErrNotFound),
+		errors.Is(err, ErrKeychainLocked),
+		errors.Is(err, ErrLocked),
+		errors.Is(err, ErrUndecryptable),
+		errors.Is(err, os.ErrNotExist),
+		errors.Is(err, os.ErrPermission),
+		errors.Is(err, exec.ErrNotFound):
+		return false
+	}
+
+	message := err.Error()
+	for _, marker := range permanentMarkers {
+		if strings.Contains(message, marker) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"bytes"
+	"sync"
+)
+
+// SealedItem is a file outside the credential files that holds tokens
+// sealed with SealLocal, such as a restore point or a backup. Replace
+// writes it back resealed when the vault passphrase changes.
+type SealedItem struct {
+	Label   string
+	Data    []byte
+	Replace func(sealed []byte) error
+}
+
+var sealedSources struct {
+	sync.Mutex
+	list []func() ([]SealedItem, error)
+}
+
+// RegisterSealedItems adds a source of SealedItems that
+// ChangeVaultPassphrase re-encrypts along with the credential files
+func RegisterSealedItems(list func() ([]SealedItem, error)) {
+	sealedSources.Lock()
+	defer sealedSources.Unlock()
+	sealedSources.list = append(sealedSources.list, list)
+}
+
+// registeredSealedItems gathers the items of every registered source
+func registeredSealedItems() ([]SealedItem, error) {
+	sealedSources.Lock()
+	sources := append([]func() ([]SealedItem, error){}, sealedSources.list...)
+	sealedSources.Unlock()
+
+	var items []SealedItem
+	for _, list := range sources {
+		found, err := list()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+	return items, nil
+}
+
+// SealLocal encrypts data for this machine the way credential files are:
+// with the vault key when a vault passphrase is set (ErrLocked while it is
+// locked) and the machine-derived key otherwise. label is bound to the
+// ciphertext and must be passed to OpenLocal.
+func SealLocal(label string, data []byte) ([]byte, error) {
+	return encrypt(label, data)
+}
+
+// OpenLocal decrypts what SealLocal sealed. Data that is not sealed is
+// returned unchanged.
+func OpenLocal(label string, data []byte) ([]byte, error) {
+	plain, _, err := decrypt(label, data)
+	return plain, err
+}
+
+// IsSealedLocal reports whether data was sealed by SealLocal
+func IsSealedLocal(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedPrefix)) || bytes.HasPrefix(data, []byte(vaultPrefix))
+}
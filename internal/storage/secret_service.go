@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Linux credential backends Claude Code may use
+const (
+	BackendFile          = "file"
+	BackendSecretService = "secret-service"
+)
+
+// CredentialBackendEnv forces the Linux backend instead of detecting it
+const CredentialBackendEnv = "CFLIP_CREDENTIAL_BACKEND"
+
+var (
+	linuxBackend     string
+	linuxBackendOnce sync.Once
+)
+
+// LinuxCredentialBackend reports where Claude Code keeps its live
+// credentials on Linux: the credentials file (see CredentialsPath), or the
+// desktop keyring (GNOME Keyring, KWallet) through the Secret Service API.
+// The file wins when it exists; the keyring is only used when it holds an
+// item.
+func LinuxCredentialBackend() string {
+	linuxBackendOnce.Do(func() {
+		linuxBackend = detectLinuxBackend()
+	})
+	return linuxBackend
+}
+
+// detectLinuxBackend implements LinuxCredentialBackend
+func detectLinuxBackend() string {
+	switch forced := os.Getenv(CredentialBackendEnv); forced {
+	case BackendFile, BackendSecretService:
+		return forced
+	}
+
+	if path, err := CredentialsPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			return BackendFile
+		}
+	}
+
+	if _, err := SecretServiceLookup(CredentialAccount()); err == nil {
+		return BackendSecretService
+	}
+	return BackendFile
+}
+
+// CredentialAccount is the account name Claude Code files its keychain and
+// keyring items under
+func CredentialAccount() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return "default"
+}
+
+// SecretServiceLookup reads Claude Code's item from the Secret Service
+// keyring with `secret-tool`
+func SecretServiceLookup(account string) (string, error) {
+	return secretServiceLookup(KeychainService(), account)
+}
+
+// SecretServiceStore writes Claude Code's item to the Secret Service keyring
+func SecretServiceStore(account, data string) error {
+	return secretServiceStore(KeychainService(), account, data)
+}
+
+// secretServiceLookup reads the keyring item of service and account
+func secretServiceLookup(service, account string) (string, error) {
+	if item, ok := cachedRetrieve(service, account); ok {
+		return item.data, item.err
+	}
+
+	output, err := runSecretTool(nil, "lookup", "service", service, "account", account)
+	if err != nil {
+		return "", err
+	}
+
+	data := strings.TrimSuffix(string(output), "\n")
+	if data == "" {
+		err := fmt.Errorf("%w: keyring item %q for account %s", ErrNotFound, service, account)
+		cacheResult(service, account, "", err)
+		return "", err
+	}
+
+	cacheResult(service, account, data, nil)
+	return data, nil
+}
+
+// secretServiceStore writes the keyring item of service and account
+func secretServiceStore(service, account, data string) error {
+	_, err := runSecretTool(strings.NewReader(data), "store",
+		"--label="+service,
+		"service", service,
+		"account", account)
+	if err != nil {
+		return fmt.Errorf("failed to store in keyring: %w", err)
+	}
+
+	cacheResult(service, account, data, nil)
+	return nil
+}
+
+// secretServiceClear deletes the keyring item of service and account
+func secretServiceClear(service, account string) error {
+	if _, err := runSecretTool(nil, "clear", "service", service, "account", account); err != nil {
+		return fmt.Errorf("failed to delete from keyring: %w", err)
+	}
+
+	cacheResult(service, account, "", fmt.Errorf("%w: keyring item %q for account %s", ErrNotFound, service, account))
+	return nil
+}
+
+// SecretServiceStorage implements SecureStorage with items in the Secret
+// Service keyring (GNOME Keyring, KWallet), filed under Service
+type SecretServiceStorage struct {
+	Service string
+}
+
+// Store saves data in the keyring
+func (k *SecretServiceStorage) Store(key, data string) error {
+	return secretServiceStore(k.Service, key, data)
+}
+
+// Retrieve gets data from the keyring
+func (k *SecretServiceStorage) Retrieve(key string) (string, error) {
+	return secretServiceLookup(k.Service, key)
+}
+
+// Delete removes data from the keyring
+func (k *SecretServiceStorage) Delete(key string) error {
+	return secretServiceClear(k.Service, key)
+}
+
+// Capture reads Claude Code's live credentials, wherever Claude Code keeps
+// them on Linux
+func (k *SecretServiceStorage) Capture() (string, error) {
+	return (&LinuxFileStorage{}).Capture()
+}
+
+// runSecretTool runs libsecret's `secret-tool`, feeding it stdin when given
+func runSecretTool(stdin *strings.Reader, args ...string) ([]byte, error) {
+	binary, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("secret-tool not found (install libsecret-tools): %w", err)
+	}
+
+	cmd := exec.Command(binary, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err == nil {
+		return output, nil
+	}
+
+	// lookup exits 1 without output when nothing matches
+	var exitErr *exec.ExitError
+	if args[0] == "lookup" && errors.As(err, &exitErr) && stderr.Len() == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w (output: %s)", err, strings.TrimSpace(stderr.String()))
+}
@@ -1,20 +1,27 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
 )
 
-// Constants for Claude Code service names
+// Constants for Claude Code service names. ClaudeCodeKeychainService is the
+// default; see KeychainService for the name actually used.
 const (
 	ClaudeCodeKeychainService = "Claude Code-credentials"
 	CFlipServiceName          = "cflip"
 )
 
+// ErrNotFound is returned when a requested secret does not exist in storage
+var ErrNotFound = errors.New("item not found")
+
 // SecureStorage defines the interface for secure credential storage
 type SecureStorage interface {
 	Store(key, data string) error
@@ -25,77 +32,145 @@ type SecureStorage interface {
 }
 
 // MacOSKeychain implements SecureStorage using macOS Keychain Services
-type MacOSKeychain struct{}
+type MacOSKeychain struct {
+	// Service is the keychain service items are filed under; empty means
+	// Claude Code's own (see KeychainService)
+	Service string
+}
 
-// LinuxFileStorage implements SecureStorage using encrypted files
+// LinuxFileStorage implements SecureStorage using files encrypted with a
+// key derived from the machine id (see crypto.go), or from the vault
+// passphrase when one is set (see vault.go)
 type LinuxFileStorage struct{}
 
-// NewSecureStorage creates the appropriate secure storage implementation based on platform
+// NewSecureStorage creates the platform's native storage (see
+// DefaultBackend), which holds Claude Code's live credentials. Operations
+// are retried on transient failures (see SetRetryPolicy).
 func NewSecureStorage() SecureStorage {
-	switch runtime.GOOS {
-	case "darwin":
-		return &MacOSKeychain{}
-	case "linux":
-		return &LinuxFileStorage{}
-	default:
+	store, err := OpenBackend(DefaultBackend(), "")
+	if err != nil {
 		return nil
 	}
+	return store
+}
+
+// NewProfileStorage creates the storage cflip keeps stored profiles'
+// credentials in, chosen by ProfileBackend: cflip's own keychain service on
+// macOS and cflip's credential files on Linux unless configured otherwise.
+// It never touches Claude Code's live item. It returns nil when the backend
+// cannot be used on this machine.
+func NewProfileStorage() SecureStorage {
+	store, err := OpenBackend(ProfileBackend(), CFlipServiceName)
+	if err != nil {
+		return nil
+	}
+	return store
 }
 
 // MacOSKeychain implementation
 
-// Store saves data in macOS Keychain
+// nativeKeychainRetrieve reads an item through Security.framework without
+// spawning `security`. It is set in builds with cgo on macOS (see
+// keychain_darwin.go) and only used for the default keychain search list.
+var nativeKeychainRetrieve func(service, account string) (string, error)
+
+// Store saves data in macOS Keychain. The secret is written to the
+// prompt `security` shows for a trailing -w, so it never appears in the
+// process list.
 func (m *MacOSKeychain) Store(key, data string) error {
-	cmd := exec.Command("security", "add-generic-password",
+	service := m.service()
+	secret, err := singleLine(data)
+	if err != nil {
+		return fmt.Errorf("failed to store in keychain: %w", err)
+	}
+	// The prompt asks for the password, then asks to retype it
+	input := secret + "\n" + secret + "\n"
+	_, err = runSecurityInput([]byte(input), "add-generic-password",
 		"-U", // Update if exists
-		"-s", ClaudeCodeKeychainService,
+		"-s", service,
 		"-a", key,
-		"-w", data)
-
-	output, err := cmd.CombinedOutput()
+		"-w") // Read the password from the prompt; must be last
 	if err != nil {
-		return fmt.Errorf("failed to store in keychain: %w (output: %s)", err, string(output))
+		return fmt.Errorf("failed to store in keychain: %w", err)
 	}
 
+	cacheResult(service, key, data, nil)
 	return nil
 }
 
 // Retrieve gets data from macOS Keychain
 func (m *MacOSKeychain) Retrieve(key string) (string, error) {
-	cmd := exec.Command("security", "find-generic-password",
-		"-s", ClaudeCodeKeychainService,
+	service := m.service()
+	if item, ok := cachedRetrieve(service, key); ok {
+		return item.data, item.err
+	}
+
+	if nativeKeychainRetrieve != nil && keychainOptions.Path == "" {
+		data, err := nativeKeychainRetrieve(service, key)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			cacheResult(service, key, data, err)
+			return data, err
+		}
+		// A locked keychain or a denied prompt: the security CLI below
+		// unlocks and reports it the usual way
+	}
+
+	output, err := runSecurity("find-generic-password",
+		"-s", service,
 		"-a", key,
 		"-w") // Return password only
-
-	output, err := cmd.Output()
 	if err != nil {
 		if strings.Contains(err.Error(), "exit status 44") {
-			return "", fmt.Errorf("key not found in keychain: %s", key)
+			err = fmt.Errorf("%w: keychain item %q for account %s", ErrNotFound, service, key)
+			cacheResult(service, key, "", err)
+			return "", err
 		}
 		return "", fmt.Errorf("failed to retrieve from keychain: %w", err)
 	}
 
 	data := strings.TrimSuffix(string(output), "\n")
+	cacheResult(service, key, data, nil)
 	return data, nil
 }
 
 // Delete removes data from macOS Keychain
 func (m *MacOSKeychain) Delete(key string) error {
-	cmd := exec.Command("security", "delete-generic-password",
-		"-s", ClaudeCodeKeychainService,
+	service := m.service()
+	_, err := runSecurity("delete-generic-password",
+		"-s", service,
 		"-a", key)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
 		if strings.Contains(err.Error(), "exit status 44") {
 			return nil
 		}
-		return fmt.Errorf("failed to delete from keychain: %w (output: %s)", err, string(output))
+		return fmt.Errorf("failed to delete from keychain: %w", err)
 	}
 
+	cacheResult(service, key, "", fmt.Errorf("%w: keychain item %q for account %s", ErrNotFound, service, key))
 	return nil
 }
 
+// singleLine returns data as one line for the keychain's password prompt.
+// JSON is compacted; other multi-line data cannot be stored.
+func singleLine(data string) (string, error) {
+	if !strings.ContainsAny(data, "\r\n") {
+		return data, nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(data)); err != nil {
+		return "", fmt.Errorf("keychain items hold a single line of data")
+	}
+	return compact.String(), nil
+}
+
+// service resolves the keychain service this instance uses
+func (m *MacOSKeychain) service() string {
+	if m.Service != "" {
+		return m.Service
+	}
+	return KeychainService()
+}
+
 // Capture reads credentials from macOS Keychain using Claude Code's service name
 func (m *MacOSKeychain) Capture() (string, error) {
 	// Use Claude Code's keychain service name
@@ -112,7 +187,7 @@ func (m *MacOSKeychain) Capture() (string, error) {
 
 // LinuxFileStorage implementation
 
-// Store saves data in encrypted file (Linux)
+// Store saves data in an encrypted file (Linux)
 func (l *LinuxFileStorage) Store(key, data string) error {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -127,20 +202,19 @@ func (l *LinuxFileStorage) Store(key, data string) error {
 	filename := fmt.Sprintf(".%s_%s.json", CFlipServiceName, key)
 	credentialsPath := filepath.Join(credentialsDir, filename)
 
-	tempPath := credentialsPath + ".tmp"
-	if err := os.WriteFile(tempPath, []byte(data), 0o600); err != nil {
-		return fmt.Errorf("failed to write credentials file: %w", err)
+	sealed, err := encrypt(key, []byte(data))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %w", err)
 	}
-
-	if err := os.Rename(tempPath, credentialsPath); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to replace credentials file: %w", err)
+	if err := fsutil.WriteFileAtomic(credentialsPath, sealed, 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
 	}
 
 	return nil
 }
 
-// Retrieve gets data from encrypted file (Linux)
+// Retrieve gets data from an encrypted file (Linux), encrypting files
+// older versions left in plaintext
 func (l *LinuxFileStorage) Retrieve(key string) (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -153,12 +227,25 @@ func (l *LinuxFileStorage) Retrieve(key string) (string, error) {
 	data, err := os.ReadFile(credentialsPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", fmt.Errorf("key not found: %s", key)
+			return "", fmt.Errorf("%w: %s", ErrNotFound, key)
 		}
 		return "", fmt.Errorf("failed to read credentials file: %w", err)
 	}
 
-	return string(data), nil
+	plain, current, err := decrypt(key, data)
+	if err != nil {
+		return "", err
+	}
+	if !current {
+		// Written by an older version, or before the vault passphrase was
+		// set or removed; encrypt it in place. Reading must not fail
+		// because of this, so a failed rewrite is retried next time.
+		if sealed, err := encrypt(key, plain); err == nil {
+			fsutil.WriteFileAtomic(credentialsPath, sealed, 0o600)
+		}
+	}
+
+	return string(plain), nil
 }
 
 // Delete removes data from encrypted file (Linux)
@@ -182,16 +269,23 @@ func (l *LinuxFileStorage) Delete(key string) error {
 	return nil
 }
 
-// Capture reads credentials from Claude Code's standard location on Linux
+// Capture reads credentials from Claude Code's standard location on Linux,
+// or from the desktop keyring when Claude Code keeps them there
 func (l *LinuxFileStorage) Capture() (string, error) {
-	home, err := os.UserHomeDir()
+	if LinuxCredentialBackend() == BackendSecretService {
+		return SecretServiceLookup(CredentialAccount())
+	}
+
+	credentialsPath, err := CredentialsPath()
 	if err != nil {
-		return "", fmt.Errorf("failed to get user home directory: %w", err)
+		return "", err
 	}
 
-	credentialsPath := filepath.Join(home, ".claude", ".credentials.json")
 	data, err := os.ReadFile(credentialsPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, credentialsPath)
+		}
 		return "", fmt.Errorf("failed to read Claude Code credentials: %w", err)
 	}
 
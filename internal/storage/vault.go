@@ -0,0 +1,548 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/fsutil"
+	"golang.org/x/crypto/argon2"
+)
+
+// vaultPrefix starts every credential file encrypted with the vault
+// passphrase instead of the machine-derived key
+const vaultPrefix = "cflip-vault:v1:"
+
+// vaultFileName records the vault's key derivation parameters, beside the
+// credential files in ~/.claude. Its presence turns the vault on.
+const vaultFileName = ".cflip.vault"
+
+// vaultSessionName holds the key of an unlocked vault in the user's
+// runtime directory, which does not survive a reboot
+const vaultSessionName = "cflip-vault.session"
+
+// MinVaultPassphraseLength is the shortest vault passphrase accepted
+const MinVaultPassphraseLength = 8
+
+// The vault key is derived with Argon2id (RFC 9106's second recommended
+// parameter set). Headers naming pbkdf2-sha256 are still read.
+const (
+	vaultFormat   = "cflip-vault"
+	vaultKDF      = "argon2id"
+	vaultTime     = 3
+	vaultMemory   = 64 * 1024 // KiB
+	vaultThreads  = 4
+	vaultSaltSize = 16
+	vaultCheck    = "cflip vault"
+
+	legacyVaultKDF = "pbkdf2-sha256"
+)
+
+// ErrLocked is returned when credentials are protected by the vault
+// passphrase and the vault is not unlocked
+var ErrLocked = errors.New("stored credentials are locked; run `cflip unlock`")
+
+// ErrWrongVaultPassphrase is returned when a passphrase does not open the vault
+var ErrWrongVaultPassphrase = errors.New("wrong vault passphrase")
+
+// vaultFile is the on-disk vault header. Check is a known value sealed
+// with the derived key, so a wrong passphrase is told apart from a
+// damaged credential file.
+type vaultFile struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	// Iterations is Argon2id's time cost, or PBKDF2's iteration count
+	Iterations int `json:"iterations"`
+	// Memory (KiB) and Threads are Argon2id's other costs
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+	Salt    []byte `json:"salt"`
+	Check   []byte `json:"check"`
+	// Hardware is set when the key is also wrapped by a hardware key
+	Hardware *hardwareWrap `json:"hardware,omitempty"`
+}
+
+// vaultSession is an unlocked vault: the derived key and when it locks
+// again (zero for never, until `cflip lock` or a reboot)
+type vaultSession struct {
+	Key       []byte    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// VaultStatus describes the vault for `cflip unlock --status` and doctor
+type VaultStatus struct {
+	Enabled  bool `json:"enabled"`
+	Unlocked bool `json:"unlocked"`
+	// ExpiresAt is when an unlocked vault locks again; zero when it stays
+	// unlocked until `cflip lock`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// HardwareKey is the age recipient of the hardware key that unlocking
+	// also needs, if any
+	HardwareKey string `json:"hardware_key,omitempty"`
+}
+
+var vaultKey struct {
+	sync.Mutex
+	aead cipher.AEAD
+}
+
+// VaultEnabled reports whether stored credentials are protected by a
+// passphrase
+func VaultEnabled() bool {
+	path, err := vaultPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// GetVaultStatus reports whether the vault is on and unlocked
+func GetVaultStatus() VaultStatus {
+	status := VaultStatus{Enabled: VaultEnabled()}
+	if !status.Enabled {
+		return status
+	}
+	if session, err := loadVaultSession(); err == nil {
+		status.Unlocked, status.ExpiresAt = true, session.ExpiresAt
+	}
+	if header, err := readVaultFile(); err == nil && header.Hardware != nil {
+		status.HardwareKey = header.Hardware.Recipient
+	}
+	return status
+}
+
+// UnlockVault checks the passphrase and keeps the vault unlocked for
+// timeout (0 until `cflip lock` or a reboot)
+func UnlockVault(passphrase string, timeout time.Duration) error {
+	header, err := readVaultFile()
+	if err != nil {
+		return err
+	}
+	key, err := header.open(passphrase)
+	if err != nil {
+		return err
+	}
+	return saveVaultSession(key, timeout)
+}
+
+// LockVault forgets the unlocked vault key
+func LockVault() error {
+	vaultKey.Lock()
+	vaultKey.aead = nil
+	vaultKey.Unlock()
+
+	path, err := vaultSessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove the vault session: %w", err)
+	}
+	return nil
+}
+
+// ChangeVaultPassphrase re-encrypts every credential file, and the items
+// of RegisterSealedItems, with a key derived from newPassphrase, returning
+// how many credential files were rewritten.
+// oldPassphrase is the current one and is ignored when there is no vault
+// yet; an empty newPassphrase turns the vault off and goes back to the
+// machine-derived key. A hardware key wrapping the vault keeps doing so.
+// The vault is left unlocked for timeout.
+func ChangeVaultPassphrase(oldPassphrase, newPassphrase string, timeout time.Duration) (int, error) {
+	var hardware *HardwareKey
+	if VaultEnabled() && newPassphrase != "" {
+		header, err := readVaultFile()
+		if err != nil {
+			return 0, err
+		}
+		if header.Hardware != nil {
+			hardware = &header.Hardware.HardwareKey
+		}
+	}
+	return rekeyVault(oldPassphrase, newPassphrase, hardware, timeout)
+}
+
+// SetVaultHardwareKey wraps the vault key with a hardware key as well as
+// the passphrase, or with a nil key stops doing so, re-encrypting every
+// credential file like ChangeVaultPassphrase
+func SetVaultHardwareKey(passphrase string, key *HardwareKey, timeout time.Duration) (int, error) {
+	if !VaultEnabled() {
+		return 0, fmt.Errorf("a hardware key wraps the vault passphrase's key; set a passphrase with `cflip passwd` first")
+	}
+	return rekeyVault(passphrase, passphrase, key, timeout)
+}
+
+// rekeyVault re-encrypts the credential files and sealed items with a new
+// vault key derived from newPassphrase and, when set, a secret wrapped by
+// hardware
+func rekeyVault(oldPassphrase, newPassphrase string, hardware *HardwareKey, timeout time.Duration) (int, error) {
+	if newPassphrase != "" && len(newPassphrase) < MinVaultPassphraseLength {
+		return 0, fmt.Errorf("passphrase must be at least %d characters", MinVaultPassphraseLength)
+	}
+
+	var oldKey cipher.AEAD
+	if VaultEnabled() {
+		header, err := readVaultFile()
+		if err != nil {
+			return 0, err
+		}
+		key, err := header.open(oldPassphrase)
+		if err != nil {
+			return 0, err
+		}
+		if oldKey, err = newVaultAEAD(key); err != nil {
+			return 0, err
+		}
+	}
+
+	// Everything is decrypted before anything is rewritten, so a file
+	// that cannot be read stops the change with nothing touched
+	paths, err := credentialFiles()
+	if err != nil {
+		return 0, err
+	}
+	original := make(map[string][]byte, len(paths))
+	plain := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		original[path] = content
+		data, err := openCredentials(credentialFileKey(path), content, oldKey)
+		if err != nil {
+			return 0, err
+		}
+		plain[path] = data
+	}
+	items, err := registeredSealedItems()
+	if err != nil {
+		return 0, err
+	}
+	itemPlain := make([][]byte, len(items))
+	for i, item := range items {
+		if itemPlain[i], err = openCredentials(item.Label, item.Data, oldKey); err != nil {
+			return 0, err
+		}
+	}
+
+	// Everything is sealed with the new key, and every file rewritten,
+	// before the header changes; a failed rewrite puts back what was
+	// already written, so the old passphrase keeps opening everything
+	prefix, newKey := encryptedPrefix, cipher.AEAD(nil)
+	var header *vaultFile
+	var key []byte
+	if newPassphrase == "" {
+		if newKey, err = fileCipher(); err != nil {
+			return 0, err
+		}
+	} else {
+		if header, key, err = newVaultHeader(newPassphrase, hardware); err != nil {
+			return 0, err
+		}
+		if newKey, err = newVaultAEAD(key); err != nil {
+			return 0, err
+		}
+		prefix = vaultPrefix
+	}
+
+	var undo []func() error
+	rollback := func(err error) (int, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				err = errors.Join(err, undoErr)
+			}
+		}
+		return 0, err
+	}
+	for _, path := range paths {
+		sealed, err := seal(credentialFileKey(path), plain[path], prefix, newKey)
+		if err != nil {
+			return rollback(err)
+		}
+		if err := fsutil.WriteFileAtomic(path, sealed, 0o600); err != nil {
+			return rollback(fmt.Errorf("failed to write credentials file: %w", err))
+		}
+		undo = append(undo, func() error { return fsutil.WriteFileAtomic(path, original[path], 0o600) })
+	}
+	for i, item := range items {
+		sealed, err := seal(item.Label, itemPlain[i], prefix, newKey)
+		if err != nil {
+			return rollback(err)
+		}
+		if err := item.Replace(sealed); err != nil {
+			return rollback(err)
+		}
+		undo = append(undo, func() error { return item.Replace(item.Data) })
+	}
+
+	if header == nil {
+		if err := removeVault(); err != nil {
+			return rollback(err)
+		}
+		return len(paths), nil
+	}
+	if err := writeVaultFile(header); err != nil {
+		return rollback(err)
+	}
+	if err := saveVaultSession(key, timeout); err != nil {
+		return 0, err
+	}
+	return len(paths), nil
+}
+
+// vaultCipher returns the cipher of the unlocked vault, or ErrLocked
+func vaultCipher() (cipher.AEAD, error) {
+	vaultKey.Lock()
+	defer vaultKey.Unlock()
+	if vaultKey.aead != nil {
+		return vaultKey.aead, nil
+	}
+
+	session, err := loadVaultSession()
+	if err != nil {
+		return nil, err
+	}
+	if vaultKey.aead, err = newVaultAEAD(session.Key); err != nil {
+		return nil, err
+	}
+	return vaultKey.aead, nil
+}
+
+// deriveKey derives the vault key from passphrase with the header's KDF
+func (v *vaultFile) deriveKey(passphrase string) ([]byte, error) {
+	if v.KDF == legacyVaultKDF {
+		key, err := pbkdf2.Key(sha256.New, passphrase, v.Salt, v.Iterations, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive vault key: %w", err)
+		}
+		return key, nil
+	}
+	if v.Iterations < 1 || v.Memory < 8*uint32(v.Threads) || v.Threads < 1 {
+		return nil, fmt.Errorf("%s has invalid Argon2id parameters", vaultFileName)
+	}
+	return argon2.IDKey([]byte(passphrase), v.Salt, uint32(v.Iterations), v.Memory, v.Threads, 32), nil
+}
+
+// open derives the key from passphrase, and the hardware key when one
+// wraps the vault, and checks it against the header
+func (v *vaultFile) open(passphrase string) ([]byte, error) {
+	key, err := v.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if v.Hardware != nil {
+		secret, err := v.Hardware.unwrap()
+		if err != nil {
+			return nil, err
+		}
+		key = mixHardwareSecret(key, secret)
+	}
+	aead, err := newVaultAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Check) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is damaged", vaultFileName)
+	}
+	nonce, sealed := v.Check[:aead.NonceSize()], v.Check[aead.NonceSize():]
+	if _, err := aead.Open(nil, nonce, sealed, []byte(vaultFormat)); err != nil {
+		return nil, ErrWrongVaultPassphrase
+	}
+	return key, nil
+}
+
+// newVaultHeader builds a vault header for passphrase with a fresh salt,
+// and a fresh secret wrapped by hardware when set, and returns it with the
+// vault key
+func newVaultHeader(passphrase string, hardware *HardwareKey) (*vaultFile, []byte, error) {
+	header := &vaultFile{
+		Format:     vaultFormat,
+		Version:    1,
+		KDF:        vaultKDF,
+		Iterations: vaultTime,
+		Memory:     vaultMemory,
+		Threads:    vaultThreads,
+		Salt:       make([]byte, vaultSaltSize),
+	}
+	if _, err := rand.Read(header.Salt); err != nil {
+		return nil, nil, err
+	}
+	key, err := header.deriveKey(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hardware != nil {
+		wrap, secret, err := wrapHardwareSecret(*hardware)
+		if err != nil {
+			return nil, nil, err
+		}
+		header.Hardware = wrap
+		key = mixHardwareSecret(key, secret)
+	}
+	aead, err := newVaultAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	header.Check = aead.Seal(nonce, nonce, []byte(vaultCheck), []byte(vaultFormat))
+	return header, key, nil
+}
+
+// writeVaultFile writes the vault header, turning the vault on
+func writeVaultFile(header *vaultFile) error {
+	path, err := vaultPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
+
+// removeVault turns the vault off
+func removeVault() error {
+	if err := LockVault(); err != nil {
+		return err
+	}
+	path, err := vaultPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove vault: %w", err)
+	}
+	return nil
+}
+
+// readVaultFile reads the vault header
+func readVaultFile() (*vaultFile, error) {
+	path, err := vaultPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no vault passphrase is set; set one with `cflip passwd`")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	var header vaultFile
+	if err := json.Unmarshal(data, &header); err != nil || header.Format != vaultFormat {
+		return nil, fmt.Errorf("%s is not a cflip vault", path)
+	}
+	if header.Version != 1 || header.KDF != vaultKDF && header.KDF != legacyVaultKDF {
+		return nil, fmt.Errorf("%s was written by a newer cflip; upgrade cflip", path)
+	}
+	return &header, nil
+}
+
+// saveVaultSession keeps key as the unlocked vault's for timeout
+func saveVaultSession(key []byte, timeout time.Duration) error {
+	session := vaultSession{Key: key}
+	if timeout > 0 {
+		session.ExpiresAt = time.Now().Add(timeout)
+	}
+	path, err := vaultSessionPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault session: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := fsutil.WriteFileAtomic(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write vault session: %w", err)
+	}
+
+	vaultKey.Lock()
+	defer vaultKey.Unlock()
+	vaultKey.aead, err = newVaultAEAD(key)
+	return err
+}
+
+// loadVaultSession reads the unlocked vault's key, removing it once it
+// has expired
+func loadVaultSession() (*vaultSession, error) {
+	path, err := vaultSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrLocked
+	}
+	var session vaultSession
+	if err := json.Unmarshal(data, &session); err != nil || len(session.Key) != 32 {
+		return nil, ErrLocked
+	}
+	if !session.ExpiresAt.IsZero() && time.Now().After(session.ExpiresAt) {
+		os.Remove(path)
+		return nil, ErrLocked
+	}
+	return &session, nil
+}
+
+// vaultPath is the vault header beside the credential files
+func vaultPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".claude", vaultFileName), nil
+}
+
+// vaultSessionPath is in $XDG_RUNTIME_DIR, or a private directory in the
+// temporary directory where there is none (macOS). A directory another
+// user created there first, or opened up, is refused.
+func vaultSessionPath() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, vaultSessionName), nil
+	}
+	dir := filepath.Join(os.TempDir(), "cflip-"+strconv.Itoa(os.Getuid()))
+	if info, err := os.Lstat(dir); err == nil {
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !info.IsDir() || info.Mode().Perm() != 0o700 || !ok || int(stat.Uid) != os.Getuid() {
+			return "", fmt.Errorf("%s is not a private directory owned by you; remove it and unlock again", dir)
+		}
+	}
+	return filepath.Join(dir, vaultSessionName), nil
+}
+
+// newVaultAEAD returns the AES-256-GCM cipher for a vault key
+func newVaultAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,433 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// setupVaultHome points the home and runtime directories at temporary ones
+// and forgets any unlocked key
+func setupVaultHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := os.MkdirAll(filepath.Join(home, ".claude"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { LockVault() })
+	LockVault()
+	return home
+}
+
+// writeCredentialFile stores data the way the file backend does
+func writeCredentialFile(t *testing.T, home, key string, data []byte) string {
+	t.Helper()
+	sealed, err := encrypt(key, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".claude", "."+CFlipServiceName+"_"+key+".json")
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readCredentialFile opens a credential file written by writeCredentialFile
+func readCredentialFile(t *testing.T, path string) ([]byte, error) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _, err := decrypt(credentialFileKey(path), content)
+	return plain, err
+}
+
+func TestVaultPassphraseLifecycle(t *testing.T) {
+	home := setupVaultHome(t)
+	secret := []byte(`{"claudeAiOauth":{"accessToken":"sk-ant-oat01-test"}}`)
+	path := writeCredentialFile(t, home, "work", secret)
+
+	content, _ := os.ReadFile(path)
+	if !bytes.HasPrefix(content, []byte(encryptedPrefix)) {
+		t.Fatalf("credential file is not sealed with the machine key: %q", content)
+	}
+
+	count, err := ChangeVaultPassphrase("", "first passphrase", 0)
+	if err != nil {
+		t.Fatalf("setting the passphrase: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("re-encrypted %d files, want 1", count)
+	}
+	content, _ = os.ReadFile(path)
+	if !bytes.HasPrefix(content, []byte(vaultPrefix)) {
+		t.Fatalf("credential file is not sealed with the vault key: %q", content)
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("reading while unlocked = %q, %v", plain, err)
+	}
+
+	if err := LockVault(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCredentialFile(t, path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("reading while locked: got %v, want ErrLocked", err)
+	}
+	if err := UnlockVault("wrong passphrase", 0); !errors.Is(err, ErrWrongVaultPassphrase) {
+		t.Fatalf("unlocking with a wrong passphrase: got %v", err)
+	}
+	if err := UnlockVault("first passphrase", 0); err != nil {
+		t.Fatalf("unlocking: %v", err)
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("reading after unlock = %q, %v", plain, err)
+	}
+
+	if _, err := ChangeVaultPassphrase("wrong passphrase", "second passphrase", 0); !errors.Is(err, ErrWrongVaultPassphrase) {
+		t.Fatalf("changing with a wrong passphrase: got %v", err)
+	}
+	if _, err := ChangeVaultPassphrase("first passphrase", "second passphrase", 0); err != nil {
+		t.Fatalf("changing the passphrase: %v", err)
+	}
+	LockVault()
+	if err := UnlockVault("first passphrase", 0); !errors.Is(err, ErrWrongVaultPassphrase) {
+		t.Fatalf("the old passphrase still unlocks: %v", err)
+	}
+	if err := UnlockVault("second passphrase", 0); err != nil {
+		t.Fatalf("unlocking with the new passphrase: %v", err)
+	}
+
+	if _, err := ChangeVaultPassphrase("second passphrase", "", 0); err != nil {
+		t.Fatalf("removing the passphrase: %v", err)
+	}
+	if VaultEnabled() {
+		t.Fatal("vault is still enabled after removing the passphrase")
+	}
+	content, _ = os.ReadFile(path)
+	if !bytes.HasPrefix(content, []byte(encryptedPrefix)) {
+		t.Fatalf("credential file is not back on the machine key: %q", content)
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("reading after removal = %q, %v", plain, err)
+	}
+}
+
+func TestVaultRejectsShortPassphrase(t *testing.T) {
+	setupVaultHome(t)
+	if _, err := ChangeVaultPassphrase("", "short", 0); err == nil {
+		t.Fatal("a passphrase shorter than the minimum was accepted")
+	}
+	if VaultEnabled() {
+		t.Fatal("vault was enabled by a rejected passphrase")
+	}
+}
+
+func TestVaultTamperedFile(t *testing.T) {
+	home := setupVaultHome(t)
+	if _, err := ChangeVaultPassphrase("", "tamper passphrase", 0); err != nil {
+		t.Fatal(err)
+	}
+	path := writeCredentialFile(t, home, "work", []byte(`{"token":"a"}`))
+
+	content, _ := os.ReadFile(path)
+	tampered := append([]byte{}, content...)
+	// Flip a character of the base64 ciphertext, past the nonce
+	i := len(tampered) - 4
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	if _, _, err := decrypt("work", tampered); !errors.Is(err, ErrUndecryptable) {
+		t.Fatalf("tampered file: got %v, want ErrUndecryptable", err)
+	}
+
+	// A file copied over another profile's does not open either
+	if _, _, err := decrypt("personal", content); !errors.Is(err, ErrUndecryptable) {
+		t.Fatalf("swapped file: got %v, want ErrUndecryptable", err)
+	}
+}
+
+func TestVaultReencryptsSealedItems(t *testing.T) {
+	setupVaultHome(t)
+	secret := []byte("restore point credentials")
+	sealed, err := SealLocal("test-item", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := []byte("plaintext from an older version")
+
+	stored := map[string][]byte{"sealed": sealed, "legacy": legacy}
+	RegisterSealedItems(func() ([]SealedItem, error) {
+		var items []SealedItem
+		for name, data := range stored {
+			items = append(items, SealedItem{
+				Label:   "test-item",
+				Data:    data,
+				Replace: func(resealed []byte) error { stored[name] = resealed; return nil },
+			})
+		}
+		return items, nil
+	})
+	t.Cleanup(func() {
+		sealedSources.Lock()
+		sealedSources.list = sealedSources.list[:len(sealedSources.list)-1]
+		sealedSources.Unlock()
+	})
+
+	if _, err := ChangeVaultPassphrase("", "items passphrase", 0); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string][]byte{"sealed": secret, "legacy": legacy} {
+		if !bytes.HasPrefix(stored[name], []byte(vaultPrefix)) {
+			t.Errorf("%s item is not sealed with the vault key: %q", name, stored[name])
+		}
+		if plain, err := OpenLocal("test-item", stored[name]); err != nil || !bytes.Equal(plain, want) {
+			t.Errorf("%s item = %q, %v", name, plain, err)
+		}
+	}
+
+	LockVault()
+	if _, err := OpenLocal("test-item", stored["sealed"]); !errors.Is(err, ErrLocked) {
+		t.Fatalf("opening an item while locked: got %v, want ErrLocked", err)
+	}
+}
+
+func TestVaultRekeyFailureKeepsOldKey(t *testing.T) {
+	home := setupVaultHome(t)
+	secret := []byte(`{"token":"rekey"}`)
+	path := writeCredentialFile(t, home, "work", secret)
+	if _, err := ChangeVaultPassphrase("", "first passphrase", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	itemSecret := []byte("restore point credentials")
+	sealed, err := SealLocal("test-item", itemSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second item fails to be written back, after the credential file
+	// and the first item were
+	stored := [][]byte{sealed, sealed}
+	failing := true
+	RegisterSealedItems(func() ([]SealedItem, error) {
+		var items []SealedItem
+		for i := range stored {
+			items = append(items, SealedItem{
+				Label: "test-item",
+				Data:  stored[i],
+				Replace: func(resealed []byte) error {
+					if i == 1 && failing {
+						return errors.New("disk full")
+					}
+					stored[i] = resealed
+					return nil
+				},
+			})
+		}
+		return items, nil
+	})
+	t.Cleanup(func() {
+		sealedSources.Lock()
+		sealedSources.list = sealedSources.list[:len(sealedSources.list)-1]
+		sealedSources.Unlock()
+	})
+
+	if _, err := ChangeVaultPassphrase("first passphrase", "second passphrase", 0); err == nil {
+		t.Fatal("a failed rewrite was reported as success")
+	}
+
+	LockVault()
+	if err := UnlockVault("second passphrase", 0); !errors.Is(err, ErrWrongVaultPassphrase) {
+		t.Fatalf("the new passphrase opens the vault after a failed change: %v", err)
+	}
+	if err := UnlockVault("first passphrase", 0); err != nil {
+		t.Fatalf("the old passphrase no longer opens the vault: %v", err)
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("credential file after a failed change = %q, %v", plain, err)
+	}
+	for i, data := range stored {
+		if plain, err := OpenLocal("test-item", data); err != nil || !bytes.Equal(plain, itemSecret) {
+			t.Fatalf("item %d after a failed change = %q, %v", i, plain, err)
+		}
+	}
+
+	// Removing the passphrase fails the same way and keeps the vault on
+	if _, err := ChangeVaultPassphrase("first passphrase", "", 0); err == nil {
+		t.Fatal("a failed rewrite was reported as success")
+	}
+	if !VaultEnabled() {
+		t.Fatal("the vault was turned off by a failed change")
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("credential file after a failed removal = %q, %v", plain, err)
+	}
+
+	failing = false
+	if _, err := ChangeVaultPassphrase("first passphrase", "second passphrase", 0); err != nil {
+		t.Fatalf("changing the passphrase once writes succeed: %v", err)
+	}
+	for i, data := range stored {
+		if plain, err := OpenLocal("test-item", data); err != nil || !bytes.Equal(plain, itemSecret) {
+			t.Fatalf("item %d after the change = %q, %v", i, plain, err)
+		}
+	}
+}
+
+func TestVaultLegacyPBKDF2Header(t *testing.T) {
+	header := &vaultFile{Format: vaultFormat, Version: 1, KDF: legacyVaultKDF, Iterations: 1000, Salt: make([]byte, vaultSaltSize)}
+	rand.Read(header.Salt)
+	key, err := pbkdf2.Key(sha256.New, "legacy passphrase", header.Salt, header.Iterations, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := newVaultAEAD(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	header.Check = aead.Seal(nonce, nonce, []byte(vaultCheck), []byte(vaultFormat))
+
+	opened, err := header.open("legacy passphrase")
+	if err != nil || !bytes.Equal(opened, key) {
+		t.Fatalf("opening a PBKDF2 header: %v", err)
+	}
+	if _, err := header.open("other passphrase"); !errors.Is(err, ErrWrongVaultPassphrase) {
+		t.Fatalf("wrong passphrase on a PBKDF2 header: got %v", err)
+	}
+}
+
+func TestVaultSessionPathRejectsOpenDirectory(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	dir := filepath.Join(tmp, "cflip-"+strconv.Itoa(os.Getuid()))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vaultSessionPath(); err == nil {
+		t.Fatal("a directory others can read was accepted")
+	}
+
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := vaultSessionPath(); err != nil || filepath.Dir(path) != dir {
+		t.Fatalf("private directory: got %q, %v", path, err)
+	}
+}
+
+// fakeAge puts an age stand-in on PATH that "encrypts" to a recipient by
+// tagging the input, and decrypts only with an identity file naming that
+// recipient, the way a hardware key's plugin only decrypts with the key
+func fakeAge(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+--encrypt) printf 'FAKEAGE:%s:' "$3"; base64 ;;
+--decrypt)
+	recipient=$(cat "$3") || exit 1
+	input=$(cat)
+	case "$input" in "FAKEAGE:$recipient:"*) ;; *) echo "no identity matched" >&2; exit 1 ;; esac
+	printf '%s' "${input#FAKEAGE:$recipient:}" | base64 -d ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "age"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestVaultHardwareKey(t *testing.T) {
+	home := setupVaultHome(t)
+	fakeAge(t)
+	secret := []byte(`{"token":"hardware"}`)
+	path := writeCredentialFile(t, home, "work", secret)
+
+	identity := filepath.Join(t.TempDir(), "yubikey.txt")
+	if err := os.WriteFile(identity, []byte("age1yubikeytest"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key := &HardwareKey{Recipient: "age1yubikeytest", Identity: identity}
+
+	if _, err := SetVaultHardwareKey("hardware passphrase", key, 0); err == nil {
+		t.Fatal("a hardware key was added without a vault passphrase")
+	}
+	if _, err := ChangeVaultPassphrase("", "hardware passphrase", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetVaultHardwareKey("hardware passphrase", key, 0); err != nil {
+		t.Fatalf("adding the hardware key: %v", err)
+	}
+	if status := GetVaultStatus(); status.HardwareKey != key.Recipient {
+		t.Fatalf("status names hardware key %q", status.HardwareKey)
+	}
+
+	LockVault()
+	if err := UnlockVault("hardware passphrase", 0); err != nil {
+		t.Fatalf("unlocking with the hardware key: %v", err)
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("reading after unlock = %q, %v", plain, err)
+	}
+
+	// The passphrase alone no longer opens the vault
+	header, err := readVaultFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphraseOnly := *header
+	passphraseOnly.Hardware = nil
+	if _, err := passphraseOnly.open("hardware passphrase"); !errors.Is(err, ErrWrongVaultPassphrase) {
+		t.Fatalf("opening without the hardware key: got %v", err)
+	}
+
+	// Nor does the passphrase without the key's identity
+	LockVault()
+	if err := os.WriteFile(identity, []byte("age1yubikeyother"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnlockVault("hardware passphrase", 0); err == nil {
+		t.Fatal("unlocked without the hardware key's identity")
+	}
+	if err := os.WriteFile(identity, []byte("age1yubikeytest"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the passphrase keeps the hardware key
+	if _, err := ChangeVaultPassphrase("hardware passphrase", "second passphrase", 0); err != nil {
+		t.Fatal(err)
+	}
+	if status := GetVaultStatus(); status.HardwareKey != key.Recipient {
+		t.Fatal("changing the passphrase dropped the hardware key")
+	}
+
+	if _, err := SetVaultHardwareKey("second passphrase", nil, 0); err != nil {
+		t.Fatalf("removing the hardware key: %v", err)
+	}
+	t.Setenv("PATH", "")
+	LockVault()
+	if err := UnlockVault("second passphrase", 0); err != nil {
+		t.Fatalf("unlocking after removing the hardware key: %v", err)
+	}
+	if plain, err := readCredentialFile(t, path); err != nil || !bytes.Equal(plain, secret) {
+		t.Fatalf("reading after removal = %q, %v", plain, err)
+	}
+}
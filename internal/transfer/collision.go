@@ -0,0 +1,76 @@
+package transfer
+
+import (
+	"fmt"
+	"time"
+)
+
+// CollisionPolicy decides what happens when an imported account is already stored
+type CollisionPolicy string
+
+// Collision policies accepted by PlanImport
+const (
+	// KeepExisting leaves the stored profile alone
+	KeepExisting CollisionPolicy = "skip"
+	// Overwrite replaces the stored profile with the imported one
+	Overwrite CollisionPolicy = "overwrite"
+	// KeepNewer keeps whichever copy was updated last
+	KeepNewer CollisionPolicy = "newer"
+)
+
+// ParseCollisionPolicy validates a policy name
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	switch policy := CollisionPolicy(name); policy {
+	case KeepExisting, Overwrite, KeepNewer:
+		return policy, nil
+	case "":
+		return KeepExisting, nil
+	default:
+		return "", fmt.Errorf("unknown collision policy %q (use skip, overwrite or newer)", name)
+	}
+}
+
+// Import actions
+const (
+	ActionAdd     = "add"
+	ActionReplace = "replace"
+	ActionSkip    = "skip"
+)
+
+// Planned is what importing one entry will do
+type Planned struct {
+	Entry  Entry
+	Action string
+	Reason string
+}
+
+// Stored looks up the stored copy of an entry's account, reporting when
+// it was last updated
+type Stored func(Entry) (updatedAt time.Time, found bool, err error)
+
+// PlanImport decides, for every entry, whether it is added, replaces the
+// stored copy of the same account, or is skipped
+func PlanImport(entries []Entry, stored Stored, policy CollisionPolicy) ([]Planned, error) {
+	plan := make([]Planned, 0, len(entries))
+	for _, entry := range entries {
+		updatedAt, found, err := stored(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		item := Planned{Entry: entry, Action: ActionAdd}
+		switch {
+		case !found:
+		case policy == Overwrite:
+			item.Action, item.Reason = ActionReplace, "already stored; overwriting"
+		case policy == KeepNewer && entry.UpdatedAt.After(updatedAt):
+			item.Action, item.Reason = ActionReplace, "imported copy is newer"
+		case policy == KeepNewer:
+			item.Action, item.Reason = ActionSkip, "stored copy is as new or newer"
+		default:
+			item.Action, item.Reason = ActionSkip, "already stored"
+		}
+		plan = append(plan, item)
+	}
+	return plan, nil
+}
@@ -0,0 +1,103 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QRPrefix starts every part of an archive split for QR codes
+const QRPrefix = "CFLIPQR:"
+
+// qrPartSize is the archive bytes per part. With the "CFLIPQR:i/n:" header
+// a part fits in one code of internal/qr for archives of up to maxQRParts
+// parts.
+const qrPartSize = 250
+
+// maxQRParts is the most parts an archive is split into. Add rejects
+// larger counts instead of allocating for them.
+const maxQRParts = 9999
+
+// SplitQR splits an archive into parts of the form "CFLIPQR:i/n:<data>",
+// one per QR code. The archive is still encrypted; the parts only carry it
+// to a machine without a network connection.
+func SplitQR(data []byte) ([]string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("not a %s file", Format)
+	}
+	text := compact.String()
+
+	total := (len(text) + qrPartSize - 1) / qrPartSize
+	if total > maxQRParts {
+		return nil, fmt.Errorf("archive is too large for QR codes (%d codes, at most %d); export fewer accounts", total, maxQRParts)
+	}
+	parts := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := min(len(text), (i+1)*qrPartSize)
+		parts = append(parts, fmt.Sprintf("%s%d/%d:%s", QRPrefix, i+1, total, text[i*qrPartSize:end]))
+	}
+	return parts, nil
+}
+
+// QRJoiner reassembles an archive from the parts of SplitQR, scanned in any
+// order
+type QRJoiner struct {
+	parts    []string
+	received int
+}
+
+// Add takes one scanned part. Parts already received are ignored.
+func (j *QRJoiner) Add(part string) error {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(part), QRPrefix)
+	if !ok {
+		return fmt.Errorf("not a cflip QR code")
+	}
+	position, data, ok := strings.Cut(rest, ":")
+	index, count, ok2 := strings.Cut(position, "/")
+	i, err := strconv.Atoi(index)
+	n, err2 := strconv.Atoi(count)
+	if !ok || !ok2 || err != nil || err2 != nil || n < 1 || n > maxQRParts || i < 1 || i > n || data == "" {
+		return fmt.Errorf("malformed cflip QR code")
+	}
+
+	if j.parts == nil {
+		j.parts = make([]string, n)
+	} else if n != len(j.parts) {
+		return fmt.Errorf("code %d/%d belongs to another export (expected %d codes)", i, n, len(j.parts))
+	}
+	if j.parts[i-1] == "" {
+		j.parts[i-1] = data
+		j.received++
+	}
+	return nil
+}
+
+// Progress returns how many of the codes have been received
+func (j *QRJoiner) Progress() (received, total int) {
+	return j.received, len(j.parts)
+}
+
+// Done reports whether every part has been received
+func (j *QRJoiner) Done() bool {
+	return j.parts != nil && j.received == len(j.parts)
+}
+
+// Data returns the reassembled archive
+func (j *QRJoiner) Data() ([]byte, error) {
+	if !j.Done() {
+		var missing []string
+		for i, part := range j.parts {
+			if part == "" {
+				missing = append(missing, strconv.Itoa(i+1))
+			}
+		}
+		if len(missing) == 0 {
+			return nil, fmt.Errorf("no cflip QR codes were scanned")
+		}
+		return nil, fmt.Errorf("missing codes %s of %d", strings.Join(missing, ", "), len(j.parts))
+	}
+	return []byte(strings.Join(j.parts, "")), nil
+}
@@ -0,0 +1,90 @@
+package transfer
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phathdt/claude-flip/internal/qr"
+)
+
+func TestQRRoundTrip(t *testing.T) {
+	archive := &Archive{
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Entries: []Entry{{
+			Name:    "work",
+			Email:   "work@example.com",
+			Profile: []byte(`{"name":"work","email":"work@example.com","token":"` + strings.Repeat("x", 900) + `"}`),
+		}},
+	}
+	data, err := Seal(archive, "qr passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, err := SplitQR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("%d parts, want the archive split over several codes", len(parts))
+	}
+	for _, part := range parts {
+		if _, err := qr.Encode([]byte(part)); err != nil {
+			t.Fatalf("part does not fit in a code: %v", err)
+		}
+	}
+
+	var joiner QRJoiner
+	rand.Shuffle(len(parts), func(i, j int) { parts[i], parts[j] = parts[j], parts[i] })
+	for i, part := range parts {
+		if joiner.Done() {
+			t.Fatalf("done after %d of %d parts", i, len(parts))
+		}
+		if err := joiner.Add(part); err != nil {
+			t.Fatal(err)
+		}
+		// Scanning a code twice is harmless
+		if err := joiner.Add(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	joined, err := joiner.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := Open(joined, "qr passphrase")
+	if err != nil {
+		t.Fatalf("opening the joined archive: %v", err)
+	}
+	if len(opened.Entries) != 1 || string(opened.Entries[0].Profile) != string(archive.Entries[0].Profile) {
+		t.Fatalf("joined archive holds %+v", opened.Entries)
+	}
+}
+
+func TestQRJoinerRejectsBadParts(t *testing.T) {
+	var joiner QRJoiner
+	for _, part := range []string{"hello", "CFLIPQR:1:abc", "CFLIPQR:0/2:abc", "CFLIPQR:3/2:abc", "CFLIPQR:1/2:"} {
+		if err := joiner.Add(part); err == nil {
+			t.Errorf("%q was accepted", part)
+		}
+	}
+	// A count no export produces is refused before anything is allocated
+	for _, part := range []string{"CFLIPQR:1/10000:abc", "CFLIPQR:1/999999999:abc"} {
+		if err := joiner.Add(part); err == nil || err.Error() != "malformed cflip QR code" {
+			t.Errorf("%q: got %v", part, err)
+		}
+	}
+
+	if err := joiner.Add("CFLIPQR:1/3:abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := joiner.Add("CFLIPQR:1/2:abc"); err == nil {
+		t.Error("a part of another export was accepted")
+	}
+	if _, err := joiner.Data(); err == nil || !strings.Contains(err.Error(), "missing codes 2, 3 of 3") {
+		t.Errorf("incomplete archive: got %v", err)
+	}
+}
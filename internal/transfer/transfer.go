@@ -0,0 +1,173 @@
+// Package transfer packs stored profiles into a passphrase-encrypted archive
+// for moving accounts between machines, and plans how an archive's profiles
+// are merged into an existing store.
+package transfer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Format identifies a cflip transfer archive
+const Format = "cflip-transfer"
+
+// Version is the archive version this build writes. Older versions are
+// still read.
+const Version = 1
+
+// PassphraseEnv supplies the archive passphrase for non-interactive use
+const PassphraseEnv = "CFLIP_TRANSFER_PASSPHRASE"
+
+// MinPassphraseLength is the shortest passphrase Seal accepts
+const MinPassphraseLength = 8
+
+const (
+	kdfName       = "pbkdf2-sha256"
+	kdfIterations = 600_000
+	saltSize      = 16
+	keySize       = 32
+)
+
+// ErrWrongPassphrase is returned when an archive cannot be decrypted
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted archive")
+
+// Archive is the decrypted content of a transfer file
+type Archive struct {
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source,omitempty"` // host the archive was made on
+	Entries   []Entry   `json:"entries"`
+}
+
+// Entry is one exported profile
+type Entry struct {
+	Name      string          `json:"name"`
+	Email     string          `json:"email"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Profile   json.RawMessage `json:"profile"` // the full profile, credentials included
+}
+
+// envelope is the on-disk form: everything but the KDF parameters and
+// nonce is encrypted
+type envelope struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	KDF        kdfParams `json:"kdf"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// kdfParams records how the key was derived from the passphrase
+type kdfParams struct {
+	Name       string `json:"name"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+}
+
+// Seal encrypts an archive with a key derived from passphrase
+func Seal(archive *Archive, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal archive: %w", err)
+	}
+	return SealData(Format, plaintext, passphrase)
+}
+
+// Open decrypts a transfer archive
+func Open(data []byte, passphrase string) (*Archive, error) {
+	plaintext, err := OpenData(Format, data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	var archive Archive
+	if err := json.Unmarshal(plaintext, &archive); err != nil {
+		return nil, fmt.Errorf("failed to parse archive: %w", err)
+	}
+	return &archive, nil
+}
+
+// SealData encrypts arbitrary data in the passphrase envelope, labelled
+// with format so files of one kind are not opened as another
+func SealData(format string, plaintext []byte, passphrase string) ([]byte, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+
+	params := kdfParams{Name: kdfName, Iterations: kdfIterations, Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+
+	env := envelope{Format: format, Version: Version, KDF: params, Nonce: make([]byte, aead.NonceSize())}
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, additionalData(env))
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// OpenData decrypts data sealed by SealData with the same format
+func OpenData(format string, data []byte, passphrase string) ([]byte, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Format != format {
+		return nil, fmt.Errorf("not a %s file", format)
+	}
+	if env.Version < 1 || env.Version > Version {
+		return nil, fmt.Errorf("%s version %d is not supported by this cflip (up to %d); upgrade cflip", format, env.Version, Version)
+	}
+	if env.KDF.Name != kdfName {
+		return nil, fmt.Errorf("unsupported key derivation %q", env.KDF.Name)
+	}
+
+	aead, err := newAEAD(passphrase, env.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, additionalData(env))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// IsSealed reports whether data is an envelope of the given format
+func IsSealed(format string, data []byte) bool {
+	var env struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &env) == nil && env.Format == format
+}
+
+// newAEAD derives the AES-256-GCM cipher for a passphrase
+func newAEAD(passphrase string, params kdfParams) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, params.Salt, params.Iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the unencrypted header to the ciphertext, so the
+// format, version and KDF parameters cannot be swapped
+func additionalData(env envelope) []byte {
+	return fmt.Appendf(nil, "%s/%d/%s/%d/%x", env.Format, env.Version, env.KDF.Name, env.KDF.Iterations, env.KDF.Salt)
+}
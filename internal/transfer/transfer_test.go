@@ -0,0 +1,147 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPassphrase = "correct horse battery"
+
+// sealTestArchive returns a sealed archive with one entry
+func sealTestArchive(t *testing.T) (*Archive, []byte) {
+	t.Helper()
+	archive := &Archive{
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Source:    "laptop",
+		Entries: []Entry{{
+			Name:      "work",
+			Email:     "work@example.com",
+			UpdatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Profile:   json.RawMessage(`{"name":"work","credentials":"secret-token"}`),
+		}},
+	}
+	data, err := Seal(archive, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archive, data
+}
+
+// editEnvelope decodes a sealed file, applies edit and encodes it again
+func editEnvelope(t *testing.T, data []byte, edit func(*envelope)) []byte {
+	t.Helper()
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	edit(&env)
+	out, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	archive, data := sealTestArchive(t)
+
+	if strings.Contains(string(data), "secret-token") || strings.Contains(string(data), "work@example.com") {
+		t.Fatal("sealed archive contains plaintext")
+	}
+	if !IsSealed(Format, data) {
+		t.Error("IsSealed does not recognise a sealed archive")
+	}
+	if IsSealed("cflip-other", data) {
+		t.Error("IsSealed accepts an archive as another format")
+	}
+
+	opened, err := Open(data, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opened.CreatedAt.Equal(archive.CreatedAt) || opened.Source != archive.Source || len(opened.Entries) != 1 {
+		t.Fatalf("opened %+v, want %+v", opened, archive)
+	}
+	got, want := opened.Entries[0], archive.Entries[0]
+	if got.Name != want.Name || got.Email != want.Email || !got.UpdatedAt.Equal(want.UpdatedAt) || string(got.Profile) != string(want.Profile) {
+		t.Errorf("entry %+v, want %+v", got, want)
+	}
+
+	// Each seal uses a fresh salt and nonce
+	again, err := Seal(archive, testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) == string(data) {
+		t.Error("sealing twice produced identical output")
+	}
+}
+
+func TestSealRejectsShortPassphrase(t *testing.T) {
+	if _, err := Seal(&Archive{}, strings.Repeat("x", MinPassphraseLength-1)); err == nil {
+		t.Error("a passphrase shorter than the minimum was accepted")
+	}
+}
+
+func TestOpenRejectsWrongPassphrase(t *testing.T) {
+	_, data := sealTestArchive(t)
+	if _, err := Open(data, testPassphrase+"!"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("got %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	_, data := sealTestArchive(t)
+
+	tests := []struct {
+		name string
+		edit func(*envelope)
+		want error
+	}{
+		{"ciphertext", func(env *envelope) { env.Ciphertext[0] ^= 1 }, ErrWrongPassphrase},
+		{"truncated ciphertext", func(env *envelope) { env.Ciphertext = env.Ciphertext[:len(env.Ciphertext)-1] }, ErrWrongPassphrase},
+		{"nonce", func(env *envelope) { env.Nonce[0] ^= 1 }, ErrWrongPassphrase},
+		{"short nonce", func(env *envelope) { env.Nonce = env.Nonce[1:] }, ErrWrongPassphrase},
+		{"salt", func(env *envelope) { env.KDF.Salt[0] ^= 1 }, ErrWrongPassphrase},
+		{"iterations", func(env *envelope) { env.KDF.Iterations++ }, ErrWrongPassphrase},
+	}
+	for _, tt := range tests {
+		if _, err := Open(editEnvelope(t, data, tt.edit), testPassphrase); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOpenRejectsForeignHeaders(t *testing.T) {
+	_, data := sealTestArchive(t)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not JSON", []byte("hello"), "not a cflip-transfer file"},
+		{"other format", editEnvelope(t, data, func(env *envelope) { env.Format = "cflip-bundle" }), "not a cflip-transfer file"},
+		{"newer version", editEnvelope(t, data, func(env *envelope) { env.Version = Version + 1 }), "upgrade cflip"},
+		{"version 0", editEnvelope(t, data, func(env *envelope) { env.Version = 0 }), "not supported"},
+		{"unknown KDF", editEnvelope(t, data, func(env *envelope) { env.KDF.Name = "md5" }), "unsupported key derivation"},
+	}
+	for _, tt := range tests {
+		_, err := Open(tt.data, testPassphrase)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Data sealed as another format does not open as an archive
+	other, err := SealData("cflip-bundle", []byte(`{}`), testPassphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(other, testPassphrase); err == nil {
+		t.Error("a cflip-bundle file opened as a transfer archive")
+	}
+}
@@ -0,0 +1,111 @@
+package update
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// Install methods recognized by DetectInstall
+const (
+	MethodHomebrew  = "homebrew"
+	MethodGoInstall = "go install"
+	MethodApt       = "apt"
+	MethodManual    = "manual"
+)
+
+// InstallMethod describes how the running binary was installed
+type InstallMethod struct {
+	Method  string
+	Path    string // resolved path of the running binary
+	Package string // formula, package or module path, when known
+}
+
+// DetectInstall works out how the binary at path was installed: from its
+// location (Homebrew Cellar, dpkg database) or its build info (go install).
+// Anything else is a manual install.
+func DetectInstall(path string) InstallMethod {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	m := InstallMethod{Method: MethodManual, Path: path}
+
+	// Homebrew keeps binaries in <prefix>/Cellar/<formula>/<version>/bin
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts {
+		if part == "Cellar" && i+1 < len(parts) {
+			m.Method, m.Package = MethodHomebrew, parts[i+1]
+			return m
+		}
+	}
+
+	if pkg := dpkgOwner(path); pkg != "" {
+		m.Method, m.Package = MethodApt, pkg
+		return m
+	}
+
+	// go install from the module proxy stamps a real module version
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		if gobin := goBinDir(); gobin != "" && filepath.Dir(path) == gobin {
+			m.Method, m.Package = MethodGoInstall, info.Path
+			return m
+		}
+	}
+
+	return m
+}
+
+// UpgradeCommand is the package manager command that upgrades this
+// install, or nil for manual installs (which use self-update)
+func (m InstallMethod) UpgradeCommand() []string {
+	switch m.Method {
+	case MethodHomebrew:
+		return []string{"brew", "upgrade", m.Package}
+	case MethodApt:
+		return []string{"sudo", "apt-get", "install", "--only-upgrade", "-y", m.Package}
+	case MethodGoInstall:
+		return []string{"go", "install", m.Package + "@latest"}
+	default:
+		return nil
+	}
+}
+
+// dpkgOwner returns the Debian package that owns path, if any
+func dpkgOwner(path string) string {
+	if _, err := exec.LookPath("dpkg"); err != nil {
+		return ""
+	}
+	output, err := exec.Command("dpkg", "-S", path).Output()
+	if err != nil {
+		return ""
+	}
+	// Output looks like "cflip: /usr/bin/cflip"
+	owner, _, ok := strings.Cut(strings.TrimSpace(string(output)), ":")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(owner)
+}
+
+// goBinDir is where `go install` puts binaries
+func goBinDir() string {
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		return gobin
+	}
+	if output, err := exec.Command("go", "env", "GOBIN").Output(); err == nil {
+		if gobin := strings.TrimSpace(string(output)); gobin != "" {
+			return gobin
+		}
+	}
+	if output, err := exec.Command("go", "env", "GOPATH").Output(); err == nil {
+		if gopath := strings.TrimSpace(string(output)); gopath != "" {
+			return filepath.Join(filepath.SplitList(gopath)[0], "bin")
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "go", "bin")
+	}
+	return ""
+}
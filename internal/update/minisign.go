@@ -0,0 +1,73 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// minisignKey is a minisign Ed25519 public key
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// parseMinisignKey parses a minisign public key: the base64 line of a .pub
+// file, with or without its comment line
+func parseMinisignKey(text string) (*minisignKey, error) {
+	line := lastLine(text)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+
+	k := &minisignKey{key: ed25519.PublicKey(raw[10:])}
+	copy(k.id[:], raw[2:10])
+	return k, nil
+}
+
+// verifyMinisign checks a .minisig signature of message, including the
+// signed trusted comment. Only legacy (non-prehashed) signatures made with
+// `minisign -S -l` are supported, since prehashing needs BLAKE2b.
+func verifyMinisign(key *minisignKey, message []byte, signature string) error {
+	lines := strings.Split(strings.ReplaceAll(signature, "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed minisign signature")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("malformed minisign signature")
+	}
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		return fmt.Errorf("prehashed minisign signatures are not supported; sign with `minisign -S -l`")
+	default:
+		return fmt.Errorf("unknown minisign signature algorithm %q", raw[:2])
+	}
+	if !bytes.Equal(raw[2:10], key.id[:]) {
+		return fmt.Errorf("signature was made with a different key")
+	}
+
+	sig := raw[10:]
+	if !ed25519.Verify(key.key, message, sig) {
+		return fmt.Errorf("signature does not match")
+	}
+
+	// The global signature covers the signature and the trusted comment
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(key.key, append(append([]byte{}, sig...), trusted...), global) {
+		return fmt.Errorf("trusted comment signature does not match")
+	}
+	return nil
+}
+
+// lastLine returns the last non-empty line of text
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
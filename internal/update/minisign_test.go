@@ -0,0 +1,111 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// signMinisign produces a legacy minisign public key and signature of
+// message, as `minisign -G` and `minisign -S -l` would
+func signMinisign(t *testing.T, id string, message []byte, trusted string) (pub, sig string) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub = "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append([]byte("Ed"+id), public...)) + "\n"
+
+	signature := ed25519.Sign(private, message)
+	global := ed25519.Sign(private, append(append([]byte{}, signature...), trusted...))
+	sig = "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append([]byte("Ed"+id), signature...)) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+	return pub, sig
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	message := []byte("0123abcd  cflip-linux-amd64\n")
+	pub, sig := signMinisign(t, "12345678", message, "timestamp:1700000000\tfile:checksums.sha256")
+
+	key, err := parseMinisignKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyMinisign(key, message, sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	// The bare base64 line, as passed through -ldflags, parses the same
+	if _, err := parseMinisignKey(lastLine(pub)); err != nil {
+		t.Errorf("key without its comment line: %v", err)
+	}
+}
+
+func TestVerifyMinisignRejectsTampering(t *testing.T) {
+	message := []byte("0123abcd  cflip-linux-amd64\n")
+	pub, sig := signMinisign(t, "12345678", message, "timestamp:1700000000")
+	key, err := parseMinisignKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(sig, "\n")
+
+	otherPub, otherSig := signMinisign(t, "87654321", message, "timestamp:1700000000")
+	otherKey, err := parseMinisignKey(otherPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameIDPub, _ := signMinisign(t, "12345678", message, "timestamp:1700000000")
+	sameIDKey, err := parseMinisignKey(sameIDPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(lines[1])
+	prehashed := append([]byte("ED"), raw[2:]...)
+
+	tests := []struct {
+		name    string
+		key     *minisignKey
+		message []byte
+		sig     string
+		want    string
+	}{
+		{"modified message", key, []byte("0123abce  cflip-linux-amd64\n"), sig, "signature does not match"},
+		{"modified trusted comment", key, message,
+			strings.Replace(sig, "timestamp:1700000000", "timestamp:1800000000", 1), "trusted comment signature does not match"},
+		{"signed with another key", key, message, otherSig, "different key"},
+		{"wrong key with the same id", sameIDKey, message, sig, "signature does not match"},
+		{"another key id", otherKey, message, sig, "different key"},
+		{"prehashed", key, message,
+			strings.Replace(sig, lines[1], base64.StdEncoding.EncodeToString(prehashed), 1), "prehashed"},
+		{"truncated", key, message, strings.Join(lines[:2], "\n"), "malformed"},
+		{"no trusted comment", key, message, strings.Replace(sig, "\ntrusted comment: ", "\ncomment: ", 1), "malformed"},
+		{"bad base64", key, message, strings.Replace(sig, lines[1], "not base64!", 1), "malformed"},
+	}
+	for _, tt := range tests {
+		err := verifyMinisign(tt.key, tt.message, tt.sig)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseMinisignKeyRejectsGarbage(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"not base64!",
+		base64.StdEncoding.EncodeToString([]byte("Ed12345678short")),
+		base64.StdEncoding.EncodeToString(append([]byte("Xx12345678"), make([]byte, ed25519.PublicKeySize)...)),
+	} {
+		if _, err := parseMinisignKey(text); err == nil {
+			t.Errorf("%q was accepted as a public key", text)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is the GitHub repository releases are published to
+const Repo = "phathdt/claude-flip"
+
+// PublicKey is the minisign public key release checksums are signed with.
+// Release builds set it with
+// -ldflags "-X github.com/phathdt/claude-flip/internal/update.PublicKey=RW..."
+var PublicKey = ""
+
+const (
+	checksumsAsset = "checksums.sha256"
+	signatureAsset = checksumsAsset + ".minisig"
+	maxAssetSize   = 200 << 20
+)
+
+var client = &http.Client{Timeout: 2 * time.Minute}
+
+// Release is a published cflip release
+type Release struct {
+	Tag    string
+	assets map[string]string // asset name -> download URL
+}
+
+// BinaryAsset is the release asset name for this platform
+func BinaryAsset() string {
+	return fmt.Sprintf("cflip-%s-%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// Latest fetches the newest release from GitHub
+func Latest() (*Release, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", Repo)
+	data, err := fetch(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the latest release: %w", err)
+	}
+
+	var payload struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse release metadata: %w", err)
+	}
+
+	release := &Release{Tag: payload.TagName, assets: make(map[string]string)}
+	for _, asset := range payload.Assets {
+		release.assets[asset.Name] = asset.URL
+	}
+	return release, nil
+}
+
+// Newer reports whether the release is newer than the given version
+func (r *Release) Newer(current string) bool {
+	return compareVersions(r.Tag, current) > 0
+}
+
+// DownloadVerified downloads this platform's binary and checks it against
+// the signed checksums. Unsigned releases are refused.
+func (r *Release) DownloadVerified() ([]byte, error) {
+	key, err := trustedKey()
+	if err != nil {
+		return nil, err
+	}
+
+	name := BinaryAsset()
+	for _, required := range []string{name, checksumsAsset, signatureAsset} {
+		if _, ok := r.assets[required]; !ok {
+			if required == signatureAsset {
+				return nil, fmt.Errorf("release %s is not signed (no %s); refusing to install it", r.Tag, signatureAsset)
+			}
+			return nil, fmt.Errorf("release %s has no %s asset", r.Tag, required)
+		}
+	}
+
+	checksums, err := fetch(r.assets[checksumsAsset])
+	if err != nil {
+		return nil, fmt.Errorf("failed to download checksums: %w", err)
+	}
+	signature, err := fetch(r.assets[signatureAsset])
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signature: %w", err)
+	}
+	if err := verifyMinisign(key, checksums, string(signature)); err != nil {
+		return nil, fmt.Errorf("checksums signature verification failed: %w", err)
+	}
+
+	want, err := checksumFor(checksums, name)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := fetch(r.assets[name])
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return binary, nil
+}
+
+// Install atomically replaces the executable at path with binary
+func Install(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cflip-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write next to %s (try with sudo?): %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm() | 0o111); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// trustedKey returns the public key compiled into this build
+func trustedKey() (*minisignKey, error) {
+	if PublicKey == "" {
+		return nil, fmt.Errorf("this build has no release signing key, so updates cannot be verified; reinstall from a release or with `go install`")
+	}
+	return parseMinisignKey(PublicKey)
+}
+
+// checksumFor finds a file's hash in sha256sum output
+func checksumFor(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// fetch downloads a URL, capping the size
+func fetch(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAssetSize {
+		return nil, fmt.Errorf("GET %s: response too large", url)
+	}
+	return data, nil
+}
+
+// compareVersions compares dotted versions, ignoring a leading "v" and any
+// pre-release suffix
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// versionParts splits "v1.2.3-rc1" into [1 2 3]
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
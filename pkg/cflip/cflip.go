@@ -0,0 +1,333 @@
+// Package cflip manages and switches between Claude Code accounts from Go
+// programs. It works on the same stored accounts, settings and audit log as
+// the cflip command.
+//
+//	client, err := cflip.New(nil)
+//	if err != nil {
+//		return err
+//	}
+//	accounts, err := client.List(ctx)
+//	...
+//	result, err := client.Switch(ctx, "work", nil)
+//
+// Nothing is printed: warnings and log records go to Options.Log. cflip's
+// state is per user, not per client, so New also applies process-wide
+// settings (storage retries, credential locations, lock timeout) and other
+// cflip processes see every change. Switches and profile writes take
+// cflip's file lock, so concurrent use with the CLI is safe.
+package cflip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/phathdt/claude-flip/internal/logger"
+	"github.com/phathdt/claude-flip/internal/service"
+	"github.com/phathdt/claude-flip/internal/storage"
+)
+
+// Account is a stored Claude Code account
+type Account = service.ProfileInfo
+
+// SwitchResult is what a completed switch changed
+type SwitchResult = service.SwitchResult
+
+// RefreshResult is the outcome of refreshing an account's tokens
+type RefreshResult = service.RefreshResult
+
+// SyncResult is what was saved from Claude Code into the outgoing account's
+// profile before a switch
+type SyncResult = service.SyncResult
+
+// ValidationResult is the outcome of validating one account
+type ValidationResult = service.ValidationResult
+
+// SwitchCheck is the outcome of verifying the credentials a switch wrote
+type SwitchCheck = service.SwitchCheck
+
+// Options configures a Client
+type Options struct {
+	// Log receives cflip's log records and warnings, such as a profile
+	// modified outside cflip; nil discards them
+	Log io.Writer
+	// Keychain is a dedicated macOS keychain file to use instead of the
+	// default search list, unlocked with CFLIP_KEYCHAIN_PASSWORD
+	Keychain string
+	// Terminal keeps cflip's terminal output and prompts. It is for the
+	// cflip command, which sets up logging and applies the settings itself;
+	// Log and Keychain are ignored.
+	Terminal bool
+}
+
+// SwitchOptions adjusts a switch
+type SwitchOptions struct {
+	// Force switches even while Claude Code is running
+	Force bool
+	// Organization selects the organization to log in to, by name or UUID,
+	// for accounts that belong to several
+	Organization string
+	// SkipDesktop leaves Claude Desktop alone even when settings switch it
+	// along with Claude Code
+	SkipDesktop bool
+	// Group limits the next account in sequence to the members of a group,
+	// when no identifier is given
+	Group string
+}
+
+// RemoveOptions adjusts a removal
+type RemoveOptions struct {
+	// Purge deletes the account immediately instead of moving it to the
+	// trash, where `cflip undelete` can restore it
+	Purge bool
+}
+
+// ValidateOptions adjusts validating every account
+type ValidateOptions struct {
+	// Jobs is how many accounts are validated at once (default 1)
+	Jobs int
+	// Online also confirms each valid token with the Anthropic API
+	Online bool
+	// Progress, when set, is called as each account is validated, never
+	// concurrently, with the number done so far
+	Progress func(done, total int, result ValidationResult)
+}
+
+// Client manages the stored Claude Code accounts. It is safe for
+// concurrent use.
+type Client struct {
+	mu  sync.Mutex
+	svc *service.Service
+}
+
+// New creates a client. opts may be nil.
+func New(opts *Options) (*Client, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Terminal {
+		svc, err := service.NewService()
+		if err != nil {
+			return nil, err
+		}
+		return &Client{svc: svc}, nil
+	}
+
+	// A library must never prompt on, or print to, its host's terminal
+	logger.SetEmbedded(opts.Log)
+	storage.SetKeychainOptions(storage.KeychainOptions{Path: opts.Keychain, NonInteractive: true})
+	if auditPath, err := service.AuditLogPath(); err == nil {
+		logger.SetAuditLogPath(auditPath)
+	}
+
+	settings, err := service.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := service.ApplySettings(settings); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	svc, err := service.NewService()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{svc: svc}, nil
+}
+
+// List returns the stored accounts in the order the CLI numbers them.
+// Accounts of shared sources are listed by ListShared.
+func (c *Client) List(ctx context.Context) ([]*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.svc.ListProfiles()
+}
+
+// ListShared fetches the accounts of the configured shared sources that
+// have no local copy. Sources that could not be fetched are returned by name
+// with their error.
+func (c *Client) ListShared(ctx context.Context) ([]*Account, map[string]error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.svc.ListSharedProfiles()
+}
+
+// Current returns the active account
+func (c *Client) Current(ctx context.Context) (*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.svc.GetCurrentAccount()
+}
+
+// Switch makes identifier the account Claude Code uses. identifier is
+// resolved like on the command line: a number from List (starting at 1),
+// a profile name, email or alias, or a unique prefix of one; empty selects
+// the next account in sequence. opts may be nil.
+//
+// ctx is checked between the steps before Claude Code's files are written,
+// such as refreshing the account's tokens; once writing starts the switch
+// runs to completion so Claude Code is never left half switched. opts only
+// apply to this switch.
+func (c *Client) Switch(ctx context.Context, identifier string, opts *SwitchOptions) (*SwitchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &SwitchOptions{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.svc.ResetSwitchOptions()
+
+	name, err := c.resolve(identifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.Organization != "" {
+		if name == "" {
+			return nil, fmt.Errorf("an organization requires an account to switch to")
+		}
+		c.svc.SelectOrganization(opts.Organization)
+	}
+	if opts.SkipDesktop {
+		c.svc.SkipDesktop()
+	}
+	if opts.Group != "" {
+		if name != "" {
+			return nil, fmt.Errorf("a group selects the next account; it cannot be combined with an account")
+		}
+		c.svc.SelectGroup(opts.Group)
+	}
+
+	return c.svc.SwitchContext(ctx, name, opts.Force)
+}
+
+// VerifySwitch checks the credentials the last switch wrote for the active
+// account, storing tokens Claude Code has refreshed since. With ping, or
+// settings.verify_switch, the access token is also checked against the
+// Anthropic API.
+func (c *Client) VerifySwitch(ctx context.Context, ping bool) (*SwitchCheck, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.svc.VerifySwitch(ping)
+}
+
+// Add stores the account Claude Code is currently logged in to, with an
+// optional alias
+func (c *Client) Add(ctx context.Context, alias string) (*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	account, err := c.svc.AddCurrentAccount(alias)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewDefault().AccountAdded(account.Email, account.Alias)
+	return account, nil
+}
+
+// Remove removes a stored account, resolving identifier like Switch. opts
+// may be nil.
+func (c *Client) Remove(ctx context.Context, identifier string, opts *RemoveOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if identifier == "" {
+		return fmt.Errorf("account identifier required")
+	}
+	if opts == nil {
+		opts = &RemoveOptions{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name, err := c.resolve(identifier)
+	if err != nil {
+		return err
+	}
+	if opts.Purge {
+		err = c.svc.PurgeAccount(name)
+	} else {
+		err = c.svc.RemoveAccount(name)
+	}
+	if err != nil {
+		return err
+	}
+	logger.NewDefault().AccountRemoved(name)
+	return nil
+}
+
+// Validate checks a stored account's credentials, resolving identifier like
+// Switch, and refreshes its tokens first when they are expired or about to
+// expire. It returns nil when the account is valid.
+func (c *Client) Validate(ctx context.Context, identifier string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if identifier == "" {
+		return fmt.Errorf("account identifier required")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name, err := c.resolve(identifier)
+	if err != nil {
+		return err
+	}
+	return c.svc.ValidateAccount(name)
+}
+
+// ValidateAll validates every stored account, in List order. An invalid
+// account is reported in its result, not as an error. opts may be nil.
+func (c *Client) ValidateAll(ctx context.Context, opts *ValidateOptions) ([]ValidationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &ValidateOptions{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	validate := c.svc.ValidateAccounts
+	if opts.Online {
+		validate = c.svc.ValidateAccountsOnline
+	}
+	return validate(max(opts.Jobs, 1), opts.Progress)
+}
+
+// resolve turns an account identifier into its profile name; empty stays
+// empty
+func (c *Client) resolve(identifier string) (string, error) {
+	if identifier == "" {
+		return "", nil
+	}
+	resolution, err := c.svc.ResolveIdentifier(identifier)
+	if err != nil {
+		return "", err
+	}
+	return resolution.Profile.Name, nil
+}
@@ -0,0 +1,150 @@
+package cflip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestClient creates a client on a temporary home directory
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	if err := os.MkdirAll(filepath.Join(home, ".claude"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(&Options{Log: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// login makes Claude Code's live account email, the way logging in does
+func login(t *testing.T, email string) {
+	t.Helper()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf(`{"oauthAccount":{"emailAddress":%q,"accountUuid":"uuid-%s"}}`, email, email)
+	if err := os.WriteFile(filepath.Join(home, ".claude.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Now().Add(8 * time.Hour).UnixMilli()
+	credentials := fmt.Sprintf(`{"claudeAiOauth":{"accessToken":"token-%s","refreshToken":"refresh-%s","expiresAt":%d}}`, email, email, expiresAt)
+	if err := os.WriteFile(filepath.Join(home, ".claude", ".credentials.json"), []byte(credentials), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// accountEmails lists the emails of the stored accounts in List order
+func accountEmails(t *testing.T, client *Client) []string {
+	t.Helper()
+	accounts, err := client.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var emails []string
+	for _, account := range accounts {
+		emails = append(emails, account.Email)
+	}
+	return emails
+}
+
+func TestClientAccountLifecycle(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	if emails := accountEmails(t, client); len(emails) != 0 {
+		t.Fatalf("new home lists %v", emails)
+	}
+
+	login(t, "work@x.com")
+	if _, err := client.Add(ctx, "work"); err != nil {
+		t.Fatalf("adding work: %v", err)
+	}
+	login(t, "home@x.com")
+	added, err := client.Add(ctx, "")
+	if err != nil {
+		t.Fatalf("adding home: %v", err)
+	}
+	if added.Email != "home@x.com" {
+		t.Fatalf("added %s, want home@x.com", added.Email)
+	}
+	if emails := accountEmails(t, client); len(emails) != 2 {
+		t.Fatalf("listed %v, want both accounts", emails)
+	}
+
+	result, err := client.Switch(ctx, "work", &SwitchOptions{Force: true})
+	if err != nil {
+		t.Fatalf("switching to work: %v", err)
+	}
+	if result.To.Email != "work@x.com" {
+		t.Fatalf("switched to %s, want work@x.com", result.To.Email)
+	}
+	current, err := client.Current(ctx)
+	if err != nil || current.Email != "work@x.com" {
+		t.Fatalf("current after switch = %v, %v", current, err)
+	}
+	if _, err := client.VerifySwitch(ctx, false); err != nil {
+		t.Fatalf("verifying the switch: %v", err)
+	}
+
+	if err := client.Validate(ctx, "home"); err != nil {
+		t.Fatalf("validating home by prefix: %v", err)
+	}
+	results, err := client.ValidateAll(ctx, &ValidateOptions{Jobs: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("%s is invalid: %v", result.Email, result.Err)
+		}
+	}
+
+	if err := client.Remove(ctx, "home@x.com", nil); err != nil {
+		t.Fatalf("removing home: %v", err)
+	}
+	if emails := accountEmails(t, client); len(emails) != 1 || emails[0] != "work@x.com" {
+		t.Fatalf("listed %v after removal, want only work@x.com", emails)
+	}
+	if err := client.Validate(ctx, "home@x.com"); err == nil {
+		t.Fatal("a removed account validated")
+	}
+}
+
+func TestClientHonorsCancelledContext(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("List: got %v, want context.Canceled", err)
+	}
+	if _, err := client.Switch(ctx, "work", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Switch: got %v, want context.Canceled", err)
+	}
+}
+
+func TestClientSwitchRejectsGroupWithAccount(t *testing.T) {
+	client := newTestClient(t)
+	login(t, "work@x.com")
+	if _, err := client.Add(context.Background(), "work"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Switch(context.Background(), "work", &SwitchOptions{Group: "team", Force: true}); err == nil {
+		t.Fatal("a group was combined with an account")
+	}
+}